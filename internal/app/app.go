@@ -3,28 +3,45 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/bissquit/incident-garden/internal/audit"
+	auditpostgres "github.com/bissquit/incident-garden/internal/audit/postgres"
+	"github.com/bissquit/incident-garden/internal/badge"
 	"github.com/bissquit/incident-garden/internal/catalog"
 	catalogpostgres "github.com/bissquit/incident-garden/internal/catalog/postgres"
 	"github.com/bissquit/incident-garden/internal/config"
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/events"
 	eventspostgres "github.com/bissquit/incident-garden/internal/events/postgres"
+	"github.com/bissquit/incident-garden/internal/feed"
 	"github.com/bissquit/incident-garden/internal/identity"
 	"github.com/bissquit/incident-garden/internal/identity/jwt"
+	"github.com/bissquit/incident-garden/internal/identity/oidc"
 	identitypostgres "github.com/bissquit/incident-garden/internal/identity/postgres"
+	"github.com/bissquit/incident-garden/internal/integrations/alertmanager"
+	alertmanagerpostgres "github.com/bissquit/incident-garden/internal/integrations/alertmanager/postgres"
 	"github.com/bissquit/incident-garden/internal/notifications"
 	"github.com/bissquit/incident-garden/internal/notifications/email"
+	"github.com/bissquit/incident-garden/internal/notifications/msteams"
 	notificationspostgres "github.com/bissquit/incident-garden/internal/notifications/postgres"
+	"github.com/bissquit/incident-garden/internal/notifications/slack"
 	"github.com/bissquit/incident-garden/internal/notifications/telegram"
 	"github.com/bissquit/incident-garden/internal/pkg/httputil"
 	"github.com/bissquit/incident-garden/internal/pkg/postgres"
+	"github.com/bissquit/incident-garden/internal/stream"
+	streampostgres "github.com/bissquit/incident-garden/internal/stream/postgres"
+	"github.com/bissquit/incident-garden/internal/summary"
+	"github.com/bissquit/incident-garden/internal/uptime"
 	"github.com/bissquit/incident-garden/internal/version"
+	"github.com/bissquit/incident-garden/internal/webhooks"
+	webhookspostgres "github.com/bissquit/incident-garden/internal/webhooks/postgres"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -36,12 +53,33 @@ type App struct {
 	logger *slog.Logger
 	db     *pgxpool.Pool
 	server *http.Server
+
+	jwtSigningKey       *jwt.Key
+	jwtVerificationKeys []*jwt.Key
+
+	// background jobs are started by Run and stopped by Shutdown.
+	background     []func(ctx context.Context)
+	backgroundOnce sync.Once
+	backgroundWG   sync.WaitGroup
+	backgroundCtx  context.Context
+	stopBackground context.CancelFunc
+
+	// onShutdown functions are called when the server starts shutting down.
+	onShutdown []func()
 }
 
+// statusStreamPath is the long-lived server-sent events endpoint.
+const statusStreamPath = "/api/v1/status/stream"
+
 // New creates a new application instance.
 func New(cfg *config.Config) (*App, error) {
 	logger := initLogger(cfg.Log)
 
+	signingKey, verificationKeys, err := loadJWTKeys(cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("load jwt keys: %w", err)
+	}
+
 	db, err := postgres.Connect(context.Background(), postgres.Config{
 		URL:             cfg.Database.URL,
 		MaxOpenConns:    cfg.Database.MaxOpenConns,
@@ -53,10 +91,13 @@ func New(cfg *config.Config) (*App, error) {
 	}
 
 	app := &App{
-		config: cfg,
-		logger: logger,
-		db:     db,
+		config:              cfg,
+		logger:              logger,
+		db:                  db,
+		jwtSigningKey:       signingKey,
+		jwtVerificationKeys: verificationKeys,
 	}
+	app.backgroundCtx, app.stopBackground = context.WithCancel(context.Background())
 
 	router := app.setupRouter()
 
@@ -66,6 +107,9 @@ func New(cfg *config.Config) (*App, error) {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
+	for _, f := range app.onShutdown {
+		app.server.RegisterOnShutdown(f)
+	}
 
 	return app, nil
 }
@@ -77,6 +121,8 @@ func (a *App) Run() error {
 		"port", a.config.Server.Port,
 	)
 
+	a.startBackground()
+
 	if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server error: %w", err)
 	}
@@ -92,11 +138,46 @@ func (a *App) Shutdown(ctx context.Context) error {
 		return fmt.Errorf("shutdown server: %w", err)
 	}
 
+	if err := a.stopBackgroundJobs(ctx); err != nil {
+		return err
+	}
+
 	a.db.Close()
 
 	return nil
 }
 
+// startBackground launches registered background jobs once.
+func (a *App) startBackground() {
+	a.backgroundOnce.Do(func() {
+		for _, job := range a.background {
+			a.backgroundWG.Add(1)
+			go func() {
+				defer a.backgroundWG.Done()
+				job(a.backgroundCtx)
+			}()
+		}
+	})
+}
+
+// stopBackgroundJobs cancels background jobs and waits for them to finish.
+func (a *App) stopBackgroundJobs(ctx context.Context) error {
+	a.stopBackground()
+
+	done := make(chan struct{})
+	go func() {
+		a.backgroundWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stop background jobs: %w", ctx.Err())
+	}
+}
+
 // Router returns the HTTP handler for testing.
 func (a *App) Router() http.Handler {
 	return a.server.Handler
@@ -108,10 +189,13 @@ func (a *App) setupRouter() *chi.Mux {
 	// CORS must be first to handle preflight requests before other middleware
 	r.Use(httputil.CORSMiddleware(a.config.CORS.AllowedOrigins))
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(httputil.ClientIPMiddleware(a.config.Server.TrustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	// The status stream is long-lived and ends when the client disconnects.
+	r.Use(middleware.Maybe(middleware.Timeout(60*time.Second), func(r *http.Request) bool {
+		return r.URL.Path != statusStreamPath
+	}))
 
 	r.Get("/healthz", a.healthzHandler)
 	r.Get("/readyz", a.readyzHandler)
@@ -145,50 +229,202 @@ func (a *App) setupRouter() *chi.Mux {
 </html>`))
 	})
 
+	auditService := audit.NewService(auditpostgres.NewRepository(a.db))
+	auditHandler := audit.NewHandler(auditService)
+
 	identityRepo := identitypostgres.NewRepository(a.db)
 	jwtAuth := jwt.NewAuthenticator(jwt.Config{
 		SecretKey:            a.config.JWT.SecretKey,
+		SigningKey:           a.jwtSigningKey,
+		VerificationKeys:     a.jwtVerificationKeys,
+		Issuer:               a.config.JWT.Issuer,
+		Audience:             a.config.JWT.Audience,
 		AccessTokenDuration:  a.config.JWT.AccessTokenDuration,
 		RefreshTokenDuration: a.config.JWT.RefreshTokenDuration,
 	}, identityRepo)
-	identityService := identity.NewService(identityRepo, jwtAuth)
+	r.Get("/.well-known/jwks.json", jwksHandler(jwtAuth))
+	var authenticator identity.Authenticator = jwtAuth
+	if a.config.OIDC.IssuerURL != "" {
+		authenticator = oidc.NewAuthenticator(oidc.Config{
+			IssuerURL:            a.config.OIDC.IssuerURL,
+			ClientID:             a.config.OIDC.ClientID,
+			ClientSecret:         a.config.OIDC.ClientSecret,
+			RedirectURL:          a.config.OIDC.RedirectURL,
+			Scopes:               a.config.OIDC.Scopes,
+			RoleClaim:            a.config.OIDC.RoleClaim,
+			RoleMap:              a.config.OIDC.RoleMap,
+			DefaultRole:          a.config.OIDC.DefaultRole,
+			DisablePasswordLogin: a.config.OIDC.DisablePasswordLogin,
+		}, jwtAuth)
+	}
+	identityService := identity.NewService(identityRepo, authenticator)
+	identityService.SetAuditLog(auditService.Record)
+	identityService.SetMFA(identity.MFAConfig{Issuer: a.config.MFA.TOTPIssuer})
 	identityHandler := identity.NewHandler(identityService)
 
 	catalogRepo := catalogpostgres.NewRepository(a.db)
 	catalogService := catalog.NewService(catalogRepo)
+	catalogService.SetAuditLog(auditService.Record)
 	catalogHandler := catalog.NewHandler(catalogService)
 
+	streamService := stream.NewService(streampostgres.NewRepository(a.db), stream.Config{
+		HeartbeatInterval: a.config.Stream.HeartbeatInterval,
+		Retention:         a.config.Stream.Retention,
+	})
+	a.background = append(a.background, streamService.Run)
+	// Open streams never go idle, so end them for the server to shut down.
+	a.onShutdown = append(a.onShutdown, streamService.Close)
+	catalogService.OnStatusChange(streamStatusHook(streamService))
+	streamHandler := stream.NewHandler(streamService, catalogService)
+
 	eventsRepo := eventspostgres.NewRepository(a.db)
 	eventsService := events.NewService(eventsRepo, catalogService)
+	eventsService.SetAuditLog(auditService.Record)
 	eventsHandler := events.NewHandler(eventsService)
 
 	notificationsRepo := notificationspostgres.NewRepository(a.db)
-	emailSender := email.NewSender(email.Config{})
-	telegramSender := telegram.NewSender(telegram.Config{})
-	dispatcher := notifications.NewDispatcher(notificationsRepo, emailSender, telegramSender)
-	notificationsService := notifications.NewService(notificationsRepo, dispatcher)
+	emailConfig := email.Config{
+		SMTPHost:           a.config.Email.SMTPHost,
+		SMTPPort:           a.config.Email.SMTPPort,
+		SMTPUser:           a.config.Email.SMTPUser,
+		SMTPPassword:       a.config.Email.SMTPPassword,
+		FromAddress:        a.config.Email.FromAddress,
+		FromName:           a.config.Email.FromName,
+		TLSMode:            a.config.Email.TLSMode,
+		AuthMechanism:      a.config.Email.AuthMechanism,
+		InsecureSkipVerify: a.config.Email.InsecureSkipVerify,
+		Timeout:            a.config.Email.Timeout,
+		UnsubscribeURL:     a.config.Email.UnsubscribeURL,
+	}
+	emailSender := email.NewSender(emailConfig)
+	if a.config.Email.SMTPHost != "" && a.config.PasswordReset.URL != "" {
+		// Account emails are not subscriptions, so they go without List-Unsubscribe.
+		accountEmailConfig := emailConfig
+		accountEmailConfig.UnsubscribeURL = ""
+		identityService.SetPasswordReset(backgroundMailer{sender: email.NewSender(accountEmailConfig)}, identity.PasswordResetConfig{
+			URL: a.config.PasswordReset.URL,
+			TTL: a.config.PasswordReset.TTL,
+		})
+	}
+	telegramSender := telegram.NewSender(telegram.Config{
+		BotToken:  a.config.Telegram.BotToken,
+		APIURL:    a.config.Telegram.APIURL,
+		ParseMode: a.config.Telegram.ParseMode,
+	})
+	dispatcher := notifications.NewDispatcher(notificationsRepo, notifications.DispatcherConfig{
+		Workers:        a.config.Notifications.Workers,
+		PollInterval:   a.config.Notifications.PollInterval,
+		MaxAttempts:    a.config.Notifications.MaxAttempts,
+		RetryBaseDelay: a.config.Notifications.RetryBaseDelay,
+		RetryMaxDelay:  a.config.Notifications.RetryMaxDelay,
+	}, emailSender, telegramSender, slack.NewSender(slack.Config{}), msteams.NewSender(msteams.Config{}))
+	a.background = append(a.background, dispatcher.Run)
+	notificationsService := notifications.NewService(notificationsRepo, dispatcher, notifications.Config{
+		TelegramBotUsername: a.config.Telegram.BotUsername,
+		PublicURL:           a.config.Server.PublicURL,
+	})
+	notificationsService.SetAuditLog(auditService.Record)
 	notificationsHandler := notifications.NewHandler(notificationsService)
+	eventsService.OnPublish(serviceStatusHook(catalogService))
+	eventsService.OnPublish(notifySubscribersHook(notificationsService))
+	eventsService.OnPublish(streamEventHook(streamService))
+
+	webhooksRepo := webhookspostgres.NewRepository(a.db)
+	webhookDispatcher := webhooks.NewDispatcher(webhooksRepo, webhooks.DispatcherConfig{
+		Workers:              a.config.Webhooks.Workers,
+		PollInterval:         a.config.Webhooks.PollInterval,
+		MaxAttempts:          a.config.Webhooks.MaxAttempts,
+		RetryBaseDelay:       a.config.Webhooks.RetryBaseDelay,
+		RetryMaxDelay:        a.config.Webhooks.RetryMaxDelay,
+		Timeout:              a.config.Webhooks.Timeout,
+		AllowPrivateNetworks: a.config.Webhooks.AllowPrivateNetworks,
+	})
+	a.background = append(a.background, webhookDispatcher.Run)
+	webhooksService := webhooks.NewService(webhooksRepo)
+	webhooksService.SetAuditLog(auditService.Record)
+	webhooksHandler := webhooks.NewHandler(webhooksService)
+	eventsService.OnPublish(webhookEventHook(webhooksService))
+	catalogService.OnStatusChange(webhookStatusHook(webhooksService))
+
+	scheduler := newMaintenanceScheduler(eventsService, notificationsService,
+		func(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+			return postgres.WithTryAdvisoryLock(ctx, a.db, maintenanceSchedulerLockKey, fn)
+		},
+		a.config.Maintenance.SchedulerInterval,
+		a.config.Maintenance.ReminderBefore,
+	)
+	a.background = append(a.background, scheduler.Run)
+	telegramWebhookHandler := telegram.NewWebhookHandler(telegramSender, notificationsService, a.config.Telegram.WebhookSecret)
+	if a.config.Telegram.BotToken != "" && a.config.Telegram.WebhookSecret == "" {
+		a.logger.Warn("TELEGRAM_WEBHOOK_SECRET is not set, telegram webhook is disabled")
+	}
+
+	alertmanagerService := alertmanager.NewService(alertmanagerpostgres.NewRepository(a.db), eventsService, catalogService, alertmanager.Config{
+		ServiceLabel:      a.config.Alertmanager.ServiceLabel,
+		SeverityLabel:     a.config.Alertmanager.SeverityLabel,
+		SeverityMap:       a.config.Alertmanager.SeverityMap,
+		DefaultSeverity:   a.config.Alertmanager.DefaultSeverity,
+		NotifySubscribers: a.config.Alertmanager.NotifySubscribers,
+	})
+	alertmanagerHandler := alertmanager.NewHandler(alertmanagerService, a.config.Alertmanager.WebhookToken)
+
+	feedHandler := feed.NewHandler(feed.NewService(eventsService, catalogService, feed.Config{
+		PublicURL: a.config.Server.PublicURL,
+	}))
+	badgeHandler := badge.NewHandler(badge.NewService(catalogService))
+	uptimeService := uptime.NewService(eventsService, catalogService)
+	uptimeHandler := uptime.NewHandler(uptimeService)
+	summaryHandler := summary.NewHandler(summary.NewService(eventsService, catalogService, uptimeService))
 
 	r.Route("/api/v1", func(r chi.Router) {
 		identityHandler.RegisterRoutes(r)
 
 		eventsHandler.RegisterPublicRoutes(r)
+		feedHandler.RegisterRoutes(r)
+		badgeHandler.RegisterRoutes(r)
+		summaryHandler.RegisterRoutes(r)
+		uptimeHandler.RegisterRoutes(r)
+		streamHandler.RegisterRoutes(r)
+		// The Telegram webhook verifies channels, so it is only enabled with a secret.
+		if a.config.Telegram.WebhookSecret != "" {
+			telegramWebhookHandler.RegisterRoutes(r)
+		}
+		// The Alertmanager webhook opens incidents, so it is only enabled with a token.
+		if a.config.Alertmanager.WebhookToken != "" {
+			alertmanagerHandler.RegisterRoutes(r)
+		}
+
+		// Users an admin asked to change the password can only do that.
+		r.Group(func(r chi.Router) {
+			r.Use(httputil.AuthMiddleware(identityService.PasswordChangeValidator()))
+			r.Use(httputil.RejectAPIKeys)
+			identityHandler.RegisterPasswordChangeRoutes(r)
+		})
 
 		r.Group(func(r chi.Router) {
 			r.Use(httputil.AuthMiddleware(identityService))
 
-			identityHandler.RegisterProtectedRoutes(r)
-			notificationsHandler.RegisterRoutes(r)
+			r.Group(func(r chi.Router) {
+				r.Use(httputil.RejectAPIKeys)
+				identityHandler.RegisterProtectedRoutes(r)
+			})
+			withScope(r, domain.ScopeResourceNotifications, notificationsHandler.RegisterRoutes)
 
 			r.Group(func(r chi.Router) {
 				r.Use(httputil.RequireRole(domain.RoleOperator))
-				eventsHandler.RegisterOperatorRoutes(r)
+				withScope(r, domain.ScopeResourceEvents, eventsHandler.RegisterOperatorRoutes)
+				withScope(r, domain.ScopeResourceCatalog, catalogHandler.RegisterOperatorRoutes)
 			})
 
 			r.Group(func(r chi.Router) {
 				r.Use(httputil.RequireRole(domain.RoleAdmin))
-				catalogHandler.RegisterRoutes(r)
-				eventsHandler.RegisterAdminRoutes(r)
+				withScope(r, domain.ScopeResourceCatalog, catalogHandler.RegisterRoutes)
+				withScope(r, domain.ScopeResourceEvents, eventsHandler.RegisterAdminRoutes)
+				withScope(r, domain.ScopeResourceNotifications, notificationsHandler.RegisterAdminRoutes)
+				withScope(r, domain.ScopeResourceAPIKeys, identityHandler.RegisterAdminRoutes)
+				withScope(r, domain.ScopeResourceUsers, identityHandler.RegisterUserAdminRoutes)
+				withScope(r, domain.ScopeResourceAudit, auditHandler.RegisterRoutes)
+				withScope(r, domain.ScopeResourceWebhooks, webhooksHandler.RegisterRoutes)
 			})
 		})
 
@@ -201,6 +437,14 @@ func (a *App) setupRouter() *chi.Mux {
 	return r
 }
 
+// withScope registers routes that scoped API keys may use only with a scope for resource.
+func withScope(r chi.Router, resource string, register func(chi.Router)) {
+	r.Group(func(r chi.Router) {
+		r.Use(httputil.RequireScope(resource))
+		register(r)
+	})
+}
+
 func (a *App) healthzHandler(w http.ResponseWriter, _ *http.Request) {
 	httputil.Text(w, http.StatusOK, "OK")
 }
@@ -218,6 +462,15 @@ func (a *App) readyzHandler(w http.ResponseWriter, r *http.Request) {
 	httputil.Text(w, http.StatusOK, "OK")
 }
 
+// jwksHandler publishes the keys that verify access tokens. Caches expire
+// quickly, so a new key is picked up within a rotation.
+func jwksHandler(auth *jwt.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		httputil.JSON(w, http.StatusOK, auth.JWKS())
+	}
+}
+
 func (a *App) versionHandler(w http.ResponseWriter, _ *http.Request) {
 	httputil.JSON(w, http.StatusOK, map[string]string{
 		"version":    version.Version,
@@ -226,6 +479,35 @@ func (a *App) versionHandler(w http.ResponseWriter, _ *http.Request) {
 	})
 }
 
+// loadJWTKeys reads the access token signing key and the additional
+// verification keys. Without a signing key tokens are signed with the secret key.
+func loadJWTKeys(cfg config.JWTConfig) (*jwt.Key, []*jwt.Key, error) {
+	if cfg.SigningKeyFile == "" {
+		if len(cfg.VerificationKeyFiles) > 0 {
+			return nil, nil, errors.New("JWT_VERIFICATION_KEY_FILES requires JWT_SIGNING_KEY_FILE")
+		}
+		return nil, nil, nil
+	}
+
+	signingKey, err := jwt.LoadKey(cfg.SigningKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !signingKey.CanSign() {
+		return nil, nil, fmt.Errorf("%s is a public key, the signing key must be private", cfg.SigningKeyFile)
+	}
+
+	verificationKeys := make([]*jwt.Key, 0, len(cfg.VerificationKeyFiles))
+	for _, path := range cfg.VerificationKeyFiles {
+		key, err := jwt.LoadKey(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+	return signingKey, verificationKeys, nil
+}
+
 func initLogger(cfg config.LogConfig) *slog.Logger {
 	var level slog.Level
 	switch cfg.Level {
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+	"github.com/bissquit/incident-garden/internal/notifications"
+	"github.com/bissquit/incident-garden/internal/stream"
+	"github.com/bissquit/incident-garden/internal/webhooks"
+)
+
+// serviceStatusHook recalculates the status of services affected by an event change.
+func serviceStatusHook(service *catalog.Service) events.PublishHook {
+	return func(ctx context.Context, pub events.Publication) {
+		if err := service.RecalculateServiceStatuses(ctx, pub.ServiceIDs, pub.Event.ID); err != nil {
+			slog.Error("failed to recalculate service statuses",
+				"event_id", pub.Event.ID,
+				"kind", pub.Kind,
+				"error", err,
+			)
+		}
+	}
+}
+
+// notifySubscribersHook queues notifications about published event changes.
+// Delivery itself happens in the notification outbox workers.
+func notifySubscribersHook(service *notifications.Service) events.PublishHook {
+	return func(ctx context.Context, pub events.Publication) {
+		if !pub.Notify {
+			return
+		}
+
+		msg := notifications.EventMessage{
+			Event:         pub.Event,
+			Update:        pub.Update,
+			ServicesAdded: pub.Kind == events.PublicationServicesAdded,
+		}
+
+		if err := service.NotifyEvent(ctx, pub.ServiceIDs, msg); err != nil {
+			slog.Error("failed to notify subscribers",
+				"event_id", pub.Event.ID,
+				"kind", pub.Kind,
+				"error", err,
+			)
+		}
+	}
+}
+
+// streamMessageTypes maps event changes to live stream message types.
+var streamMessageTypes = map[events.PublicationKind]string{
+	events.PublicationEventCreated:    stream.TypeEventCreated,
+	events.PublicationEventUpdated:    stream.TypeEventUpdated,
+	events.PublicationServicesAdded:   stream.TypeEventServicesAdded,
+	events.PublicationServicesRemoved: stream.TypeEventServicesRemoved,
+	events.PublicationEventDeleted:    stream.TypeEventDeleted,
+}
+
+// streamEventHook publishes event changes to the live status stream.
+func streamEventHook(service *stream.Service) events.PublishHook {
+	return func(ctx context.Context, pub events.Publication) {
+		payload := stream.EventPayload{
+			Event:      pub.Event,
+			Update:     pub.Update,
+			ServiceIDs: pub.ServiceIDs,
+		}
+
+		if err := service.Publish(ctx, streamMessageTypes[pub.Kind], pub.ServiceIDs, payload); err != nil {
+			slog.Error("failed to publish event to status stream",
+				"event_id", pub.Event.ID,
+				"kind", pub.Kind,
+				"error", err,
+			)
+		}
+	}
+}
+
+// streamStatusHook publishes service status changes to the live status stream.
+// The actor is not exposed on the public stream.
+func streamStatusHook(service *stream.Service) catalog.StatusChangeHook {
+	return func(ctx context.Context, change domain.ServiceStatusChange) {
+		payload := stream.StatusPayload{
+			ServiceID: change.ServiceID,
+			OldStatus: change.OldStatus,
+			NewStatus: change.NewStatus,
+			Reason:    change.Reason,
+			EventID:   change.EventID,
+			ChangedAt: change.CreatedAt,
+		}
+
+		if err := service.Publish(ctx, stream.TypeServiceStatusChanged, []string{change.ServiceID}, payload); err != nil {
+			slog.Error("failed to publish status change to status stream",
+				"service_id", change.ServiceID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// webhookEventHook queues webhook deliveries for event changes.
+// Changes to the list of affected services are not sent to webhooks.
+func webhookEventHook(service *webhooks.Service) events.PublishHook {
+	return func(ctx context.Context, pub events.Publication) {
+		var kinds []domain.WebhookEventKind
+		switch pub.Kind {
+		case events.PublicationEventCreated:
+			kinds = append(kinds, domain.WebhookEventCreated)
+			if pub.Event.Status.IsResolved() {
+				kinds = append(kinds, domain.WebhookEventResolved)
+			}
+		case events.PublicationEventUpdated:
+			kinds = append(kinds, domain.WebhookEventUpdated)
+			if pub.Update != nil && pub.Update.Status.IsResolved() {
+				kinds = append(kinds, domain.WebhookEventResolved)
+			}
+		case events.PublicationEventDeleted:
+			kinds = append(kinds, domain.WebhookEventDeleted)
+		default:
+			return
+		}
+
+		payload := stream.EventPayload{
+			Event:      pub.Event,
+			Update:     pub.Update,
+			ServiceIDs: pub.ServiceIDs,
+		}
+
+		for _, kind := range kinds {
+			if err := service.Enqueue(ctx, kind, pub.ServiceIDs, payload); err != nil {
+				slog.Error("failed to queue webhook deliveries",
+					"event_id", pub.Event.ID,
+					"kind", kind,
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+// webhookStatusHook queues webhook deliveries for service status changes.
+func webhookStatusHook(service *webhooks.Service) catalog.StatusChangeHook {
+	return func(ctx context.Context, change domain.ServiceStatusChange) {
+		payload := stream.StatusPayload{
+			ServiceID: change.ServiceID,
+			OldStatus: change.OldStatus,
+			NewStatus: change.NewStatus,
+			Reason:    change.Reason,
+			EventID:   change.EventID,
+			ChangedAt: change.CreatedAt,
+		}
+
+		if err := service.Enqueue(ctx, domain.WebhookServiceStatusChanged, []string{change.ServiceID}, payload); err != nil {
+			slog.Error("failed to queue webhook deliveries",
+				"service_id", change.ServiceID,
+				"kind", domain.WebhookServiceStatusChanged,
+				"error", err,
+			)
+		}
+	}
+}
+
+// backgroundMailer sends account emails without waiting for the SMTP server,
+// so a response does not reveal whether an email was sent.
+type backgroundMailer struct {
+	sender notifications.Sender
+}
+
+// SendMail implements identity.Mailer.
+func (m backgroundMailer) SendMail(ctx context.Context, to, subject, body string) error {
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		if err := m.sender.Send(ctx, notifications.Notification{To: to, Subject: subject, Body: body}); err != nil {
+			slog.Error("failed to send email", "subject", subject, "error", err)
+		}
+	}()
+	return nil
+}
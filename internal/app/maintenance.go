@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+// maintenanceSchedulerLockKey is the advisory lock key that keeps the
+// scheduler running on a single replica at a time.
+const maintenanceSchedulerLockKey int64 = 0x6d61696e74 // "maint"
+
+// maintenanceEvents is the part of events.Service used by the scheduler.
+type maintenanceEvents interface {
+	ListMaintenanceToStart(ctx context.Context) ([]*domain.Event, error)
+	ListMaintenanceToComplete(ctx context.Context) ([]*domain.Event, error)
+	ListMaintenanceToRemind(ctx context.Context, within time.Duration) ([]*domain.Event, error)
+	MarkMaintenanceReminded(ctx context.Context, id string) error
+	AddUpdate(ctx context.Context, input events.CreateEventUpdateInput, createdBy string) (*domain.EventUpdate, error)
+}
+
+// maintenanceNotifier sends "starting soon" reminders.
+type maintenanceNotifier interface {
+	NotifyEvent(ctx context.Context, serviceIDs []string, msg notifications.EventMessage) error
+}
+
+// lockFunc runs fn if the lock could be taken and reports whether it did.
+type lockFunc func(ctx context.Context, fn func(ctx context.Context) error) (bool, error)
+
+// maintenanceScheduler moves maintenance events through their scheduled window:
+// scheduled -> in_progress at start and -> completed at end.
+type maintenanceScheduler struct {
+	events         maintenanceEvents
+	notifier       maintenanceNotifier
+	lock           lockFunc
+	interval       time.Duration
+	reminderBefore time.Duration
+}
+
+func newMaintenanceScheduler(eventsService maintenanceEvents, notifier maintenanceNotifier, lock lockFunc, interval, reminderBefore time.Duration) *maintenanceScheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &maintenanceScheduler{
+		events:         eventsService,
+		notifier:       notifier,
+		lock:           lock,
+		interval:       interval,
+		reminderBefore: reminderBefore,
+	}
+}
+
+// Run checks maintenance windows every interval until ctx is cancelled.
+func (s *maintenanceScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.lock(ctx, s.runOnce); err != nil && ctx.Err() == nil {
+			slog.Error("maintenance scheduler failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce completes finished windows, starts due ones and sends reminders.
+// Completion goes first so a window that passed entirely is not started afterwards.
+func (s *maintenanceScheduler) runOnce(ctx context.Context) error {
+	toComplete, err := s.events.ListMaintenanceToComplete(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range toComplete {
+		s.transition(ctx, event, domain.EventStatusCompleted, "Scheduled maintenance has been completed.")
+	}
+
+	toStart, err := s.events.ListMaintenanceToStart(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range toStart {
+		s.transition(ctx, event, domain.EventStatusInProgress, "Scheduled maintenance is now in progress.")
+	}
+
+	if s.reminderBefore <= 0 {
+		return nil
+	}
+
+	toRemind, err := s.events.ListMaintenanceToRemind(ctx, s.reminderBefore)
+	if err != nil {
+		return err
+	}
+	for _, event := range toRemind {
+		s.remind(ctx, event)
+	}
+
+	return nil
+}
+
+func (s *maintenanceScheduler) transition(ctx context.Context, event *domain.Event, status domain.EventStatus, message string) {
+	_, err := s.events.AddUpdate(ctx, events.CreateEventUpdateInput{
+		EventID:           event.ID,
+		Status:            status,
+		Message:           message,
+		NotifySubscribers: event.NotifySubscribers,
+	}, domain.SystemUserID)
+	if err != nil {
+		slog.Error("failed to update scheduled maintenance",
+			"event_id", event.ID,
+			"status", status,
+			"error", err,
+		)
+		return
+	}
+
+	slog.Info("scheduled maintenance status changed", "event_id", event.ID, "status", status)
+}
+
+func (s *maintenanceScheduler) remind(ctx context.Context, event *domain.Event) {
+	msg := notifications.EventMessage{Event: event, StartingSoon: true}
+	if err := s.notifier.NotifyEvent(ctx, event.ServiceIDs, msg); err != nil {
+		slog.Error("failed to send maintenance reminder", "event_id", event.ID, "error", err)
+		return
+	}
+
+	if err := s.events.MarkMaintenanceReminded(ctx, event.ID); err != nil {
+		slog.Error("failed to mark maintenance reminded", "event_id", event.ID, "error", err)
+	}
+}
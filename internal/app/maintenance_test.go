@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+type fakeMaintenanceEvents struct {
+	toStart, toComplete, toRemind []*domain.Event
+	remindWithin                  time.Duration
+
+	updates  []events.CreateEventUpdateInput
+	authors  []string
+	reminded []string
+}
+
+func (f *fakeMaintenanceEvents) ListMaintenanceToStart(context.Context) ([]*domain.Event, error) {
+	return f.toStart, nil
+}
+
+func (f *fakeMaintenanceEvents) ListMaintenanceToComplete(context.Context) ([]*domain.Event, error) {
+	return f.toComplete, nil
+}
+
+func (f *fakeMaintenanceEvents) ListMaintenanceToRemind(_ context.Context, within time.Duration) ([]*domain.Event, error) {
+	f.remindWithin = within
+	return f.toRemind, nil
+}
+
+func (f *fakeMaintenanceEvents) MarkMaintenanceReminded(_ context.Context, id string) error {
+	f.reminded = append(f.reminded, id)
+	return nil
+}
+
+func (f *fakeMaintenanceEvents) AddUpdate(_ context.Context, input events.CreateEventUpdateInput, createdBy string) (*domain.EventUpdate, error) {
+	f.updates = append(f.updates, input)
+	f.authors = append(f.authors, createdBy)
+	return &domain.EventUpdate{EventID: input.EventID, Status: input.Status}, nil
+}
+
+type fakeNotifier struct {
+	messages []notifications.EventMessage
+}
+
+func (f *fakeNotifier) NotifyEvent(_ context.Context, _ []string, msg notifications.EventMessage) error {
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+func TestMaintenanceScheduler_RunOnce(t *testing.T) {
+	eventsFake := &fakeMaintenanceEvents{
+		toComplete: []*domain.Event{{ID: "done", NotifySubscribers: true}},
+		toStart:    []*domain.Event{{ID: "start"}},
+		toRemind:   []*domain.Event{{ID: "soon", ServiceIDs: []string{"s1"}}},
+	}
+	notifier := &fakeNotifier{}
+	s := newMaintenanceScheduler(eventsFake, notifier, nil, 0, 15*time.Minute)
+
+	if err := s.runOnce(context.Background()); err != nil {
+		t.Fatalf("runOnce() error = %v", err)
+	}
+
+	if len(eventsFake.updates) != 2 {
+		t.Fatalf("updates = %d, want 2", len(eventsFake.updates))
+	}
+	if got := eventsFake.updates[0]; got.EventID != "done" || got.Status != domain.EventStatusCompleted || !got.NotifySubscribers {
+		t.Errorf("first update = %+v, want completion of \"done\" with notification", got)
+	}
+	if got := eventsFake.updates[1]; got.EventID != "start" || got.Status != domain.EventStatusInProgress || got.NotifySubscribers {
+		t.Errorf("second update = %+v, want start of \"start\" without notification", got)
+	}
+	for _, author := range eventsFake.authors {
+		if author != domain.SystemUserID {
+			t.Errorf("update author = %q, want system user", author)
+		}
+	}
+
+	if eventsFake.remindWithin != 15*time.Minute {
+		t.Errorf("remind window = %v, want 15m", eventsFake.remindWithin)
+	}
+	if len(notifier.messages) != 1 || !notifier.messages[0].StartingSoon || notifier.messages[0].Event.ID != "soon" {
+		t.Errorf("reminders = %+v, want one starting-soon reminder for \"soon\"", notifier.messages)
+	}
+	if len(eventsFake.reminded) != 1 || eventsFake.reminded[0] != "soon" {
+		t.Errorf("reminded = %v, want [soon]", eventsFake.reminded)
+	}
+}
+
+func TestMaintenanceScheduler_RemindersDisabled(t *testing.T) {
+	eventsFake := &fakeMaintenanceEvents{toRemind: []*domain.Event{{ID: "soon"}}}
+	notifier := &fakeNotifier{}
+	s := newMaintenanceScheduler(eventsFake, notifier, nil, time.Minute, 0)
+
+	if err := s.runOnce(context.Background()); err != nil {
+		t.Fatalf("runOnce() error = %v", err)
+	}
+	if len(notifier.messages) != 0 {
+		t.Errorf("reminders sent while disabled: %+v", notifier.messages)
+	}
+}
+
+func TestMaintenanceScheduler_SkipsWhenLockHeldElsewhere(t *testing.T) {
+	eventsFake := &fakeMaintenanceEvents{toStart: []*domain.Event{{ID: "start"}}}
+	lock := func(context.Context, func(context.Context) error) (bool, error) { return false, nil }
+	s := newMaintenanceScheduler(eventsFake, &fakeNotifier{}, lock, time.Hour, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+
+	if len(eventsFake.updates) != 0 {
+		t.Errorf("updates = %d, want 0 when another replica holds the lock", len(eventsFake.updates))
+	}
+}
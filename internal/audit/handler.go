@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// Page size limits; CSV exports may fetch more rows at once.
+const (
+	defaultLimit    = 50
+	maxLimit        = 500
+	defaultCSVLimit = 10000
+	maxCSVLimit     = 10000
+)
+
+// Handler handles HTTP requests for the audit log.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new audit handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers audit routes (require admin).
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/audit", h.List)
+}
+
+// List handles GET /audit. With format=csv the entries are exported as CSV.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	asCSV := q.Get("format") == "csv"
+	if format := q.Get("format"); format != "" && format != "json" && !asCSV {
+		h.respondError(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	filter := Filter{Limit: defaultLimit}
+	limitMax := maxLimit
+	if asCSV {
+		filter.Limit = defaultCSVLimit
+		limitMax = maxCSVLimit
+	}
+
+	if actorType := q.Get("actor_type"); actorType != "" {
+		t := domain.AuditActorType(actorType)
+		if !t.IsValid() {
+			h.respondError(w, http.StatusBadRequest, "invalid actor_type")
+			return
+		}
+		filter.ActorType = &t
+	}
+
+	for param, dst := range map[string]**string{
+		"actor_id":    &filter.ActorID,
+		"api_key_id":  &filter.APIKeyID,
+		"action":      &filter.Action,
+		"entity_type": &filter.EntityType,
+		"entity_id":   &filter.EntityID,
+	} {
+		if value := q.Get(param); value != "" {
+			*dst = &value
+		}
+	}
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := q.Get(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				h.respondError(w, http.StatusBadRequest, param+" must be an RFC 3339 timestamp")
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > limitMax {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(limitMax))
+			return
+		}
+		filter.Limit = n
+	}
+
+	if offset := q.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			h.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		filter.Offset = n
+	}
+
+	entries, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		slog.Error("internal error", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if asCSV {
+		h.respondCSV(w, entries)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, entries)
+}
+
+var csvHeader = []string{
+	"id", "created_at", "actor_type", "actor_id", "api_key_id", "action",
+	"entity_type", "entity_id", "before", "after", "ip", "request_id",
+}
+
+func (h *Handler) respondCSV(w http.ResponseWriter, entries []*domain.AuditEntry) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(csvHeader)
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			e.ID,
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			string(e.ActorType),
+			deref(e.ActorID),
+			deref(e.APIKeyID),
+			csvSafe(e.Action),
+			csvSafe(e.EntityType),
+			csvSafe(e.EntityID),
+			csvSafe(string(e.Before)),
+			csvSafe(string(e.After)),
+			csvSafe(e.IP),
+			csvSafe(e.RequestID),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		slog.Error("failed to write csv response", "error", err)
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// csvSafe prevents spreadsheet formula injection from cells starting with a formula character.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": data}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
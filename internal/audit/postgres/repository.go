@@ -0,0 +1,122 @@
+// Package postgres provides PostgreSQL implementation of the audit repository.
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bissquit/incident-garden/internal/audit"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository implements audit.Repository using PostgreSQL.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a new PostgreSQL repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateEntry stores an audit entry.
+func (r *Repository) CreateEntry(ctx context.Context, entry *domain.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_type, actor_id, api_key_id, action, entity_type, entity_id,
+			before, after, ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query,
+		entry.ActorType, entry.ActorID, entry.APIKeyID, entry.Action, entry.EntityType, entry.EntityID,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.IP, entry.RequestID,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListEntries returns audit entries matching the filter, newest first.
+func (r *Repository) ListEntries(ctx context.Context, filter audit.Filter) ([]*domain.AuditEntry, error) {
+	query := `
+		SELECT id, actor_type, actor_id, api_key_id, action, entity_type, entity_id,
+			before, after, ip, request_id, created_at
+		FROM audit_log
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argNum := 1
+
+	conditions := []struct {
+		column string
+		op     string
+		value  interface{}
+		set    bool
+	}{
+		{"actor_type", "=", filter.ActorType, filter.ActorType != nil},
+		{"actor_id", "=", filter.ActorID, filter.ActorID != nil},
+		{"api_key_id", "=", filter.APIKeyID, filter.APIKeyID != nil},
+		{"action", "=", filter.Action, filter.Action != nil},
+		{"entity_type", "=", filter.EntityType, filter.EntityType != nil},
+		{"entity_id", "=", filter.EntityID, filter.EntityID != nil},
+		{"created_at", ">=", filter.From, filter.From != nil},
+		{"created_at", "<", filter.To, filter.To != nil},
+	}
+	for _, c := range conditions {
+		if !c.set {
+			continue
+		}
+		query += fmt.Sprintf(" AND %s %s $%d", c.column, c.op, argNum)
+		args = append(args, c.value)
+		argNum++
+	}
+
+	query += " ORDER BY created_at DESC, id"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*domain.AuditEntry, 0)
+	for rows.Next() {
+		var e domain.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(
+			&e.ID, &e.ActorType, &e.ActorID, &e.APIKeyID, &e.Action, &e.EntityType, &e.EntityID,
+			&before, &after, &e.IP, &e.RequestID, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		e.Before = before
+		e.After = after
+		entries = append(entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// nullJSON stores an empty snapshot as NULL.
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,29 @@
+// Package audit records who changed what and serves the audit log.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// Filter narrows the audit log listing.
+type Filter struct {
+	ActorType  *domain.AuditActorType
+	ActorID    *string
+	APIKeyID   *string
+	Action     *string
+	EntityType *string
+	EntityID   *string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// Repository defines the interface for audit log data access.
+type Repository interface {
+	CreateEntry(ctx context.Context, entry *domain.AuditEntry) error
+	ListEntries(ctx context.Context, filter Filter) ([]*domain.AuditEntry, error)
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Service records changes and lists the audit log.
+type Service struct {
+	repo Repository
+}
+
+// NewService creates a new audit service.
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Record stores a change with the actor, client IP and request ID taken from
+// the context. It is a domain.AuditFunc; failures are logged so that auditing
+// never fails the change itself.
+func (s *Service) Record(ctx context.Context, change domain.AuditChange) {
+	entry, err := newEntry(ctx, change)
+	if err == nil {
+		err = s.repo.CreateEntry(ctx, entry)
+	}
+	if err != nil {
+		slog.Error("failed to record audit entry",
+			"action", change.Action,
+			"entity_type", change.EntityType,
+			"entity_id", change.EntityID,
+			"error", err,
+		)
+	}
+}
+
+// List returns audit entries matching the filter, newest first.
+func (s *Service) List(ctx context.Context, filter Filter) ([]*domain.AuditEntry, error) {
+	return s.repo.ListEntries(ctx, filter)
+}
+
+func newEntry(ctx context.Context, change domain.AuditChange) (*domain.AuditEntry, error) {
+	before, after, err := diff(change.Before, change.After)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &domain.AuditEntry{
+		ActorType:  domain.AuditActorSystem,
+		Action:     change.Action,
+		EntityType: change.EntityType,
+		EntityID:   change.EntityID,
+		Before:     before,
+		After:      after,
+		IP:         httputil.GetClientIP(ctx),
+		RequestID:  middleware.GetReqID(ctx),
+	}
+
+	if userID := httputil.GetUserID(ctx); userID != "" {
+		entry.ActorType = domain.AuditActorUser
+		entry.ActorID = &userID
+	}
+	if keyID := httputil.GetAPIKeyID(ctx); keyID != "" {
+		entry.ActorType = domain.AuditActorAPIKey
+		entry.APIKeyID = &keyID
+	}
+
+	return entry, nil
+}
+
+// diff encodes before and after as JSON. When both are objects only the
+// top-level fields that differ are kept.
+func diff(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	beforeJSON, err := encode(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := encode(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if beforeJSON == nil || afterJSON == nil {
+		return beforeJSON, afterJSON, nil
+	}
+
+	var beforeFields, afterFields map[string]interface{}
+	if json.Unmarshal(beforeJSON, &beforeFields) != nil || json.Unmarshal(afterJSON, &afterFields) != nil {
+		return beforeJSON, afterJSON, nil
+	}
+
+	for key, value := range beforeFields {
+		if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+			delete(beforeFields, key)
+			delete(afterFields, key)
+		}
+	}
+
+	if beforeJSON, err = json.Marshal(beforeFields); err != nil {
+		return nil, nil, fmt.Errorf("encode audit diff: %w", err)
+	}
+	if afterJSON, err = json.Marshal(afterFields); err != nil {
+		return nil, nil, fmt.Errorf("encode audit diff: %w", err)
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+func encode(v interface{}) (json.RawMessage, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode audit snapshot: %w", err)
+	}
+	return data, nil
+}
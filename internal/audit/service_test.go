@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type fakeRepo struct {
+	Repository
+
+	entries []*domain.AuditEntry
+}
+
+func (f *fakeRepo) CreateEntry(_ context.Context, entry *domain.AuditEntry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func TestService_RecordDiff(t *testing.T) {
+	repo := &fakeRepo{}
+	s := NewService(repo)
+
+	before := &domain.Service{ID: "s1", Name: "API", Slug: "api", Order: 1}
+	after := &domain.Service{ID: "s1", Name: "Public API", Slug: "api", Order: 1}
+	s.Record(context.Background(), domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityService,
+		EntityID:   "s1",
+		Before:     before,
+		After:      after,
+	})
+
+	if len(repo.entries) != 1 {
+		t.Fatalf("recorded %d entries, want 1", len(repo.entries))
+	}
+	entry := repo.entries[0]
+	if string(entry.Before) != `{"name":"API"}` || string(entry.After) != `{"name":"Public API"}` {
+		t.Errorf("diff = %s -> %s, want only the name", entry.Before, entry.After)
+	}
+	if entry.ActorType != domain.AuditActorSystem || entry.ActorID != nil {
+		t.Errorf("actor = %s/%v, want system without ID", entry.ActorType, entry.ActorID)
+	}
+}
+
+func TestService_RecordCreateKeepsSnapshot(t *testing.T) {
+	repo := &fakeRepo{}
+	s := NewService(repo)
+
+	s.Record(context.Background(), domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityServiceTags,
+		EntityID:   "s1",
+		Before:     (*domain.Service)(nil),
+		After:      map[string]string{"env": "prod"},
+	})
+
+	entry := repo.entries[0]
+	if entry.Before != nil {
+		t.Errorf("before = %s, want none for a nil snapshot", entry.Before)
+	}
+	var after map[string]string
+	if err := json.Unmarshal(entry.After, &after); err != nil || after["env"] != "prod" {
+		t.Errorf("after = %s, want the full snapshot", entry.After)
+	}
+}
+
+func TestService_RecordActor(t *testing.T) {
+	ctx := context.WithValue(context.Background(), httputil.UserIDKey, "u1")
+	ctx = context.WithValue(ctx, httputil.ClientIPKey, "203.0.113.7")
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, "host/abc-000001")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantType  domain.AuditActorType
+		wantKeyID string
+	}{
+		{name: "user", ctx: ctx, wantType: domain.AuditActorUser},
+		{
+			name:      "api key",
+			ctx:       context.WithValue(ctx, httputil.APIKeyIDKey, "k1"),
+			wantType:  domain.AuditActorAPIKey,
+			wantKeyID: "k1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{}
+			NewService(repo).Record(tt.ctx, domain.AuditChange{
+				Action:     domain.AuditActionArchive,
+				EntityType: domain.AuditEntityService,
+				EntityID:   "s1",
+			})
+
+			entry := repo.entries[0]
+			if entry.ActorType != tt.wantType || entry.ActorID == nil || *entry.ActorID != "u1" {
+				t.Errorf("actor = %s/%v, want %s/u1", entry.ActorType, entry.ActorID, tt.wantType)
+			}
+			if got := deref(entry.APIKeyID); got != tt.wantKeyID {
+				t.Errorf("api key = %q, want %q", got, tt.wantKeyID)
+			}
+			if entry.IP != "203.0.113.7" || entry.RequestID != "host/abc-000001" {
+				t.Errorf("request = %s/%s", entry.IP, entry.RequestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package badge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
+	"github.com/go-chi/chi/v5"
+)
+
+// CacheMaxAge is how long clients and proxies may cache a badge.
+const CacheMaxAge = 60 * time.Second
+
+// maxLabelLength limits the label query parameter, in characters.
+const maxLabelLength = 64
+
+// Endpoint is the shields.io endpoint badge schema (https://shields.io/badges/endpoint-badge).
+type Endpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	IsError       bool   `json:"isError,omitempty"`
+	Style         string `json:"style,omitempty"`
+}
+
+// Handler handles badge requests.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new badge handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers public badge routes.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/badges", func(r chi.Router) {
+		r.Get("/services/{slug}.svg", h.serveSVG(h.service.ServiceBadge))
+		r.Get("/services/{slug}.json", h.serveEndpoint(h.service.ServiceBadge))
+		r.Get("/groups/{slug}.svg", h.serveSVG(h.service.GroupBadge))
+		r.Get("/groups/{slug}.json", h.serveEndpoint(h.service.GroupBadge))
+	})
+}
+
+type loadFunc func(ctx context.Context, slug string) (*Badge, error)
+
+// badgeOptions holds the query parameters of a badge request.
+type badgeOptions struct {
+	label    string
+	hasLabel bool
+	style    string
+}
+
+func parseOptions(r *http.Request) (badgeOptions, error) {
+	query := r.URL.Query()
+	opts := badgeOptions{
+		label:    query.Get("label"),
+		hasLabel: query.Has("label"),
+		style:    query.Get("style"),
+	}
+
+	if opts.style == "" {
+		opts.style = StyleFlat
+	}
+	if !IsValidStyle(opts.style) {
+		return opts, errors.New("invalid style: must be flat, flat-square or for-the-badge")
+	}
+	if utf8.RuneCountInString(opts.label) > maxLabelLength {
+		return opts, errors.New("label is too long")
+	}
+	return opts, nil
+}
+
+// load returns the badge with the label from the query applied and the HTTP status to send it with.
+// An unknown service or group yields a grey "not found" badge, so that embedding pages still show something.
+func (h *Handler) load(r *http.Request, load loadFunc, opts badgeOptions) (*Badge, int, bool) {
+	badge, err := load(r.Context(), chi.URLParam(r, "slug"))
+	status := http.StatusOK
+	switch {
+	case err == nil:
+	case errors.Is(err, catalog.ErrServiceNotFound), errors.Is(err, catalog.ErrGroupNotFound):
+		badge = &Badge{Label: "status", Message: "not found"}
+		status = http.StatusNotFound
+	default:
+		slog.Error("failed to load badge", "error", err)
+		return nil, http.StatusInternalServerError, false
+	}
+
+	if opts.hasLabel {
+		badge.Label = opts.label
+	}
+	return badge, status, true
+}
+
+func (h *Handler) serveSVG(load loadFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseOptions(r)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		badge, status, ok := h.load(r, load, opts)
+		if !ok {
+			h.respondError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		h.write(w, r, status, "image/svg+xml; charset=utf-8", RenderSVG(badge, opts.style))
+	}
+}
+
+func (h *Handler) serveEndpoint(load loadFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseOptions(r)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		badge, status, ok := h.load(r, load, opts)
+		if !ok {
+			h.respondError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		endpoint := Endpoint{
+			SchemaVersion: 1,
+			Label:         badge.Label,
+			Message:       badge.Message,
+			Color:         statusColor(badge.Status).name,
+			IsError:       status != http.StatusOK,
+		}
+		if r.URL.Query().Has("style") {
+			endpoint.Style = opts.style
+		}
+
+		body, err := json.Marshal(endpoint)
+		if err != nil {
+			slog.Error("failed to encode badge", "error", err)
+			h.respondError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		h.write(w, r, status, "application/json", body)
+	}
+}
+
+// write sends a badge with cache headers so that READMEs and dashboards can poll it cheaply.
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, status int, contentType string, body []byte) {
+	etag := httputil.ETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(CacheMaxAge.Seconds())))
+
+	if status == http.StatusOK && httputil.NotModified(r, etag, time.Time{}) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		slog.Error("failed to write badge", "error", err)
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
@@ -0,0 +1,134 @@
+package badge
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeCatalog struct{}
+
+func (fakeCatalog) GetServiceBySlug(_ context.Context, slug string) (*domain.Service, error) {
+	if slug != "api" {
+		return nil, catalog.ErrServiceNotFound
+	}
+	return &domain.Service{ID: "svc-1", Name: "API <v2>", Slug: "api", Status: domain.ServiceStatusDegraded}, nil
+}
+
+func (fakeCatalog) GetGroupBySlug(_ context.Context, slug string) (*domain.ServiceGroup, error) {
+	if slug != "core" {
+		return nil, catalog.ErrGroupNotFound
+	}
+	return &domain.ServiceGroup{ID: "grp-1", Name: "Core", Slug: "core"}, nil
+}
+
+func (fakeCatalog) GroupStatus(_ context.Context, _ string) (domain.ServiceStatus, error) {
+	return domain.ServiceStatusMajorOutage, nil
+}
+
+func newTestRouter() chi.Router {
+	r := chi.NewRouter()
+	NewHandler(NewService(fakeCatalog{})).RegisterRoutes(r)
+	return r
+}
+
+func get(r http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServiceBadgeSVG(t *testing.T) {
+	r := newTestRouter()
+
+	rec := get(r, "/badges/services/api.svg", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "image/svg+xml") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+
+	body := rec.Body.String()
+	if err := xml.Unmarshal([]byte(body), new(struct{})); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, body)
+	}
+	for _, want := range []string{"<title>API &lt;v2&gt;: degraded</title>", statusColors[domain.ServiceStatusDegraded].hex} {
+		if !strings.Contains(body, want) {
+			t.Errorf("SVG does not contain %q:\n%s", want, body)
+		}
+	}
+
+	if rec := get(r, "/badges/services/api.svg", map[string]string{"If-None-Match": rec.Header().Get("ETag")}); rec.Code != http.StatusNotModified {
+		t.Errorf("conditional status = %d, want 304", rec.Code)
+	}
+}
+
+func TestServiceBadgeSVG_Options(t *testing.T) {
+	r := newTestRouter()
+
+	rec := get(r, "/badges/services/api.svg?label=uptime&style=for-the-badge", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<title>UPTIME: DEGRADED</title>") {
+		t.Errorf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = get(r, "/badges/services/api.svg?label=", nil)
+	if !strings.Contains(rec.Body.String(), "<title>degraded</title>") {
+		t.Errorf("empty label: %s", rec.Body.String())
+	}
+
+	if rec := get(r, "/badges/services/api.svg?style=plastic3d", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid style status = %d, want 400", rec.Code)
+	}
+}
+
+func TestGroupBadgeEndpoint(t *testing.T) {
+	r := newTestRouter()
+
+	rec := get(r, "/badges/groups/core.json", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var endpoint Endpoint
+	if err := json.Unmarshal(rec.Body.Bytes(), &endpoint); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := Endpoint{SchemaVersion: 1, Label: "Core", Message: "major outage", Color: "red"}
+	if endpoint != want {
+		t.Errorf("endpoint = %+v, want %+v", endpoint, want)
+	}
+}
+
+func TestBadge_NotFound(t *testing.T) {
+	r := newTestRouter()
+
+	rec := get(r, "/badges/services/missing.svg", nil)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "not found") {
+		t.Errorf("svg status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = get(r, "/badges/groups/missing.json", nil)
+	var endpoint Endpoint
+	if err := json.Unmarshal(rec.Body.Bytes(), &endpoint); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusNotFound || !endpoint.IsError || endpoint.Color != unknownColor.name {
+		t.Errorf("json status = %d, endpoint = %+v", rec.Code, endpoint)
+	}
+}
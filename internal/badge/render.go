@@ -0,0 +1,140 @@
+package badge
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// Badge styles, named as in shields.io.
+const (
+	StyleFlat        = "flat"
+	StyleFlatSquare  = "flat-square"
+	StyleForTheBadge = "for-the-badge"
+)
+
+// IsValidStyle checks if the badge style is supported.
+func IsValidStyle(style string) bool {
+	return style == StyleFlat || style == StyleFlatSquare || style == StyleForTheBadge
+}
+
+type color struct {
+	name string
+	hex  string
+}
+
+var (
+	labelColor   = "#555"
+	unknownColor = color{name: "lightgrey", hex: "#9f9f9f"}
+	statusColors = map[domain.ServiceStatus]color{
+		domain.ServiceStatusOperational:   {name: "brightgreen", hex: "#4c1"},
+		domain.ServiceStatusMaintenance:   {name: "blue", hex: "#007ec6"},
+		domain.ServiceStatusDegraded:      {name: "yellow", hex: "#dfb317"},
+		domain.ServiceStatusPartialOutage: {name: "orange", hex: "#fe7d37"},
+		domain.ServiceStatusMajorOutage:   {name: "red", hex: "#e05d44"},
+	}
+)
+
+func statusColor(status domain.ServiceStatus) color {
+	if c, ok := statusColors[status]; ok {
+		return c
+	}
+	return unknownColor
+}
+
+// RenderSVG renders the badge in the given style; an empty label renders the message only.
+func RenderSVG(badge *Badge, style string) []byte {
+	label, message := badge.Label, badge.Message
+	height, fontSize, padding, textY := 20, 110, 10, 140
+	if style == StyleForTheBadge {
+		label, message = strings.ToUpper(label), strings.ToUpper(message)
+		height, fontSize, padding, textY = 28, 100, 24, 175
+	}
+
+	labelWidth := 0
+	if label != "" {
+		labelWidth = textWidth(label, style) + padding
+	}
+	messageWidth := textWidth(message, style) + padding
+	width := labelWidth + messageWidth
+
+	title := message
+	if label != "" {
+		title = label + ": " + message
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s">`,
+		width, height, html.EscapeString(title))
+	fmt.Fprintf(&b, `<title>%s</title>`, html.EscapeString(title))
+
+	radius := 0
+	if style == StyleFlat {
+		radius = 3
+		b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	}
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="%d" rx="%d" fill="#fff"/></clipPath>`, width, height, radius)
+
+	b.WriteString(`<g clip-path="url(#r)">`)
+	if labelWidth > 0 {
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, labelWidth, height, labelColor)
+	}
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="%d" fill="%s"/>`, labelWidth, messageWidth, height, statusColor(badge.Status).hex)
+	if style == StyleFlat {
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="url(#s)"/>`, width, height)
+	}
+	b.WriteString(`</g>`)
+
+	// Text is laid out at 10x scale, as shields.io does, to keep sub-pixel positions in integers.
+	fmt.Fprintf(&b, `<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="%d">`, fontSize)
+	if labelWidth > 0 {
+		writeText(&b, label, labelWidth*5, textY, (labelWidth-padding)*10, style)
+	}
+	writeText(&b, message, (labelWidth*2+messageWidth)*5, textY, (messageWidth-padding)*10, style)
+	b.WriteString(`</g></svg>`)
+
+	return b.Bytes()
+}
+
+func writeText(b *bytes.Buffer, text string, x, y, length int, style string) {
+	escaped := html.EscapeString(text)
+	weight := ""
+	if style == StyleForTheBadge {
+		weight = ` font-weight="bold"`
+	} else {
+		// Shadow under the text.
+		fmt.Fprintf(b, `<text x="%d" y="%d" transform="scale(.1)" fill="#010101" fill-opacity=".3" textLength="%d">%s</text>`,
+			x, y+10, length, escaped)
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d" transform="scale(.1)"%s textLength="%d">%s</text>`, x, y, weight, length, escaped)
+}
+
+// textWidth estimates the width in pixels of text set in 11px Verdana.
+func textWidth(text, style string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("ijl.,:;!|'I ", r):
+			width += 3.9
+		case strings.ContainsRune("ftr()[]-", r):
+			width += 4.8
+		case strings.ContainsRune("mwMW", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.6
+		case r >= '0' && r <= '9':
+			width += 7.0
+		default:
+			width += 6.8
+		}
+	}
+	if style == StyleForTheBadge {
+		// Bold 10px capitals with letter spacing.
+		width *= 1.1
+	}
+	return int(math.Ceil(width))
+}
@@ -0,0 +1,65 @@
+// Package badge renders embeddable status badges of services and groups.
+package badge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// Badge is the content of a status badge.
+type Badge struct {
+	Label   string
+	Message string
+	Status  domain.ServiceStatus
+}
+
+// ServiceCatalog is the part of catalog.Service used to look up statuses.
+type ServiceCatalog interface {
+	GetServiceBySlug(ctx context.Context, slug string) (*domain.Service, error)
+	GetGroupBySlug(ctx context.Context, slug string) (*domain.ServiceGroup, error)
+	GroupStatus(ctx context.Context, groupID string) (domain.ServiceStatus, error)
+}
+
+// Service builds status badges.
+type Service struct {
+	catalog ServiceCatalog
+}
+
+// NewService creates a new badge service.
+func NewService(serviceCatalog ServiceCatalog) *Service {
+	return &Service{catalog: serviceCatalog}
+}
+
+// ServiceBadge returns the badge of the service status.
+func (s *Service) ServiceBadge(ctx context.Context, slug string) (*Badge, error) {
+	service, err := s.catalog.GetServiceBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return newBadge(service.Name, service.Status), nil
+}
+
+// GroupBadge returns the badge of the worst status among the group's services.
+func (s *Service) GroupBadge(ctx context.Context, slug string) (*Badge, error) {
+	group, err := s.catalog.GetGroupBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.catalog.GroupStatus(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get group status: %w", err)
+	}
+	return newBadge(group.Name, status), nil
+}
+
+func newBadge(label string, status domain.ServiceStatus) *Badge {
+	return &Badge{
+		Label:   label,
+		Message: strings.ReplaceAll(string(status), "_", " "),
+		Status:  status,
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/go-chi/chi/v5"
@@ -49,6 +51,11 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 	})
 }
 
+// RegisterOperatorRoutes registers operator-level routes.
+func (h *Handler) RegisterOperatorRoutes(r chi.Router) {
+	r.Get("/services/{slug}/history", h.GetServiceStatusHistory)
+}
+
 // CreateGroupRequest represents the request body for creating a service group.
 type CreateGroupRequest struct {
 	Name        string `json:"name" validate:"required,min=1,max=255"`
@@ -77,16 +84,18 @@ type UpdateGroupRequest struct {
 
 // CreateServiceRequest represents the request body for creating a service.
 type CreateServiceRequest struct {
-	Name        string            `json:"name" validate:"required,min=1,max=255"`
-	Slug        string            `json:"slug" validate:"required,min=1,max=255"`
-	Description string            `json:"description"`
-	Status      string            `json:"status" validate:"omitempty,oneof=operational degraded partial_outage major_outage maintenance"`
-	GroupIDs    []string          `json:"group_ids"`
-	Order       int               `json:"order"`
-	Tags        map[string]string `json:"tags"`
+	Name           string            `json:"name" validate:"required,min=1,max=255"`
+	Slug           string            `json:"slug" validate:"required,min=1,max=255"`
+	Description    string            `json:"description"`
+	Status         string            `json:"status" validate:"omitempty,oneof=operational degraded partial_outage major_outage maintenance"`
+	StatusOverride *bool             `json:"status_override"`
+	GroupIDs       []string          `json:"group_ids"`
+	Order          int               `json:"order"`
+	Tags           map[string]string `json:"tags"`
 }
 
 // ToDomain converts the request to a domain model.
+// A non-operational status is treated as a manual override unless status_override says otherwise.
 func (r *CreateServiceRequest) ToDomain() *domain.Service {
 	status := domain.ServiceStatus(r.Status)
 	if status == "" {
@@ -98,24 +107,31 @@ func (r *CreateServiceRequest) ToDomain() *domain.Service {
 		groupIDs = make([]string, 0)
 	}
 
+	override := status != domain.ServiceStatusOperational
+	if r.StatusOverride != nil {
+		override = *r.StatusOverride
+	}
+
 	return &domain.Service{
-		Name:        r.Name,
-		Slug:        r.Slug,
-		Description: r.Description,
-		Status:      status,
-		GroupIDs:    groupIDs,
-		Order:       r.Order,
+		Name:           r.Name,
+		Slug:           r.Slug,
+		Description:    r.Description,
+		Status:         status,
+		StatusOverride: override,
+		GroupIDs:       groupIDs,
+		Order:          r.Order,
 	}
 }
 
 // UpdateServiceRequest represents the request body for updating a service.
 type UpdateServiceRequest struct {
-	Name        string   `json:"name" validate:"required,min=1,max=255"`
-	Slug        string   `json:"slug" validate:"required,min=1,max=255"`
-	Description string   `json:"description"`
-	Status      string   `json:"status" validate:"required,oneof=operational degraded partial_outage major_outage maintenance"`
-	GroupIDs    []string `json:"group_ids"`
-	Order       int      `json:"order"`
+	Name           string   `json:"name" validate:"required,min=1,max=255"`
+	Slug           string   `json:"slug" validate:"required,min=1,max=255"`
+	Description    string   `json:"description"`
+	Status         string   `json:"status" validate:"omitempty,oneof=operational degraded partial_outage major_outage maintenance"`
+	StatusOverride *bool    `json:"status_override"`
+	GroupIDs       []string `json:"group_ids"`
+	Order          int      `json:"order"`
 }
 
 // UpdateServiceTagsRequest represents the request body for updating service tags.
@@ -294,6 +310,59 @@ func (h *Handler) GetService(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, service)
 }
 
+// Page size limits of the status history.
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// GetServiceStatusHistory handles GET /services/{slug}/history request.
+func (h *Handler) GetServiceStatusHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := StatusHistoryFilter{Limit: defaultHistoryLimit}
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := q.Get(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				h.respondError(w, http.StatusBadRequest, param+" must be an RFC 3339 timestamp")
+				return
+			}
+			*dst = &t
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		h.respondError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxHistoryLimit {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxHistoryLimit))
+			return
+		}
+		filter.Limit = n
+	}
+
+	if offset := q.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			h.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		filter.Offset = n
+	}
+
+	changes, err := h.service.GetStatusHistory(r.Context(), chi.URLParam(r, "slug"), filter)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, changes)
+}
+
 // ListServices handles GET /services request.
 func (h *Handler) ListServices(w http.ResponseWriter, r *http.Request) {
 	filter := ServiceFilter{}
@@ -321,6 +390,7 @@ func (h *Handler) ListServices(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateService handles PATCH /services/{slug} request.
+// Changing the status overrides the status derived from events; status_override=false clears the override.
 func (h *Handler) UpdateService(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
@@ -344,7 +414,13 @@ func (h *Handler) UpdateService(w http.ResponseWriter, r *http.Request) {
 	existing.Name = req.Name
 	existing.Slug = req.Slug
 	existing.Description = req.Description
-	existing.Status = domain.ServiceStatus(req.Status)
+	if status := domain.ServiceStatus(req.Status); status != "" && status != existing.Status {
+		existing.Status = status
+		existing.StatusOverride = true
+	}
+	if req.StatusOverride != nil {
+		existing.StatusOverride = *req.StatusOverride
+	}
 	existing.GroupIDs = req.GroupIDs
 	if existing.GroupIDs == nil {
 		existing.GroupIDs = make([]string, 0)
@@ -186,8 +186,8 @@ func (r *Repository) DeleteGroup(ctx context.Context, id string) error {
 // CreateService creates a new service in the database.
 func (r *Repository) CreateService(ctx context.Context, service *domain.Service) error {
 	query := `
-		INSERT INTO services (name, slug, description, status, "order")
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO services (name, slug, description, status, status_override, "order")
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at, updated_at
 	`
 	err := r.db.QueryRow(ctx, query,
@@ -195,6 +195,7 @@ func (r *Repository) CreateService(ctx context.Context, service *domain.Service)
 		service.Slug,
 		service.Description,
 		service.Status,
+		service.StatusOverride,
 		service.Order,
 	).Scan(&service.ID, &service.CreatedAt, &service.UpdatedAt)
 
@@ -207,7 +208,7 @@ func (r *Repository) CreateService(ctx context.Context, service *domain.Service)
 // GetServiceBySlug retrieves a service by its slug.
 func (r *Repository) GetServiceBySlug(ctx context.Context, slug string) (*domain.Service, error) {
 	query := `
-		SELECT id, name, slug, description, status, "order", created_at, updated_at, archived_at
+		SELECT id, name, slug, description, status, status_override, "order", created_at, updated_at, archived_at
 		FROM services
 		WHERE slug = $1
 	`
@@ -218,6 +219,7 @@ func (r *Repository) GetServiceBySlug(ctx context.Context, slug string) (*domain
 		&service.Slug,
 		&service.Description,
 		&service.Status,
+		&service.StatusOverride,
 		&service.Order,
 		&service.CreatedAt,
 		&service.UpdatedAt,
@@ -244,7 +246,7 @@ func (r *Repository) GetServiceBySlug(ctx context.Context, slug string) (*domain
 // GetServiceByID retrieves a service by its ID.
 func (r *Repository) GetServiceByID(ctx context.Context, id string) (*domain.Service, error) {
 	query := `
-		SELECT id, name, slug, description, status, "order", created_at, updated_at, archived_at
+		SELECT id, name, slug, description, status, status_override, "order", created_at, updated_at, archived_at
 		FROM services
 		WHERE id = $1
 	`
@@ -255,6 +257,7 @@ func (r *Repository) GetServiceByID(ctx context.Context, id string) (*domain.Ser
 		&service.Slug,
 		&service.Description,
 		&service.Status,
+		&service.StatusOverride,
 		&service.Order,
 		&service.CreatedAt,
 		&service.UpdatedAt,
@@ -287,7 +290,7 @@ func (r *Repository) ListServices(ctx context.Context, filter catalog.ServiceFil
 	if filter.GroupID != nil {
 		// Filter by group using JOIN on service_group_members
 		query = `
-			SELECT DISTINCT s.id, s.name, s.slug, s.description, s.status, s."order", s.created_at, s.updated_at, s.archived_at
+			SELECT DISTINCT s.id, s.name, s.slug, s.description, s.status, s.status_override, s."order", s.created_at, s.updated_at, s.archived_at
 			FROM services s
 			JOIN service_group_members sgm ON s.id = sgm.service_id
 			WHERE sgm.group_id = $1
@@ -302,11 +305,17 @@ func (r *Repository) ListServices(ctx context.Context, filter catalog.ServiceFil
 		if filter.Status != nil {
 			query += fmt.Sprintf(" AND s.status = $%d", argNum)
 			args = append(args, *filter.Status)
+			argNum++
+		}
+
+		if filter.Tag != nil {
+			query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM service_tags st WHERE st.service_id = s.id AND st.key = $%d AND st.value = $%d)", argNum, argNum+1)
+			args = append(args, filter.Tag.Key, filter.Tag.Value)
 		}
 	} else {
 		// No group filter
 		query = `
-			SELECT id, name, slug, description, status, "order", created_at, updated_at, archived_at
+			SELECT id, name, slug, description, status, status_override, "order", created_at, updated_at, archived_at
 			FROM services
 			WHERE 1=1
 		`
@@ -318,6 +327,12 @@ func (r *Repository) ListServices(ctx context.Context, filter catalog.ServiceFil
 		if filter.Status != nil {
 			query += fmt.Sprintf(" AND status = $%d", argNum)
 			args = append(args, *filter.Status)
+			argNum++
+		}
+
+		if filter.Tag != nil {
+			query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM service_tags st WHERE st.service_id = services.id AND st.key = $%d AND st.value = $%d)", argNum, argNum+1)
+			args = append(args, filter.Tag.Key, filter.Tag.Value)
 		}
 	}
 
@@ -338,6 +353,7 @@ func (r *Repository) ListServices(ctx context.Context, filter catalog.ServiceFil
 			&service.Slug,
 			&service.Description,
 			&service.Status,
+			&service.StatusOverride,
 			&service.Order,
 			&service.CreatedAt,
 			&service.UpdatedAt,
@@ -369,7 +385,7 @@ func (r *Repository) ListServices(ctx context.Context, filter catalog.ServiceFil
 func (r *Repository) UpdateService(ctx context.Context, service *domain.Service) error {
 	query := `
 		UPDATE services
-		SET name = $2, slug = $3, description = $4, status = $5, "order" = $6, updated_at = NOW()
+		SET name = $2, slug = $3, description = $4, status = $5, status_override = $6, "order" = $7, updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at
 	`
@@ -379,6 +395,7 @@ func (r *Repository) UpdateService(ctx context.Context, service *domain.Service)
 		service.Slug,
 		service.Description,
 		service.Status,
+		service.StatusOverride,
 		service.Order,
 	).Scan(&service.UpdatedAt)
 
@@ -391,6 +408,32 @@ func (r *Repository) UpdateService(ctx context.Context, service *domain.Service)
 	return nil
 }
 
+// UpdateServiceStatus sets the derived status of a service unless its status is
+// overridden or unchanged. The previous status is read under a row lock, so
+// concurrent updates each return the status they actually replaced.
+func (r *Repository) UpdateServiceStatus(ctx context.Context, id string, status domain.ServiceStatus) (domain.ServiceStatus, bool, error) {
+	query := `
+		WITH previous AS (
+			SELECT id, status FROM services
+			WHERE id = $1 AND NOT status_override
+			FOR UPDATE
+		)
+		UPDATE services s
+		SET status = $2, updated_at = NOW()
+		FROM previous
+		WHERE s.id = previous.id AND previous.status <> $2
+		RETURNING previous.status
+	`
+	var old domain.ServiceStatus
+	if err := r.db.QueryRow(ctx, query, id, status).Scan(&old); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("update service status: %w", err)
+	}
+	return old, true, nil
+}
+
 // DeleteService deletes a service by its ID.
 func (r *Repository) DeleteService(ctx context.Context, id string) error {
 	query := `DELETE FROM services WHERE id = $1`
@@ -405,6 +448,93 @@ func (r *Repository) DeleteService(ctx context.Context, id string) error {
 	return nil
 }
 
+// CreateStatusChange records a change of a service status.
+func (r *Repository) CreateStatusChange(ctx context.Context, change *domain.ServiceStatusChange) error {
+	query := `
+		INSERT INTO service_status_history (service_id, old_status, new_status, reason, actor_id, event_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query,
+		change.ServiceID,
+		change.OldStatus,
+		change.NewStatus,
+		change.Reason,
+		change.ActorID,
+		change.EventID,
+	).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create status change: %w", err)
+	}
+	return nil
+}
+
+// ListStatusChanges returns status changes of a service matching the filter, newest first.
+func (r *Repository) ListStatusChanges(ctx context.Context, serviceID string, filter catalog.StatusHistoryFilter) ([]*domain.ServiceStatusChange, error) {
+	query := `
+		SELECT id, service_id, old_status, new_status, reason, actor_id, event_id, created_at
+		FROM service_status_history
+		WHERE service_id = $1
+	`
+	args := []interface{}{serviceID}
+	argNum := 2
+
+	if filter.From != nil {
+		query += fmt.Sprintf(" AND created_at >= $%d", argNum)
+		args = append(args, *filter.From)
+		argNum++
+	}
+
+	if filter.To != nil {
+		query += fmt.Sprintf(" AND created_at < $%d", argNum)
+		args = append(args, *filter.To)
+		argNum++
+	}
+
+	query += " ORDER BY created_at DESC, id"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list status changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]*domain.ServiceStatusChange, 0)
+	for rows.Next() {
+		var change domain.ServiceStatusChange
+		err := rows.Scan(
+			&change.ID,
+			&change.ServiceID,
+			&change.OldStatus,
+			&change.NewStatus,
+			&change.Reason,
+			&change.ActorID,
+			&change.EventID,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan status change: %w", err)
+		}
+		changes = append(changes, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate status changes: %w", err)
+	}
+	return changes, nil
+}
+
 // SetServiceTags replaces all tags for a service with the provided tags.
 func (r *Repository) SetServiceTags(ctx context.Context, serviceID string, tags []domain.ServiceTag) error {
 	tx, err := r.db.Begin(ctx)
@@ -695,3 +825,34 @@ func (r *Repository) GetActiveEventCountForGroup(ctx context.Context, groupID st
 	}
 	return count, nil
 }
+
+// ListActiveEventsForService returns active (non-resolved/completed) events affecting a service.
+func (r *Repository) ListActiveEventsForService(ctx context.Context, serviceID string) ([]catalog.ActiveEvent, error) {
+	query := `
+		SELECT e.type, e.status, e.severity
+		FROM events e
+		JOIN event_services es ON e.id = es.event_id
+		WHERE es.service_id = $1
+		  AND e.status NOT IN ('resolved', 'completed')
+	`
+	rows, err := r.db.Query(ctx, query, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("list active events for service: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]catalog.ActiveEvent, 0)
+	for rows.Next() {
+		var event catalog.ActiveEvent
+		if err := rows.Scan(&event.Type, &event.Status, &event.Severity); err != nil {
+			return nil, fmt.Errorf("scan active event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate active events: %w", err)
+	}
+
+	return events, nil
+}
@@ -2,6 +2,7 @@ package catalog
 
 import (
 	"context"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 )
@@ -20,8 +21,15 @@ type Repository interface {
 	GetServiceByID(ctx context.Context, id string) (*domain.Service, error)
 	ListServices(ctx context.Context, filter ServiceFilter) ([]domain.Service, error)
 	UpdateService(ctx context.Context, service *domain.Service) error
+	// UpdateServiceStatus sets the derived status of a service and returns the
+	// status it replaced. It reports false when the status is overridden or
+	// already equal to status.
+	UpdateServiceStatus(ctx context.Context, id string, status domain.ServiceStatus) (domain.ServiceStatus, bool, error)
 	DeleteService(ctx context.Context, id string) error
 
+	CreateStatusChange(ctx context.Context, change *domain.ServiceStatusChange) error
+	ListStatusChanges(ctx context.Context, serviceID string, filter StatusHistoryFilter) ([]*domain.ServiceStatusChange, error)
+
 	SetServiceTags(ctx context.Context, serviceID string, tags []domain.ServiceTag) error
 	GetServiceTags(ctx context.Context, serviceID string) ([]domain.ServiceTag, error)
 
@@ -38,12 +46,14 @@ type Repository interface {
 	// Active events check
 	GetActiveEventCountForService(ctx context.Context, serviceID string) (int, error)
 	GetActiveEventCountForGroup(ctx context.Context, groupID string) (int, error)
+	ListActiveEventsForService(ctx context.Context, serviceID string) ([]ActiveEvent, error)
 }
 
 // ServiceFilter represents filter criteria for listing services.
 type ServiceFilter struct {
 	GroupID         *string
 	Status          *domain.ServiceStatus
+	Tag             *domain.ServiceTag
 	IncludeArchived bool
 }
 
@@ -51,3 +61,11 @@ type ServiceFilter struct {
 type GroupFilter struct {
 	IncludeArchived bool
 }
+
+// StatusHistoryFilter represents filter criteria for listing status changes.
+type StatusHistoryFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
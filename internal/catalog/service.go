@@ -26,7 +26,9 @@ var slugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
 
 // Service provides business logic for managing service groups and services.
 type Service struct {
-	repo Repository
+	repo  Repository
+	audit domain.AuditFunc
+	hooks []StatusChangeHook
 }
 
 // NewService creates a new catalog service.
@@ -34,6 +36,11 @@ func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+// SetAuditLog sets where changes to groups, services and tags are recorded.
+func (s *Service) SetAuditLog(audit domain.AuditFunc) {
+	s.audit = audit
+}
+
 // CreateGroup creates a new service group.
 func (s *Service) CreateGroup(ctx context.Context, group *domain.ServiceGroup) error {
 	if err := validateSlug(group.Slug); err != nil {
@@ -48,7 +55,17 @@ func (s *Service) CreateGroup(ctx context.Context, group *domain.ServiceGroup) e
 		return ErrSlugExists
 	}
 
-	return s.repo.CreateGroup(ctx, group)
+	if err := s.repo.CreateGroup(ctx, group); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityServiceGroup,
+		EntityID:   group.ID,
+		After:      group,
+	})
+	return nil
 }
 
 // GetGroupBySlug returns a service group by its slug.
@@ -87,7 +104,18 @@ func (s *Service) UpdateGroup(ctx context.Context, group *domain.ServiceGroup) e
 		}
 	}
 
-	return s.repo.UpdateGroup(ctx, group)
+	if err := s.repo.UpdateGroup(ctx, group); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityServiceGroup,
+		EntityID:   group.ID,
+		Before:     existing,
+		After:      group,
+	})
+	return nil
 }
 
 // DeleteGroup archives a service group (soft delete).
@@ -101,12 +129,30 @@ func (s *Service) DeleteGroup(ctx context.Context, id string) error {
 		return ErrGroupHasActiveEvents
 	}
 
-	return s.repo.ArchiveGroup(ctx, id)
+	if err := s.repo.ArchiveGroup(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionArchive,
+		EntityType: domain.AuditEntityServiceGroup,
+		EntityID:   id,
+	})
+	return nil
 }
 
 // RestoreGroup restores an archived group.
 func (s *Service) RestoreGroup(ctx context.Context, id string) error {
-	return s.repo.RestoreGroup(ctx, id)
+	if err := s.repo.RestoreGroup(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionRestore,
+		EntityType: domain.AuditEntityServiceGroup,
+		EntityID:   id,
+	})
+	return nil
 }
 
 // CreateService creates a new service.
@@ -123,7 +169,8 @@ func (s *Service) CreateService(ctx context.Context, service *domain.Service) er
 		return ErrSlugExists
 	}
 
-	if service.Status == "" {
+	// A new service has no events yet, so only a manual status can differ from operational.
+	if service.Status == "" || !service.StatusOverride {
 		service.Status = domain.ServiceStatusOperational
 	}
 
@@ -138,6 +185,20 @@ func (s *Service) CreateService(ctx context.Context, service *domain.Service) er
 		}
 	}
 
+	if err := s.recordStatusChange(ctx, domain.ServiceStatusChange{
+		ServiceID: service.ID,
+		NewStatus: service.Status,
+		Reason:    domain.StatusChangeCreated,
+	}); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityService,
+		EntityID:   service.ID,
+		After:      service,
+	})
 	return nil
 }
 
@@ -157,6 +218,7 @@ func (s *Service) ListServices(ctx context.Context, filter ServiceFilter) ([]dom
 }
 
 // UpdateService updates an existing service.
+// Unless the status is overridden, it is recalculated from active events.
 func (s *Service) UpdateService(ctx context.Context, service *domain.Service) error {
 	if err := validateSlug(service.Slug); err != nil {
 		return err
@@ -177,6 +239,14 @@ func (s *Service) UpdateService(ctx context.Context, service *domain.Service) er
 		}
 	}
 
+	if !service.StatusOverride {
+		status, err := s.derivedStatus(ctx, service.ID)
+		if err != nil {
+			return err
+		}
+		service.Status = status
+	}
+
 	if err := s.repo.UpdateService(ctx, service); err != nil {
 		return err
 	}
@@ -186,6 +256,28 @@ func (s *Service) UpdateService(ctx context.Context, service *domain.Service) er
 		return fmt.Errorf("set service groups: %w", err)
 	}
 
+	if service.Status != existing.Status {
+		reason := domain.StatusChangeManual
+		if !service.StatusOverride {
+			reason = domain.StatusChangeOverrideCleared
+		}
+		if err := s.recordStatusChange(ctx, domain.ServiceStatusChange{
+			ServiceID: service.ID,
+			OldStatus: &existing.Status,
+			NewStatus: service.Status,
+			Reason:    reason,
+		}); err != nil {
+			return err
+		}
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityService,
+		EntityID:   service.ID,
+		Before:     existing,
+		After:      service,
+	})
 	return nil
 }
 
@@ -200,12 +292,30 @@ func (s *Service) DeleteService(ctx context.Context, id string) error {
 		return ErrServiceHasActiveEvents
 	}
 
-	return s.repo.ArchiveService(ctx, id)
+	if err := s.repo.ArchiveService(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionArchive,
+		EntityType: domain.AuditEntityService,
+		EntityID:   id,
+	})
+	return nil
 }
 
 // RestoreService restores an archived service.
 func (s *Service) RestoreService(ctx context.Context, id string) error {
-	return s.repo.RestoreService(ctx, id)
+	if err := s.repo.RestoreService(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionRestore,
+		EntityType: domain.AuditEntityService,
+		EntityID:   id,
+	})
+	return nil
 }
 
 // UpdateServiceTags replaces all tags for a service.
@@ -215,6 +325,11 @@ func (s *Service) UpdateServiceTags(ctx context.Context, serviceID string, tagsM
 		return err
 	}
 
+	existing, err := s.repo.GetServiceTags(ctx, serviceID)
+	if err != nil {
+		return fmt.Errorf("get service tags: %w", err)
+	}
+
 	tags := make([]domain.ServiceTag, 0, len(tagsMap))
 	for key, value := range tagsMap {
 		tags = append(tags, domain.ServiceTag{
@@ -224,7 +339,22 @@ func (s *Service) UpdateServiceTags(ctx context.Context, serviceID string, tagsM
 		})
 	}
 
-	return s.repo.SetServiceTags(ctx, serviceID, tags)
+	if err := s.repo.SetServiceTags(ctx, serviceID, tags); err != nil {
+		return err
+	}
+
+	before := make(map[string]string, len(existing))
+	for _, tag := range existing {
+		before[tag.Key] = tag.Value
+	}
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityServiceTags,
+		EntityID:   serviceID,
+		Before:     before,
+		After:      tagsMap,
+	})
+	return nil
 }
 
 // GetServiceTags returns all tags for a service.
@@ -0,0 +1,184 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
+)
+
+// ActiveEvent is an unresolved event affecting a service.
+type ActiveEvent struct {
+	Type     domain.EventType
+	Status   domain.EventStatus
+	Severity *domain.Severity
+}
+
+// statusRank orders service statuses from best to worst.
+var statusRank = map[domain.ServiceStatus]int{
+	domain.ServiceStatusOperational:   0,
+	domain.ServiceStatusMaintenance:   1,
+	domain.ServiceStatusDegraded:      2,
+	domain.ServiceStatusPartialOutage: 3,
+	domain.ServiceStatusMajorOutage:   4,
+}
+
+// StatusFromEvents returns the service status implied by its active events.
+// Incidents map by severity, in-progress maintenance maps to maintenance,
+// and the worst status wins. Without active events the service is operational.
+func StatusFromEvents(events []ActiveEvent) domain.ServiceStatus {
+	statuses := make([]domain.ServiceStatus, 0, len(events))
+	for _, event := range events {
+		statuses = append(statuses, eventServiceStatus(event))
+	}
+	return WorstStatus(statuses...)
+}
+
+// WorstStatus returns the worst of the statuses, or operational if there are none.
+func WorstStatus(statuses ...domain.ServiceStatus) domain.ServiceStatus {
+	worst := domain.ServiceStatusOperational
+	for _, status := range statuses {
+		if statusRank[status] > statusRank[worst] {
+			worst = status
+		}
+	}
+	return worst
+}
+
+func eventServiceStatus(event ActiveEvent) domain.ServiceStatus {
+	if event.Status.IsResolved() {
+		return domain.ServiceStatusOperational
+	}
+
+	switch event.Type {
+	case domain.EventTypeMaintenance:
+		// Scheduled maintenance does not affect the service until it starts.
+		if event.Status == domain.EventStatusInProgress {
+			return domain.ServiceStatusMaintenance
+		}
+	case domain.EventTypeIncident:
+		return IncidentStatus(event.Severity)
+	}
+	return domain.ServiceStatusOperational
+}
+
+// IncidentStatus returns the status of a service affected by an unresolved incident of the given severity.
+func IncidentStatus(severity *domain.Severity) domain.ServiceStatus {
+	if severity == nil {
+		return domain.ServiceStatusDegraded
+	}
+	switch *severity {
+	case domain.SeverityCritical:
+		return domain.ServiceStatusMajorOutage
+	case domain.SeverityMajor:
+		return domain.ServiceStatusPartialOutage
+	default:
+		return domain.ServiceStatusDegraded
+	}
+}
+
+// RecalculateServiceStatuses updates the status of the given services from their active events
+// after a change of the event eventID. Services with a manual status override are left untouched.
+func (s *Service) RecalculateServiceStatuses(ctx context.Context, serviceIDs []string, eventID string) error {
+	for _, id := range serviceIDs {
+		service, err := s.repo.GetServiceByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if service.StatusOverride {
+			continue
+		}
+
+		status, err := s.derivedStatus(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if status == service.Status {
+			continue
+		}
+
+		// The override or the status may have changed since the service was read;
+		// only a status that was actually replaced is recorded.
+		old, updated, err := s.repo.UpdateServiceStatus(ctx, id, status)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			continue
+		}
+
+		change := domain.ServiceStatusChange{
+			ServiceID: id,
+			OldStatus: &old,
+			NewStatus: status,
+			Reason:    domain.StatusChangeEvent,
+		}
+		if eventID != "" {
+			change.EventID = &eventID
+		}
+		if err := s.recordStatusChange(ctx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStatusHistory returns the status changes of a service, newest first.
+func (s *Service) GetStatusHistory(ctx context.Context, slug string, filter StatusHistoryFilter) ([]*domain.ServiceStatusChange, error) {
+	service, err := s.repo.GetServiceBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListStatusChanges(ctx, service.ID, filter)
+}
+
+// recordStatusChange adds a status change to the service history. The actor,
+// if any, is the authenticated user of the request.
+func (s *Service) recordStatusChange(ctx context.Context, change domain.ServiceStatusChange) error {
+	if userID := httputil.GetUserID(ctx); userID != "" {
+		change.ActorID = &userID
+	}
+
+	if err := s.repo.CreateStatusChange(ctx, &change); err != nil {
+		return fmt.Errorf("record status change: %w", err)
+	}
+
+	for _, hook := range s.hooks {
+		hook(ctx, change)
+	}
+	return nil
+}
+
+// StatusChangeHook is called after a service status change has been recorded.
+// Hooks run synchronously and must not block for long.
+type StatusChangeHook func(ctx context.Context, change domain.ServiceStatusChange)
+
+// OnStatusChange registers a hook called for every recorded service status change.
+func (s *Service) OnStatusChange(hook StatusChangeHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// GroupStatus returns the worst status of the group's active services.
+func (s *Service) GroupStatus(ctx context.Context, groupID string) (domain.ServiceStatus, error) {
+	services, err := s.repo.ListServices(ctx, ServiceFilter{GroupID: &groupID})
+	if err != nil {
+		return "", fmt.Errorf("list group services: %w", err)
+	}
+
+	statuses := make([]domain.ServiceStatus, 0, len(services))
+	for _, service := range services {
+		statuses = append(statuses, service.Status)
+	}
+	return WorstStatus(statuses...), nil
+}
+
+func (s *Service) derivedStatus(ctx context.Context, serviceID string) (domain.ServiceStatus, error) {
+	events, err := s.repo.ListActiveEventsForService(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("list active events: %w", err)
+	}
+	return StatusFromEvents(events), nil
+}
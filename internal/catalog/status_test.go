@@ -0,0 +1,170 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+func TestStatusFromEvents(t *testing.T) {
+	severity := func(s domain.Severity) *domain.Severity { return &s }
+
+	incident := func(s domain.Severity) ActiveEvent {
+		return ActiveEvent{Type: domain.EventTypeIncident, Status: domain.EventStatusInvestigating, Severity: severity(s)}
+	}
+	maintenance := func(status domain.EventStatus) ActiveEvent {
+		return ActiveEvent{Type: domain.EventTypeMaintenance, Status: status}
+	}
+
+	tests := []struct {
+		name   string
+		events []ActiveEvent
+		want   domain.ServiceStatus
+	}{
+		{"no events", nil, domain.ServiceStatusOperational},
+		{"minor incident", []ActiveEvent{incident(domain.SeverityMinor)}, domain.ServiceStatusDegraded},
+		{"major incident", []ActiveEvent{incident(domain.SeverityMajor)}, domain.ServiceStatusPartialOutage},
+		{"critical incident", []ActiveEvent{incident(domain.SeverityCritical)}, domain.ServiceStatusMajorOutage},
+		{"scheduled maintenance", []ActiveEvent{maintenance(domain.EventStatusScheduled)}, domain.ServiceStatusOperational},
+		{"maintenance in progress", []ActiveEvent{maintenance(domain.EventStatusInProgress)}, domain.ServiceStatusMaintenance},
+		{
+			"resolved incident is ignored",
+			[]ActiveEvent{{Type: domain.EventTypeIncident, Status: domain.EventStatusResolved, Severity: severity(domain.SeverityCritical)}},
+			domain.ServiceStatusOperational,
+		},
+		{
+			"worst of overlapping events wins",
+			[]ActiveEvent{
+				incident(domain.SeverityMinor),
+				incident(domain.SeverityCritical),
+				incident(domain.SeverityMajor),
+			},
+			domain.ServiceStatusMajorOutage,
+		},
+		{
+			"incident outranks maintenance",
+			[]ActiveEvent{maintenance(domain.EventStatusInProgress), incident(domain.SeverityMinor)},
+			domain.ServiceStatusDegraded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusFromEvents(tt.events); got != tt.want {
+				t.Errorf("StatusFromEvents() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorstStatus(t *testing.T) {
+	if got := WorstStatus(); got != domain.ServiceStatusOperational {
+		t.Errorf("WorstStatus() = %q, want operational", got)
+	}
+
+	got := WorstStatus(domain.ServiceStatusMaintenance, domain.ServiceStatusPartialOutage, domain.ServiceStatusDegraded)
+	if got != domain.ServiceStatusPartialOutage {
+		t.Errorf("WorstStatus() = %q, want partial_outage", got)
+	}
+}
+
+// fakeStatusRepo stores one service and its status history.
+type fakeStatusRepo struct {
+	Repository
+
+	service  domain.Service
+	events   []ActiveEvent
+	changes  []*domain.ServiceStatusChange
+	onUpdate func()
+}
+
+func (f *fakeStatusRepo) GetServiceByID(_ context.Context, _ string) (*domain.Service, error) {
+	service := f.service
+	return &service, nil
+}
+
+func (f *fakeStatusRepo) ListActiveEventsForService(_ context.Context, _ string) ([]ActiveEvent, error) {
+	return f.events, nil
+}
+
+func (f *fakeStatusRepo) UpdateServiceStatus(_ context.Context, _ string, status domain.ServiceStatus) (domain.ServiceStatus, bool, error) {
+	if f.onUpdate != nil {
+		f.onUpdate()
+	}
+	if f.service.StatusOverride || f.service.Status == status {
+		return "", false, nil
+	}
+	old := f.service.Status
+	f.service.Status = status
+	return old, true, nil
+}
+
+func (f *fakeStatusRepo) CreateStatusChange(_ context.Context, change *domain.ServiceStatusChange) error {
+	f.changes = append(f.changes, change)
+	return nil
+}
+
+func TestService_RecalculateServiceStatuses(t *testing.T) {
+	critical := domain.SeverityCritical
+	incident := []ActiveEvent{{Type: domain.EventTypeIncident, Status: domain.EventStatusInvestigating, Severity: &critical}}
+
+	tests := []struct {
+		name     string
+		onUpdate func(repo *fakeStatusRepo)
+		wantOld  *domain.ServiceStatus
+	}{
+		{
+			name:    "records the replaced status",
+			wantOld: ptr(domain.ServiceStatusOperational),
+		},
+		{
+			name:     "skips a service overridden meanwhile",
+			onUpdate: func(repo *fakeStatusRepo) { repo.service.StatusOverride = true },
+		},
+		{
+			name:     "records the status set by a concurrent update",
+			onUpdate: func(repo *fakeStatusRepo) { repo.service.Status = domain.ServiceStatusDegraded },
+			wantOld:  ptr(domain.ServiceStatusDegraded),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStatusRepo{
+				service: domain.Service{ID: "s1", Status: domain.ServiceStatusOperational},
+				events:  incident,
+			}
+			if tt.onUpdate != nil {
+				repo.onUpdate = func() { tt.onUpdate(repo) }
+			}
+			s := NewService(repo)
+			var hooked int
+			s.OnStatusChange(func(context.Context, domain.ServiceStatusChange) { hooked++ })
+
+			if err := s.RecalculateServiceStatuses(context.Background(), []string{"s1"}, "e1"); err != nil {
+				t.Fatalf("RecalculateServiceStatuses() error = %v", err)
+			}
+
+			if tt.wantOld == nil {
+				if len(repo.changes) != 0 || hooked != 0 {
+					t.Errorf("changes = %d, hooks = %d; want nothing recorded", len(repo.changes), hooked)
+				}
+				return
+			}
+			if len(repo.changes) != 1 || hooked != 1 {
+				t.Fatalf("changes = %d, hooks = %d; want one", len(repo.changes), hooked)
+			}
+			if got := *repo.changes[0].OldStatus; got != *tt.wantOld {
+				t.Errorf("old status = %q, want %q", got, *tt.wantOld)
+			}
+			if got := repo.changes[0].NewStatus; got != domain.ServiceStatusMajorOutage {
+				t.Errorf("new status = %q, want major_outage", got)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -3,10 +3,12 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
@@ -20,6 +22,116 @@ type Config struct {
 	Log      LogConfig
 	JWT      JWTConfig
 	CORS     CORSConfig
+	Email    EmailConfig
+	Telegram TelegramConfig
+
+	Notifications NotificationsConfig
+	Maintenance   MaintenanceConfig
+	Stream        StreamConfig
+	Webhooks      WebhooksConfig
+	Alertmanager  AlertmanagerConfig
+	OIDC          OIDCConfig
+	PasswordReset PasswordResetConfig
+	MFA           MFAConfig
+}
+
+// MFAConfig contains two-factor authentication settings.
+type MFAConfig struct {
+	// TOTPIssuer names the service in authenticator apps.
+	TOTPIssuer string
+}
+
+// PasswordResetConfig contains settings for password reset emails.
+type PasswordResetConfig struct {
+	// URL is the page that completes the reset; the token is added as the token query parameter.
+	URL string
+	// TTL is how long a reset link stays valid.
+	TTL time.Duration
+}
+
+// OIDCConfig contains OpenID Connect single sign-on settings.
+type OIDCConfig struct {
+	// IssuerURL enables SSO; discovery is read from <issuer>/.well-known/openid-configuration.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered at the provider.
+	RedirectURL string
+	Scopes      []string
+	// RoleClaim is the ID token claim (string or list, e.g. groups) mapped to a role by RoleMap.
+	RoleClaim   string
+	RoleMap     map[string]domain.Role
+	DefaultRole domain.Role
+	// DisablePasswordLogin turns off local registration and password login.
+	DisablePasswordLogin bool
+}
+
+// AlertmanagerConfig contains settings for the Prometheus Alertmanager webhook.
+type AlertmanagerConfig struct {
+	// WebhookToken is the bearer token Alertmanager must send; the webhook is disabled when empty.
+	WebhookToken string
+	// ServiceLabel is the alert label holding a service slug or the value of a service tag with the same key.
+	ServiceLabel string
+	// SeverityLabel is the alert label mapped to an incident severity.
+	SeverityLabel string
+	// SeverityMap maps SeverityLabel values to incident severities.
+	SeverityMap map[string]domain.Severity
+	// DefaultSeverity is used when the severity label is missing or not mapped.
+	DefaultSeverity   domain.Severity
+	NotifySubscribers bool
+}
+
+// MaintenanceConfig contains scheduled maintenance automation settings.
+type MaintenanceConfig struct {
+	// SchedulerInterval is how often maintenance windows are checked.
+	SchedulerInterval time.Duration
+	// ReminderBefore is how long before the start subscribers are reminded; zero disables reminders.
+	ReminderBefore time.Duration
+}
+
+// StreamConfig contains live status stream settings.
+type StreamConfig struct {
+	// HeartbeatInterval is how often an idle stream sends a keep-alive comment.
+	HeartbeatInterval time.Duration
+	// Retention is how long stream messages are kept for clients resuming with Last-Event-ID.
+	Retention time.Duration
+}
+
+// WebhooksConfig contains outgoing webhook delivery settings.
+type WebhooksConfig struct {
+	Workers        int
+	PollInterval   time.Duration
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// Timeout limits a single request to a webhook endpoint.
+	Timeout time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback and private addresses.
+	AllowPrivateNetworks bool
+}
+
+// NotificationsConfig contains notification outbox worker settings.
+type NotificationsConfig struct {
+	Workers        int
+	PollInterval   time.Duration
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// EmailConfig contains SMTP settings for email notifications.
+type EmailConfig struct {
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUser           string
+	SMTPPassword       string
+	FromAddress        string
+	FromName           string
+	TLSMode            string
+	AuthMechanism      string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+	UnsubscribeURL     string
 }
 
 // CORSConfig contains CORS settings.
@@ -33,6 +145,10 @@ type ServerConfig struct {
 	Port         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// PublicURL is the externally visible base URL of the status page.
+	PublicURL string
+	// TrustedProxies are the proxies whose X-Forwarded-For and X-Real-IP headers are honored.
+	TrustedProxies []netip.Prefix
 }
 
 // DatabaseConfig contains database connection settings.
@@ -51,11 +167,29 @@ type LogConfig struct {
 
 // JWTConfig contains JWT authentication settings.
 type JWTConfig struct {
-	SecretKey            string
+	// SecretKey signs access tokens with HS256 when SigningKeyFile is empty.
+	SecretKey string
+	// SigningKeyFile is a PEM private key (RSA or Ed25519) that signs access tokens.
+	SigningKeyFile string
+	// VerificationKeyFiles are PEM keys accepted in addition to the signing key
+	// while keys are rotated.
+	VerificationKeyFiles []string
+	// Issuer and Audience are the iss and aud claims of access tokens.
+	Issuer               string
+	Audience             string
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
 }
 
+// TelegramConfig contains Telegram Bot API settings.
+type TelegramConfig struct {
+	BotToken      string
+	BotUsername   string
+	APIURL        string
+	WebhookSecret string
+	ParseMode     string
+}
+
 // Load loads configuration from config.yaml and environment variables.
 func Load() (*Config, error) {
 	k := koanf.New(".")
@@ -76,6 +210,7 @@ func Load() (*Config, error) {
 			Port:         k.String("SERVER_PORT"),
 			ReadTimeout:  k.Duration("SERVER_READ_TIMEOUT"),
 			WriteTimeout: k.Duration("SERVER_WRITE_TIMEOUT"),
+			PublicURL:    k.String("SERVER_PUBLIC_URL"),
 		},
 		Database: DatabaseConfig{
 			URL:             k.String("DATABASE_URL"),
@@ -89,16 +224,126 @@ func Load() (*Config, error) {
 		},
 		JWT: JWTConfig{
 			SecretKey:            k.String("JWT_SECRET_KEY"),
+			SigningKeyFile:       k.String("JWT_SIGNING_KEY_FILE"),
+			VerificationKeyFiles: parseList(k.String("JWT_VERIFICATION_KEY_FILES")),
+			Issuer:               k.String("JWT_ISSUER"),
+			Audience:             k.String("JWT_AUDIENCE"),
 			AccessTokenDuration:  k.Duration("JWT_ACCESS_TOKEN_DURATION"),
 			RefreshTokenDuration: k.Duration("JWT_REFRESH_TOKEN_DURATION"),
 		},
 		CORS: CORSConfig{
-			AllowedOrigins: parseOrigins(k.String("CORS_ALLOWED_ORIGINS")),
+			AllowedOrigins: parseList(k.String("CORS_ALLOWED_ORIGINS")),
+		},
+		Email: EmailConfig{
+			SMTPHost:           k.String("SMTP_HOST"),
+			SMTPPort:           k.Int("SMTP_PORT"),
+			SMTPUser:           k.String("SMTP_USER"),
+			SMTPPassword:       k.String("SMTP_PASSWORD"),
+			FromAddress:        k.String("SMTP_FROM_ADDRESS"),
+			FromName:           k.String("SMTP_FROM_NAME"),
+			TLSMode:            strings.ToLower(k.String("SMTP_TLS_MODE")),
+			AuthMechanism:      strings.ToLower(k.String("SMTP_AUTH_MECHANISM")),
+			InsecureSkipVerify: k.Bool("SMTP_INSECURE_SKIP_VERIFY"),
+			Timeout:            k.Duration("SMTP_TIMEOUT"),
+			UnsubscribeURL:     k.String("SMTP_UNSUBSCRIBE_URL"),
+		},
+		Telegram: TelegramConfig{
+			BotToken:      k.String("TELEGRAM_BOT_TOKEN"),
+			BotUsername:   k.String("TELEGRAM_BOT_USERNAME"),
+			APIURL:        k.String("TELEGRAM_API_URL"),
+			WebhookSecret: k.String("TELEGRAM_WEBHOOK_SECRET"),
+			ParseMode:     k.String("TELEGRAM_PARSE_MODE"),
+		},
+		Notifications: NotificationsConfig{
+			Workers:        k.Int("NOTIFICATIONS_WORKERS"),
+			PollInterval:   k.Duration("NOTIFICATIONS_POLL_INTERVAL"),
+			MaxAttempts:    k.Int("NOTIFICATIONS_MAX_ATTEMPTS"),
+			RetryBaseDelay: k.Duration("NOTIFICATIONS_RETRY_BASE_DELAY"),
+			RetryMaxDelay:  k.Duration("NOTIFICATIONS_RETRY_MAX_DELAY"),
+		},
+		Maintenance: MaintenanceConfig{
+			SchedulerInterval: k.Duration("MAINTENANCE_SCHEDULER_INTERVAL"),
+			ReminderBefore:    k.Duration("MAINTENANCE_REMINDER_BEFORE"),
+		},
+		Stream: StreamConfig{
+			HeartbeatInterval: k.Duration("STREAM_HEARTBEAT_INTERVAL"),
+			Retention:         k.Duration("STREAM_RETENTION"),
 		},
+		Webhooks: WebhooksConfig{
+			Workers:              k.Int("WEBHOOKS_WORKERS"),
+			PollInterval:         k.Duration("WEBHOOKS_POLL_INTERVAL"),
+			MaxAttempts:          k.Int("WEBHOOKS_MAX_ATTEMPTS"),
+			RetryBaseDelay:       k.Duration("WEBHOOKS_RETRY_BASE_DELAY"),
+			RetryMaxDelay:        k.Duration("WEBHOOKS_RETRY_MAX_DELAY"),
+			Timeout:              k.Duration("WEBHOOKS_TIMEOUT"),
+			AllowPrivateNetworks: k.Bool("WEBHOOKS_ALLOW_PRIVATE_NETWORKS"),
+		},
+		Alertmanager: AlertmanagerConfig{
+			WebhookToken:      k.String("ALERTMANAGER_WEBHOOK_TOKEN"),
+			ServiceLabel:      k.String("ALERTMANAGER_SERVICE_LABEL"),
+			SeverityLabel:     k.String("ALERTMANAGER_SEVERITY_LABEL"),
+			DefaultSeverity:   domain.Severity(k.String("ALERTMANAGER_DEFAULT_SEVERITY")),
+			NotifySubscribers: k.Bool("ALERTMANAGER_NOTIFY_SUBSCRIBERS"),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:            strings.TrimSuffix(k.String("OIDC_ISSUER_URL"), "/"),
+			ClientID:             k.String("OIDC_CLIENT_ID"),
+			ClientSecret:         k.String("OIDC_CLIENT_SECRET"),
+			RedirectURL:          k.String("OIDC_REDIRECT_URL"),
+			Scopes:               strings.Fields(k.String("OIDC_SCOPES")),
+			RoleClaim:            k.String("OIDC_ROLE_CLAIM"),
+			DefaultRole:          domain.Role(k.String("OIDC_DEFAULT_ROLE")),
+			DisablePasswordLogin: k.Bool("OIDC_DISABLE_PASSWORD_LOGIN"),
+		},
+		PasswordReset: PasswordResetConfig{
+			URL: k.String("PASSWORD_RESET_URL"),
+			TTL: k.Duration("PASSWORD_RESET_TTL"),
+		},
+		MFA: MFAConfig{
+			TOTPIssuer: k.String("MFA_TOTP_ISSUER"),
+		},
+	}
+
+	trustedProxies, err := parsePrefixes(k.String("SERVER_TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("parse SERVER_TRUSTED_PROXIES: %w", err)
 	}
+	cfg.Server.TrustedProxies = trustedProxies
+
+	severityMap, err := parseSeverityMap(k.String("ALERTMANAGER_SEVERITY_MAP"))
+	if err != nil {
+		return nil, fmt.Errorf("parse ALERTMANAGER_SEVERITY_MAP: %w", err)
+	}
+	cfg.Alertmanager.SeverityMap = severityMap
+
+	roleMap, err := parseRoleMap(k.String("OIDC_ROLE_MAP"))
+	if err != nil {
+		return nil, fmt.Errorf("parse OIDC_ROLE_MAP: %w", err)
+	}
+	cfg.OIDC.RoleMap = roleMap
 
 	setDefaults(cfg)
 
+	if !cfg.Alertmanager.DefaultSeverity.IsValid() {
+		return nil, fmt.Errorf("invalid ALERTMANAGER_DEFAULT_SEVERITY: %s", cfg.Alertmanager.DefaultSeverity)
+	}
+	if !cfg.OIDC.DefaultRole.IsValid() {
+		return nil, fmt.Errorf("invalid OIDC_DEFAULT_ROLE: %s", cfg.OIDC.DefaultRole)
+	}
+	switch cfg.Email.TLSMode {
+	case "none", "starttls", "tls":
+	default:
+		return nil, fmt.Errorf("invalid SMTP_TLS_MODE: %s", cfg.Email.TLSMode)
+	}
+	switch cfg.Email.AuthMechanism {
+	case "plain", "login":
+	default:
+		return nil, fmt.Errorf("invalid SMTP_AUTH_MECHANISM: %s", cfg.Email.AuthMechanism)
+	}
+	if cfg.OIDC.DisablePasswordLogin && cfg.OIDC.IssuerURL == "" {
+		return nil, fmt.Errorf("OIDC_DISABLE_PASSWORD_LOGIN requires OIDC_ISSUER_URL")
+	}
+
 	return cfg, nil
 }
 
@@ -149,13 +394,195 @@ func setDefaults(cfg *Config) {
 	if len(cfg.CORS.AllowedOrigins) == 0 {
 		cfg.CORS.AllowedOrigins = []string{"http://localhost:3000"}
 	}
+
+	if cfg.Email.SMTPPort == 0 {
+		cfg.Email.SMTPPort = 587
+	}
+	if cfg.Email.TLSMode == "" {
+		cfg.Email.TLSMode = "starttls"
+	}
+	if cfg.Email.AuthMechanism == "" {
+		cfg.Email.AuthMechanism = "plain"
+	}
+	if cfg.Email.Timeout == 0 {
+		cfg.Email.Timeout = 30 * time.Second
+	}
+
+	if cfg.Telegram.APIURL == "" {
+		cfg.Telegram.APIURL = "https://api.telegram.org"
+	}
+	if cfg.Telegram.ParseMode == "" {
+		cfg.Telegram.ParseMode = "MarkdownV2"
+	}
+
+	if cfg.Notifications.Workers == 0 {
+		cfg.Notifications.Workers = 2
+	}
+	if cfg.Notifications.PollInterval == 0 {
+		cfg.Notifications.PollInterval = 5 * time.Second
+	}
+	if cfg.Notifications.MaxAttempts == 0 {
+		cfg.Notifications.MaxAttempts = 5
+	}
+	if cfg.Notifications.RetryBaseDelay == 0 {
+		cfg.Notifications.RetryBaseDelay = 30 * time.Second
+	}
+	if cfg.Notifications.RetryMaxDelay == 0 {
+		cfg.Notifications.RetryMaxDelay = time.Hour
+	}
+
+	if cfg.Maintenance.SchedulerInterval == 0 {
+		cfg.Maintenance.SchedulerInterval = 30 * time.Second
+	}
+
+	if cfg.Stream.HeartbeatInterval == 0 {
+		cfg.Stream.HeartbeatInterval = 15 * time.Second
+	}
+	if cfg.Stream.Retention == 0 {
+		cfg.Stream.Retention = 24 * time.Hour
+	}
+
+	if cfg.Webhooks.Workers == 0 {
+		cfg.Webhooks.Workers = 2
+	}
+	if cfg.Webhooks.PollInterval == 0 {
+		cfg.Webhooks.PollInterval = 5 * time.Second
+	}
+	if cfg.Webhooks.MaxAttempts == 0 {
+		cfg.Webhooks.MaxAttempts = 8
+	}
+	if cfg.Webhooks.RetryBaseDelay == 0 {
+		cfg.Webhooks.RetryBaseDelay = 30 * time.Second
+	}
+	if cfg.Webhooks.RetryMaxDelay == 0 {
+		cfg.Webhooks.RetryMaxDelay = time.Hour
+	}
+	if cfg.Webhooks.Timeout == 0 {
+		cfg.Webhooks.Timeout = 10 * time.Second
+	}
+
+	if cfg.Alertmanager.ServiceLabel == "" {
+		cfg.Alertmanager.ServiceLabel = "statuspage_service"
+	}
+	if cfg.Alertmanager.SeverityLabel == "" {
+		cfg.Alertmanager.SeverityLabel = "severity"
+	}
+	if len(cfg.Alertmanager.SeverityMap) == 0 {
+		cfg.Alertmanager.SeverityMap = map[string]domain.Severity{
+			"critical": domain.SeverityCritical,
+			"warning":  domain.SeverityMajor,
+			"info":     domain.SeverityMinor,
+		}
+	}
+	if cfg.Alertmanager.DefaultSeverity == "" {
+		cfg.Alertmanager.DefaultSeverity = domain.SeverityMinor
+	}
+
+	if cfg.OIDC.RedirectURL == "" && cfg.Server.PublicURL != "" {
+		cfg.OIDC.RedirectURL = strings.TrimSuffix(cfg.Server.PublicURL, "/") + "/api/v1/auth/oidc/callback"
+	}
+	if cfg.PasswordReset.URL == "" && cfg.Server.PublicURL != "" {
+		cfg.PasswordReset.URL = strings.TrimSuffix(cfg.Server.PublicURL, "/") + "/reset-password"
+	}
+	if cfg.PasswordReset.TTL == 0 {
+		cfg.PasswordReset.TTL = time.Hour
+	}
+	if cfg.MFA.TOTPIssuer == "" {
+		cfg.MFA.TOTPIssuer = "IncidentGarden"
+	}
+	if len(cfg.OIDC.Scopes) == 0 {
+		cfg.OIDC.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.OIDC.DefaultRole == "" {
+		cfg.OIDC.DefaultRole = domain.RoleUser
+	}
+}
+
+// parseSeverityMap parses "label=severity" pairs separated by commas,
+// e.g. "critical=critical,warning=major".
+func parseSeverityMap(value string) (map[string]domain.Severity, error) {
+	pairs, err := parsePairs(value)
+	if err != nil || pairs == nil {
+		return nil, err
+	}
+	result := make(map[string]domain.Severity, len(pairs))
+	for label, severity := range pairs {
+		s := domain.Severity(severity)
+		if !s.IsValid() {
+			return nil, fmt.Errorf("invalid severity %q", s)
+		}
+		result[label] = s
+	}
+	return result, nil
+}
+
+// parseRoleMap parses "value=role" pairs separated by commas,
+// e.g. "statuspage-admins=admin,sre=operator".
+func parseRoleMap(value string) (map[string]domain.Role, error) {
+	pairs, err := parsePairs(value)
+	if err != nil || pairs == nil {
+		return nil, err
+	}
+	result := make(map[string]domain.Role, len(pairs))
+	for claim, role := range pairs {
+		r := domain.Role(role)
+		if !r.IsValid() {
+			return nil, fmt.Errorf("invalid role %q", r)
+		}
+		result[claim] = r
+	}
+	return result, nil
+}
+
+// parsePairs parses comma-separated key=value pairs.
+// parsePrefixes parses a comma-separated list of IP addresses and CIDR ranges.
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range parseList(value) {
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", item)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address %q", item)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func parsePairs(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, expected key=value", pair)
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return result, nil
 }
 
-func parseOrigins(origins string) []string {
-	if origins == "" {
+// parseList parses comma-separated values, skipping empty ones.
+func parseList(value string) []string {
+	if value == "" {
 		return nil
 	}
-	parts := strings.Split(origins, ",")
+	parts := strings.Split(value, ",")
 	result := make([]string, 0, len(parts))
 	for _, p := range parts {
 		trimmed := strings.TrimSpace(p)
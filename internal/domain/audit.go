@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// AuditActorType identifies who made an audited change.
+type AuditActorType string
+
+// Audit actor types.
+const (
+	AuditActorUser   AuditActorType = "user"
+	AuditActorAPIKey AuditActorType = "api_key"
+	// AuditActorSystem covers background jobs, integrations and unauthenticated
+	// requests such as registration.
+	AuditActorSystem AuditActorType = "system"
+)
+
+// IsValid checks if the actor type is valid.
+func (t AuditActorType) IsValid() bool {
+	switch t {
+	case AuditActorUser, AuditActorAPIKey, AuditActorSystem:
+		return true
+	}
+	return false
+}
+
+// Audit actions.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionArchive = "archive"
+	AuditActionRestore = "restore"
+	AuditActionVerify  = "verify"
+	AuditActionRevoke  = "revoke"
+	AuditActionRetry   = "retry"
+	AuditActionPurge   = "purge"
+
+	AuditActionDeactivate = "deactivate"
+	AuditActionReactivate = "reactivate"
+)
+
+// Audited entity types.
+const (
+	AuditEntityServiceGroup         = "service_group"
+	AuditEntityService              = "service"
+	AuditEntityServiceTags          = "service_tags"
+	AuditEntityEvent                = "event"
+	AuditEntityEventUpdate          = "event_update"
+	AuditEntityEventServices        = "event_services"
+	AuditEntityTemplate             = "template"
+	AuditEntityUser                 = "user"
+	AuditEntityAPIKey               = "api_key"
+	AuditEntityMFAPolicy            = "mfa_policy"
+	AuditEntityChannel              = "channel"
+	AuditEntitySubscription         = "subscription"
+	AuditEntityNotificationDelivery = "notification_delivery"
+	AuditEntityWebhook              = "webhook"
+	AuditEntityWebhookDelivery      = "webhook_delivery"
+)
+
+// AuditEntry is a recorded change. Before and After hold only the fields that
+// changed; a created entity has no Before, a deleted one no After.
+type AuditEntry struct {
+	ID         string          `json:"id"`
+	ActorType  AuditActorType  `json:"actor_type"`
+	ActorID    *string         `json:"actor_id,omitempty"`
+	APIKeyID   *string         `json:"api_key_id,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditChange is a change reported by a module. The audit log adds the actor
+// and request details from the context.
+type AuditChange struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditFunc records a change in the audit log.
+type AuditFunc func(ctx context.Context, change AuditChange)
+
+// Record calls f; a nil AuditFunc records nothing.
+func (f AuditFunc) Record(ctx context.Context, change AuditChange) {
+	if f != nil {
+		f(ctx, change)
+	}
+}
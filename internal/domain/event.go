@@ -23,6 +23,7 @@ const (
 	EventStatusScheduled     EventStatus = "scheduled"
 	EventStatusInProgress    EventStatus = "in_progress"
 	EventStatusCompleted     EventStatus = "completed"
+	EventStatusCancelled     EventStatus = "cancelled"
 )
 
 // Severity represents the severity level of an event.
@@ -52,6 +53,8 @@ type Event struct {
 	CreatedBy         string       `json:"created_by"`
 	CreatedAt         time.Time    `json:"created_at"`
 	UpdatedAt         time.Time    `json:"updated_at"`
+	// Sequence counts changes of the event; calendars use it to pick the latest version.
+	Sequence          int          `json:"-"`
 	ServiceIDs        []string     `json:"service_ids"`
 	GroupIDs          []string     `json:"group_ids"`
 }
@@ -78,7 +81,8 @@ func (s EventStatus) IsValidForType(eventType EventType) bool {
 	case EventTypeMaintenance:
 		return s == EventStatusScheduled ||
 			s == EventStatusInProgress ||
-			s == EventStatusCompleted
+			s == EventStatusCompleted ||
+			s == EventStatusCancelled
 	}
 	return false
 }
@@ -93,9 +97,9 @@ func (s Severity) IsValid() bool {
 	return s == SeverityMinor || s == SeverityMajor || s == SeverityCritical
 }
 
-// IsResolved checks if the status represents a resolved/completed state.
+// IsResolved checks if the status represents a resolved/completed/cancelled state.
 func (s EventStatus) IsResolved() bool {
-	return s == EventStatusResolved || s == EventStatusCompleted
+	return s == EventStatusResolved || s == EventStatusCompleted || s == EventStatusCancelled
 }
 
 // ChangeAction represents the type of change to event services.
@@ -9,6 +9,21 @@ type ChannelType string
 const (
 	ChannelTypeEmail    ChannelType = "email"
 	ChannelTypeTelegram ChannelType = "telegram"
+	ChannelTypeSlack    ChannelType = "slack"
+	ChannelTypeMSTeams  ChannelType = "msteams"
+)
+
+// NotificationLevel classifies a notification; chat channels render it as a colour.
+type NotificationLevel string
+
+// Notification levels.
+const (
+	NotificationLevelInfo        NotificationLevel = "info"
+	NotificationLevelMinor       NotificationLevel = "minor"
+	NotificationLevelMajor       NotificationLevel = "major"
+	NotificationLevelCritical    NotificationLevel = "critical"
+	NotificationLevelMaintenance NotificationLevel = "maintenance"
+	NotificationLevelResolved    NotificationLevel = "resolved"
 )
 
 // NotificationChannel represents a user's notification channel.
@@ -21,4 +36,50 @@ type NotificationChannel struct {
 	IsVerified bool        `json:"is_verified"`
 	CreatedAt  time.Time   `json:"created_at"`
 	UpdatedAt  time.Time   `json:"updated_at"`
+
+	// VerificationLink is returned once on creation for channels verified out of band.
+	VerificationLink string `json:"verification_link,omitempty"`
+}
+
+// DeliveryStatus represents the state of a notification delivery in the outbox.
+type DeliveryStatus string
+
+// Delivery statuses.
+const (
+	DeliveryStatusPending    DeliveryStatus = "pending"
+	DeliveryStatusProcessing DeliveryStatus = "processing"
+	DeliveryStatusSent       DeliveryStatus = "sent"
+	DeliveryStatusFailed     DeliveryStatus = "failed"
+)
+
+// IsValid checks if the delivery status is valid.
+func (s DeliveryStatus) IsValid() bool {
+	switch s {
+	case DeliveryStatusPending, DeliveryStatusProcessing, DeliveryStatusSent, DeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+// NotificationDelivery represents a single notification queued for one channel.
+type NotificationDelivery struct {
+	ID          string            `json:"id"`
+	ChannelID   string            `json:"channel_id"`
+	ChannelType ChannelType       `json:"channel_type"`
+	Target      string            `json:"target"`
+	Subject     string            `json:"subject"`
+	Body        string            `json:"body"`
+	Level       NotificationLevel `json:"level"`
+	// Link points to the event page; empty for notifications not about an event.
+	Link          string         `json:"link"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	LastError     *string        `json:"last_error"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	SentAt        *time.Time     `json:"sent_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	// LockedUntil is the end of the lease of the worker sending the delivery.
+	// It identifies the claim, so a worker whose lease expired cannot record an outcome.
+	LockedUntil *time.Time `json:"-"`
 }
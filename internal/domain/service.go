@@ -16,16 +16,17 @@ const (
 
 // Service represents a monitored service.
 type Service struct {
-	ID          string        `json:"id"`
-	Name        string        `json:"name"`
-	Slug        string        `json:"slug"`
-	Description string        `json:"description"`
-	Status      ServiceStatus `json:"status"`
-	GroupIDs    []string      `json:"group_ids"`
-	Order       int           `json:"order"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
-	ArchivedAt  *time.Time    `json:"archived_at,omitempty"`
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Slug           string        `json:"slug"`
+	Description    string        `json:"description"`
+	Status         ServiceStatus `json:"status"`
+	StatusOverride bool          `json:"status_override"`
+	GroupIDs       []string      `json:"group_ids"`
+	Order          int           `json:"order"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	ArchivedAt     *time.Time    `json:"archived_at,omitempty"`
 }
 
 // IsArchived returns true if the service is archived.
@@ -33,6 +34,31 @@ func (s *Service) IsArchived() bool {
 	return s.ArchivedAt != nil
 }
 
+// StatusChangeReason explains why a service status changed.
+type StatusChangeReason string
+
+// Status change reasons.
+const (
+	StatusChangeCreated         StatusChangeReason = "created"
+	StatusChangeManual          StatusChangeReason = "manual"
+	StatusChangeOverrideCleared StatusChangeReason = "override_cleared"
+	StatusChangeEvent           StatusChangeReason = "event"
+)
+
+// ServiceStatusChange is a recorded change of a service status.
+// OldStatus is nil for the initial status of a new service; EventID is set
+// when the status was derived from an event change.
+type ServiceStatusChange struct {
+	ID        string             `json:"id"`
+	ServiceID string             `json:"service_id"`
+	OldStatus *ServiceStatus     `json:"old_status"`
+	NewStatus ServiceStatus      `json:"new_status"`
+	Reason    StatusChangeReason `json:"reason"`
+	ActorID   *string            `json:"actor_id"`
+	EventID   *string            `json:"event_id"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
 // ServiceGroup represents a group of related services.
 type ServiceGroup struct {
 	ID          string     `json:"id"`
@@ -13,6 +13,10 @@ const (
 	RoleAdmin    Role = "admin"
 )
 
+// SystemUserID is the author of changes made by the application itself.
+// The user is created by migrations and cannot log in.
+const SystemUserID = "00000000-0000-0000-0000-000000000001"
+
 // IsValid checks if the role is valid.
 func (r Role) IsValid() bool {
 	switch r {
@@ -34,21 +38,60 @@ func (r Role) HasPermission(required Role) bool {
 
 // User represents a user account in the system.
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	FirstName    string    `json:"first_name,omitempty"`
-	LastName     string    `json:"last_name,omitempty"`
-	Role         Role      `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"`
+	FirstName    string `json:"first_name,omitempty"`
+	LastName     string `json:"last_name,omitempty"`
+	Role         Role   `json:"role"`
+	// IsActive is false for deactivated users, who cannot log in.
+	IsActive      bool       `json:"is_active"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	// PasswordResetRequired asks the user to change the password after login.
+	PasswordResetRequired bool `json:"password_reset_required"`
+	// MFAEnabled is true once the user has confirmed a TOTP authenticator.
+	MFAEnabled bool `json:"mfa_enabled"`
+	// TOTPSecret is the base32 TOTP secret. It is also set while an
+	// enrolment waits for confirmation.
+	TOTPSecret string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// MFAPolicy decides who must use two-factor authentication.
+type MFAPolicy struct {
+	// RequiredRole is the lowest role that must use two-factor
+	// authentication; nil when it is optional for everyone.
+	RequiredRole *Role     `json:"required_role"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
-// RefreshToken represents a refresh token stored in the database.
+// Requires reports whether users with role must use two-factor authentication.
+func (p *MFAPolicy) Requires(role Role) bool {
+	return p.RequiredRole != nil && role.HasPermission(*p.RequiredRole)
+}
+
+// Session is a login on one device. Its refresh tokens form a family: every
+// refresh replaces the token, and presenting a replaced token ends the session.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RefreshToken represents a refresh token stored in the database. Only the
+// hash of the token is stored.
 type RefreshToken struct {
 	ID        string
+	SessionID string
 	UserID    string
-	Token     string
+	TokenHash string
+	// UsedAt is set once the token has been exchanged for a new one.
+	UsedAt    *time.Time
 	ExpiresAt time.Time
 	CreatedAt time.Time
 }
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEventKind is a kind of change a webhook can subscribe to.
+type WebhookEventKind string
+
+// Webhook event kinds.
+const (
+	WebhookEventCreated         WebhookEventKind = "event.created"
+	WebhookEventUpdated         WebhookEventKind = "event.updated"
+	WebhookEventResolved        WebhookEventKind = "event.resolved"
+	WebhookEventDeleted         WebhookEventKind = "event.deleted"
+	WebhookServiceStatusChanged WebhookEventKind = "service.status_changed"
+)
+
+// IsValid checks if the webhook event kind is valid.
+func (k WebhookEventKind) IsValid() bool {
+	switch k {
+	case WebhookEventCreated, WebhookEventUpdated, WebhookEventResolved, WebhookEventDeleted, WebhookServiceStatusChanged:
+		return true
+	}
+	return false
+}
+
+// Webhook is an endpoint that receives signed change notifications.
+// The secret is shown once on creation.
+type Webhook struct {
+	ID         string             `json:"id"`
+	URL        string             `json:"url"`
+	Secret     string             `json:"-"`
+	EventKinds []WebhookEventKind `json:"event_kinds"`
+	// ServiceIDs limits deliveries to changes affecting these services; empty means all.
+	ServiceIDs []string  `json:"service_ids"`
+	IsEnabled  bool      `json:"is_enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook wants changes of kind affecting serviceIDs.
+func (w *Webhook) Subscribes(kind WebhookEventKind, serviceIDs []string) bool {
+	if !w.IsEnabled {
+		return false
+	}
+
+	subscribed := false
+	for _, k := range w.EventKinds {
+		if k == kind {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		return false
+	}
+
+	if len(w.ServiceIDs) == 0 {
+		return true
+	}
+	for _, id := range serviceIDs {
+		for _, wanted := range w.ServiceIDs {
+			if id == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a payload queued for one webhook.
+type WebhookDelivery struct {
+	ID        string           `json:"id"`
+	WebhookID string           `json:"webhook_id"`
+	EventKind WebhookEventKind `json:"event_kind"`
+	Payload   json.RawMessage  `json:"payload"`
+	Status    DeliveryStatus   `json:"status"`
+	Attempts  int              `json:"attempts"`
+	// ResponseCode is the HTTP status of the latest attempt, if a response was received.
+	ResponseCode  *int       `json:"response_code"`
+	LastError     *string    `json:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	// LockedUntil is the end of the lease of the worker sending the delivery.
+	// It identifies the claim, so a worker whose lease expired cannot record an outcome.
+	LockedUntil *time.Time `json:"-"`
+
+	// AttemptHistory is set when a single delivery is requested.
+	AttemptHistory []WebhookDeliveryAttempt `json:"attempt_history,omitempty"`
+}
+
+// WebhookDeliveryAttempt is the outcome of one attempt to deliver a webhook.
+type WebhookDeliveryAttempt struct {
+	ID           string    `json:"id"`
+	DeliveryID   string    `json:"delivery_id"`
+	Attempt      int       `json:"attempt"`
+	ResponseCode *int      `json:"response_code"`
+	ResponseBody *string   `json:"response_body"`
+	Error        *string   `json:"error"`
+	DurationMS   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
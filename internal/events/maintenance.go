@@ -0,0 +1,29 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// ListMaintenanceToStart returns scheduled maintenance whose start time has passed.
+func (s *Service) ListMaintenanceToStart(ctx context.Context) ([]*domain.Event, error) {
+	return s.repo.ListMaintenanceToStart(ctx)
+}
+
+// ListMaintenanceToComplete returns unfinished maintenance whose end time has passed.
+func (s *Service) ListMaintenanceToComplete(ctx context.Context) ([]*domain.Event, error) {
+	return s.repo.ListMaintenanceToComplete(ctx)
+}
+
+// ListMaintenanceToRemind returns maintenance starting within the given duration
+// whose subscribers have not been reminded yet.
+func (s *Service) ListMaintenanceToRemind(ctx context.Context, within time.Duration) ([]*domain.Event, error) {
+	return s.repo.ListMaintenanceToRemind(ctx, within)
+}
+
+// MarkMaintenanceReminded records that subscribers were reminded about an upcoming maintenance.
+func (s *Service) MarkMaintenanceReminded(ctx context.Context, id string) error {
+	return s.repo.MarkMaintenanceReminded(ctx, id)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/events"
@@ -59,7 +60,8 @@ func (r *Repository) GetEvent(ctx context.Context, id string) (*domain.Event, er
 		SELECT 
 			id, title, type, status, severity, description,
 			started_at, resolved_at, scheduled_start_at, scheduled_end_at,
-			notify_subscribers, template_id, created_by, created_at, updated_at
+			notify_subscribers, template_id, created_by, created_at, updated_at,
+			sequence
 		FROM events
 		WHERE id = $1
 	`
@@ -80,6 +82,7 @@ func (r *Repository) GetEvent(ctx context.Context, id string) (*domain.Event, er
 		&event.CreatedBy,
 		&event.CreatedAt,
 		&event.UpdatedAt,
+		&event.Sequence,
 	)
 
 	if err != nil {
@@ -110,7 +113,8 @@ func (r *Repository) ListEvents(ctx context.Context, filters events.EventFilters
 		SELECT 
 			id, title, type, status, severity, description,
 			started_at, resolved_at, scheduled_start_at, scheduled_end_at,
-			notify_subscribers, template_id, created_by, created_at, updated_at
+			notify_subscribers, template_id, created_by, created_at, updated_at,
+			sequence
 		FROM events
 		WHERE 1=1
 	`
@@ -129,6 +133,22 @@ func (r *Repository) ListEvents(ctx context.Context, filters events.EventFilters
 		argNum++
 	}
 
+	if len(filters.ServiceIDs) > 0 {
+		query += fmt.Sprintf(" AND id IN (SELECT event_id FROM event_services WHERE service_id = ANY($%d))", argNum)
+		args = append(args, filters.ServiceIDs)
+		argNum++
+	}
+
+	if filters.Unresolved {
+		query += " AND status NOT IN ('resolved', 'completed', 'cancelled')"
+	}
+
+	if filters.ResolvedSince != nil {
+		query += fmt.Sprintf(" AND (resolved_at >= $%d OR (resolved_at IS NULL AND status NOT IN ('resolved', 'completed', 'cancelled')))", argNum)
+		args = append(args, *filters.ResolvedSince)
+		argNum++
+	}
+
 	query += " ORDER BY created_at DESC"
 
 	if filters.Limit > 0 {
@@ -142,10 +162,19 @@ func (r *Repository) ListEvents(ctx context.Context, filters events.EventFilters
 		args = append(args, filters.Offset)
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	eventsList, err := r.queryEvents(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("list events: %w", err)
 	}
+	return eventsList, nil
+}
+
+// queryEvents runs a query selecting event columns and loads related services and groups.
+func (r *Repository) queryEvents(ctx context.Context, query string, args ...interface{}) ([]*domain.Event, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	eventsList := make([]*domain.Event, 0)
@@ -167,6 +196,7 @@ func (r *Repository) ListEvents(ctx context.Context, filters events.EventFilters
 			&event.CreatedBy,
 			&event.CreatedAt,
 			&event.UpdatedAt,
+			&event.Sequence,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan event: %w", err)
@@ -187,18 +217,95 @@ func (r *Repository) ListEvents(ctx context.Context, filters events.EventFilters
 		eventsList = append(eventsList, &event)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate events: %w", err)
+	}
+
+	return eventsList, nil
+}
+
+const eventColumns = `
+	id, title, type, status, severity, description,
+	started_at, resolved_at, scheduled_start_at, scheduled_end_at,
+	notify_subscribers, template_id, created_by, created_at, updated_at,
+	sequence
+`
+
+// ListMaintenanceToStart returns scheduled maintenance whose start time has passed.
+func (r *Repository) ListMaintenanceToStart(ctx context.Context) ([]*domain.Event, error) {
+	query := `SELECT ` + eventColumns + `
+		FROM events
+		WHERE type = 'maintenance'
+		  AND status = 'scheduled'
+		  AND scheduled_start_at <= NOW()
+		ORDER BY scheduled_start_at
+	`
+	eventsList, err := r.queryEvents(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list maintenance to start: %w", err)
+	}
+	return eventsList, nil
+}
+
+// ListMaintenanceToComplete returns unfinished maintenance whose end time has passed.
+func (r *Repository) ListMaintenanceToComplete(ctx context.Context) ([]*domain.Event, error) {
+	query := `SELECT ` + eventColumns + `
+		FROM events
+		WHERE type = 'maintenance'
+		  AND status IN ('scheduled', 'in_progress')
+		  AND scheduled_end_at <= NOW()
+		ORDER BY scheduled_end_at
+	`
+	eventsList, err := r.queryEvents(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list maintenance to complete: %w", err)
+	}
 	return eventsList, nil
 }
 
+// ListMaintenanceToRemind returns scheduled maintenance with subscriber notifications
+// that starts within the given duration and has not been reminded about yet.
+func (r *Repository) ListMaintenanceToRemind(ctx context.Context, within time.Duration) ([]*domain.Event, error) {
+	query := `SELECT ` + eventColumns + `
+		FROM events
+		WHERE type = 'maintenance'
+		  AND status = 'scheduled'
+		  AND notify_subscribers
+		  AND reminder_sent_at IS NULL
+		  AND scheduled_start_at > NOW()
+		  AND scheduled_start_at <= NOW() + $1 * INTERVAL '1 second'
+		ORDER BY scheduled_start_at
+	`
+	eventsList, err := r.queryEvents(ctx, query, within.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("list maintenance to remind: %w", err)
+	}
+	return eventsList, nil
+}
+
+// MarkMaintenanceReminded records that a "starting soon" reminder was sent for an event.
+func (r *Repository) MarkMaintenanceReminded(ctx context.Context, id string) error {
+	query := `UPDATE events SET reminder_sent_at = NOW() WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("mark maintenance reminded: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return events.ErrEventNotFound
+	}
+	return nil
+}
+
 // UpdateEvent updates an existing event.
 func (r *Repository) UpdateEvent(ctx context.Context, event *domain.Event) error {
 	query := `
 		UPDATE events
 		SET title = $2, status = $3, severity = $4, description = $5,
 		    resolved_at = $6, scheduled_start_at = $7, scheduled_end_at = $8,
-		    notify_subscribers = $9, updated_at = NOW()
+		    notify_subscribers = $9, updated_at = NOW(), sequence = sequence + 1
 		WHERE id = $1
-		RETURNING updated_at
+		RETURNING updated_at, sequence
 	`
 	err := r.db.QueryRow(ctx, query,
 		event.ID,
@@ -210,7 +317,7 @@ func (r *Repository) UpdateEvent(ctx context.Context, event *domain.Event) error
 		event.ScheduledStartAt,
 		event.ScheduledEndAt,
 		event.NotifySubscribers,
-	).Scan(&event.UpdatedAt)
+	).Scan(&event.UpdatedAt, &event.Sequence)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// PublicationKind describes what happened to an event.
+type PublicationKind string
+
+// Publication kinds.
+const (
+	PublicationEventCreated    PublicationKind = "event_created"
+	PublicationEventUpdated    PublicationKind = "event_updated"
+	PublicationServicesAdded   PublicationKind = "services_added"
+	PublicationServicesRemoved PublicationKind = "services_removed"
+	PublicationEventDeleted    PublicationKind = "event_deleted"
+)
+
+// Publication describes a persisted event change that other modules may react to.
+type Publication struct {
+	Kind  PublicationKind
+	Event *domain.Event
+	// Update is set for PublicationEventUpdated.
+	Update *domain.EventUpdate
+	// ServiceIDs are the services affected by this change: all event services,
+	// or only the added/removed ones for PublicationServicesAdded/PublicationServicesRemoved.
+	ServiceIDs []string
+	// Notify reports whether subscribers asked to be notified about this change.
+	Notify bool
+}
+
+// PublishHook is called after an event change has been stored.
+// Hooks run synchronously and must not block for long.
+type PublishHook func(ctx context.Context, pub Publication)
+
+// OnPublish registers a hook called for every published event change.
+func (s *Service) OnPublish(hook PublishHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+func (s *Service) publish(ctx context.Context, pub Publication) {
+	for _, hook := range s.hooks {
+		hook(ctx, pub)
+	}
+}
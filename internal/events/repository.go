@@ -2,6 +2,7 @@ package events
 
 import (
 	"context"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 )
@@ -14,6 +15,11 @@ type Repository interface {
 	UpdateEvent(ctx context.Context, event *domain.Event) error
 	DeleteEvent(ctx context.Context, id string) error
 
+	ListMaintenanceToStart(ctx context.Context) ([]*domain.Event, error)
+	ListMaintenanceToComplete(ctx context.Context) ([]*domain.Event, error)
+	ListMaintenanceToRemind(ctx context.Context, within time.Duration) ([]*domain.Event, error)
+	MarkMaintenanceReminded(ctx context.Context, id string) error
+
 	CreateEventUpdate(ctx context.Context, update *domain.EventUpdate) error
 	ListEventUpdates(ctx context.Context, eventID string) ([]*domain.EventUpdate, error)
 
@@ -39,6 +45,12 @@ type Repository interface {
 type EventFilters struct {
 	Type   *domain.EventType
 	Status *domain.EventStatus
-	Limit  int
-	Offset int
+	// ServiceIDs limits events to those affecting any of the services.
+	ServiceIDs []string
+	// Unresolved limits events to those not yet resolved, completed or cancelled.
+	Unresolved bool
+	// ResolvedSince limits events to unresolved ones and those resolved at or after the time.
+	ResolvedSince *time.Time
+	Limit         int
+	Offset        int
 }
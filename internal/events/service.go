@@ -13,6 +13,8 @@ type Service struct {
 	repo     Repository
 	resolver GroupServiceResolver
 	renderer *TemplateRenderer
+	hooks    []PublishHook
+	audit    domain.AuditFunc
 }
 
 // NewService creates a new event service.
@@ -24,6 +26,11 @@ func NewService(repo Repository, resolver GroupServiceResolver) *Service {
 	}
 }
 
+// SetAuditLog sets where changes to events and templates are recorded.
+func (s *Service) SetAuditLog(audit domain.AuditFunc) {
+	s.audit = audit
+}
+
 // CreateEventInput holds data for creating an event.
 type CreateEventInput struct {
 	Title             string
@@ -137,6 +144,20 @@ func (s *Service) CreateEvent(ctx context.Context, input CreateEventInput, creat
 		return nil, fmt.Errorf("record initial services: %w", err)
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityEvent,
+		EntityID:   event.ID,
+		After:      event,
+	})
+
+	s.publish(ctx, Publication{
+		Kind:       PublicationEventCreated,
+		Event:      event,
+		ServiceIDs: event.ServiceIDs,
+		Notify:     event.NotifySubscribers,
+	})
+
 	return event, nil
 }
 
@@ -173,6 +194,7 @@ func (s *Service) AddUpdate(ctx context.Context, input CreateEventUpdateInput, c
 		return nil, fmt.Errorf("create event update: %w", err)
 	}
 
+	before := *event
 	event.Status = input.Status
 	if input.Status.IsResolved() && event.ResolvedAt == nil {
 		now := time.Now()
@@ -183,6 +205,28 @@ func (s *Service) AddUpdate(ctx context.Context, input CreateEventUpdateInput, c
 		return nil, fmt.Errorf("update event status: %w", err)
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityEventUpdate,
+		EntityID:   update.ID,
+		After:      update,
+	})
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityEvent,
+		EntityID:   event.ID,
+		Before:     &before,
+		After:      event,
+	})
+
+	s.publish(ctx, Publication{
+		Kind:       PublicationEventUpdated,
+		Event:      event,
+		Update:     update,
+		ServiceIDs: event.ServiceIDs,
+		Notify:     update.NotifySubscribers,
+	})
+
 	return update, nil
 }
 
@@ -193,7 +237,29 @@ func (s *Service) GetEventUpdates(ctx context.Context, eventID string) ([]*domai
 
 // DeleteEvent deletes an event by ID.
 func (s *Service) DeleteEvent(ctx context.Context, id string) error {
-	return s.repo.DeleteEvent(ctx, id)
+	event, err := s.repo.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteEvent(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityEvent,
+		EntityID:   id,
+		Before:     event,
+	})
+
+	s.publish(ctx, Publication{
+		Kind:       PublicationEventDeleted,
+		Event:      event,
+		ServiceIDs: event.ServiceIDs,
+	})
+
+	return nil
 }
 
 // CreateTemplate creates a new event template with validation.
@@ -221,6 +287,13 @@ func (s *Service) CreateTemplate(ctx context.Context, input CreateTemplateInput)
 		return nil, fmt.Errorf("create template: %w", err)
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityTemplate,
+		EntityID:   template.ID,
+		After:      template,
+	})
+
 	return template, nil
 }
 
@@ -261,7 +334,22 @@ func (s *Service) PreviewTemplate(ctx context.Context, templateSlug string, data
 
 // DeleteTemplate deletes a template by ID.
 func (s *Service) DeleteTemplate(ctx context.Context, id string) error {
-	return s.repo.DeleteTemplate(ctx, id)
+	template, err := s.repo.GetTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteTemplate(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityTemplate,
+		EntityID:   id,
+		Before:     template,
+	})
+	return nil
 }
 
 // recordInitialServices записывает начальный состав события в историю.
@@ -297,6 +385,13 @@ func (s *Service) recordInitialServices(ctx context.Context, eventID string, ser
 	return nil
 }
 
+// eventServices is the audit snapshot of the services affected by an event.
+type eventServices struct {
+	ServiceIDs    []string `json:"service_ids"`
+	AddedGroupIDs []string `json:"added_group_ids,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+}
+
 // AddServicesToEventInput holds data for adding services to an event.
 type AddServicesToEventInput struct {
 	ServiceIDs []string
@@ -392,6 +487,24 @@ func (s *Service) AddServicesToEvent(ctx context.Context, eventID string, input
 		}
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityEventServices,
+		EntityID:   eventID,
+		Before:     eventServices{ServiceIDs: event.ServiceIDs},
+		After:      eventServices{ServiceIDs: allServiceIDs, AddedGroupIDs: input.GroupIDs, Reason: reason},
+	})
+
+	if len(newServiceIDs) > 0 {
+		event.ServiceIDs = allServiceIDs
+		s.publish(ctx, Publication{
+			Kind:       PublicationServicesAdded,
+			Event:      event,
+			ServiceIDs: newServiceIDs,
+			Notify:     event.NotifySubscribers && !event.Status.IsResolved(),
+		})
+	}
+
 	return nil
 }
 
@@ -414,15 +527,15 @@ func (s *Service) RemoveServicesFromEvent(ctx context.Context, eventID string, i
 		currentServices[sid] = true
 	}
 
-	removedCount := 0
+	removedServiceIDs := make([]string, 0)
 	for _, sid := range input.ServiceIDs {
 		if currentServices[sid] {
 			delete(currentServices, sid)
-			removedCount++
+			removedServiceIDs = append(removedServiceIDs, sid)
 		}
 	}
 
-	if removedCount == 0 {
+	if len(removedServiceIDs) == 0 {
 		return nil // Ничего не изменилось
 	}
 
@@ -454,6 +567,21 @@ func (s *Service) RemoveServicesFromEvent(ctx context.Context, eventID string, i
 		}
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityEventServices,
+		EntityID:   eventID,
+		Before:     eventServices{ServiceIDs: event.ServiceIDs},
+		After:      eventServices{ServiceIDs: remainingServiceIDs, Reason: reason},
+	})
+
+	event.ServiceIDs = remainingServiceIDs
+	s.publish(ctx, Publication{
+		Kind:       PublicationServicesRemoved,
+		Event:      event,
+		ServiceIDs: removedServiceIDs,
+	})
+
 	return nil
 }
 
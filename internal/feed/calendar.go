@@ -0,0 +1,204 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// MaxCalendarEvents is the number of latest maintenance windows in a calendar.
+const MaxCalendarEvents = 100
+
+const (
+	calendarProductID = "-//IncidentGarden//Status Page//EN"
+	calendarUIDSuffix = "@incident-garden"
+	icsTimeFormat     = "20060102T150405Z"
+	// icsLineLimit is the maximum line length in octets (RFC 5545, section 3.1).
+	icsLineLimit = 75
+)
+
+// Calendar is a calendar of maintenance windows.
+type Calendar struct {
+	Name    string
+	Updated time.Time
+	Events  []CalendarEvent
+}
+
+// CalendarEvent is a single maintenance window.
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         *time.Time
+	Stamp       time.Time
+	Sequence    int
+	Cancelled   bool
+}
+
+// MaintenanceCalendar returns the calendar of all maintenance windows.
+func (s *Service) MaintenanceCalendar(ctx context.Context) (*Calendar, error) {
+	return s.buildCalendar(ctx, s.statusScope())
+}
+
+// ServiceMaintenanceCalendar returns the calendar of maintenance windows affecting the service.
+func (s *Service) ServiceMaintenanceCalendar(ctx context.Context, slug string) (*Calendar, error) {
+	sc, err := s.serviceScope(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildCalendar(ctx, sc)
+}
+
+// GroupMaintenanceCalendar returns the calendar of maintenance windows affecting any service of the group.
+func (s *Service) GroupMaintenanceCalendar(ctx context.Context, slug string) (*Calendar, error) {
+	sc, err := s.groupScope(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildCalendar(ctx, sc)
+}
+
+func (s *Service) buildCalendar(ctx context.Context, sc scope) (*Calendar, error) {
+	calendar := &Calendar{
+		Name:    sc.title + " maintenance",
+		Updated: time.Unix(0, 0).UTC(),
+		Events:  []CalendarEvent{},
+	}
+
+	maintenance := domain.EventTypeMaintenance
+	list, err := s.listEvents(ctx, sc, &maintenance, MaxCalendarEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range list {
+		// A window without a start time cannot be put in a calendar.
+		if event.ScheduledStartAt == nil {
+			continue
+		}
+
+		updates, err := s.events.GetEventUpdates(ctx, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get event updates: %w", err)
+		}
+
+		vevent := CalendarEvent{
+			UID:         event.ID + calendarUIDSuffix,
+			Summary:     event.Title,
+			Description: calendarDescription(event, updates),
+			URL:         s.eventLink(event.ID),
+			Start:       event.ScheduledStartAt.UTC(),
+			Stamp:       event.UpdatedAt.UTC(),
+			Sequence:    event.Sequence,
+			Cancelled:   event.Status == domain.EventStatusCancelled,
+		}
+		if event.ScheduledEndAt != nil {
+			end := event.ScheduledEndAt.UTC()
+			vevent.End = &end
+		}
+
+		if vevent.Stamp.After(calendar.Updated) {
+			calendar.Updated = vevent.Stamp
+		}
+		calendar.Events = append(calendar.Events, vevent)
+	}
+
+	return calendar, nil
+}
+
+// calendarDescription lists the latest updates of the event, newest first, followed by its description.
+func calendarDescription(event *domain.Event, updates []*domain.EventUpdate) string {
+	parts := make([]string, 0, len(updates)+1)
+	for i, update := range updates {
+		if i == MaxEntryUpdates {
+			break
+		}
+		parts = append(parts, statusLabel(update.Status)+" ("+update.CreatedAt.UTC().Format("2006-01-02 15:04 UTC")+")\n"+update.Message)
+	}
+	if event.Description != "" {
+		parts = append(parts, event.Description)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// RenderICS renders the calendar as iCalendar (RFC 5545).
+func RenderICS(calendar *Calendar) []byte {
+	var buf bytes.Buffer
+	write := func(name, value string) {
+		writeFolded(&buf, name+":"+value)
+	}
+
+	write("BEGIN", "VCALENDAR")
+	write("VERSION", "2.0")
+	write("PRODID", calendarProductID)
+	write("CALSCALE", "GREGORIAN")
+	write("METHOD", "PUBLISH")
+	write("X-WR-CALNAME", escapeText(calendar.Name))
+
+	for _, event := range calendar.Events {
+		write("BEGIN", "VEVENT")
+		write("UID", escapeText(event.UID))
+		write("DTSTAMP", event.Stamp.UTC().Format(icsTimeFormat))
+		write("LAST-MODIFIED", event.Stamp.UTC().Format(icsTimeFormat))
+		write("SEQUENCE", strconv.Itoa(event.Sequence))
+		write("DTSTART", event.Start.UTC().Format(icsTimeFormat))
+		if event.End != nil {
+			write("DTEND", event.End.UTC().Format(icsTimeFormat))
+		}
+		write("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			write("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.URL != "" {
+			write("URL", event.URL)
+		}
+		if event.Cancelled {
+			write("STATUS", "CANCELLED")
+		} else {
+			write("STATUS", "CONFIRMED")
+		}
+		write("TRANSP", "TRANSPARENT")
+		write("END", "VEVENT")
+	}
+
+	write("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+// escapeText escapes a TEXT value (RFC 5545, section 3.3.11).
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// writeFolded writes a content line folded at icsLineLimit octets without splitting UTF-8 characters.
+func writeFolded(buf *bytes.Buffer, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards the limit.
+		limit = icsLineLimit - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+func TestMaintenanceCalendar(t *testing.T) {
+	start := time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	ev := &fakeEvents{
+		events: []*domain.Event{
+			{
+				ID:               "22222222-2222-2222-2222-222222222222",
+				Title:            "Database upgrade, part 1; primary",
+				Type:             domain.EventTypeMaintenance,
+				Status:           domain.EventStatusCancelled,
+				Description:      "Upgrade to PostgreSQL 17",
+				ScheduledStartAt: &start,
+				ScheduledEndAt:   &end,
+				UpdatedAt:        latest,
+				Sequence:         3,
+			},
+			{
+				ID:     "33333333-3333-3333-3333-333333333333",
+				Title:  "Unscheduled",
+				Type:   domain.EventTypeMaintenance,
+				Status: domain.EventStatusScheduled,
+			},
+		},
+		updates: map[string][]*domain.EventUpdate{
+			"22222222-2222-2222-2222-222222222222": {
+				{Status: domain.EventStatusCancelled, Message: "Postponed", CreatedAt: latest},
+			},
+		},
+	}
+	svc := NewService(ev, &fakeCatalog{}, Config{PublicURL: "https://status.example.com"})
+
+	calendar, err := svc.MaintenanceCalendar(context.Background())
+	if err != nil {
+		t.Fatalf("MaintenanceCalendar() error = %v", err)
+	}
+	if ev.filters.Type == nil || *ev.filters.Type != domain.EventTypeMaintenance {
+		t.Errorf("Type filter = %v, want maintenance", ev.filters.Type)
+	}
+	if len(calendar.Events) != 1 {
+		t.Fatalf("events = %d, want 1 (window without start is skipped)", len(calendar.Events))
+	}
+	if !calendar.Updated.Equal(latest) {
+		t.Errorf("Updated = %v, want %v", calendar.Updated, latest)
+	}
+
+	ics := string(RenderICS(calendar))
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:22222222-2222-2222-2222-222222222222@incident-garden\r\n",
+		"SEQUENCE:3\r\n",
+		"DTSTART:20260310T220000Z\r\n",
+		"DTEND:20260311T000000Z\r\n",
+		"SUMMARY:Database upgrade\\, part 1\\; primary\r\n",
+		"STATUS:CANCELLED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar does not contain %q:\n%s", want, ics)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	if !strings.Contains(unfolded, "DESCRIPTION:Cancelled (2026-03-01 12:30 UTC)\\nPostponed\\n\\nUpgrade to PostgreSQL 17\r\n") {
+		t.Errorf("unexpected description:\n%s", unfolded)
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > icsLineLimit {
+			t.Errorf("line longer than %d octets: %q", icsLineLimit, line)
+		}
+	}
+}
+
+func TestWriteFolded_KeepsUTF8(t *testing.T) {
+	var buf bytes.Buffer
+	line := "SUMMARY:" + strings.Repeat("Обновление ", 20)
+	writeFolded(&buf, line)
+
+	unfolded := strings.TrimSuffix(strings.ReplaceAll(buf.String(), "\r\n ", ""), "\r\n")
+	if unfolded != line {
+		t.Errorf("unfolded line = %q, want %q", unfolded, line)
+	}
+	for _, part := range strings.Split(buf.String(), "\r\n") {
+		if len(part) > icsLineLimit {
+			t.Errorf("line longer than %d octets: %q", icsLineLimit, part)
+		}
+		if !utf8.ValidString(part) {
+			t.Errorf("line splits a UTF-8 character: %q", part)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
+	"github.com/go-chi/chi/v5"
+)
+
+// Content types of the feeds.
+const (
+	ContentTypeRSS      = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom     = "application/atom+xml; charset=utf-8"
+	ContentTypeCalendar = "text/calendar; charset=utf-8"
+)
+
+// Handler handles feed requests.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new feed handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// document is a rendered feed with the time of its latest change.
+type document struct {
+	body    []byte
+	updated time.Time
+}
+
+type renderFunc func(feed *Feed, self string) ([]byte, error)
+
+// RegisterRoutes registers public feed routes.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/status/feed.rss", h.serve(ContentTypeRSS, h.feed(h.statusFeed, RenderRSS)))
+	r.Get("/status/feed.atom", h.serve(ContentTypeAtom, h.feed(h.statusFeed, RenderAtom)))
+	r.Get("/services/{slug}/feed.rss", h.serve(ContentTypeRSS, h.feed(h.serviceFeed, RenderRSS)))
+	r.Get("/services/{slug}/feed.atom", h.serve(ContentTypeAtom, h.feed(h.serviceFeed, RenderAtom)))
+	r.Get("/groups/{slug}/feed.rss", h.serve(ContentTypeRSS, h.feed(h.groupFeed, RenderRSS)))
+	r.Get("/groups/{slug}/feed.atom", h.serve(ContentTypeAtom, h.feed(h.groupFeed, RenderAtom)))
+
+	r.Get("/maintenance.ics", h.serve(ContentTypeCalendar, h.calendar(h.maintenanceCalendar)))
+	r.Get("/services/{slug}/maintenance.ics", h.serve(ContentTypeCalendar, h.calendar(h.serviceMaintenanceCalendar)))
+	r.Get("/groups/{slug}/maintenance.ics", h.serve(ContentTypeCalendar, h.calendar(h.groupMaintenanceCalendar)))
+}
+
+func (h *Handler) statusFeed(r *http.Request) (*Feed, error) {
+	return h.service.StatusFeed(r.Context())
+}
+
+func (h *Handler) serviceFeed(r *http.Request) (*Feed, error) {
+	return h.service.ServiceFeed(r.Context(), chi.URLParam(r, "slug"))
+}
+
+func (h *Handler) groupFeed(r *http.Request) (*Feed, error) {
+	return h.service.GroupFeed(r.Context(), chi.URLParam(r, "slug"))
+}
+
+func (h *Handler) maintenanceCalendar(r *http.Request) (*Calendar, error) {
+	return h.service.MaintenanceCalendar(r.Context())
+}
+
+func (h *Handler) serviceMaintenanceCalendar(r *http.Request) (*Calendar, error) {
+	return h.service.ServiceMaintenanceCalendar(r.Context(), chi.URLParam(r, "slug"))
+}
+
+func (h *Handler) groupMaintenanceCalendar(r *http.Request) (*Calendar, error) {
+	return h.service.GroupMaintenanceCalendar(r.Context(), chi.URLParam(r, "slug"))
+}
+
+func (h *Handler) feed(load func(r *http.Request) (*Feed, error), render renderFunc) func(r *http.Request) (*document, error) {
+	return func(r *http.Request) (*document, error) {
+		feed, err := load(r)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := render(feed, selfURL(r))
+		if err != nil {
+			return nil, err
+		}
+		return &document{body: body, updated: feed.Updated}, nil
+	}
+}
+
+func (h *Handler) calendar(load func(r *http.Request) (*Calendar, error)) func(r *http.Request) (*document, error) {
+	return func(r *http.Request) (*document, error) {
+		calendar, err := load(r)
+		if err != nil {
+			return nil, err
+		}
+		return &document{body: RenderICS(calendar), updated: calendar.Updated}, nil
+	}
+}
+
+// serve writes a document with validators so that feed readers can poll it with conditional requests.
+func (h *Handler) serve(contentType string, load func(r *http.Request) (*document, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := load(r)
+		if err != nil {
+			h.handleServiceError(w, err)
+			return
+		}
+
+		etag := httputil.ETag(doc.body)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", doc.updated.UTC().Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "public, max-age=60")
+
+		if httputil.NotModified(r, etag, doc.updated) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(doc.body); err != nil {
+			slog.Error("failed to write feed", "error", err)
+		}
+	}
+}
+
+// selfURL returns the absolute URL of the request, honouring X-Forwarded-Proto from a proxy.
+func selfURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, catalog.ErrServiceNotFound):
+		h.respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, catalog.ErrGroupNotFound):
+		h.respondError(w, http.StatusNotFound, err.Error())
+	default:
+		slog.Error("failed to build feed", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
@@ -0,0 +1,264 @@
+// Package feed serves RSS, Atom and iCalendar feeds of public incidents and maintenance.
+package feed
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+)
+
+// Feed sizes.
+const (
+	MaxEntries      = 25
+	MaxEntryUpdates = 10
+)
+
+const (
+	defaultTitle    = "Status"
+	defaultSubtitle = "Incidents and scheduled maintenance"
+	feedIDPrefix    = "urn:incident-garden:feed:"
+	entryIDPrefix   = "urn:uuid:"
+)
+
+// Config holds feed settings.
+type Config struct {
+	// PublicURL is the status page base URL used for entry links; links are omitted when empty.
+	PublicURL string
+	// Title is the feed title, "Status" by default.
+	Title string
+}
+
+// Feed is a format-independent feed of events.
+type Feed struct {
+	ID       string
+	Title    string
+	Subtitle string
+	Link     string
+	Updated  time.Time
+	Entries  []Entry
+}
+
+// Entry is a single event of a feed.
+type Entry struct {
+	ID         string
+	Title      string
+	Link       string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+	// Content is HTML with the latest updates of the event.
+	Content string
+}
+
+// EventService is the part of events.Service used to read events.
+type EventService interface {
+	ListEvents(ctx context.Context, filters events.EventFilters) ([]*domain.Event, error)
+	GetEventUpdates(ctx context.Context, eventID string) ([]*domain.EventUpdate, error)
+}
+
+// ServiceCatalog is the part of catalog.Service used to resolve services and groups.
+type ServiceCatalog interface {
+	GetServiceBySlug(ctx context.Context, slug string) (*domain.Service, error)
+	GetGroupBySlug(ctx context.Context, slug string) (*domain.ServiceGroup, error)
+	GetGroupServices(ctx context.Context, groupID string) ([]string, error)
+}
+
+// Service builds feeds of events.
+type Service struct {
+	events  EventService
+	catalog ServiceCatalog
+	config  Config
+}
+
+// NewService creates a new feed service.
+func NewService(eventService EventService, serviceCatalog ServiceCatalog, config Config) *Service {
+	if config.Title == "" {
+		config.Title = defaultTitle
+	}
+	config.PublicURL = strings.TrimRight(config.PublicURL, "/")
+
+	return &Service{
+		events:  eventService,
+		catalog: serviceCatalog,
+		config:  config,
+	}
+}
+
+// scope selects the events of a feed: all of them or those affecting serviceIDs.
+type scope struct {
+	id         string
+	title      string
+	all        bool
+	serviceIDs []string
+}
+
+func (s *Service) statusScope() scope {
+	return scope{id: "status", title: s.config.Title, all: true}
+}
+
+func (s *Service) serviceScope(ctx context.Context, slug string) (scope, error) {
+	service, err := s.catalog.GetServiceBySlug(ctx, slug)
+	if err != nil {
+		return scope{}, err
+	}
+
+	return scope{
+		id:         "service:" + service.ID,
+		title:      s.config.Title + ": " + service.Name,
+		serviceIDs: []string{service.ID},
+	}, nil
+}
+
+func (s *Service) groupScope(ctx context.Context, slug string) (scope, error) {
+	group, err := s.catalog.GetGroupBySlug(ctx, slug)
+	if err != nil {
+		return scope{}, err
+	}
+
+	serviceIDs, err := s.catalog.GetGroupServices(ctx, group.ID)
+	if err != nil {
+		return scope{}, fmt.Errorf("get group services: %w", err)
+	}
+
+	return scope{
+		id:         "group:" + group.ID,
+		title:      s.config.Title + ": " + group.Name,
+		serviceIDs: serviceIDs,
+	}, nil
+}
+
+// listEvents lists the latest events of the scope. A group without services has no events.
+func (s *Service) listEvents(ctx context.Context, sc scope, eventType *domain.EventType, limit int) ([]*domain.Event, error) {
+	if !sc.all && len(sc.serviceIDs) == 0 {
+		return nil, nil
+	}
+
+	list, err := s.events.ListEvents(ctx, events.EventFilters{
+		Type:       eventType,
+		ServiceIDs: sc.serviceIDs,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	return list, nil
+}
+
+// StatusFeed returns the feed of all events.
+func (s *Service) StatusFeed(ctx context.Context) (*Feed, error) {
+	return s.build(ctx, s.statusScope())
+}
+
+// ServiceFeed returns the feed of events affecting the service.
+func (s *Service) ServiceFeed(ctx context.Context, slug string) (*Feed, error) {
+	sc, err := s.serviceScope(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.build(ctx, sc)
+}
+
+// GroupFeed returns the feed of events affecting any service of the group.
+func (s *Service) GroupFeed(ctx context.Context, slug string) (*Feed, error) {
+	sc, err := s.groupScope(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.build(ctx, sc)
+}
+
+func (s *Service) build(ctx context.Context, sc scope) (*Feed, error) {
+	feed := &Feed{
+		ID:       feedIDPrefix + sc.id,
+		Title:    sc.title,
+		Subtitle: defaultSubtitle,
+		Link:     s.config.PublicURL,
+		Updated:  time.Unix(0, 0).UTC(),
+		Entries:  []Entry{},
+	}
+
+	list, err := s.listEvents(ctx, sc, nil, MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range list {
+		updates, err := s.events.GetEventUpdates(ctx, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get event updates: %w", err)
+		}
+
+		entry := s.entry(event, updates)
+		if entry.Updated.After(feed.Updated) {
+			feed.Updated = entry.Updated
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed, nil
+}
+
+func (s *Service) entry(event *domain.Event, updates []*domain.EventUpdate) Entry {
+	entry := Entry{
+		ID:         entryIDPrefix + event.ID,
+		Title:      event.Title,
+		Categories: []string{string(event.Type)},
+		Published:  event.CreatedAt.UTC(),
+		Updated:    event.UpdatedAt.UTC(),
+	}
+	if event.Severity != nil {
+		entry.Categories = append(entry.Categories, string(*event.Severity))
+	}
+	entry.Link = s.eventLink(event.ID)
+
+	// Updates come newest first, so the first one is the latest change of the event.
+	if len(updates) > 0 && updates[0].CreatedAt.After(entry.Updated) {
+		entry.Updated = updates[0].CreatedAt.UTC()
+	}
+
+	var content strings.Builder
+	for i, update := range updates {
+		if i == MaxEntryUpdates {
+			break
+		}
+		writeUpdate(&content, update)
+	}
+	if event.Description != "" {
+		content.WriteString("<p>" + html.EscapeString(event.Description) + "</p>")
+	}
+	entry.Content = content.String()
+
+	return entry
+}
+
+// eventLink returns the status page URL of the event, or "" without a public URL.
+func (s *Service) eventLink(eventID string) string {
+	if s.config.PublicURL == "" {
+		return ""
+	}
+	return s.config.PublicURL + "/events/" + eventID
+}
+
+func writeUpdate(b *strings.Builder, update *domain.EventUpdate) {
+	b.WriteString("<p><strong>")
+	b.WriteString(html.EscapeString(statusLabel(update.Status)))
+	b.WriteString("</strong> &#8212; ")
+	b.WriteString(update.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"))
+	b.WriteString("<br>")
+	b.WriteString(strings.ReplaceAll(html.EscapeString(update.Message), "\n", "<br>"))
+	b.WriteString("</p>")
+}
+
+// statusLabel turns a status such as "in_progress" into "In progress".
+func statusLabel(status domain.EventStatus) string {
+	label := strings.ReplaceAll(string(status), "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
@@ -0,0 +1,215 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	created = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	latest  = time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+)
+
+type fakeEvents struct {
+	events  []*domain.Event
+	updates map[string][]*domain.EventUpdate
+	filters events.EventFilters
+}
+
+func (f *fakeEvents) ListEvents(_ context.Context, filters events.EventFilters) ([]*domain.Event, error) {
+	f.filters = filters
+	return f.events, nil
+}
+
+func (f *fakeEvents) GetEventUpdates(_ context.Context, eventID string) ([]*domain.EventUpdate, error) {
+	return f.updates[eventID], nil
+}
+
+type fakeCatalog struct {
+	groupServices []string
+}
+
+func (f *fakeCatalog) GetServiceBySlug(_ context.Context, slug string) (*domain.Service, error) {
+	if slug != "api" {
+		return nil, catalog.ErrServiceNotFound
+	}
+	return &domain.Service{ID: "svc-1", Name: "API", Slug: "api"}, nil
+}
+
+func (f *fakeCatalog) GetGroupBySlug(_ context.Context, slug string) (*domain.ServiceGroup, error) {
+	if slug != "core" {
+		return nil, catalog.ErrGroupNotFound
+	}
+	return &domain.ServiceGroup{ID: "grp-1", Name: "Core", Slug: "core"}, nil
+}
+
+func (f *fakeCatalog) GetGroupServices(_ context.Context, _ string) ([]string, error) {
+	return f.groupServices, nil
+}
+
+func newTestService() (*Service, *fakeEvents, *fakeCatalog) {
+	severity := domain.SeverityMajor
+	ev := &fakeEvents{
+		events: []*domain.Event{{
+			ID:          "11111111-1111-1111-1111-111111111111",
+			Title:       "API errors",
+			Type:        domain.EventTypeIncident,
+			Status:      domain.EventStatusMonitoring,
+			Severity:    &severity,
+			Description: "Elevated <5xx> rate",
+			CreatedAt:   created,
+			UpdatedAt:   created,
+		}},
+		updates: map[string][]*domain.EventUpdate{
+			"11111111-1111-1111-1111-111111111111": {
+				{Status: domain.EventStatusMonitoring, Message: "Fix deployed", CreatedAt: latest},
+				{Status: domain.EventStatusIdentified, Message: "Bad release", CreatedAt: created.Add(time.Hour)},
+			},
+		},
+	}
+	cat := &fakeCatalog{groupServices: []string{"svc-1", "svc-2"}}
+	return NewService(ev, cat, Config{PublicURL: "https://status.example.com/"}), ev, cat
+}
+
+func TestStatusFeed_Entries(t *testing.T) {
+	svc, _, _ := newTestService()
+
+	feed, err := svc.StatusFeed(context.Background())
+	if err != nil {
+		t.Fatalf("StatusFeed() error = %v", err)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(feed.Entries))
+	}
+
+	entry := feed.Entries[0]
+	if entry.ID != "urn:uuid:11111111-1111-1111-1111-111111111111" {
+		t.Errorf("ID = %q", entry.ID)
+	}
+	if entry.Link != "https://status.example.com/events/11111111-1111-1111-1111-111111111111" {
+		t.Errorf("Link = %q", entry.Link)
+	}
+	if !entry.Updated.Equal(latest) || !feed.Updated.Equal(latest) {
+		t.Errorf("Updated = %v, feed Updated = %v, want %v", entry.Updated, feed.Updated, latest)
+	}
+	if !entry.Published.Equal(created) {
+		t.Errorf("Published = %v, want %v", entry.Published, created)
+	}
+	if strings.Index(entry.Content, "Fix deployed") > strings.Index(entry.Content, "Bad release") {
+		t.Errorf("latest update is not first: %q", entry.Content)
+	}
+	if !strings.Contains(entry.Content, "Elevated &lt;5xx&gt; rate") {
+		t.Errorf("description is not escaped: %q", entry.Content)
+	}
+}
+
+func TestGroupFeed_FiltersByGroupServices(t *testing.T) {
+	svc, ev, cat := newTestService()
+
+	feed, err := svc.GroupFeed(context.Background(), "core")
+	if err != nil {
+		t.Fatalf("GroupFeed() error = %v", err)
+	}
+	if feed.ID != "urn:incident-garden:feed:group:grp-1" || feed.Title != "Status: Core" {
+		t.Errorf("feed = %q %q", feed.ID, feed.Title)
+	}
+	if strings.Join(ev.filters.ServiceIDs, ",") != "svc-1,svc-2" {
+		t.Errorf("ServiceIDs = %v", ev.filters.ServiceIDs)
+	}
+
+	cat.groupServices = nil
+	ev.filters = events.EventFilters{}
+	feed, err = svc.GroupFeed(context.Background(), "core")
+	if err != nil {
+		t.Fatalf("GroupFeed() error = %v", err)
+	}
+	if len(feed.Entries) != 0 || ev.filters.Limit != 0 {
+		t.Errorf("empty group listed events: %d entries", len(feed.Entries))
+	}
+}
+
+func TestRenderAtom_Valid(t *testing.T) {
+	svc, _, _ := newTestService()
+	feed, _ := svc.StatusFeed(context.Background())
+
+	body, err := RenderAtom(feed, "http://localhost/api/v1/status/feed.atom")
+	if err != nil {
+		t.Fatalf("RenderAtom() error = %v", err)
+	}
+
+	var doc struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if doc.ID != "urn:incident-garden:feed:status" || doc.Updated != "2026-03-01T12:30:00Z" {
+		t.Errorf("feed = %q %q", doc.ID, doc.Updated)
+	}
+	if len(doc.Entries) != 1 || !strings.Contains(doc.Entries[0].Content, "<strong>Monitoring</strong>") {
+		t.Errorf("entries = %+v", doc.Entries)
+	}
+}
+
+func TestHandler_ConditionalGet(t *testing.T) {
+	svc, _, _ := newTestService()
+	r := chi.NewRouter()
+	NewHandler(svc).RegisterRoutes(r)
+
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/status/feed.rss", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentTypeRSS {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Header().Get("Last-Modified") != "Sun, 01 Mar 2026 12:30:00 GMT" {
+		t.Fatalf("ETag = %q, Last-Modified = %q", etag, rec.Header().Get("Last-Modified"))
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak etag in list", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"stale etag wins over date", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Sun, 01 Mar 2026 12:30:00 GMT"}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:30:00 GMT"}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:29:59 GMT"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := get("/status/feed.rss", tt.header); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	if rec := get("/services/missing/feed.atom", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown service status = %d, want 404", rec.Code)
+	}
+}
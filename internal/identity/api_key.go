@@ -0,0 +1,187 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// apiKeyPrefixBytes is the size of the random public part of a key, used for lookup.
+const apiKeyPrefixBytes = 4
+
+// apiKeySecretBytes is the size of the random secret part of a key.
+const apiKeySecretBytes = 32
+
+// CreateAPIKeyInput contains data for creating an API key.
+type CreateAPIKeyInput struct {
+	Name      string
+	Role      domain.Role
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// CreateAPIKey creates an API key acting on behalf of createdBy.
+// The returned plaintext key is not stored and cannot be retrieved later.
+func (s *Service) CreateAPIKey(ctx context.Context, input CreateAPIKeyInput, createdBy string) (*domain.APIKey, string, error) {
+	for _, scope := range input.Scopes {
+		if !domain.IsValidScope(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	prefix, plaintext, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	scopes := input.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	key := &domain.APIKey{
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(plaintext),
+		Role:      input.Role,
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityAPIKey,
+		EntityID:   key.ID,
+		After:      key,
+	})
+
+	return key, plaintext, nil
+}
+
+// ListAPIKeys returns all API keys, including revoked and expired ones.
+func (s *Service) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey revokes an API key. Requests with a revoked key are rejected immediately.
+func (s *Service) RevokeAPIKey(ctx context.Context, id string) error {
+	if err := s.repo.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionRevoke,
+		EntityType: domain.AuditEntityAPIKey,
+		EntityID:   id,
+	})
+	return nil
+}
+
+// ValidateAPIKey validates an API key and returns it. The returned role is
+// capped by the current role of the key creator; keys of deactivated users
+// are rejected. The creator's pending password change and two-factor
+// enrolment apply to keys as they do to access tokens.
+func (s *Service) ValidateAPIKey(ctx context.Context, plaintext string) (*domain.APIKey, error) {
+	prefix, ok := parseAPIKey(plaintext)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(plaintext)), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidToken
+	}
+
+	if !key.IsActive(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, key.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordChangeRequired
+	}
+
+	role := user.Role
+	enrollmentRequired, err := s.mfaEnrollmentRequired(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if enrollmentRequired {
+		role = domain.RoleUser
+	}
+
+	validated := *key
+	if !role.HasPermission(validated.Role) {
+		validated.Role = role
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		slog.Warn("failed to record api key use", "api_key_id", key.ID, "error", err)
+	}
+
+	return &validated, nil
+}
+
+// generateAPIKey returns a key of the form sp_<prefix>_<secret> and its prefix.
+func generateAPIKey() (string, string, error) {
+	buf := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	prefix := hex.EncodeToString(buf[:apiKeyPrefixBytes])
+	secret := base64.RawURLEncoding.EncodeToString(buf[apiKeyPrefixBytes:])
+	return prefix, domain.APIKeyPrefix + prefix + "_" + secret, nil
+}
+
+// parseAPIKey extracts the lookup prefix from a key.
+func parseAPIKey(plaintext string) (string, bool) {
+	rest, ok := strings.CutPrefix(plaintext, domain.APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+
+	prefixLen := hex.EncodedLen(apiKeyPrefixBytes)
+	if len(rest) <= prefixLen+1 || rest[prefixLen] != '_' {
+		return "", false
+	}
+	return rest[:prefixLen], true
+}
+
+// hashAPIKey returns the hex SHA-256 of a key. Keys are random, so a fast hash is enough.
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,195 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+type fakeRepo struct {
+	Repository
+
+	users   map[string]*domain.User
+	keys    map[string]*domain.APIKey
+	policy  domain.MFAPolicy
+	touched int
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		users: map[string]*domain.User{
+			"admin": {ID: "admin", Role: domain.RoleAdmin, IsActive: true},
+		},
+		keys: map[string]*domain.APIKey{},
+	}
+}
+
+func (f *fakeRepo) GetUserByID(_ context.Context, id string) (*domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeRepo) CreateAPIKey(_ context.Context, key *domain.APIKey) error {
+	key.ID = "k" + key.Prefix
+	f.keys[key.Prefix] = key
+	return nil
+}
+
+func (f *fakeRepo) GetAPIKeyByPrefix(_ context.Context, prefix string) (*domain.APIKey, error) {
+	key, ok := f.keys[prefix]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (f *fakeRepo) GetMFAPolicy(_ context.Context) (*domain.MFAPolicy, error) {
+	policy := f.policy
+	return &policy, nil
+}
+
+func (f *fakeRepo) TouchAPIKey(_ context.Context, _ string) error {
+	f.touched++
+	return nil
+}
+
+func TestService_APIKeyValidation(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, nil)
+	ctx := context.Background()
+
+	key, plaintext, err := s.CreateAPIKey(ctx, CreateAPIKeyInput{
+		Name:   "ci",
+		Role:   domain.RoleOperator,
+		Scopes: []string{"events:write"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(plaintext, "sp_"+key.Prefix+"_") {
+		t.Errorf("key %q does not start with sp_<prefix>_", plaintext)
+	}
+	if key.KeyHash == plaintext || strings.Contains(key.KeyHash, plaintext) {
+		t.Error("key must be stored hashed")
+	}
+
+	validated, err := s.ValidateAPIKey(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("ValidateAPIKey() error = %v", err)
+	}
+	if validated.CreatedBy != "admin" || validated.Role != domain.RoleOperator || len(validated.Scopes) != 1 {
+		t.Errorf("ValidateAPIKey() = %q, %q, %v", validated.CreatedBy, validated.Role, validated.Scopes)
+	}
+	if repo.touched != 1 {
+		t.Errorf("last use recorded %d times, want 1", repo.touched)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		mutate func(*domain.APIKey)
+	}{
+		{name: "wrong secret", key: plaintext[:len(plaintext)-2] + "xx"},
+		{name: "malformed", key: "sp_short"},
+		{name: "unknown prefix", key: "sp_00000000_secret"},
+		{
+			name:   "revoked",
+			key:    plaintext,
+			mutate: func(k *domain.APIKey) { now := time.Now(); k.RevokedAt = &now },
+		},
+		{
+			name:   "expired",
+			key:    plaintext,
+			mutate: func(k *domain.APIKey) { past := time.Now().Add(-time.Minute); k.ExpiresAt = &past },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := *repo.keys[key.Prefix]
+			if tt.mutate != nil {
+				tt.mutate(repo.keys[key.Prefix])
+				defer func() { *repo.keys[key.Prefix] = stored }()
+			}
+
+			if _, err := s.ValidateAPIKey(ctx, tt.key); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("ValidateAPIKey() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestService_APIKeyRoleCappedByCreator(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, nil)
+	ctx := context.Background()
+
+	_, plaintext, err := s.CreateAPIKey(ctx, CreateAPIKeyInput{Name: "ci", Role: domain.RoleAdmin}, "admin")
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	repo.users["admin"].Role = domain.RoleOperator
+
+	validated, err := s.ValidateAPIKey(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("ValidateAPIKey() error = %v", err)
+	}
+	if validated.Role != domain.RoleOperator {
+		t.Errorf("role = %q, want the demoted creator role", validated.Role)
+	}
+}
+
+func TestService_APIKeyFollowsCreatorPolicy(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, nil)
+	ctx := context.Background()
+
+	_, plaintext, err := s.CreateAPIKey(ctx, CreateAPIKeyInput{Name: "ci", Role: domain.RoleAdmin}, "admin")
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	// Until the creator sets up required two-factor authentication, the key
+	// has the access of a regular user.
+	operator := domain.RoleOperator
+	repo.policy.RequiredRole = &operator
+	validated, err := s.ValidateAPIKey(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("ValidateAPIKey() error = %v", err)
+	}
+	if validated.Role != domain.RoleUser {
+		t.Errorf("role = %q, want user until the creator enrols", validated.Role)
+	}
+
+	repo.users["admin"].MFAEnabled = true
+	if validated, err = s.ValidateAPIKey(ctx, plaintext); err != nil || validated.Role != domain.RoleAdmin {
+		t.Errorf("ValidateAPIKey() after enrolment = %v, %v, want admin", validated, err)
+	}
+
+	repo.users["admin"].PasswordResetRequired = true
+	if _, err := s.ValidateAPIKey(ctx, plaintext); !errors.Is(err, ErrPasswordChangeRequired) {
+		t.Errorf("ValidateAPIKey() error = %v, want ErrPasswordChangeRequired", err)
+	}
+}
+
+func TestService_CreateAPIKeyValidation(t *testing.T) {
+	s := NewService(newFakeRepo(), nil)
+	ctx := context.Background()
+
+	if _, _, err := s.CreateAPIKey(ctx, CreateAPIKeyInput{Name: "x", Role: domain.RoleUser, Scopes: []string{"events:delete"}}, "admin"); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("CreateAPIKey(bad scope) error = %v, want ErrInvalidScope", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if _, _, err := s.CreateAPIKey(ctx, CreateAPIKeyInput{Name: "x", Role: domain.RoleUser, ExpiresAt: &past}, "admin"); !errors.Is(err, ErrInvalidExpiry) {
+		t.Errorf("CreateAPIKey(past expiry) error = %v, want ErrInvalidExpiry", err)
+	}
+}
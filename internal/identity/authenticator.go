@@ -22,3 +22,27 @@ type Authenticator interface {
 	RevokeRefreshToken(ctx context.Context, refreshToken string) error
 	Type() string
 }
+
+// ExternalIdentity is a user authenticated by an external identity provider.
+type ExternalIdentity struct {
+	// Provider identifies the identity provider, e.g. the OIDC issuer.
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	// Role is given to new users. With SyncRole it also replaces the role of
+	// existing users, so the provider stays the source of truth.
+	Role     domain.Role
+	SyncRole bool
+}
+
+// SSOAuthenticator is an Authenticator that signs users in through an external
+// identity provider using the authorization code flow with PKCE.
+type SSOAuthenticator interface {
+	Authenticator
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error)
+	PasswordLoginEnabled() bool
+}
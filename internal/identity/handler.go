@@ -5,6 +5,9 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/pkg/httputil"
@@ -33,12 +36,56 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Post("/login", h.Login)
 		r.Post("/refresh", h.Refresh)
 		r.Post("/logout", h.Logout)
+		r.Post("/forgot-password", h.ForgotPassword)
+		r.Post("/reset-password", h.ResetPassword)
+		r.Post("/mfa/verify", h.VerifyMFA)
+		r.Get("/methods", h.Methods)
+		r.Get("/oidc/login", h.OIDCLogin)
+		r.Get("/oidc/callback", h.OIDCCallback)
 	})
 }
 
 // RegisterProtectedRoutes registers routes that require authentication.
 func (h *Handler) RegisterProtectedRoutes(r chi.Router) {
 	r.Get("/me", h.Me)
+	r.Get("/me/sessions", h.ListSessions)
+	r.Delete("/me/sessions/{id}", h.RevokeSession)
+	r.Post("/me/mfa/totp", h.StartTOTPEnrollment)
+	r.Post("/me/mfa/totp/confirm", h.ConfirmTOTP)
+	r.Delete("/me/mfa/totp", h.DisableTOTP)
+	r.Post("/me/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+}
+
+// RegisterPasswordChangeRoutes registers the password change route. It must be
+// authenticated with Service.PasswordChangeValidator, so that users asked to
+// change the password can reach it.
+func (h *Handler) RegisterPasswordChangeRoutes(r chi.Router) {
+	r.Post("/me/password", h.ChangePassword)
+}
+
+// RegisterAdminRoutes registers API key management routes (require admin).
+func (h *Handler) RegisterAdminRoutes(r chi.Router) {
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Get("/", h.ListAPIKeys)
+		r.Post("/", h.CreateAPIKey)
+		r.Delete("/{id}", h.RevokeAPIKey)
+	})
+}
+
+// RegisterUserAdminRoutes registers user management routes (require admin).
+func (h *Handler) RegisterUserAdminRoutes(r chi.Router) {
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/", h.ListUsers)
+		r.Get("/{id}", h.GetUser)
+		r.Delete("/{id}", h.DeleteUser)
+		r.Patch("/{id}/role", h.ChangeRole)
+		r.Post("/{id}/deactivate", h.DeactivateUser)
+		r.Post("/{id}/reactivate", h.ReactivateUser)
+		r.Post("/{id}/force-password-reset", h.ForcePasswordReset)
+		r.Post("/{id}/reset-mfa", h.ResetMFA)
+	})
+	r.Get("/mfa-policy", h.GetMFAPolicy)
+	r.Put("/mfa-policy", h.SetMFAPolicy)
 }
 
 // RegisterRequest represents registration request body.
@@ -77,10 +124,24 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-// LoginResponse represents login response.
+// LoginResponse represents login response. When MFARequired is set it has
+// only MFAToken, to pass to POST /auth/mfa/verify with a code.
 type LoginResponse struct {
-	User   *domain.User `json:"user"`
-	Tokens *TokenPair   `json:"tokens"`
+	User                  *domain.User `json:"user,omitempty"`
+	Tokens                *TokenPair   `json:"tokens,omitempty"`
+	MFARequired           bool         `json:"mfa_required,omitempty"`
+	MFAToken              string       `json:"mfa_token,omitempty"`
+	MFAEnrollmentRequired bool         `json:"mfa_enrollment_required,omitempty"`
+}
+
+func newLoginResponse(result *LoginResult) LoginResponse {
+	return LoginResponse{
+		User:                  result.User,
+		Tokens:                result.Tokens,
+		MFARequired:           result.MFAToken != "",
+		MFAToken:              result.MFAToken,
+		MFAEnrollmentRequired: result.MFAEnrollmentRequired,
+	}
 }
 
 // Login handles POST /auth/login.
@@ -96,16 +157,42 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, tokens, err := h.service.Login(r.Context(), LoginInput(req))
+	result, err := h.service.Login(r.Context(), LoginInput(req))
 	if err != nil {
 		h.handleServiceError(w, err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, LoginResponse{
-		User:   user,
-		Tokens: tokens,
-	})
+	h.respondJSON(w, http.StatusOK, newLoginResponse(result))
+}
+
+// VerifyMFARequest represents request body for completing a login with a second factor.
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	// Code is a TOTP code or a recovery code.
+	Code string `json:"code" validate:"required"`
+}
+
+// VerifyMFA handles POST /auth/mfa/verify.
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req VerifyMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.VerifyMFA(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newLoginResponse(result))
 }
 
 // RefreshRequest represents refresh token request.
@@ -150,6 +237,53 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// MethodsResponse lists the enabled login methods.
+type MethodsResponse struct {
+	Password bool `json:"password"`
+	OIDC     bool `json:"oidc"`
+}
+
+// Methods handles GET /auth/methods.
+func (h *Handler) Methods(w http.ResponseWriter, _ *http.Request) {
+	h.respondJSON(w, http.StatusOK, MethodsResponse{
+		Password: h.service.PasswordLoginEnabled(),
+		OIDC:     h.service.SSOEnabled(),
+	})
+}
+
+// OIDCLogin handles GET /auth/oidc/login by redirecting to the identity provider.
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.service.StartSSOLogin(r.Context())
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback handles GET /auth/oidc/callback.
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if providerErr := q.Get("error"); providerErr != "" {
+		slog.Warn("identity provider rejected login", "error", providerErr, "description", q.Get("error_description"))
+		h.respondError(w, http.StatusUnauthorized, ErrSSOFailed.Error())
+		return
+	}
+	if q.Get("code") == "" || q.Get("state") == "" {
+		h.respondError(w, http.StatusBadRequest, "code and state are required")
+		return
+	}
+
+	result, err := h.service.CompleteSSOLogin(r.Context(), q.Get("code"), q.Get("state"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newLoginResponse(result))
+}
+
 // Me handles GET /me.
 func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	userID := httputil.GetUserID(r.Context())
@@ -167,6 +301,473 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, user)
 }
 
+// ListSessions handles GET /me/sessions.
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessions, err := h.service.ListSessions(r.Context(), userID)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession handles DELETE /me/sessions/{id}.
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePasswordRequest represents request body for changing own password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+// ChangePassword handles POST /me/password.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	tokens, err := h.service.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, tokens)
+}
+
+// MFACodeRequest represents request body with a TOTP or recovery code.
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// RecoveryCodesResponse contains recovery codes; they are returned only once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// StartTOTPEnrollment handles POST /me/mfa/totp.
+func (h *Handler) StartTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	enrollment, err := h.service.StartTOTPEnrollment(r.Context(), userID)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP handles POST /me/mfa/totp/confirm.
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	req, ok := h.decodeMFACode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(r.Context(), userID, req.Code)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP handles DELETE /me/mfa/totp.
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	req, ok := h.decodeMFACode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DisableTOTP(r.Context(), userID, req.Code); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles POST /me/mfa/recovery-codes.
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	req, ok := h.decodeMFACode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *Handler) decodeMFACode(w http.ResponseWriter, r *http.Request) (MFACodeRequest, bool) {
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return req, false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return req, false
+	}
+	return req, true
+}
+
+// ForgotPasswordRequest represents request body for requesting a password reset.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPassword handles POST /auth/forgot-password. The response is the same
+// whether or not the email belongs to a user.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		if errors.Is(err, ErrPasswordLoginDisabled) {
+			h.handleServiceError(w, err)
+			return
+		}
+		slog.Error("password reset request failed", "error", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPasswordRequest represents request body for setting a password with a reset token.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+// ResetPassword handles POST /auth/reset-password.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateAPIKeyRequest represents API key creation request.
+type CreateAPIKeyRequest struct {
+	Name      string      `json:"name" validate:"required,min=1,max=255"`
+	Role      domain.Role `json:"role" validate:"required,oneof=user operator admin"`
+	Scopes    []string    `json:"scopes"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse contains the created key; Key is returned only once.
+type CreateAPIKeyResponse struct {
+	*domain.APIKey
+	Key string `json:"key"`
+}
+
+// ListAPIKeys handles GET /api-keys.
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey handles POST /api-keys.
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	key, plaintext, err := h.service.CreateAPIKey(r.Context(), CreateAPIKeyInput(req), httputil.GetUserID(r.Context()))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, CreateAPIKeyResponse{APIKey: key, Key: plaintext})
+}
+
+// RevokeAPIKey handles DELETE /api-keys/{id}.
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RevokeAPIKey(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeRoleRequest represents request body for changing a user role.
+type ChangeRoleRequest struct {
+	Role domain.Role `json:"role" validate:"required,oneof=user operator admin"`
+}
+
+// ListUsers handles GET /users.
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := UserFilter{Query: strings.TrimSpace(q.Get("q")), Limit: 50}
+
+	if role := q.Get("role"); role != "" {
+		rl := domain.Role(role)
+		if !rl.IsValid() {
+			h.respondError(w, http.StatusBadRequest, "invalid role")
+			return
+		}
+		filter.Role = &rl
+	}
+
+	if active := q.Get("is_active"); active != "" {
+		v, err := strconv.ParseBool(active)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "is_active must be true or false")
+			return
+		}
+		filter.IsActive = &v
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 500 {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		filter.Limit = n
+	}
+
+	if offset := q.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			h.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		filter.Offset = n
+	}
+
+	users, err := h.service.ListUsers(r.Context(), filter)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, users)
+}
+
+// GetUser handles GET /users/{id}.
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.GetUserByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// ChangeRole handles PATCH /users/{id}/role.
+func (h *Handler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	user, err := h.service.ChangeRole(r.Context(), httputil.GetUserID(r.Context()), chi.URLParam(r, "id"), req.Role)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// DeactivateUser handles POST /users/{id}/deactivate.
+func (h *Handler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.DeactivateUser(r.Context(), httputil.GetUserID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// ReactivateUser handles POST /users/{id}/reactivate.
+func (h *Handler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.ReactivateUser(r.Context(), httputil.GetUserID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// ForcePasswordReset handles POST /users/{id}/force-password-reset.
+func (h *Handler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.RequirePasswordReset(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// ResetMFA handles POST /users/{id}/reset-mfa.
+func (h *Handler) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.ResetMFA(r.Context(), httputil.GetUserID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// MFAPolicyRequest represents request body for changing the two-factor policy.
+type MFAPolicyRequest struct {
+	RequiredRole *domain.Role `json:"required_role" validate:"omitempty,oneof=operator admin"`
+}
+
+// GetMFAPolicy handles GET /mfa-policy.
+func (h *Handler) GetMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.service.GetMFAPolicy(r.Context())
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, policy)
+}
+
+// SetMFAPolicy handles PUT /mfa-policy.
+func (h *Handler) SetMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	var req MFAPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	policy, err := h.service.SetMFAPolicy(r.Context(), req.RequiredRole)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, policy)
+}
+
+// DeleteUser handles DELETE /users/{id}.
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteUser(r.Context(), httputil.GetUserID(r.Context()), chi.URLParam(r, "id")); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -218,6 +819,35 @@ func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
 		h.respondError(w, http.StatusUnauthorized, err.Error())
 	case errors.Is(err, ErrInvalidToken):
 		h.respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, ErrAPIKeyNotFound), errors.Is(err, ErrSessionNotFound):
+		h.respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidScope), errors.Is(err, ErrInvalidExpiry):
+		h.respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrSSONotConfigured):
+		h.respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidSSOState):
+		h.respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, ErrSSOFailed):
+		slog.Warn("sso login failed", "error", err)
+		h.respondError(w, http.StatusUnauthorized, ErrSSOFailed.Error())
+	case errors.Is(err, ErrPasswordLoginDisabled):
+		h.respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrWrongPassword), errors.Is(err, ErrInvalidResetToken):
+		h.respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrInvalidMFAToken):
+		h.respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, ErrInvalidMFACode), errors.Is(err, ErrInvalidMFAPolicy):
+		h.respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrMFALocked):
+		h.respondError(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, ErrMFAAlreadyEnabled), errors.Is(err, ErrMFANotEnabled), errors.Is(err, ErrMFANotStarted):
+		h.respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrUserDeactivated):
+		h.respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrPasswordChangeRequired):
+		h.respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrLastAdmin), errors.Is(err, ErrOwnAccount), errors.Is(err, ErrSystemUser), errors.Is(err, ErrNoPassword):
+		h.respondError(w, http.StatusConflict, err.Error())
 	default:
 		slog.Error("internal error", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "internal error")
@@ -4,16 +4,23 @@ package jwt
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/identity"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// maxUserAgentLength limits the user agent stored with a session.
+const maxUserAgentLength = 512
+
 // JWT errors.
 var (
 	ErrInvalidToken = errors.New("invalid token")
@@ -22,11 +29,25 @@ var (
 
 // Config holds JWT configuration.
 type Config struct {
-	SecretKey            string
+	// SecretKey signs and verifies access tokens with HS256 when there is no SigningKey.
+	SecretKey string
+	// SigningKey signs access tokens; it must have a private part.
+	SigningKey *Key
+	// VerificationKeys are accepted in addition to the signing key, e.g. the
+	// previous signing key until the tokens it signed expire.
+	VerificationKeys []*Key
+	// Issuer and Audience are set in the iss and aud claims of access tokens
+	// and required when tokens are validated, so that services sharing the
+	// keys can tell which tokens are meant for them.
+	Issuer               string
+	Audience             string
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
 }
 
+// DefaultIssuer is the iss and aud claim of access tokens when none is configured.
+const DefaultIssuer = "incident-garden"
+
 // Claims represents JWT claims.
 type Claims struct {
 	jwt.RegisteredClaims
@@ -34,18 +55,21 @@ type Claims struct {
 	Role   domain.Role `json:"role"`
 }
 
-// TokenStore interface for storing refresh tokens.
+// TokenStore interface for storing sessions and refresh tokens.
 type TokenStore interface {
-	SaveRefreshToken(ctx context.Context, token *domain.RefreshToken) error
-	GetRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error)
-	DeleteRefreshToken(ctx context.Context, token string) error
-	DeleteUserRefreshTokens(ctx context.Context, userID string) error
+	GetUserByID(ctx context.Context, id string) (*domain.User, error)
+	SaveRefreshToken(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error
+	UseRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	DeleteSession(ctx context.Context, userID, id string) error
+	DeleteSessionByRefreshToken(ctx context.Context, tokenHash string) error
 }
 
 // Authenticator implements JWT-based authentication.
 type Authenticator struct {
 	config     Config
 	tokenStore TokenStore
+	// keys verify access tokens by key ID; empty when tokens are signed with SecretKey.
+	keys map[string]*Key
 }
 
 // NewAuthenticator creates a new JWT authenticator.
@@ -56,9 +80,23 @@ func NewAuthenticator(config Config, tokenStore TokenStore) *Authenticator {
 	if config.RefreshTokenDuration == 0 {
 		config.RefreshTokenDuration = 7 * 24 * time.Hour
 	}
+	if config.Issuer == "" {
+		config.Issuer = DefaultIssuer
+	}
+	if config.Audience == "" {
+		config.Audience = DefaultIssuer
+	}
+
+	keys := make(map[string]*Key)
+	if config.SigningKey != nil {
+		for _, key := range append([]*Key{config.SigningKey}, config.VerificationKeys...) {
+			keys[key.ID] = key
+		}
+	}
 	return &Authenticator{
 		config:     config,
 		tokenStore: tokenStore,
+		keys:       keys,
 	}
 }
 
@@ -67,11 +105,19 @@ func (a *Authenticator) Type() string {
 	return "jwt"
 }
 
-// GenerateTokens creates a new token pair.
+// GenerateTokens starts a new session and creates a token pair for it.
 func (a *Authenticator) GenerateTokens(ctx context.Context, user *domain.User) (*identity.TokenPair, error) {
+	return a.issueTokens(ctx, user, &domain.Session{UserID: user.ID})
+}
+
+// issueTokens creates a token pair and stores the refresh token in the session.
+// The session records the client the tokens were issued to.
+func (a *Authenticator) issueTokens(ctx context.Context, user *domain.User, session *domain.Session) (*identity.TokenPair, error) {
 	now := time.Now()
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.config.Issuer,
+			Audience:  jwt.ClaimStrings{a.config.Audience},
 			Subject:   user.ID,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(a.config.AccessTokenDuration)),
@@ -80,8 +126,7 @@ func (a *Authenticator) GenerateTokens(ctx context.Context, user *domain.User) (
 		Role:   user.Role,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessToken, err := token.SignedString([]byte(a.config.SecretKey))
+	accessToken, err := a.sign(claims)
 	if err != nil {
 		return nil, fmt.Errorf("sign access token: %w", err)
 	}
@@ -92,13 +137,20 @@ func (a *Authenticator) GenerateTokens(ctx context.Context, user *domain.User) (
 	}
 	refreshToken := base64.URLEncoding.EncodeToString(refreshTokenBytes)
 
+	session.IP = httputil.GetClientIP(ctx)
+	session.UserAgent = httputil.GetUserAgent(ctx)
+	if len(session.UserAgent) > maxUserAgentLength {
+		session.UserAgent = session.UserAgent[:maxUserAgentLength]
+	}
+	session.ExpiresAt = now.Add(a.config.RefreshTokenDuration)
+
 	rt := &domain.RefreshToken{
 		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: now.Add(a.config.RefreshTokenDuration),
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: session.ExpiresAt,
 		CreatedAt: now,
 	}
-	if err := a.tokenStore.SaveRefreshToken(ctx, rt); err != nil {
+	if err := a.tokenStore.SaveRefreshToken(ctx, session, rt); err != nil {
 		return nil, fmt.Errorf("save refresh token: %w", err)
 	}
 
@@ -111,12 +163,11 @@ func (a *Authenticator) GenerateTokens(ctx context.Context, user *domain.User) (
 
 // ValidateAccessToken validates the access token.
 func (a *Authenticator) ValidateAccessToken(_ context.Context, tokenString string) (string, domain.Role, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(a.config.SecretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, a.verificationKey,
+		jwt.WithValidMethods(a.validMethods()),
+		jwt.WithIssuer(a.config.Issuer),
+		jwt.WithAudience(a.config.Audience),
+	)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -133,12 +184,104 @@ func (a *Authenticator) ValidateAccessToken(_ context.Context, tokenString strin
 	return claims.UserID, claims.Role, nil
 }
 
-// RefreshTokens generates new tokens using refresh token.
-func (a *Authenticator) RefreshTokens(_ context.Context, _ string) (*identity.TokenPair, error) {
-	return nil, errors.New("not implemented - use Service.RefreshTokens")
+// JWKS returns the public keys that verify access tokens, so other services can
+// validate them. The set is empty when tokens are signed with a shared secret.
+func (a *Authenticator) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(a.keys))}
+	if a.config.SigningKey == nil {
+		return set
+	}
+
+	seen := make(map[string]bool, len(a.keys))
+	for _, key := range append([]*Key{a.config.SigningKey}, a.config.VerificationKeys...) {
+		if !seen[key.ID] {
+			seen[key.ID] = true
+			set.Keys = append(set.Keys, key.JWK())
+		}
+	}
+	return set
 }
 
-// RevokeRefreshToken invalidates the refresh token.
+// sign signs access token claims with the signing key, or with the secret
+// key when there is none.
+func (a *Authenticator) sign(claims Claims) (string, error) {
+	key := a.config.SigningKey
+	if key == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(a.config.SecretKey))
+	}
+
+	var method jwt.SigningMethod = jwt.SigningMethodRS256
+	if key.Algorithm == AlgorithmEdDSA {
+		method = jwt.SigningMethodEdDSA
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// validMethods returns the signing algorithms accepted for access tokens.
+func (a *Authenticator) validMethods() []string {
+	if len(a.keys) == 0 {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+	return []string{AlgorithmRS256, AlgorithmEdDSA}
+}
+
+// verificationKey selects the key that verifies a token by its kid header.
+func (a *Authenticator) verificationKey(token *jwt.Token) (interface{}, error) {
+	if len(a.keys) == 0 {
+		return []byte(a.config.SecretKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("signing method %s does not match key %q", token.Method.Alg(), kid)
+	}
+	return key.public, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair in the same
+// session. A refresh token works once: presenting a used one means it leaked,
+// so the whole session is ended.
+func (a *Authenticator) RefreshTokens(ctx context.Context, refreshToken string) (*identity.TokenPair, error) {
+	rt, err := a.tokenStore.UseRefreshToken(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, identity.ErrRefreshTokenReused) {
+			slog.Warn("refresh token reused, ending session", "user_id", rt.UserID, "session_id", rt.SessionID)
+			if err := a.tokenStore.DeleteSession(ctx, rt.UserID, rt.SessionID); err != nil && !errors.Is(err, identity.ErrSessionNotFound) {
+				return nil, err
+			}
+			return nil, identity.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	user, err := a.tokenStore.GetUserByID(ctx, rt.UserID)
+	if err != nil {
+		if errors.Is(err, identity.ErrUserNotFound) {
+			return nil, identity.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, identity.ErrUserDeactivated
+	}
+
+	return a.issueTokens(ctx, user, &domain.Session{ID: rt.SessionID, UserID: user.ID})
+}
+
+// RevokeRefreshToken ends the session of the refresh token.
 func (a *Authenticator) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
-	return a.tokenStore.DeleteRefreshToken(ctx, refreshToken)
+	return a.tokenStore.DeleteSessionByRefreshToken(ctx, hashRefreshToken(refreshToken))
+}
+
+// hashRefreshToken returns the hex SHA-256 of a refresh token. Tokens are
+// random, so a fast hash is enough.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
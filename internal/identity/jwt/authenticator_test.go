@@ -0,0 +1,160 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/identity"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
+)
+
+type fakeStore struct {
+	users    map[string]*domain.User
+	sessions map[string]*domain.Session
+	tokens   map[string]*domain.RefreshToken
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users: map[string]*domain.User{
+			"u1": {ID: "u1", Role: domain.RoleOperator, IsActive: true},
+		},
+		sessions: map[string]*domain.Session{},
+		tokens:   map[string]*domain.RefreshToken{},
+	}
+}
+
+func (f *fakeStore) GetUserByID(_ context.Context, id string) (*domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, identity.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeStore) SaveRefreshToken(_ context.Context, session *domain.Session, token *domain.RefreshToken) error {
+	if session.ID == "" {
+		session.ID = "s" + token.TokenHash[:8]
+	} else if _, ok := f.sessions[session.ID]; !ok {
+		return identity.ErrInvalidToken
+	}
+	f.sessions[session.ID] = session
+	token.SessionID = session.ID
+	f.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (f *fakeStore) UseRefreshToken(_ context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	rt, ok := f.tokens[tokenHash]
+	if !ok {
+		return nil, identity.ErrInvalidToken
+	}
+	if rt.UsedAt != nil {
+		return rt, identity.ErrRefreshTokenReused
+	}
+	now := time.Now()
+	rt.UsedAt = &now
+	return rt, nil
+}
+
+func (f *fakeStore) DeleteSession(_ context.Context, _, id string) error {
+	if _, ok := f.sessions[id]; !ok {
+		return identity.ErrSessionNotFound
+	}
+	delete(f.sessions, id)
+	for hash, rt := range f.tokens {
+		if rt.SessionID == id {
+			delete(f.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) DeleteSessionByRefreshToken(ctx context.Context, tokenHash string) error {
+	if rt, ok := f.tokens[tokenHash]; ok {
+		return f.DeleteSession(ctx, rt.UserID, rt.SessionID)
+	}
+	return nil
+}
+
+func TestAuthenticator_RefreshTokens(t *testing.T) {
+	store := newFakeStore()
+	a := NewAuthenticator(Config{SecretKey: "secret"}, store)
+	ctx := context.WithValue(context.Background(), httputil.ClientIPKey, "192.0.2.1")
+	ctx = context.WithValue(ctx, httputil.UserAgentKey, "curl/8.0")
+
+	first, err := a.GenerateTokens(ctx, store.users["u1"])
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+	if _, ok := store.tokens[first.RefreshToken]; ok {
+		t.Error("refresh token must be stored hashed")
+	}
+	if len(store.sessions) != 1 {
+		t.Fatalf("sessions = %d, want 1", len(store.sessions))
+	}
+	for _, session := range store.sessions {
+		if session.IP != "192.0.2.1" || session.UserAgent != "curl/8.0" {
+			t.Errorf("session = %+v, want the client recorded", session)
+		}
+	}
+
+	second, err := a.RefreshTokens(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokens() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh token must be rotated")
+	}
+	if len(store.sessions) != 1 {
+		t.Errorf("sessions = %d, want the rotation to stay in the session", len(store.sessions))
+	}
+
+	// The first token leaked: presenting it again ends the session, so the
+	// token issued to the legitimate client stops working as well.
+	if _, err := a.RefreshTokens(ctx, first.RefreshToken); !errors.Is(err, identity.ErrInvalidToken) {
+		t.Fatalf("reused RefreshTokens() error = %v, want ErrInvalidToken", err)
+	}
+	if len(store.sessions) != 0 {
+		t.Errorf("sessions = %d, want the session ended", len(store.sessions))
+	}
+	if _, err := a.RefreshTokens(ctx, second.RefreshToken); !errors.Is(err, identity.ErrInvalidToken) {
+		t.Errorf("RefreshTokens() after reuse error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAuthenticator_RefreshTokensRejectsDeactivatedUser(t *testing.T) {
+	store := newFakeStore()
+	a := NewAuthenticator(Config{SecretKey: "secret"}, store)
+	ctx := context.Background()
+
+	tokens, err := a.GenerateTokens(ctx, store.users["u1"])
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	store.users["u1"].IsActive = false
+	if _, err := a.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, identity.ErrUserDeactivated) {
+		t.Errorf("RefreshTokens() error = %v, want ErrUserDeactivated", err)
+	}
+}
+
+func TestAuthenticator_RevokeRefreshToken(t *testing.T) {
+	store := newFakeStore()
+	a := NewAuthenticator(Config{SecretKey: "secret"}, store)
+	ctx := context.Background()
+
+	tokens, err := a.GenerateTokens(ctx, store.users["u1"])
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+	if err := a.RevokeRefreshToken(ctx, tokens.RefreshToken); err != nil {
+		t.Fatalf("RevokeRefreshToken() error = %v", err)
+	}
+	if _, err := a.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, identity.ErrInvalidToken) {
+		t.Errorf("RefreshTokens() after logout error = %v, want ErrInvalidToken", err)
+	}
+}
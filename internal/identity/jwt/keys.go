@@ -0,0 +1,174 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for access tokens.
+const minRSAKeyBits = 2048
+
+// Signing algorithms of asymmetric keys.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Key is an asymmetric key for access tokens: RSA keys sign with RS256,
+// Ed25519 keys with EdDSA. A key loaded from a public key can only verify.
+type Key struct {
+	// ID is the RFC 7638 thumbprint of the public key, sent as the kid header.
+	ID        string
+	Algorithm string
+
+	public  crypto.PublicKey
+	private crypto.PrivateKey
+}
+
+// CanSign reports whether the key has a private part.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// LoadKey reads a PEM encoded key from a file.
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+	key, err := ParseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseKey parses a PEM encoded RSA or Ed25519 key: a PKCS#8 or PKCS#1
+// private key, or a PKIX or PKCS#1 public key.
+func ParseKey(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newKey(parsed)
+}
+
+func newKey(parsed any) (*Key, error) {
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key, err := newKey(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		key.private = k
+		return key, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key must have at least %d bits", minRSAKeyBits)
+		}
+		key := &Key{Algorithm: AlgorithmRS256, public: k}
+		key.ID = thumbprint(key.JWK())
+		return key, nil
+	case ed25519.PrivateKey:
+		key, err := newKey(k.Public())
+		if err != nil {
+			return nil, err
+		}
+		key.private = k
+		return key, nil
+	case ed25519.PublicKey:
+		key := &Key{Algorithm: AlgorithmEdDSA, public: k}
+		key.ID = thumbprint(key.JWK())
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 keys.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public part of the key.
+func (k *Key) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// thumbprint computes the RFC 7638 thumbprint: the SHA-256 of the required
+// members of the key in lexicographic order.
+func thumbprint(jwk JWK) string {
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	// Encoding strings cannot fail.
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
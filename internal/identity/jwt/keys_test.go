@@ -0,0 +1,201 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+func newEd25519Key(t *testing.T) *Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := newKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestParseKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	private, err := ParseKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	if err != nil {
+		t.Fatalf("ParseKey(private) error = %v", err)
+	}
+	public, err := ParseKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+	if err != nil {
+		t.Fatalf("ParseKey(public) error = %v", err)
+	}
+
+	if private.Algorithm != AlgorithmRS256 || !private.CanSign() {
+		t.Errorf("private key = %s, can sign %v", private.Algorithm, private.CanSign())
+	}
+	if public.CanSign() {
+		t.Error("a public key must not sign")
+	}
+	if private.ID != public.ID {
+		t.Errorf("key IDs differ: %q and %q", private.ID, public.ID)
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(weak)})); err == nil {
+		t.Error("a 1024-bit RSA key must be rejected")
+	}
+	if _, err := ParseKey([]byte("not a key")); err == nil {
+		t.Error("ParseKey() must fail without a PEM block")
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// Example from RFC 7638, section 3.1.
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := newKey(&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; key.ID != want {
+		t.Errorf("key ID = %q, want %q", key.ID, want)
+	}
+}
+
+func TestAuthenticator_KeyRotation(t *testing.T) {
+	store := newFakeStore()
+	user := store.users["u1"]
+	ctx := context.Background()
+
+	previous, next := newEd25519Key(t), newEd25519Key(t)
+	before := NewAuthenticator(Config{SigningKey: previous}, store)
+	tokens, err := before.GenerateTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	// During the overlap window tokens signed with the old key stay valid.
+	during := NewAuthenticator(Config{SigningKey: next, VerificationKeys: []*Key{previous}}, store)
+	if userID, _, err := during.ValidateAccessToken(ctx, tokens.AccessToken); err != nil || userID != "u1" {
+		t.Fatalf("ValidateAccessToken() = %q, %v during rotation", userID, err)
+	}
+	jwks := during.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != next.ID || jwks.Keys[1].Kid != previous.ID {
+		t.Errorf("JWKS = %+v, want the new key first and the old one", jwks)
+	}
+
+	after := NewAuthenticator(Config{SigningKey: next}, store)
+	if _, _, err := after.ValidateAccessToken(ctx, tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateAccessToken() after rotation error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAuthenticator_RejectsOtherAlgorithms(t *testing.T) {
+	store := newFakeStore()
+	ctx := context.Background()
+
+	hmac := NewAuthenticator(Config{SecretKey: "secret"}, store)
+	tokens, err := hmac.GenerateTokens(ctx, store.users["u1"])
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	a := NewAuthenticator(Config{SecretKey: "secret", SigningKey: newEd25519Key(t)}, store)
+	if _, _, err := a.ValidateAccessToken(ctx, tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("HS256 token with a signing key: error = %v, want ErrInvalidToken", err)
+	}
+	if keys := hmac.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("JWKS with a shared secret = %+v, want no keys", keys)
+	}
+}
+
+func TestAuthenticator_RequiresIssuerAndAudience(t *testing.T) {
+	store := newFakeStore()
+	ctx := context.Background()
+	key := newEd25519Key(t)
+
+	a := NewAuthenticator(Config{SigningKey: key, Issuer: "https://status.example.com", Audience: "status"}, store)
+	tokens, err := a.GenerateTokens(ctx, store.users["u1"])
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+	if _, _, err := a.ValidateAccessToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"other issuer", Config{SigningKey: key, Issuer: "https://other.example.com", Audience: "status"}},
+		{"other audience", Config{SigningKey: key, Issuer: "https://status.example.com", Audience: "billing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := NewAuthenticator(tt.config, store)
+			if _, _, err := other.ValidateAccessToken(ctx, tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("ValidateAccessToken() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_SignsWithRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := newKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newFakeStore()
+	store.users["u1"].Role = domain.RoleAdmin
+	a := NewAuthenticator(Config{SigningKey: key}, store)
+	ctx := context.Background()
+
+	tokens, err := a.GenerateTokens(ctx, store.users["u1"])
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+	userID, role, err := a.ValidateAccessToken(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if userID != "u1" || role != domain.RoleAdmin {
+		t.Errorf("ValidateAccessToken() = %q, %q", userID, role)
+	}
+
+	jwk := a.JWKS().Keys[0]
+	if jwk.Kty != "RSA" || jwk.Alg != AlgorithmRS256 || jwk.Kid != key.ID || jwk.E != "AQAB" {
+		t.Errorf("JWK = %+v", jwk)
+	}
+}
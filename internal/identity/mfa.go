@@ -0,0 +1,391 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+const (
+	// defaultTOTPIssuer names the service in authenticator apps.
+	defaultTOTPIssuer = "IncidentGarden"
+	// mfaChallengeTTL is how long a user has to enter the second factor after the password.
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaMaxAttempts limits the codes tried against one challenge.
+	mfaMaxAttempts = 5
+	// mfaMaxFailures limits the failed codes of a user across all challenges
+	// within mfaFailureWindow. After that logins are refused until the window
+	// passes since the last failure.
+	mfaMaxFailures = 10
+	// mfaFailureWindow is how long failed codes count against a user.
+	mfaFailureWindow = 15 * time.Minute
+	// recoveryCodeCount is how many recovery codes a user gets.
+	recoveryCodeCount = 10
+)
+
+// MFAConfig configures two-factor authentication.
+type MFAConfig struct {
+	// Issuer is the account issuer shown in authenticator apps.
+	Issuer string
+}
+
+// SetMFA configures two-factor authentication.
+func (s *Service) SetMFA(config MFAConfig) {
+	if config.Issuer == "" {
+		config.Issuer = defaultTOTPIssuer
+	}
+	s.mfa = config
+}
+
+// LoginResult is the outcome of a password or single sign-on login. It has either tokens or,
+// when the user has two-factor authentication, a challenge token to pass to
+// VerifyMFA with a code.
+type LoginResult struct {
+	User     *domain.User
+	Tokens   *TokenPair
+	MFAToken string
+	// MFAEnrollmentRequired means the policy requires two-factor authentication
+	// the user has not set up. Until they do, they have the access of RoleUser.
+	MFAEnrollmentRequired bool
+}
+
+// TOTPEnrollment is a TOTP secret waiting for confirmation.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// URI to show as a QR code.
+	URI string `json:"uri"`
+}
+
+// VerifyMFA completes a login with a TOTP or recovery code. Failed codes are
+// also counted per user across challenges, see mfaMaxFailures.
+func (s *Service) VerifyMFA(ctx context.Context, mfaToken, code string) (*LoginResult, error) {
+	tokenHash := hashMFAToken(mfaToken)
+	userID, err := s.repo.UseMFAChallenge(ctx, tokenHash, mfaMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserDeactivated
+	}
+
+	allowed, err := s.repo.UseMFAAttempt(ctx, user.ID, mfaMaxFailures, mfaFailureWindow)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrMFALocked
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ResetMFAFailures(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.authenticator.GenerateTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+// StartTOTPEnrollment creates a TOTP secret for a user. Two-factor
+// authentication is turned on by ConfirmTOTP with a code from the app.
+func (s *Service) StartTOTPEnrollment(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totpURI(s.mfa.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP turns on two-factor authentication with the first code from the
+// authenticator app. It returns the recovery codes; they are not shown again.
+func (s *Service) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotStarted
+	}
+
+	step, ok := validateTOTP(user.TOTPSecret, normalizeMFACode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableTOTP(ctx, user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	before := *user
+	user.MFAEnabled = true
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return codes, nil
+}
+
+// DisableTOTP turns off two-factor authentication of a user who proves they
+// still have the second factor.
+func (s *Service) DisableTOTP(ctx context.Context, userID, code string) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.disableTOTP(ctx, user)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user. The old codes
+// stop working.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetMFA turns off two-factor authentication of a user who lost their
+// device and recovery codes. actorID is the admin making the change.
+func (s *Service) ResetMFA(ctx context.Context, actorID, id string) (*domain.User, error) {
+	user, err := s.managedUser(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled && user.TOTPSecret == "" {
+		return user, nil
+	}
+
+	if err := s.disableTOTP(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetMFAPolicy returns the two-factor authentication policy.
+func (s *Service) GetMFAPolicy(ctx context.Context) (*domain.MFAPolicy, error) {
+	return s.repo.GetMFAPolicy(ctx)
+}
+
+// SetMFAPolicy sets the lowest role that must use two-factor authentication;
+// nil makes it optional.
+func (s *Service) SetMFAPolicy(ctx context.Context, requiredRole *domain.Role) (*domain.MFAPolicy, error) {
+	if requiredRole != nil && !requiredRole.HasPermission(domain.RoleOperator) {
+		return nil, ErrInvalidMFAPolicy
+	}
+
+	before, err := s.repo.GetMFAPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &domain.MFAPolicy{RequiredRole: requiredRole}
+	if err := s.repo.SaveMFAPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityMFAPolicy,
+		Before:     before,
+		After:      policy,
+	})
+	return policy, nil
+}
+
+// mfaEnrollmentRequired reports whether the policy requires two-factor
+// authentication the user has not set up. Users provisioned by single sign-on
+// are exempt: their second factor is up to the identity provider.
+func (s *Service) mfaEnrollmentRequired(ctx context.Context, user *domain.User) (bool, error) {
+	// The policy never applies to regular users, so they skip the lookup.
+	if user.MFAEnabled || user.Role == domain.RoleUser || user.PasswordHash == ssoPasswordHash {
+		return false, nil
+	}
+
+	policy, err := s.repo.GetMFAPolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	return policy.Requires(user.Role), nil
+}
+
+// startMFAChallenge stores a login waiting for the second factor and returns its token.
+// Users with too many recent failed codes get ErrMFALocked instead.
+func (s *Service) startMFAChallenge(ctx context.Context, user *domain.User) (string, error) {
+	failures, err := s.repo.CountMFAFailures(ctx, user.ID, mfaFailureWindow)
+	if err != nil {
+		return "", err
+	}
+	if failures >= mfaMaxFailures {
+		return "", ErrMFALocked
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.SaveMFAChallenge(ctx, user.ID, hashMFAToken(token), mfaChallengeTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// checkSecondFactor accepts a TOTP code or an unused recovery code of a user.
+// Both work once.
+func (s *Service) checkSecondFactor(ctx context.Context, user *domain.User, code string) error {
+	if !user.MFAEnabled {
+		return ErrInvalidMFACode
+	}
+	code = normalizeMFACode(code)
+
+	if step, ok := validateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		fresh, err := s.repo.UseTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	if len(code) != recoveryCodeLength {
+		return ErrInvalidMFACode
+	}
+	used, err := s.repo.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// disableTOTP turns off two-factor authentication of a user.
+func (s *Service) disableTOTP(ctx context.Context, user *domain.User) error {
+	if err := s.repo.DisableTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+
+	before := *user
+	user.MFAEnabled = false
+	user.TOTPSecret = ""
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return nil
+}
+
+// recoveryCodeLength is the length of a recovery code without the separator.
+const recoveryCodeLength = 10
+
+// recoveryCodeAlphabet has 32 characters, so every random byte maps to one
+// of them without bias.
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// newRecoveryCodes returns recovery codes formatted for the user and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(code); err != nil {
+			return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		for j, b := range code {
+			code[j] = recoveryCodeAlphabet[b%byte(len(recoveryCodeAlphabet))]
+		}
+		codes[i] = string(code[:recoveryCodeLength/2]) + "-" + string(code[recoveryCodeLength/2:])
+		hashes[i] = hashRecoveryCode(string(code))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeMFACode removes the separators users may type or paste with a code.
+func normalizeMFACode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// hashRecoveryCode returns the hex SHA-256 of a normalized recovery code.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashMFAToken returns the hex SHA-256 of a challenge token.
+func hashMFAToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,402 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+type mfaRepo struct {
+	*passwordRepo
+
+	lastStep   map[string]int64
+	recovery   map[string]map[string]bool
+	challenges map[string]string
+	attempts   map[string]int
+	failures   map[string]int
+	policy     domain.MFAPolicy
+}
+
+func newMFARepo(t *testing.T) *mfaRepo {
+	t.Helper()
+	return &mfaRepo{
+		passwordRepo: newPasswordRepo(t),
+		lastStep:     map[string]int64{},
+		recovery:     map[string]map[string]bool{},
+		challenges:   map[string]string{},
+		attempts:     map[string]int{},
+		failures:     map[string]int{},
+	}
+}
+
+func (r *mfaRepo) SetTOTPSecret(_ context.Context, userID, secret string) error {
+	r.users[userID].TOTPSecret = secret
+	r.users[userID].MFAEnabled = false
+	return nil
+}
+
+func (r *mfaRepo) EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	r.users[userID].MFAEnabled = true
+	r.lastStep[userID] = step
+	return r.ReplaceRecoveryCodes(ctx, userID, recoveryCodeHashes)
+}
+
+func (r *mfaRepo) DisableTOTP(_ context.Context, userID string) error {
+	r.users[userID].MFAEnabled = false
+	r.users[userID].TOTPSecret = ""
+	delete(r.lastStep, userID)
+	delete(r.recovery, userID)
+	delete(r.failures, userID)
+	return nil
+}
+
+func (r *mfaRepo) ReplaceRecoveryCodes(_ context.Context, userID string, codeHashes []string) error {
+	r.recovery[userID] = map[string]bool{}
+	for _, hash := range codeHashes {
+		r.recovery[userID][hash] = true
+	}
+	return nil
+}
+
+func (r *mfaRepo) UseTOTPStep(_ context.Context, userID string, step int64) (bool, error) {
+	if last, ok := r.lastStep[userID]; ok && last >= step {
+		return false, nil
+	}
+	r.lastStep[userID] = step
+	return true, nil
+}
+
+func (r *mfaRepo) UseRecoveryCode(_ context.Context, userID, codeHash string) (bool, error) {
+	if !r.recovery[userID][codeHash] {
+		return false, nil
+	}
+	delete(r.recovery[userID], codeHash)
+	return true, nil
+}
+
+func (r *mfaRepo) SaveMFAChallenge(_ context.Context, userID, tokenHash string, _ time.Duration) error {
+	r.challenges[tokenHash] = userID
+	return nil
+}
+
+func (r *mfaRepo) UseMFAChallenge(_ context.Context, tokenHash string, maxAttempts int) (string, error) {
+	userID, ok := r.challenges[tokenHash]
+	if !ok || r.attempts[tokenHash] >= maxAttempts {
+		return "", ErrInvalidMFAToken
+	}
+	r.attempts[tokenHash]++
+	return userID, nil
+}
+
+func (r *mfaRepo) DeleteMFAChallenge(_ context.Context, tokenHash string) error {
+	delete(r.challenges, tokenHash)
+	return nil
+}
+
+func (r *mfaRepo) CountMFAFailures(_ context.Context, userID string, _ time.Duration) (int, error) {
+	return r.failures[userID], nil
+}
+
+func (r *mfaRepo) UseMFAAttempt(_ context.Context, userID string, maxFailures int, _ time.Duration) (bool, error) {
+	if r.failures[userID] >= maxFailures {
+		return false, nil
+	}
+	r.failures[userID]++
+	return true, nil
+}
+
+func (r *mfaRepo) ResetMFAFailures(_ context.Context, userID string) error {
+	delete(r.failures, userID)
+	return nil
+}
+
+func (r *mfaRepo) GetMFAPolicy(_ context.Context) (*domain.MFAPolicy, error) {
+	policy := r.policy
+	return &policy, nil
+}
+
+func (r *mfaRepo) SaveMFAPolicy(_ context.Context, policy *domain.MFAPolicy) error {
+	r.policy = *policy
+	return nil
+}
+
+// codeAt returns the code an authenticator app shows for a secret at a time step.
+func codeAt(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, step)
+}
+
+// enrollTOTP turns on two-factor authentication for a user and returns the
+// secret and the recovery codes.
+func enrollTOTP(t *testing.T, s *Service, userID string) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := s.StartTOTPEnrollment(ctx, userID)
+	if err != nil {
+		t.Fatalf("StartTOTPEnrollment() error = %v", err)
+	}
+	codes, err := s.ConfirmTOTP(ctx, userID, codeAt(t, enrollment.Secret, totpStep(time.Now())))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() error = %v", err)
+	}
+	return enrollment.Secret, codes
+}
+
+func TestService_TOTPEnrollment(t *testing.T) {
+	repo := newMFARepo(t)
+	s := NewService(repo, &fakeAuthenticator{})
+	ctx := context.Background()
+
+	enrollment, err := s.StartTOTPEnrollment(ctx, "operator")
+	if err != nil {
+		t.Fatalf("StartTOTPEnrollment() error = %v", err)
+	}
+	if !strings.HasPrefix(enrollment.URI, "otpauth://totp/IncidentGarden:ops@example.com?") {
+		t.Errorf("URI = %s", enrollment.URI)
+	}
+	if repo.users["operator"].MFAEnabled {
+		t.Fatal("two-factor authentication must wait for confirmation")
+	}
+
+	if _, err := s.ConfirmTOTP(ctx, "operator", "not-a-code"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("ConfirmTOTP() with a wrong code error = %v, want ErrInvalidMFACode", err)
+	}
+
+	codes, err := s.ConfirmTOTP(ctx, "operator", codeAt(t, enrollment.Secret, totpStep(time.Now())))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(repo.recovery["operator"]) != recoveryCodeCount {
+		t.Errorf("recovery codes = %d, stored %d", len(codes), len(repo.recovery["operator"]))
+	}
+	if repo.recovery["operator"][codes[0]] {
+		t.Error("recovery codes must be stored hashed")
+	}
+	if !repo.users["operator"].MFAEnabled {
+		t.Error("two-factor authentication must be enabled")
+	}
+
+	if _, err := s.StartTOTPEnrollment(ctx, "operator"); !errors.Is(err, ErrMFAAlreadyEnabled) {
+		t.Errorf("StartTOTPEnrollment() when enabled error = %v, want ErrMFAAlreadyEnabled", err)
+	}
+}
+
+func TestService_LoginWithTOTP(t *testing.T) {
+	repo := newMFARepo(t)
+	s := NewService(repo, &fakeAuthenticator{})
+	ctx := context.Background()
+	secret, recoveryCodes := enrollTOTP(t, s, "operator")
+
+	login := func() string {
+		t.Helper()
+		result, err := s.Login(ctx, LoginInput{Email: "ops@example.com", Password: "password123"})
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		if result.Tokens != nil || result.User != nil || result.MFAToken == "" {
+			t.Fatalf("Login() = %+v, want only a challenge", result)
+		}
+		return result.MFAToken
+	}
+
+	mfaToken := login()
+	if _, ok := repo.challenges[mfaToken]; ok {
+		t.Error("challenge token must be stored hashed")
+	}
+
+	// The code used to confirm the enrolment cannot be used again.
+	used := repo.lastStep["operator"]
+	if _, err := s.VerifyMFA(ctx, mfaToken, codeAt(t, secret, used)); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA() with a used code error = %v, want ErrInvalidMFACode", err)
+	}
+	result, err := s.VerifyMFA(ctx, mfaToken, codeAt(t, secret, used+1))
+	if err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+	if result.Tokens == nil || result.User.ID != "operator" {
+		t.Errorf("VerifyMFA() = %+v", result)
+	}
+	if _, err := s.VerifyMFA(ctx, mfaToken, codeAt(t, secret, used+1)); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("VerifyMFA() with a completed challenge error = %v, want ErrInvalidMFAToken", err)
+	}
+
+	// A recovery code works once, in any case and with a space for the dash.
+	mfaToken = login()
+	typed := strings.ToUpper(recoveryCodes[0][:5] + " " + recoveryCodes[0][6:])
+	if _, err := s.VerifyMFA(ctx, mfaToken, typed); err != nil {
+		t.Fatalf("VerifyMFA() with a recovery code error = %v", err)
+	}
+	mfaToken = login()
+	if _, err := s.VerifyMFA(ctx, mfaToken, recoveryCodes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA() with a used recovery code error = %v, want ErrInvalidMFACode", err)
+	}
+
+	// Guessing is limited per challenge.
+	for i := 1; i < mfaMaxAttempts; i++ {
+		if _, err := s.VerifyMFA(ctx, mfaToken, "wrong-code"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("VerifyMFA() attempt %d error = %v, want ErrInvalidMFACode", i, err)
+		}
+	}
+	if _, err := s.VerifyMFA(ctx, mfaToken, recoveryCodes[1]); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("VerifyMFA() after %d attempts error = %v, want ErrInvalidMFAToken", mfaMaxAttempts, err)
+	}
+}
+
+func TestService_LoginLockedAfterMFAFailures(t *testing.T) {
+	repo := newMFARepo(t)
+	s := NewService(repo, &fakeAuthenticator{})
+	ctx := context.Background()
+	secret, _ := enrollTOTP(t, s, "operator")
+	input := LoginInput{Email: "ops@example.com", Password: "password123"}
+
+	// A correct code resets the failures counted so far.
+	result, err := s.Login(ctx, input)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := s.VerifyMFA(ctx, result.MFAToken, "wrong-code"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("VerifyMFA() error = %v, want ErrInvalidMFACode", err)
+	}
+	if _, err := s.VerifyMFA(ctx, result.MFAToken, codeAt(t, secret, repo.lastStep["operator"]+1)); err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+	if repo.failures["operator"] != 0 {
+		t.Fatalf("failures after a successful login = %d, want 0", repo.failures["operator"])
+	}
+
+	pending, err := s.Login(ctx, input)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	// Guessing with a new challenge for every few codes runs into the per-user limit.
+	failures := 0
+	var locked error
+	for failures <= mfaMaxFailures && locked == nil {
+		result, err := s.Login(ctx, input)
+		if err != nil {
+			locked = err
+			break
+		}
+		for i := 0; i < 2; i++ {
+			_, err := s.VerifyMFA(ctx, result.MFAToken, "wrong-code")
+			if errors.Is(err, ErrInvalidMFACode) {
+				failures++
+				continue
+			}
+			locked = err
+			break
+		}
+	}
+	if !errors.Is(locked, ErrMFALocked) {
+		t.Fatalf("after %d failed codes error = %v, want ErrMFALocked", failures, locked)
+	}
+	if failures != mfaMaxFailures {
+		t.Errorf("failed codes before lockout = %d, want %d", failures, mfaMaxFailures)
+	}
+
+	// Even the right code and a fresh login are refused while locked.
+	if _, err := s.VerifyMFA(ctx, pending.MFAToken, codeAt(t, secret, repo.lastStep["operator"]+1)); !errors.Is(err, ErrMFALocked) {
+		t.Errorf("VerifyMFA() while locked error = %v, want ErrMFALocked", err)
+	}
+	if _, err := s.Login(ctx, input); !errors.Is(err, ErrMFALocked) {
+		t.Errorf("Login() while locked error = %v, want ErrMFALocked", err)
+	}
+}
+
+func TestService_DisableTOTP(t *testing.T) {
+	repo := newMFARepo(t)
+	s := NewService(repo, &fakeAuthenticator{})
+	ctx := context.Background()
+	secret, recoveryCodes := enrollTOTP(t, s, "operator")
+
+	if err := s.DisableTOTP(ctx, "operator", "wrong-code"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("DisableTOTP() with a wrong code error = %v, want ErrInvalidMFACode", err)
+	}
+
+	newCodes, err := s.RegenerateRecoveryCodes(ctx, "operator", codeAt(t, secret, repo.lastStep["operator"]+1))
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes() error = %v", err)
+	}
+	if err := s.DisableTOTP(ctx, "operator", recoveryCodes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("DisableTOTP() with a replaced recovery code error = %v, want ErrInvalidMFACode", err)
+	}
+	if err := s.DisableTOTP(ctx, "operator", newCodes[0]); err != nil {
+		t.Fatalf("DisableTOTP() error = %v", err)
+	}
+
+	result, err := s.Login(ctx, LoginInput{Email: "ops@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if result.Tokens == nil {
+		t.Error("Login() without two-factor authentication must return tokens")
+	}
+}
+
+func TestService_ResetMFA(t *testing.T) {
+	repo := newMFARepo(t)
+	s := NewService(repo, &fakeAuthenticator{})
+	ctx := context.Background()
+	enrollTOTP(t, s, "operator")
+
+	if _, err := s.ResetMFA(ctx, "operator", "operator"); !errors.Is(err, ErrOwnAccount) {
+		t.Errorf("ResetMFA() of own account error = %v, want ErrOwnAccount", err)
+	}
+	user, err := s.ResetMFA(ctx, "admin", "operator")
+	if err != nil {
+		t.Fatalf("ResetMFA() error = %v", err)
+	}
+	if user.MFAEnabled || len(repo.recovery["operator"]) != 0 {
+		t.Errorf("ResetMFA() left two-factor authentication on: %+v", user)
+	}
+}
+
+func TestService_MFAPolicy(t *testing.T) {
+	repo := newMFARepo(t)
+	s := NewService(repo, &fakeAuthenticator{userID: "operator"})
+	ctx := context.Background()
+
+	user := domain.RoleUser
+	if _, err := s.SetMFAPolicy(ctx, &user); !errors.Is(err, ErrInvalidMFAPolicy) {
+		t.Errorf("SetMFAPolicy(user) error = %v, want ErrInvalidMFAPolicy", err)
+	}
+	operator := domain.RoleOperator
+	if _, err := s.SetMFAPolicy(ctx, &operator); err != nil {
+		t.Fatalf("SetMFAPolicy() error = %v", err)
+	}
+
+	result, err := s.Login(ctx, LoginInput{Email: "ops@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if !result.MFAEnrollmentRequired || result.Tokens == nil {
+		t.Errorf("Login() = %+v, want tokens and the enrolment required", result)
+	}
+
+	// Until they enrol, operators only have the access of a regular user.
+	if _, role, err := s.ValidateToken(ctx, "access"); err != nil || role != domain.RoleUser {
+		t.Errorf("ValidateToken() = %q, %v, want RoleUser", role, err)
+	}
+
+	// Single sign-on users are left to the identity provider.
+	repo.users["operator"].PasswordHash = ssoPasswordHash
+	if _, role, err := s.ValidateToken(ctx, "access"); err != nil || role != domain.RoleOperator {
+		t.Errorf("ValidateToken() for an SSO user = %q, %v, want RoleOperator", role, err)
+	}
+
+	repo.users["operator"].PasswordHash = "hash"
+	enrollTOTP(t, s, "operator")
+	if _, role, err := s.ValidateToken(ctx, "access"); err != nil || role != domain.RoleOperator {
+		t.Errorf("ValidateToken() after enrolment = %q, %v, want RoleOperator", role, err)
+	}
+}
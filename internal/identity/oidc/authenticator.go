@@ -0,0 +1,246 @@
+// Package oidc provides OpenID Connect single sign-on.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/identity"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config holds OpenID Connect provider settings.
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// RoleClaim is the ID token claim mapped to a role; when empty roles are not synced.
+	RoleClaim   string
+	RoleMap     map[string]domain.Role
+	DefaultRole domain.Role
+	// DisablePasswordLogin turns off local registration and password login.
+	DisablePasswordLogin bool
+	Timeout              time.Duration
+}
+
+// Authenticator signs users in through an OpenID Connect provider. Sessions
+// are issued by the wrapped authenticator.
+type Authenticator struct {
+	identity.Authenticator
+
+	config Config
+	client *http.Client
+	keys   *providerKeys
+}
+
+// NewAuthenticator creates an OIDC authenticator on top of the session authenticator.
+// Provider metadata is discovered on first use.
+func NewAuthenticator(config Config, session identity.Authenticator) *Authenticator {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if config.DefaultRole == "" {
+		config.DefaultRole = domain.RoleUser
+	}
+
+	client := &http.Client{Timeout: config.Timeout}
+	return &Authenticator{
+		Authenticator: session,
+		config:        config,
+		client:        client,
+		keys:          newProviderKeys(config.IssuerURL, client),
+	}
+}
+
+// Type returns the authenticator type.
+func (a *Authenticator) Type() string {
+	return "oidc"
+}
+
+// PasswordLoginEnabled reports whether local password login stays available.
+func (a *Authenticator) PasswordLoginEnabled() bool {
+	return !a.config.DisablePasswordLogin
+}
+
+// AuthCodeURL returns the provider authorization URL for the code flow with PKCE (S256).
+func (a *Authenticator) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := a.keys.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {a.config.ClientID},
+		"redirect_uri":          {a.config.RedirectURL},
+		"scope":                 {strings.Join(a.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems the authorization code and returns the identity from the verified ID token.
+func (a *Authenticator) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*identity.ExternalIdentity, error) {
+	meta, err := a.keys.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.config.RedirectURL},
+		"client_id":     {a.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	if a.config.ClientSecret != "" {
+		form.Set("client_secret", a.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.IDToken == "" {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+
+	claims, err := a.verifyIDToken(ctx, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.identityFromClaims(claims)
+}
+
+func (a *Authenticator) verifyIDToken(ctx context.Context, raw, nonce string) (jwt.MapClaims, error) {
+	meta, err := a.keys.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return a.keys.key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(a.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("verify id token: %w", err)
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("verify id token: nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+func (a *Authenticator) identityFromClaims(claims jwt.MapClaims) (*identity.ExternalIdentity, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	issuer, _ := claims["iss"].(string)
+	email, _ := claims["email"].(string)
+	firstName, _ := claims["given_name"].(string)
+	lastName, _ := claims["family_name"].(string)
+
+	ext := &identity.ExternalIdentity{
+		Provider:      issuer,
+		Subject:       subject,
+		Email:         strings.ToLower(email),
+		EmailVerified: claimBool(claims["email_verified"]),
+		FirstName:     firstName,
+		LastName:      lastName,
+		Role:          a.config.DefaultRole,
+	}
+
+	if a.config.RoleClaim != "" {
+		ext.SyncRole = true
+		ext.Role = a.mapRole(claims[a.config.RoleClaim])
+	}
+
+	return ext, nil
+}
+
+// mapRole returns the highest role mapped from a string or list claim, or the default role.
+func (a *Authenticator) mapRole(claim interface{}) domain.Role {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	role := a.config.DefaultRole
+	for _, value := range values {
+		if mapped, ok := a.config.RoleMap[value]; ok && !role.HasPermission(mapped) {
+			role = mapped
+		}
+	}
+	return role
+}
+
+// claimBool reads a boolean claim; some providers send "true" as a string.
+func claimBool(claim interface{}) bool {
+	switch v := claim.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/testutil"
+)
+
+const (
+	testClientID = "statuspage"
+	testVerifier = "dBjftJeZ4CKP-1QHi2RYzY3ZpsdqjNn-bBuugasq-lc"
+)
+
+var testChallenge = func() string {
+	sum := sha256.Sum256([]byte(testVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}()
+
+func newTestProvider(t *testing.T) *testutil.MockOIDCProvider {
+	t.Helper()
+	provider, err := testutil.NewMockOIDCProvider(testClientID)
+	if err != nil {
+		t.Fatalf("start provider: %v", err)
+	}
+	t.Cleanup(provider.Close)
+	return provider
+}
+
+// authorize follows the authorization URL and returns the code from the redirect.
+func authorize(t *testing.T, authURL string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || location.Query().Get("code") == "" {
+		t.Fatalf("authorize: unexpected redirect %q (status %d)", resp.Header.Get("Location"), resp.StatusCode)
+	}
+	if location.Query().Get("state") != "state-1" {
+		t.Fatalf("authorize: state = %q", location.Query().Get("state"))
+	}
+	return location.Query().Get("code")
+}
+
+func TestAuthenticator_Exchange(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.SetClaims(map[string]interface{}{
+		"sub":            "user-42",
+		"email":          "Jane@Example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+		"groups":         []string{"everyone", "sre", "statuspage-admins"},
+	})
+
+	auth := NewAuthenticator(Config{
+		IssuerURL:   provider.Issuer(),
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/callback",
+		RoleClaim:   "groups",
+		RoleMap: map[string]domain.Role{
+			"sre":               domain.RoleOperator,
+			"statuspage-admins": domain.RoleAdmin,
+		},
+	}, nil)
+	ctx := context.Background()
+
+	authURL, err := auth.AuthCodeURL(ctx, "state-1", "nonce-1", testChallenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	ext, err := auth.Exchange(ctx, authorize(t, authURL), testVerifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	want := identityFields{
+		Provider: provider.Issuer(), Subject: "user-42", Email: "jane@example.com",
+		FirstName: "Jane", LastName: "Doe", Role: domain.RoleAdmin,
+	}
+	got := identityFields{
+		Provider: ext.Provider, Subject: ext.Subject, Email: ext.Email,
+		FirstName: ext.FirstName, LastName: ext.LastName, Role: ext.Role,
+	}
+	if got != want {
+		t.Errorf("identity = %+v, want %+v", got, want)
+	}
+	if !ext.EmailVerified || !ext.SyncRole {
+		t.Errorf("EmailVerified = %v, SyncRole = %v, want both true", ext.EmailVerified, ext.SyncRole)
+	}
+}
+
+// identityFields holds the comparable part of identity.ExternalIdentity.
+type identityFields struct {
+	Provider, Subject, Email, FirstName, LastName string
+	Role                                          domain.Role
+}
+
+func TestAuthenticator_ExchangeRejects(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.SetClaims(map[string]interface{}{"sub": "user-42"})
+
+	auth := NewAuthenticator(Config{
+		IssuerURL:   provider.Issuer(),
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/callback",
+	}, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		verifier string
+		nonce    string
+	}{
+		{name: "wrong code verifier", verifier: "wrong-verifier", nonce: "nonce-1"},
+		{name: "nonce mismatch", verifier: testVerifier, nonce: "other-nonce"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, err := auth.AuthCodeURL(ctx, "state-1", "nonce-1", testChallenge)
+			if err != nil {
+				t.Fatalf("AuthCodeURL: %v", err)
+			}
+			if _, err := auth.Exchange(ctx, authorize(t, authURL), tt.verifier, tt.nonce); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestAuthenticator_MapRole(t *testing.T) {
+	auth := NewAuthenticator(Config{
+		RoleClaim:   "role",
+		DefaultRole: domain.RoleUser,
+		RoleMap:     map[string]domain.Role{"ops": domain.RoleOperator, "root": domain.RoleAdmin},
+	}, nil)
+
+	tests := []struct {
+		name  string
+		claim interface{}
+		want  domain.Role
+	}{
+		{name: "missing", claim: nil, want: domain.RoleUser},
+		{name: "unmapped string", claim: "guest", want: domain.RoleUser},
+		{name: "mapped string", claim: "ops", want: domain.RoleOperator},
+		{name: "highest of list", claim: []interface{}{"root", "ops"}, want: domain.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auth.mapRole(tt.claim); got != tt.want {
+				t.Errorf("mapRole(%v) = %s, want %s", tt.claim, got, tt.want)
+			}
+		})
+	}
+}
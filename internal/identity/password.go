@@ -0,0 +1,191 @@
+package identity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// defaultPasswordResetTTL is how long a password reset link stays valid.
+	defaultPasswordResetTTL = time.Hour
+	// passwordResetCooldown is the minimum interval between two reset emails to a user.
+	passwordResetCooldown = time.Minute
+)
+
+// Mailer sends emails to users.
+type Mailer interface {
+	SendMail(ctx context.Context, to, subject, body string) error
+}
+
+// PasswordResetConfig configures password reset emails.
+type PasswordResetConfig struct {
+	// URL is the page that completes the reset; the token is added as the token query parameter.
+	URL string
+	TTL time.Duration
+}
+
+// SetPasswordReset enables password reset emails sent through mailer.
+func (s *Service) SetPasswordReset(mailer Mailer, config PasswordResetConfig) {
+	if config.TTL == 0 {
+		config.TTL = defaultPasswordResetTTL
+	}
+	s.mailer = mailer
+	s.passwordReset = config
+}
+
+// ChangePassword changes the password of a user who knows the current one. All
+// sessions are signed out and a new token pair is issued for the caller.
+func (s *Service) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*TokenPair, error) {
+	if !s.PasswordLoginEnabled() {
+		return nil, ErrPasswordLoginDisabled
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return nil, err
+	}
+
+	return s.authenticator.GenerateTokens(ctx, user)
+}
+
+// RequestPasswordReset emails a password reset link. Unknown emails are ignored
+// silently, so the caller cannot tell whether an account exists.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	if !s.PasswordLoginEnabled() {
+		return ErrPasswordLoginDisabled
+	}
+	if s.mailer == nil {
+		return nil
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Single sign-on users have no password; a local one would outlive their
+	// account at the identity provider.
+	if !user.IsActive || user.ID == domain.SystemUserID || user.PasswordHash == ssoPasswordHash {
+		return nil
+	}
+
+	pending, err := s.repo.GetPasswordReset(ctx, user.ID)
+	if err != nil && !errors.Is(err, ErrInvalidResetToken) {
+		return err
+	}
+	if pending != nil && time.Since(pending.CreatedAt) < passwordResetCooldown {
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SavePasswordReset(ctx, user.ID, hashResetToken(token), s.passwordReset.TTL); err != nil {
+		return err
+	}
+
+	subject := "Reset your password"
+	body := fmt.Sprintf(
+		"Follow the link to set a new password:\n\n%s\n\nThe link expires in %d minutes and works once. "+
+			"If you did not ask to reset your password, ignore this message.",
+		resetLink(s.passwordReset.URL, token), int(s.passwordReset.TTL.Minutes()),
+	)
+	if err := s.mailer.SendMail(ctx, user.Email, subject, body); err != nil {
+		slog.Error("failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a token from a reset email and signs
+// the user out everywhere.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if !s.PasswordLoginEnabled() {
+		return ErrPasswordLoginDisabled
+	}
+
+	reset, err := s.repo.TakePasswordReset(ctx, hashResetToken(token))
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if !user.IsActive {
+		return ErrInvalidResetToken
+	}
+
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// setPassword stores a new password and ends all sessions of the user.
+func (s *Service) setPassword(ctx context.Context, user *domain.User, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	before := *user
+	if err := s.repo.UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+		return err
+	}
+	user.PasswordHash = string(hashedPassword)
+	user.PasswordResetRequired = false
+
+	if err := s.repo.DeleteUserSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return nil
+}
+
+// resetLink adds the token to the reset page URL.
+func resetLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// hashResetToken returns the hex SHA-256 of a reset token. Tokens are random,
+// so a fast hash is enough.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,224 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type passwordRepo struct {
+	*usersRepo
+
+	resets map[string]*PasswordReset
+	hashes map[string]string
+}
+
+func newPasswordRepo(t *testing.T) *passwordRepo {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &passwordRepo{
+		usersRepo: newUsersRepo(),
+		resets:    map[string]*PasswordReset{},
+		hashes:    map[string]string{},
+	}
+	repo.users["operator"].PasswordHash = string(hash)
+	return repo
+}
+
+func (r *passwordRepo) UpdatePassword(_ context.Context, userID, passwordHash string) error {
+	user := r.users[userID]
+	user.PasswordHash = passwordHash
+	user.PasswordResetRequired = false
+	delete(r.resets, userID)
+	return nil
+}
+
+func (r *passwordRepo) SavePasswordReset(_ context.Context, userID, tokenHash string, _ time.Duration) error {
+	r.resets[userID] = &PasswordReset{UserID: userID, CreatedAt: time.Now()}
+	r.hashes[tokenHash] = userID
+	return nil
+}
+
+func (r *passwordRepo) GetPasswordReset(_ context.Context, userID string) (*PasswordReset, error) {
+	reset, ok := r.resets[userID]
+	if !ok {
+		return nil, ErrInvalidResetToken
+	}
+	return reset, nil
+}
+
+func (r *passwordRepo) TakePasswordReset(_ context.Context, tokenHash string) (*PasswordReset, error) {
+	userID, ok := r.hashes[tokenHash]
+	delete(r.hashes, tokenHash)
+	reset, pending := r.resets[userID]
+	if !ok || !pending {
+		return nil, ErrInvalidResetToken
+	}
+	delete(r.resets, userID)
+	return reset, nil
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+type fakeMailer struct {
+	sent []sentMail
+}
+
+func (m *fakeMailer) SendMail(_ context.Context, to, subject, body string) error {
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// resetToken extracts the token from the link in a reset email.
+func resetToken(t *testing.T, body string) string {
+	t.Helper()
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "https://") {
+			u, err := url.Parse(line)
+			if err != nil {
+				t.Fatalf("parse reset link: %v", err)
+			}
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("no reset link in %q", body)
+	return ""
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	repo := newPasswordRepo(t)
+	repo.users["operator"].PasswordResetRequired = true
+	s := NewService(repo, &fakeAuthenticator{})
+	ctx := context.Background()
+
+	if _, err := s.ChangePassword(ctx, "operator", "wrong", "new-password"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("ChangePassword() with a wrong password error = %v, want ErrWrongPassword", err)
+	}
+	if len(repo.revoked) != 0 {
+		t.Error("a rejected change must not sign the user out")
+	}
+
+	tokens, err := s.ChangePassword(ctx, "operator", "password123", "new-password")
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	if tokens == nil {
+		t.Error("a new token pair must be issued for the caller")
+	}
+
+	user := repo.users["operator"]
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-password")) != nil {
+		t.Error("password was not changed")
+	}
+	if user.PasswordResetRequired {
+		t.Error("changing the password must clear the reset requirement")
+	}
+	if len(repo.revoked) != 1 || repo.revoked[0] != "operator" {
+		t.Errorf("revoked refresh tokens of %v, want [operator]", repo.revoked)
+	}
+}
+
+func TestService_PasswordReset(t *testing.T) {
+	repo := newPasswordRepo(t)
+	mailer := &fakeMailer{}
+	s := NewService(repo, nil)
+	s.SetPasswordReset(mailer, PasswordResetConfig{URL: "https://status.example.com/reset-password"})
+	ctx := context.Background()
+
+	if err := s.RequestPasswordReset(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() for an unknown email error = %v", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatal("no email must be sent for an unknown address")
+	}
+
+	if err := s.RequestPasswordReset(ctx, "ops@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if err := s.RequestPasswordReset(ctx, "ops@example.com"); err != nil {
+		t.Fatalf("repeated RequestPasswordReset() error = %v", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].to != "ops@example.com" {
+		t.Fatalf("sent = %+v, want one email to ops@example.com within the cooldown", mailer.sent)
+	}
+
+	token := resetToken(t, mailer.sent[0].body)
+	if _, ok := repo.hashes[token]; ok {
+		t.Error("the token must be stored hashed")
+	}
+
+	if err := s.ResetPassword(ctx, "forged", "new-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("ResetPassword() with a forged token error = %v, want ErrInvalidResetToken", err)
+	}
+
+	if err := s.ResetPassword(ctx, token, "new-password"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(repo.users["operator"].PasswordHash), []byte("new-password")) != nil {
+		t.Error("password was not changed")
+	}
+	if len(repo.revoked) != 1 {
+		t.Errorf("revoked = %v, want the user signed out", repo.revoked)
+	}
+
+	if err := s.ResetPassword(ctx, token, "another-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("reusing the token: error = %v, want ErrInvalidResetToken", err)
+	}
+}
+
+func TestService_RequestPasswordResetSkipsAccounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*domain.User)
+	}{
+		{name: "deactivated", modify: func(u *domain.User) { u.IsActive = false }},
+		{name: "single sign-on", modify: func(u *domain.User) { u.PasswordHash = ssoPasswordHash }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newPasswordRepo(t)
+			tt.modify(repo.users["operator"])
+			mailer := &fakeMailer{}
+			s := NewService(repo, nil)
+			s.SetPasswordReset(mailer, PasswordResetConfig{URL: "https://status.example.com/reset-password"})
+
+			if err := s.RequestPasswordReset(context.Background(), "ops@example.com"); err != nil {
+				t.Fatalf("RequestPasswordReset() error = %v", err)
+			}
+			if len(mailer.sent) != 0 || len(repo.resets) != 0 {
+				t.Errorf("sent = %+v, resets = %v, want nothing", mailer.sent, repo.resets)
+			}
+		})
+	}
+}
+
+func TestResetLink(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{"https://status.example.com/reset-password", "https://status.example.com/reset-password?token=abc"},
+		{"https://status.example.com/#/reset?lang=en", "https://status.example.com/?token=abc#/reset?lang=en"},
+		{"https://status.example.com/reset?lang=en", "https://status.example.com/reset?lang=en&token=abc"},
+	}
+
+	for _, tt := range tests {
+		if got := resetLink(tt.base, "abc"); got != tt.want {
+			t.Errorf("resetLink(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
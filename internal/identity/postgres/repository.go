@@ -5,6 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/identity"
@@ -22,12 +25,39 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+const userColumns = `id, email, password_hash, COALESCE(first_name, ''), COALESCE(last_name, ''), role,
+	is_active, deactivated_at, password_reset_required, totp_enabled_at IS NOT NULL, COALESCE(totp_secret, ''),
+	created_at, updated_at`
+
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var user domain.User
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.FirstName,
+		&user.LastName,
+		&user.Role,
+		&user.IsActive,
+		&user.DeactivatedAt,
+		&user.PasswordResetRequired,
+		&user.MFAEnabled,
+		&user.TOTPSecret,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // CreateUser creates a new user.
 func (r *Repository) CreateUser(ctx context.Context, user *domain.User) error {
 	query := `
 		INSERT INTO users (email, password_hash, first_name, last_name, role)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, updated_at
+		RETURNING id, is_active, created_at, updated_at
 	`
 	err := r.db.QueryRow(ctx, query,
 		user.Email,
@@ -35,7 +65,7 @@ func (r *Repository) CreateUser(ctx context.Context, user *domain.User) error {
 		user.FirstName,
 		user.LastName,
 		user.Role,
-	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("create user: %w", err)
@@ -45,74 +75,67 @@ func (r *Repository) CreateUser(ctx context.Context, user *domain.User) error {
 
 // GetUserByID retrieves a user by ID.
 func (r *Repository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
-	query := `
-		SELECT id, email, password_hash, first_name, last_name, role, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
-	var user domain.User
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.PasswordHash,
-		&user.FirstName,
-		&user.LastName,
-		&user.Role,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
+	user, err := scanUser(r.db.QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, identity.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("get user by id: %w", err)
 	}
-	return &user, nil
+	return user, nil
 }
 
 // GetUserByEmail retrieves a user by email.
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
-	query := `
-		SELECT id, email, password_hash, first_name, last_name, role, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`
-	var user domain.User
-	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.PasswordHash,
-		&user.FirstName,
-		&user.LastName,
-		&user.Role,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
+	user, err := scanUser(r.db.QueryRow(ctx, query, email))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, identity.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("get user by email: %w", err)
 	}
-	return &user, nil
+	return user, nil
 }
 
-// UpdateUser updates an existing user.
+// UpdateUser updates an existing user. Demoting or deactivating the last
+// active admin fails with identity.ErrLastAdmin.
 func (r *Repository) UpdateUser(ctx context.Context, user *domain.User) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	if user.Role != domain.RoleAdmin || !user.IsActive {
+		if err := ensureNotLastAdmin(ctx, tx, user.ID); err != nil {
+			return err
+		}
+	}
+
 	query := `
 		UPDATE users
-		SET email = $2, first_name = $3, last_name = $4, role = $5, updated_at = NOW()
+		SET email = $2, first_name = $3, last_name = $4, role = $5,
+			is_active = $6, deactivated_at = $7, password_reset_required = $8, updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at
 	`
-	err := r.db.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		user.ID,
 		user.Email,
 		user.FirstName,
 		user.LastName,
 		user.Role,
+		user.IsActive,
+		user.DeactivatedAt,
+		user.PasswordResetRequired,
 	).Scan(&user.UpdatedAt)
 
 	if err != nil {
@@ -121,70 +144,865 @@ func (r *Repository) UpdateUser(ctx context.Context, user *domain.User) error {
 		}
 		return fmt.Errorf("update user: %w", err)
 	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
 	return nil
 }
 
-// SaveRefreshToken saves a refresh token to the database.
-func (r *Repository) SaveRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+// ListUsers returns users matching the filter ordered by email.
+func (r *Repository) ListUsers(ctx context.Context, filter identity.UserFilter) ([]*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id <> $1`
+	args := []interface{}{domain.SystemUserID}
+	argNum := 2
+
+	if filter.Query != "" {
+		query += fmt.Sprintf(
+			` AND (email ILIKE $%[1]d ESCAPE '\' OR first_name ILIKE $%[1]d ESCAPE '\' OR last_name ILIKE $%[1]d ESCAPE '\')`,
+			argNum,
+		)
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		argNum++
+	}
+
+	if filter.Role != nil {
+		query += fmt.Sprintf(" AND role = $%d", argNum)
+		args = append(args, *filter.Role)
+		argNum++
+	}
+
+	if filter.IsActive != nil {
+		query += fmt.Sprintf(" AND is_active = $%d", argNum)
+		args = append(args, *filter.IsActive)
+		argNum++
+	}
+
+	query += " ORDER BY email"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+	return users, nil
+}
+
+// escapeLike escapes LIKE wildcards so the search matches them literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ensureNotLastAdmin locks the active admins and fails with
+// identity.ErrLastAdmin when id is the only one. The lock is held until the
+// transaction ends, so concurrent changes cannot remove the last two admins.
+func ensureNotLastAdmin(ctx context.Context, tx pgx.Tx, id string) error {
+	rows, err := tx.Query(ctx, `SELECT id FROM users WHERE role = $1 AND is_active FOR UPDATE`, domain.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("lock active admins: %w", err)
+	}
+	defer rows.Close()
+
+	var admins []string
+	for rows.Next() {
+		var adminID string
+		if err := rows.Scan(&adminID); err != nil {
+			return fmt.Errorf("scan admin: %w", err)
+		}
+		admins = append(admins, adminID)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate admins: %w", err)
+	}
+
+	if len(admins) == 1 && admins[0] == id {
+		return identity.ErrLastAdmin
+	}
+	return nil
+}
+
+// DeleteUser reassigns the user's events, updates and service changes to the
+// system user and deletes the user with their tokens, keys and subscriptions.
+// Deleting the last active admin fails with identity.ErrLastAdmin.
+func (r *Repository) DeleteUser(ctx context.Context, id string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	if err := ensureNotLastAdmin(ctx, tx, id); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"events", "event_updates", "event_service_changes"} {
+		query := `UPDATE ` + table + ` SET created_by = $2 WHERE created_by = $1`
+		if _, err := tx.Exec(ctx, query, id, domain.SystemUserID); err != nil {
+			return fmt.Errorf("reassign %s: %w", table, err)
+		}
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return identity.ErrUserNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword sets a new password hash and drops a pending password reset.
+func (r *Repository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
 	query := `
-		INSERT INTO refresh_tokens (user_id, token, expires_at, created_at)
-		VALUES ($1, $2, $3, $4)
+		UPDATE users
+		SET password_hash = $2, password_reset_required = FALSE, updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := tx.Exec(ctx, query, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return identity.ErrUserNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete password reset: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SaveRefreshToken stores a refresh token and creates or updates its session.
+func (r *Repository) SaveRefreshToken(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	if session.ID == "" {
+		query := `
+			INSERT INTO sessions (user_id, user_agent, ip, expires_at, last_used_at, created_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+			RETURNING id, last_used_at, created_at
+		`
+		err := tx.QueryRow(ctx, query, session.UserID, session.UserAgent, session.IP, session.ExpiresAt).
+			Scan(&session.ID, &session.LastUsedAt, &session.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("create session: %w", err)
+		}
+	} else {
+		query := `
+			UPDATE sessions
+			SET user_agent = $2, ip = $3, expires_at = $4, last_used_at = NOW()
+			WHERE id = $1
+			RETURNING last_used_at, created_at
+		`
+		err := tx.QueryRow(ctx, query, session.ID, session.UserAgent, session.IP, session.ExpiresAt).
+			Scan(&session.LastUsedAt, &session.CreatedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return identity.ErrInvalidToken
+			}
+			return fmt.Errorf("update session: %w", err)
+		}
+
+		// Expired tokens are rejected anyway, so they are not needed to detect reuse.
+		if _, err := tx.Exec(ctx, `DELETE FROM refresh_tokens WHERE session_id = $1 AND expires_at <= NOW()`, session.ID); err != nil {
+			return fmt.Errorf("delete expired refresh tokens: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO refresh_tokens (session_id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
 	`
-	err := r.db.QueryRow(ctx, query,
+	token.SessionID = session.ID
+	err = tx.QueryRow(ctx, query,
+		token.SessionID,
 		token.UserID,
-		token.Token,
+		token.TokenHash,
 		token.ExpiresAt,
 		token.CreatedAt,
 	).Scan(&token.ID)
-
 	if err != nil {
 		return fmt.Errorf("save refresh token: %w", err)
 	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
 	return nil
 }
 
-// GetRefreshToken retrieves a refresh token from the database.
-func (r *Repository) GetRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error) {
-	query := `
-		SELECT id, user_id, token, expires_at, created_at
-		FROM refresh_tokens
-		WHERE token = $1 AND expires_at > NOW()
-	`
+const refreshTokenColumns = `id, session_id, user_id, token_hash, used_at, expires_at, created_at`
+
+func scanRefreshToken(row pgx.Row) (*domain.RefreshToken, error) {
 	var rt domain.RefreshToken
-	err := r.db.QueryRow(ctx, query, token).Scan(
+	err := row.Scan(
 		&rt.ID,
+		&rt.SessionID,
 		&rt.UserID,
-		&rt.Token,
+		&rt.TokenHash,
+		&rt.UsedAt,
 		&rt.ExpiresAt,
 		&rt.CreatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// UseRefreshToken marks an unexpired refresh token as used. The update is
+// conditional, so of two concurrent refreshes with one token only one succeeds.
+func (r *Repository) UseRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	query := `
+		UPDATE refresh_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING ` + refreshTokenColumns
+	rt, err := scanRefreshToken(r.db.QueryRow(ctx, query, tokenHash))
+	if err == nil {
+		return rt, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("use refresh token: %w", err)
+	}
 
+	query = `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1 AND expires_at > NOW()`
+	rt, err = scanRefreshToken(r.db.QueryRow(ctx, query, tokenHash))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, identity.ErrInvalidToken
 		}
 		return nil, fmt.Errorf("get refresh token: %w", err)
 	}
-	return &rt, nil
+	return rt, identity.ErrRefreshTokenReused
+}
+
+// ListSessions returns unexpired sessions of a user, most recently used first.
+func (r *Repository) ListSessions(ctx context.Context, userID string) ([]*domain.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, expires_at, last_used_at, created_at
+		FROM sessions
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*domain.Session, 0)
+	for rows.Next() {
+		var session domain.Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IP,
+			&session.ExpiresAt,
+			&session.LastUsedAt,
+			&session.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+	return sessions, nil
 }
 
-// DeleteRefreshToken deletes a refresh token from the database.
-func (r *Repository) DeleteRefreshToken(ctx context.Context, token string) error {
-	query := `DELETE FROM refresh_tokens WHERE token = $1`
-	_, err := r.db.Exec(ctx, query, token)
+// DeleteSession deletes a session of a user together with its refresh tokens.
+func (r *Repository) DeleteSession(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM sessions WHERE id = $1 AND user_id = $2`
+	result, err := r.db.Exec(ctx, query, id, userID)
 	if err != nil {
-		return fmt.Errorf("delete refresh token: %w", err)
+		return fmt.Errorf("delete session: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return identity.ErrSessionNotFound
+	}
+	return nil
+}
+
+// DeleteSessionByRefreshToken deletes the session a refresh token belongs to.
+func (r *Repository) DeleteSessionByRefreshToken(ctx context.Context, tokenHash string) error {
+	query := `DELETE FROM sessions WHERE id = (SELECT session_id FROM refresh_tokens WHERE token_hash = $1)`
+	_, err := r.db.Exec(ctx, query, tokenHash)
+	if err != nil {
+		return fmt.Errorf("delete session: %w", err)
 	}
 	return nil
 }
 
-// DeleteUserRefreshTokens deletes all refresh tokens for a user.
-func (r *Repository) DeleteUserRefreshTokens(ctx context.Context, userID string) error {
-	query := `DELETE FROM refresh_tokens WHERE user_id = $1`
+// DeleteUserSessions deletes all sessions and refresh tokens of a user.
+func (r *Repository) DeleteUserSessions(ctx context.Context, userID string) error {
+	query := `DELETE FROM sessions WHERE user_id = $1`
 	_, err := r.db.Exec(ctx, query, userID)
 	if err != nil {
-		return fmt.Errorf("delete user refresh tokens: %w", err)
+		return fmt.Errorf("delete user sessions: %w", err)
+	}
+	return nil
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, role, scopes, created_by, expires_at, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Role,
+		&key.Scopes,
+		&key.CreatedBy,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateAPIKey stores a new API key.
+func (r *Repository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, role, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Role,
+		key.Scopes,
+		key.CreatedBy,
+		key.ExpiresAt,
+	).Scan(&key.ID, &key.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByPrefix retrieves an API key by its public prefix.
+func (r *Repository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, identity.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns all API keys, newest first.
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*domain.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks an API key as revoked. Revoking a revoked key keeps the original time.
+func (r *Repository) RevokeAPIKey(ctx context.Context, id string) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return identity.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey records the API key use. The timestamp is written at most once
+// a minute to avoid a write on every request.
+func (r *Repository) TouchAPIKey(ctx context.Context, id string) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+	return nil
+}
+
+// GetUserByIdentity retrieves the user linked to an external identity.
+func (r *Repository) GetUserByIdentity(ctx context.Context, provider, subject string) (*domain.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2)
+	`
+	user, err := scanUser(r.db.QueryRow(ctx, query, provider, subject))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, identity.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("get user by identity: %w", err)
+	}
+	return user, nil
+}
+
+// LinkIdentity links an external identity to a user.
+func (r *Repository) LinkIdentity(ctx context.Context, userID, provider, subject string) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, subject) DO NOTHING
+	`
+	if _, err := r.db.Exec(ctx, query, userID, provider, subject); err != nil {
+		return fmt.Errorf("link identity: %w", err)
+	}
+	return nil
+}
+
+// SaveSSOState stores a pending SSO login and drops expired ones.
+func (r *Repository) SaveSSOState(ctx context.Context, state *identity.SSOState, ttl time.Duration) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM sso_login_states WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("delete expired sso states: %w", err)
+	}
+
+	query := `
+		INSERT INTO sso_login_states (state, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')
+	`
+	if _, err := r.db.Exec(ctx, query, state.State, state.CodeVerifier, state.Nonce, ttl.Seconds()); err != nil {
+		return fmt.Errorf("save sso state: %w", err)
+	}
+	return nil
+}
+
+// TakeSSOState deletes a pending SSO login and returns it if it has not expired.
+func (r *Repository) TakeSSOState(ctx context.Context, state string) (*identity.SSOState, error) {
+	query := `
+		DELETE FROM sso_login_states
+		WHERE state = $1
+		RETURNING state, code_verifier, nonce, expires_at > NOW()
+	`
+	var s identity.SSOState
+	var valid bool
+	err := r.db.QueryRow(ctx, query, state).Scan(&s.State, &s.CodeVerifier, &s.Nonce, &valid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, identity.ErrInvalidSSOState
+		}
+		return nil, fmt.Errorf("take sso state: %w", err)
+	}
+
+	if !valid {
+		return nil, identity.ErrInvalidSSOState
+	}
+	return &s, nil
+}
+
+// SavePasswordReset stores a password reset, replacing a pending one of the user.
+func (r *Repository) SavePasswordReset(ctx context.Context, userID, tokenHash string, ttl time.Duration) error {
+	query := `
+		INSERT INTO password_resets (user_id, token_hash, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second')
+		ON CONFLICT (user_id) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash, expires_at = EXCLUDED.expires_at, created_at = NOW()
+	`
+	if _, err := r.db.Exec(ctx, query, userID, tokenHash, ttl.Seconds()); err != nil {
+		return fmt.Errorf("save password reset: %w", err)
+	}
+	return nil
+}
+
+// GetPasswordReset retrieves the pending password reset of a user.
+func (r *Repository) GetPasswordReset(ctx context.Context, userID string) (*identity.PasswordReset, error) {
+	query := `SELECT user_id, created_at FROM password_resets WHERE user_id = $1`
+
+	var reset identity.PasswordReset
+	err := r.db.QueryRow(ctx, query, userID).Scan(&reset.UserID, &reset.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, identity.ErrInvalidResetToken
+		}
+		return nil, fmt.Errorf("get password reset: %w", err)
+	}
+	return &reset, nil
+}
+
+// TakePasswordReset deletes a password reset and returns it if it has not expired.
+func (r *Repository) TakePasswordReset(ctx context.Context, tokenHash string) (*identity.PasswordReset, error) {
+	query := `
+		DELETE FROM password_resets
+		WHERE token_hash = $1
+		RETURNING user_id, created_at, expires_at > NOW()
+	`
+	var reset identity.PasswordReset
+	var valid bool
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(&reset.UserID, &reset.CreatedAt, &valid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, identity.ErrInvalidResetToken
+		}
+		return nil, fmt.Errorf("take password reset: %w", err)
+	}
+
+	if !valid {
+		return nil, identity.ErrInvalidResetToken
+	}
+	return &reset, nil
+}
+
+// SetTOTPSecret stores the secret of a new enrolment. Two-factor
+// authentication stays off until EnableTOTP.
+func (r *Repository) SetTOTPSecret(ctx context.Context, userID, secret string) error {
+	query := `
+		UPDATE users
+		SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := r.db.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("set totp secret: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return identity.ErrUserNotFound
+	}
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication and replaces the recovery codes.
+func (r *Repository) EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	query := `
+		UPDATE users
+		SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+		WHERE id = $1 AND totp_secret IS NOT NULL
+	`
+	result, err := tx.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("enable totp: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return identity.ErrUserNotFound
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DisableTOTP turns off two-factor authentication and deletes the recovery
+// codes and pending challenges of a user.
+func (r *Repository) DisableTOTP(ctx context.Context, userID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	query := `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL,
+			mfa_failed_attempts = 0, mfa_failed_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := tx.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("disable totp: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return identity.ErrUserNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_challenges WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete mfa challenges: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a user.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+
+	query := `INSERT INTO recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`
+	if _, err := tx.Exec(ctx, query, userID, codeHashes); err != nil {
+		return fmt.Errorf("save recovery codes: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records a time step as used unless the same or a later step
+// was used before. The update is conditional, so a code is accepted once
+// even by concurrent requests.
+func (r *Repository) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query := `
+		UPDATE users SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)
+	`
+	result, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("use totp step: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode deletes a recovery code of a user and reports whether it existed.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	query := `DELETE FROM recovery_codes WHERE user_id = $1 AND code_hash = $2`
+	result, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// SaveMFAChallenge stores a login waiting for the second factor and drops expired ones.
+func (r *Repository) SaveMFAChallenge(ctx context.Context, userID, tokenHash string, ttl time.Duration) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM mfa_challenges WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("delete expired mfa challenges: %w", err)
+	}
+
+	query := `
+		INSERT INTO mfa_challenges (token_hash, user_id, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second')
+	`
+	if _, err := r.db.Exec(ctx, query, tokenHash, userID, ttl.Seconds()); err != nil {
+		return fmt.Errorf("save mfa challenge: %w", err)
+	}
+	return nil
+}
+
+// UseMFAChallenge counts an attempt at a challenge. The attempt is counted
+// before the code is checked, so concurrent guesses cannot exceed the limit.
+func (r *Repository) UseMFAChallenge(ctx context.Context, tokenHash string, maxAttempts int) (string, error) {
+	query := `
+		UPDATE mfa_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND expires_at > NOW() AND attempts < $2
+		RETURNING user_id
+	`
+	var userID string
+	if err := r.db.QueryRow(ctx, query, tokenHash, maxAttempts).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", identity.ErrInvalidMFAToken
+		}
+		return "", fmt.Errorf("use mfa challenge: %w", err)
+	}
+	return userID, nil
+}
+
+// DeleteMFAChallenge deletes a challenge once the login is complete.
+func (r *Repository) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM mfa_challenges WHERE token_hash = $1`, tokenHash); err != nil {
+		return fmt.Errorf("delete mfa challenge: %w", err)
+	}
+	return nil
+}
+
+// CountMFAFailures returns the failed second factor attempts of a user. The
+// count starts over once the last failure is older than the window.
+func (r *Repository) CountMFAFailures(ctx context.Context, userID string, window time.Duration) (int, error) {
+	query := `
+		SELECT CASE WHEN mfa_failed_at > NOW() - $2 * INTERVAL '1 second' THEN mfa_failed_attempts ELSE 0 END
+		FROM users
+		WHERE id = $1
+	`
+	var failures int
+	if err := r.db.QueryRow(ctx, query, userID, window.Seconds()).Scan(&failures); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, identity.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("count mfa failures: %w", err)
+	}
+	return failures, nil
+}
+
+// UseMFAAttempt counts an attempt at the second factor as failed before the
+// code is checked, so concurrent guesses over several challenges cannot
+// exceed the limit. A successful attempt is cleared by ResetMFAFailures.
+func (r *Repository) UseMFAAttempt(ctx context.Context, userID string, maxFailures int, window time.Duration) (bool, error) {
+	query := `
+		UPDATE users
+		SET mfa_failed_attempts = CASE
+				WHEN mfa_failed_at > NOW() - $3 * INTERVAL '1 second' THEN mfa_failed_attempts + 1
+				ELSE 1
+			END,
+			mfa_failed_at = NOW()
+		WHERE id = $1
+			AND (mfa_failed_at IS NULL OR mfa_failed_at <= NOW() - $3 * INTERVAL '1 second' OR mfa_failed_attempts < $2)
+	`
+	result, err := r.db.Exec(ctx, query, userID, maxFailures, window.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("use mfa attempt: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ResetMFAFailures clears the failed second factor attempts of a user.
+func (r *Repository) ResetMFAFailures(ctx context.Context, userID string) error {
+	query := `UPDATE users SET mfa_failed_attempts = 0, mfa_failed_at = NULL WHERE id = $1`
+	if _, err := r.db.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("reset mfa failures: %w", err)
+	}
+	return nil
+}
+
+// GetMFAPolicy retrieves the two-factor authentication policy.
+func (r *Repository) GetMFAPolicy(ctx context.Context) (*domain.MFAPolicy, error) {
+	var policy domain.MFAPolicy
+	err := r.db.QueryRow(ctx, `SELECT required_role, updated_at FROM mfa_policy`).
+		Scan(&policy.RequiredRole, &policy.UpdatedAt)
+	if err != nil {
+		// The row is created by migrations; without it nothing is required.
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &domain.MFAPolicy{}, nil
+		}
+		return nil, fmt.Errorf("get mfa policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SaveMFAPolicy stores the two-factor authentication policy.
+func (r *Repository) SaveMFAPolicy(ctx context.Context, policy *domain.MFAPolicy) error {
+	query := `
+		INSERT INTO mfa_policy (id, required_role, updated_at)
+		VALUES (TRUE, $1, NOW())
+		ON CONFLICT (id) DO UPDATE
+		SET required_role = EXCLUDED.required_role, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+	if err := r.db.QueryRow(ctx, query, policy.RequiredRole).Scan(&policy.UpdatedAt); err != nil {
+		return fmt.Errorf("save mfa policy: %w", err)
 	}
 	return nil
 }
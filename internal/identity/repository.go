@@ -2,6 +2,7 @@ package identity
 
 import (
 	"context"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 )
@@ -11,10 +12,101 @@ type Repository interface {
 	CreateUser(ctx context.Context, user *domain.User) error
 	GetUserByID(ctx context.Context, id string) (*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	// UpdateUser updates a user. It fails with ErrLastAdmin when the update
+	// would leave no active admin; the check and the write are atomic.
 	UpdateUser(ctx context.Context, user *domain.User) error
+	ListUsers(ctx context.Context, filter UserFilter) ([]*domain.User, error)
+	// DeleteUser deletes a user. Events and updates authored by the user are
+	// reassigned to the system user, so the incident history is kept. Like
+	// UpdateUser, it fails with ErrLastAdmin for the last active admin.
+	DeleteUser(ctx context.Context, id string) error
+	// UpdatePassword sets the password hash, clears the reset requirement and
+	// drops a pending password reset.
+	UpdatePassword(ctx context.Context, userID, passwordHash string) error
 
-	SaveRefreshToken(ctx context.Context, token *domain.RefreshToken) error
-	GetRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error)
-	DeleteRefreshToken(ctx context.Context, token string) error
-	DeleteUserRefreshTokens(ctx context.Context, userID string) error
+	// SaveRefreshToken stores a refresh token. A session without an ID is
+	// created, an existing one is updated with the client and expiry.
+	SaveRefreshToken(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error
+	// UseRefreshToken marks an unexpired refresh token as used and returns it.
+	// A token that was used before is returned with ErrRefreshTokenReused.
+	UseRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	// ListSessions returns unexpired sessions of a user, most recently used first.
+	ListSessions(ctx context.Context, userID string) ([]*domain.Session, error)
+	DeleteSession(ctx context.Context, userID, id string) error
+	// DeleteSessionByRefreshToken ends the session a refresh token belongs to.
+	DeleteSessionByRefreshToken(ctx context.Context, tokenHash string) error
+	DeleteUserSessions(ctx context.Context, userID string) error
+
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	TouchAPIKey(ctx context.Context, id string) error
+
+	GetUserByIdentity(ctx context.Context, provider, subject string) (*domain.User, error)
+	LinkIdentity(ctx context.Context, userID, provider, subject string) error
+	SaveSSOState(ctx context.Context, state *SSOState, ttl time.Duration) error
+	// TakeSSOState returns and deletes an unexpired login state.
+	TakeSSOState(ctx context.Context, state string) (*SSOState, error)
+
+	// SavePasswordReset replaces the pending password reset of a user.
+	SavePasswordReset(ctx context.Context, userID, tokenHash string, ttl time.Duration) error
+	GetPasswordReset(ctx context.Context, userID string) (*PasswordReset, error)
+	// TakePasswordReset deletes a pending password reset and returns it if it has not expired.
+	TakePasswordReset(ctx context.Context, tokenHash string) (*PasswordReset, error)
+
+	// SetTOTPSecret stores the secret of an enrolment that is not confirmed yet.
+	SetTOTPSecret(ctx context.Context, userID, secret string) error
+	// EnableTOTP turns on two-factor authentication, records step as used and
+	// replaces the recovery codes.
+	EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error
+	// DisableTOTP removes the TOTP secret and the recovery codes of a user.
+	DisableTOTP(ctx context.Context, userID string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	// UseTOTPStep records a time step as used. It returns false if the step is
+	// not later than the last used one, so a code cannot be replayed.
+	UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+	// UseRecoveryCode deletes a recovery code and reports whether it existed.
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+
+	// SaveMFAChallenge stores a login waiting for the second factor.
+	SaveMFAChallenge(ctx context.Context, userID, tokenHash string, ttl time.Duration) error
+	// UseMFAChallenge counts an attempt at an unexpired challenge and returns
+	// its user. Challenges out of attempts are rejected with ErrInvalidMFAToken.
+	UseMFAChallenge(ctx context.Context, tokenHash string, maxAttempts int) (string, error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+	// CountMFAFailures returns the failed second factor attempts of a user
+	// within the window.
+	CountMFAFailures(ctx context.Context, userID string, window time.Duration) (int, error)
+	// UseMFAAttempt counts an attempt at the second factor of a user as failed
+	// until ResetMFAFailures. It returns false if maxFailures attempts have
+	// already failed within the window.
+	UseMFAAttempt(ctx context.Context, userID string, maxFailures int, window time.Duration) (bool, error)
+	ResetMFAFailures(ctx context.Context, userID string) error
+
+	GetMFAPolicy(ctx context.Context) (*domain.MFAPolicy, error)
+	SaveMFAPolicy(ctx context.Context, policy *domain.MFAPolicy) error
+}
+
+// UserFilter selects users to list. The system user is never listed.
+type UserFilter struct {
+	// Query matches email, first or last name, case-insensitive.
+	Query    string
+	Role     *domain.Role
+	IsActive *bool
+	Limit    int
+	Offset   int
+}
+
+// PasswordReset is a pending password reset. Only the hash of the token is stored.
+type PasswordReset struct {
+	UserID    string
+	CreatedAt time.Time
+}
+
+// SSOState is a pending single sign-on login.
+type SSOState struct {
+	State        string
+	CodeVerifier string
+	Nonce        string
 }
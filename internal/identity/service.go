@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,12 +16,46 @@ var (
 	ErrEmailExists        = errors.New("email already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidToken       = errors.New("invalid token")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrInvalidScope       = errors.New("invalid scope")
+	ErrInvalidExpiry      = errors.New("expiry must be in the future")
+	ErrUserDeactivated    = errors.New("user is deactivated")
+	ErrLastAdmin          = errors.New("the last active admin cannot be demoted, deactivated or deleted")
+	ErrOwnAccount         = errors.New("admins cannot change the role of, deactivate or delete their own account")
+	ErrSystemUser         = errors.New("the system user cannot be changed")
+	ErrWrongPassword      = errors.New("current password is incorrect")
+	ErrInvalidResetToken  = errors.New("password reset token is invalid or expired")
+	ErrNoPassword         = errors.New("single sign-on users have no password")
+	// ErrPasswordChangeRequired rejects the access tokens of a user an admin
+	// asked to change the password, except on the password change route.
+	ErrPasswordChangeRequired = httputil.ErrPasswordChangeRequired
+	ErrSessionNotFound        = errors.New("session not found")
+	// ErrRefreshTokenReused means a refresh token was presented after it had
+	// been exchanged, so it may have been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+
+	ErrInvalidMFAToken   = errors.New("two-factor challenge is invalid or expired")
+	ErrInvalidMFACode    = errors.New("two-factor code is invalid")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFANotStarted     = errors.New("two-factor enrolment has not been started")
+	ErrInvalidMFAPolicy  = errors.New("two-factor authentication can be required for operators and admins only")
+	ErrMFALocked         = errors.New("too many failed two-factor attempts, try again later")
+
+	ErrSSONotConfigured      = errors.New("single sign-on is not configured")
+	ErrSSOFailed             = errors.New("single sign-on failed")
+	ErrInvalidSSOState       = errors.New("single sign-on state is invalid or expired")
+	ErrPasswordLoginDisabled = errors.New("password login is disabled, use single sign-on")
 )
 
 // Service provides identity business logic.
 type Service struct {
 	repo          Repository
 	authenticator Authenticator
+	audit         domain.AuditFunc
+	mailer        Mailer
+	passwordReset PasswordResetConfig
+	mfa           MFAConfig
 }
 
 // NewService creates a new identity service.
@@ -28,9 +63,15 @@ func NewService(repo Repository, authenticator Authenticator) *Service {
 	return &Service{
 		repo:          repo,
 		authenticator: authenticator,
+		mfa:           MFAConfig{Issuer: defaultTOTPIssuer},
 	}
 }
 
+// SetAuditLog sets where changes to users and API keys are recorded.
+func (s *Service) SetAuditLog(audit domain.AuditFunc) {
+	s.audit = audit
+}
+
 // RegisterInput contains data for user registration.
 type RegisterInput struct {
 	Email     string
@@ -41,6 +82,10 @@ type RegisterInput struct {
 
 // Register creates a new user account.
 func (s *Service) Register(ctx context.Context, input RegisterInput) (*domain.User, error) {
+	if !s.PasswordLoginEnabled() {
+		return nil, ErrPasswordLoginDisabled
+	}
+
 	existing, err := s.repo.GetUserByEmail(ctx, input.Email)
 	if err != nil && !errors.Is(err, ErrUserNotFound) {
 		return nil, fmt.Errorf("check email: %w", err)
@@ -66,6 +111,13 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*domain.Us
 		return nil, err
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		After:      user,
+	})
+
 	return user, nil
 }
 
@@ -75,50 +127,59 @@ type LoginInput struct {
 	Password string
 }
 
-// Login authenticates user and returns tokens.
-func (s *Service) Login(ctx context.Context, input LoginInput) (*domain.User, *TokenPair, error) {
+// Login authenticates user and returns tokens. Users with two-factor
+// authentication get a challenge token instead, see VerifyMFA.
+func (s *Service) Login(ctx context.Context, input LoginInput) (*LoginResult, error) {
+	if !s.PasswordLoginEnabled() {
+		return nil, ErrPasswordLoginDisabled
+	}
+
 	user, err := s.repo.GetUserByEmail(ctx, input.Email)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			return nil, nil, ErrInvalidCredentials
+			return nil, ErrInvalidCredentials
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
-		return nil, nil, ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
 	}
 
-	tokens, err := s.authenticator.GenerateTokens(ctx, user)
-	if err != nil {
-		return nil, nil, err
+	if !user.IsActive {
+		return nil, ErrUserDeactivated
 	}
 
-	return user, tokens, nil
-}
-
-// RefreshTokens generates new tokens using refresh token.
-func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error) {
-	storedToken, err := s.repo.GetRefreshToken(ctx, refreshToken)
-	if err != nil {
-		return nil, ErrInvalidToken
+	if user.MFAEnabled {
+		mfaToken, err := s.startMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: mfaToken}, nil
 	}
 
-	user, err := s.repo.GetUserByID(ctx, storedToken.UserID)
+	enrollmentRequired, err := s.mfaEnrollmentRequired(ctx, user)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.repo.DeleteRefreshToken(ctx, refreshToken); err != nil {
+	tokens, err := s.authenticator.GenerateTokens(ctx, user)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.authenticator.GenerateTokens(ctx, user)
+	return &LoginResult{User: user, Tokens: tokens, MFAEnrollmentRequired: enrollmentRequired}, nil
 }
 
-// Logout invalidates the refresh token.
+// RefreshTokens exchanges a refresh token for a new token pair. The refresh
+// token can be used once; presenting it again ends the session.
+func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	return s.authenticator.RefreshTokens(ctx, refreshToken)
+}
+
+// Logout ends the session of the refresh token.
 func (s *Service) Logout(ctx context.Context, refreshToken string) error {
-	return s.repo.DeleteRefreshToken(ctx, refreshToken)
+	return s.authenticator.RevokeRefreshToken(ctx, refreshToken)
 }
 
 // GetUserByID returns user by ID.
@@ -126,7 +187,57 @@ func (s *Service) GetUserByID(ctx context.Context, id string) (*domain.User, err
 	return s.repo.GetUserByID(ctx, id)
 }
 
-// ValidateToken validates access token and returns user info.
+// ValidateToken validates access token and returns user info. The role is read
+// from the user, so role changes and deactivation apply to issued tokens at once.
+// Staff who have not set up two-factor authentication required by the policy
+// get the role of a regular user until they do. Users who must change the
+// password are rejected with ErrPasswordChangeRequired.
 func (s *Service) ValidateToken(ctx context.Context, token string) (string, domain.Role, error) {
-	return s.authenticator.ValidateAccessToken(ctx, token)
+	return s.validateToken(ctx, token, false)
+}
+
+// PasswordChangeValidator returns a validator that also accepts the tokens of
+// users who must change the password. It guards the password change route only.
+func (s *Service) PasswordChangeValidator() httputil.TokenValidator {
+	return passwordChangeValidator{s}
+}
+
+// passwordChangeValidator accepts tokens regardless of a pending password change.
+type passwordChangeValidator struct {
+	*Service
+}
+
+func (v passwordChangeValidator) ValidateToken(ctx context.Context, token string) (string, domain.Role, error) {
+	return v.validateToken(ctx, token, true)
+}
+
+func (s *Service) validateToken(ctx context.Context, token string, allowPasswordChange bool) (string, domain.Role, error) {
+	userID, _, err := s.authenticator.ValidateAccessToken(ctx, token)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return "", "", ErrInvalidToken
+		}
+		return "", "", err
+	}
+	if !user.IsActive {
+		return "", "", ErrInvalidToken
+	}
+	if user.PasswordResetRequired && !allowPasswordChange {
+		return "", "", ErrPasswordChangeRequired
+	}
+
+	enrollmentRequired, err := s.mfaEnrollmentRequired(ctx, user)
+	if err != nil {
+		return "", "", err
+	}
+	if enrollmentRequired {
+		return user.ID, domain.RoleUser, nil
+	}
+
+	return user.ID, user.Role, nil
 }
@@ -0,0 +1,18 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// ListSessions returns the active sessions of a user.
+func (s *Service) ListSessions(ctx context.Context, userID string) ([]*domain.Session, error) {
+	return s.repo.ListSessions(ctx, userID)
+}
+
+// RevokeSession ends a session of a user; its refresh token stops working.
+// Access tokens already issued stay valid until they expire.
+func (s *Service) RevokeSession(ctx context.Context, userID, id string) error {
+	return s.repo.DeleteSession(ctx, userID, id)
+}
@@ -0,0 +1,210 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// ssoStateTTL is how long a user has to complete the login at the identity provider.
+const ssoStateTTL = 10 * time.Minute
+
+// ssoPasswordHash marks users provisioned by single sign-on; it is not a bcrypt hash,
+// so password login is impossible for them.
+const ssoPasswordHash = "!"
+
+// SSOEnabled reports whether single sign-on is configured.
+func (s *Service) SSOEnabled() bool {
+	_, ok := s.authenticator.(SSOAuthenticator)
+	return ok
+}
+
+// PasswordLoginEnabled reports whether local registration and password login are allowed.
+func (s *Service) PasswordLoginEnabled() bool {
+	sso, ok := s.authenticator.(SSOAuthenticator)
+	return !ok || sso.PasswordLoginEnabled()
+}
+
+// StartSSOLogin stores a pending login and returns the identity provider URL to redirect to.
+func (s *Service) StartSSOLogin(ctx context.Context) (string, error) {
+	sso, ok := s.authenticator.(SSOAuthenticator)
+	if !ok {
+		return "", ErrSSONotConfigured
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.SaveSSOState(ctx, &SSOState{
+		State:        state,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+	}, ssoStateTTL); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	return sso.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+}
+
+// CompleteSSOLogin exchanges the authorization code, provisions the user on first
+// login and issues tokens. Users with two-factor authentication get a challenge
+// token instead, as with Login: linked accounts keep their own second factor.
+func (s *Service) CompleteSSOLogin(ctx context.Context, code, state string) (*LoginResult, error) {
+	sso, ok := s.authenticator.(SSOAuthenticator)
+	if !ok {
+		return nil, ErrSSONotConfigured
+	}
+
+	pending, err := s.repo.TakeSSOState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	ext, err := sso.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSSOFailed, err)
+	}
+
+	user, err := s.provisionUser(ctx, ext)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserDeactivated
+	}
+
+	if user.MFAEnabled {
+		mfaToken, err := s.startMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: mfaToken}, nil
+	}
+
+	enrollmentRequired, err := s.mfaEnrollmentRequired(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.authenticator.GenerateTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{User: user, Tokens: tokens, MFAEnrollmentRequired: enrollmentRequired}, nil
+}
+
+// provisionUser finds the user linked to the external identity. An unlinked identity
+// is linked to the user with the same verified email or to a newly created user.
+func (s *Service) provisionUser(ctx context.Context, ext *ExternalIdentity) (*domain.User, error) {
+	user, err := s.repo.GetUserByIdentity(ctx, ext.Provider, ext.Subject)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return s.linkOrCreateUser(ctx, ext)
+	case err != nil:
+		return nil, err
+	}
+
+	if err := s.syncRole(ctx, user, ext); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Service) linkOrCreateUser(ctx context.Context, ext *ExternalIdentity) (*domain.User, error) {
+	if ext.Email == "" {
+		return nil, fmt.Errorf("%w: identity provider did not return an email", ErrSSOFailed)
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, ext.Email)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	switch {
+	case user != nil && !ext.EmailVerified:
+		// Linking by an unverified email would let anyone take over the account.
+		return nil, ErrEmailExists
+	case user != nil:
+		if err := s.syncRole(ctx, user, ext); err != nil {
+			return nil, err
+		}
+	default:
+		user = &domain.User{
+			Email:        ext.Email,
+			PasswordHash: ssoPasswordHash,
+			FirstName:    ext.FirstName,
+			LastName:     ext.LastName,
+			Role:         ext.Role,
+		}
+		if err := s.repo.CreateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		s.audit.Record(ctx, domain.AuditChange{
+			Action:     domain.AuditActionCreate,
+			EntityType: domain.AuditEntityUser,
+			EntityID:   user.ID,
+			After:      user,
+		})
+	}
+
+	if err := s.repo.LinkIdentity(ctx, user.ID, ext.Provider, ext.Subject); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// syncRole applies the role mapped by the identity provider when role sync is on.
+// The last active admin keeps the role, so that the instance stays manageable.
+func (s *Service) syncRole(ctx context.Context, user *domain.User, ext *ExternalIdentity) error {
+	if !ext.SyncRole || user.Role == ext.Role {
+		return nil
+	}
+
+	before := *user
+	user.Role = ext.Role
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		if errors.Is(err, ErrLastAdmin) {
+			slog.Warn("identity provider role not applied to the last admin", "user_id", user.ID, "role", ext.Role)
+			user.Role = before.Role
+			return nil
+		}
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return nil
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs.
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,267 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+type fakeSSO struct {
+	Authenticator
+
+	identity *ExternalIdentity
+}
+
+func (f *fakeSSO) AuthCodeURL(_ context.Context, state, _, _ string) (string, error) {
+	return "https://idp.example.com/authorize?state=" + state, nil
+}
+
+func (f *fakeSSO) Exchange(_ context.Context, _, _, _ string) (*ExternalIdentity, error) {
+	return f.identity, nil
+}
+
+func (f *fakeSSO) PasswordLoginEnabled() bool {
+	return false
+}
+
+func (f *fakeSSO) GenerateTokens(_ context.Context, _ *domain.User) (*TokenPair, error) {
+	return &TokenPair{AccessToken: "access"}, nil
+}
+
+type ssoRepo struct {
+	*fakeRepo
+
+	identities map[string]string
+	states     map[string]*SSOState
+	challenges map[string]string
+}
+
+func newSSORepo() *ssoRepo {
+	return &ssoRepo{
+		fakeRepo:   newFakeRepo(),
+		identities: map[string]string{},
+		states:     map[string]*SSOState{},
+		challenges: map[string]string{},
+	}
+}
+
+func (r *ssoRepo) GetUserByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *ssoRepo) CreateUser(_ context.Context, user *domain.User) error {
+	user.ID = "u-" + user.Email
+	user.IsActive = true
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *ssoRepo) UpdateUser(_ context.Context, user *domain.User) error {
+	if r.lastAdmin(user.ID, user.Role == domain.RoleAdmin && user.IsActive) {
+		return ErrLastAdmin
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *ssoRepo) GetUserByIdentity(ctx context.Context, provider, subject string) (*domain.User, error) {
+	id, ok := r.identities[provider+"|"+subject]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return r.GetUserByID(ctx, id)
+}
+
+func (r *ssoRepo) LinkIdentity(_ context.Context, userID, provider, subject string) error {
+	r.identities[provider+"|"+subject] = userID
+	return nil
+}
+
+func (r *ssoRepo) SaveSSOState(_ context.Context, state *SSOState, _ time.Duration) error {
+	r.states[state.State] = state
+	return nil
+}
+
+func (r *ssoRepo) TakeSSOState(_ context.Context, state string) (*SSOState, error) {
+	s, ok := r.states[state]
+	if !ok {
+		return nil, ErrInvalidSSOState
+	}
+	delete(r.states, state)
+	return s, nil
+}
+
+func (r *ssoRepo) CountMFAFailures(_ context.Context, _ string, _ time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (r *ssoRepo) SaveMFAChallenge(_ context.Context, userID, tokenHash string, _ time.Duration) error {
+	r.challenges[tokenHash] = userID
+	return nil
+}
+
+func startLogin(t *testing.T, s *Service, repo *ssoRepo) string {
+	t.Helper()
+	if _, err := s.StartSSOLogin(context.Background()); err != nil {
+		t.Fatalf("StartSSOLogin: %v", err)
+	}
+	for state := range repo.states {
+		return state
+	}
+	t.Fatal("no state saved")
+	return ""
+}
+
+func TestService_CompleteSSOLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("provisions user and syncs role on next login", func(t *testing.T) {
+		repo := newSSORepo()
+		sso := &fakeSSO{identity: &ExternalIdentity{
+			Provider: "idp", Subject: "42", Email: "jane@example.com", EmailVerified: true,
+			Role: domain.RoleOperator, SyncRole: true,
+		}}
+		s := NewService(repo, sso)
+
+		result, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo))
+		if err != nil {
+			t.Fatalf("CompleteSSOLogin: %v", err)
+		}
+		user := result.User
+		if user.Role != domain.RoleOperator || user.PasswordHash != ssoPasswordHash || result.Tokens == nil {
+			t.Fatalf("unexpected user %+v", user)
+		}
+
+		sso.identity.Role = domain.RoleUser
+		again, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo))
+		if err != nil {
+			t.Fatalf("second login: %v", err)
+		}
+		if again.User.ID != user.ID || again.User.Role != domain.RoleUser {
+			t.Errorf("second login user = %s/%s, want %s/user", again.User.ID, again.User.Role, user.ID)
+		}
+	})
+
+	t.Run("links existing user by verified email", func(t *testing.T) {
+		repo := newSSORepo()
+		repo.users["existing"] = &domain.User{ID: "existing", Email: "ops@example.com", Role: domain.RoleAdmin, IsActive: true}
+		s := NewService(repo, &fakeSSO{identity: &ExternalIdentity{
+			Provider: "idp", Subject: "7", Email: "ops@example.com", EmailVerified: true, Role: domain.RoleUser,
+		}})
+
+		result, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo))
+		if err != nil {
+			t.Fatalf("CompleteSSOLogin: %v", err)
+		}
+		if result.User.ID != "existing" || result.User.Role != domain.RoleAdmin {
+			t.Errorf("user = %s/%s, want existing/admin", result.User.ID, result.User.Role)
+		}
+	})
+
+	t.Run("linked user keeps the two-factor policy", func(t *testing.T) {
+		repo := newSSORepo()
+		operator := domain.RoleOperator
+		repo.policy.RequiredRole = &operator
+		repo.users["existing"] = &domain.User{ID: "existing", Email: "ops@example.com", Role: domain.RoleAdmin, IsActive: true}
+		s := NewService(repo, &fakeSSO{identity: &ExternalIdentity{
+			Provider: "idp", Subject: "7", Email: "ops@example.com", EmailVerified: true,
+		}})
+
+		result, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo))
+		if err != nil {
+			t.Fatalf("CompleteSSOLogin: %v", err)
+		}
+		if !result.MFAEnrollmentRequired {
+			t.Error("MFAEnrollmentRequired = false for a linked admin without two-factor authentication")
+		}
+	})
+
+	t.Run("requires the second factor of a linked user", func(t *testing.T) {
+		repo := newSSORepo()
+		repo.users["existing"] = &domain.User{
+			ID: "existing", Email: "ops@example.com", Role: domain.RoleAdmin, IsActive: true,
+			MFAEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP",
+		}
+		repo.identities["idp|7"] = "existing"
+		s := NewService(repo, &fakeSSO{identity: &ExternalIdentity{
+			Provider: "idp", Subject: "7", Email: "ops@example.com", EmailVerified: true,
+		}})
+
+		result, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo))
+		if err != nil {
+			t.Fatalf("CompleteSSOLogin: %v", err)
+		}
+		if result.Tokens != nil || result.User != nil || result.MFAToken == "" {
+			t.Fatalf("CompleteSSOLogin() = %+v, want only a challenge", result)
+		}
+		if repo.challenges[hashMFAToken(result.MFAToken)] != "existing" {
+			t.Error("challenge is not stored for the user")
+		}
+	})
+
+	t.Run("keeps the role of the last admin", func(t *testing.T) {
+		repo := newSSORepo()
+		delete(repo.users, "admin")
+		repo.users["existing"] = &domain.User{ID: "existing", Email: "ops@example.com", Role: domain.RoleAdmin, IsActive: true}
+		repo.identities["idp|7"] = "existing"
+		s := NewService(repo, &fakeSSO{identity: &ExternalIdentity{
+			Provider: "idp", Subject: "7", Email: "ops@example.com", EmailVerified: true,
+			Role: domain.RoleUser, SyncRole: true,
+		}})
+
+		result, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo))
+		if err != nil {
+			t.Fatalf("CompleteSSOLogin: %v", err)
+		}
+		if user := result.User; user.Role != domain.RoleAdmin || repo.users["existing"].Role != domain.RoleAdmin {
+			t.Errorf("role = %s, want admin", user.Role)
+		}
+	})
+
+	t.Run("refuses unverified email of existing user", func(t *testing.T) {
+		repo := newSSORepo()
+		repo.users["existing"] = &domain.User{ID: "existing", Email: "ops@example.com"}
+		s := NewService(repo, &fakeSSO{identity: &ExternalIdentity{
+			Provider: "idp", Subject: "7", Email: "ops@example.com",
+		}})
+
+		if _, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo)); !errors.Is(err, ErrEmailExists) {
+			t.Errorf("err = %v, want ErrEmailExists", err)
+		}
+	})
+
+	t.Run("rejects deactivated user", func(t *testing.T) {
+		repo := newSSORepo()
+		repo.users["existing"] = &domain.User{ID: "existing", Email: "ops@example.com"}
+		repo.identities["idp|7"] = "existing"
+		s := NewService(repo, &fakeSSO{identity: &ExternalIdentity{
+			Provider: "idp", Subject: "7", Email: "ops@example.com", EmailVerified: true,
+		}})
+
+		if _, err := s.CompleteSSOLogin(ctx, "code", startLogin(t, s, repo)); !errors.Is(err, ErrUserDeactivated) {
+			t.Errorf("err = %v, want ErrUserDeactivated", err)
+		}
+	})
+
+	t.Run("rejects unknown state", func(t *testing.T) {
+		s := NewService(newSSORepo(), &fakeSSO{})
+		if _, err := s.CompleteSSOLogin(ctx, "code", "forged"); !errors.Is(err, ErrInvalidSSOState) {
+			t.Errorf("err = %v, want ErrInvalidSSOState", err)
+		}
+	})
+}
+
+func TestService_PasswordLoginDisabled(t *testing.T) {
+	s := NewService(newSSORepo(), &fakeSSO{})
+	if _, err := s.Login(context.Background(), LoginInput{Email: "a@example.com", Password: "x"}); !errors.Is(err, ErrPasswordLoginDisabled) {
+		t.Errorf("Login err = %v, want ErrPasswordLoginDisabled", err)
+	}
+}
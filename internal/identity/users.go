@@ -0,0 +1,167 @@
+package identity
+
+import (
+	"context"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// ListUsers returns users matching the filter.
+func (s *Service) ListUsers(ctx context.Context, filter UserFilter) ([]*domain.User, error) {
+	return s.repo.ListUsers(ctx, filter)
+}
+
+// ChangeRole sets the role of a user. actorID is the admin making the change.
+func (s *Service) ChangeRole(ctx context.Context, actorID, id string, role domain.Role) (*domain.User, error) {
+	user, err := s.managedUser(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	before := *user
+	user.Role = role
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return user, nil
+}
+
+// DeactivateUser blocks login for a user and signs them out everywhere.
+func (s *Service) DeactivateUser(ctx context.Context, actorID, id string) (*domain.User, error) {
+	user, err := s.managedUser(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return user, nil
+	}
+
+	before := *user
+	now := time.Now()
+	user.IsActive = false
+	user.DeactivatedAt = &now
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteUserSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionDeactivate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return user, nil
+}
+
+// ReactivateUser allows a deactivated user to log in again.
+func (s *Service) ReactivateUser(ctx context.Context, actorID, id string) (*domain.User, error) {
+	user, err := s.managedUser(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsActive {
+		return user, nil
+	}
+
+	before := *user
+	user.IsActive = true
+	user.DeactivatedAt = nil
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionReactivate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return user, nil
+}
+
+// RequirePasswordReset signs a user out everywhere and asks them to change the
+// password after the next login. Until they do, their access tokens only work
+// for the password change.
+func (s *Service) RequirePasswordReset(ctx context.Context, id string) (*domain.User, error) {
+	if !s.PasswordLoginEnabled() {
+		return nil, ErrPasswordLoginDisabled
+	}
+	if id == domain.SystemUserID {
+		return nil, ErrSystemUser
+	}
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash == ssoPasswordHash {
+		return nil, ErrNoPassword
+	}
+
+	before := *user
+	user.PasswordResetRequired = true
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteUserSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+	return user, nil
+}
+
+// DeleteUser deletes a user. Events they authored stay and are attributed to
+// the system user.
+func (s *Service) DeleteUser(ctx context.Context, actorID, id string) error {
+	user, err := s.managedUser(ctx, actorID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     user,
+	})
+	return nil
+}
+
+// managedUser loads a user an admin is about to change. Admins cannot lock
+// themselves out, and the system user is not managed through the API.
+func (s *Service) managedUser(ctx context.Context, actorID, id string) (*domain.User, error) {
+	if id == domain.SystemUserID {
+		return nil, ErrSystemUser
+	}
+	if id == actorID {
+		return nil, ErrOwnAccount
+	}
+	return s.repo.GetUserByID(ctx, id)
+}
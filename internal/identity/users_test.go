@@ -0,0 +1,274 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type usersRepo struct {
+	*fakeRepo
+
+	revoked []string
+	deleted []string
+}
+
+func newUsersRepo() *usersRepo {
+	repo := &usersRepo{fakeRepo: newFakeRepo()}
+	repo.users["operator"] = &domain.User{ID: "operator", Email: "ops@example.com", Role: domain.RoleOperator, IsActive: true}
+	repo.users["second-admin"] = &domain.User{ID: "second-admin", Email: "boss@example.com", Role: domain.RoleAdmin, IsActive: true}
+	return repo
+}
+
+func (r *usersRepo) GetUserByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *usersRepo) UpdateUser(_ context.Context, user *domain.User) error {
+	if r.lastAdmin(user.ID, user.Role == domain.RoleAdmin && user.IsActive) {
+		return ErrLastAdmin
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *usersRepo) DeleteUser(_ context.Context, id string) error {
+	if r.lastAdmin(id, false) {
+		return ErrLastAdmin
+	}
+	delete(r.users, id)
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *usersRepo) DeleteUserSessions(_ context.Context, userID string) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+// lastAdmin reports whether a change leaving user id with or without admin
+// rights would remove the last active admin, as the PostgreSQL repository checks.
+func (f *fakeRepo) lastAdmin(id string, stillAdmin bool) bool {
+	if stillAdmin {
+		return false
+	}
+	for _, u := range f.users {
+		if u.ID != id && u.Role == domain.RoleAdmin && u.IsActive {
+			return false
+		}
+	}
+	return true
+}
+
+type fakeAuthenticator struct {
+	Authenticator
+
+	userID string
+	role   domain.Role
+}
+
+func (f *fakeAuthenticator) GenerateTokens(_ context.Context, _ *domain.User) (*TokenPair, error) {
+	return &TokenPair{AccessToken: "access"}, nil
+}
+
+func (f *fakeAuthenticator) ValidateAccessToken(_ context.Context, _ string) (string, domain.Role, error) {
+	return f.userID, f.role, nil
+}
+
+func TestService_ChangeRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID string
+		id      string
+		role    domain.Role
+		lone    bool
+		wantErr error
+	}{
+		{name: "promote operator", actorID: "admin", id: "operator", role: domain.RoleAdmin},
+		{name: "demote admin", actorID: "admin", id: "second-admin", role: domain.RoleUser},
+		{name: "own account", actorID: "admin", id: "admin", role: domain.RoleUser, wantErr: ErrOwnAccount},
+		{name: "system user", actorID: "admin", id: domain.SystemUserID, role: domain.RoleUser, wantErr: ErrSystemUser},
+		{name: "unknown user", actorID: "admin", id: "ghost", role: domain.RoleUser, wantErr: ErrUserNotFound},
+		{name: "last admin", actorID: "api-key-owner", id: "admin", role: domain.RoleOperator, lone: true, wantErr: ErrLastAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newUsersRepo()
+			if tt.lone {
+				delete(repo.users, "second-admin")
+			}
+			var audited []domain.AuditChange
+			s := NewService(repo, nil)
+			s.SetAuditLog(func(_ context.Context, change domain.AuditChange) {
+				audited = append(audited, change)
+			})
+
+			user, err := s.ChangeRole(context.Background(), tt.actorID, tt.id, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangeRole() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(audited) != 0 {
+					t.Error("rejected change must not be audited")
+				}
+				return
+			}
+			if user.Role != tt.role || repo.users[tt.id].Role != tt.role {
+				t.Errorf("role = %q, want %q", user.Role, tt.role)
+			}
+			if len(audited) != 1 || audited[0].Action != domain.AuditActionUpdate {
+				t.Errorf("audited = %+v, want one update", audited)
+			}
+		})
+	}
+}
+
+func TestService_DeactivateUser(t *testing.T) {
+	repo := newUsersRepo()
+	s := NewService(repo, nil)
+	ctx := context.Background()
+
+	user, err := s.DeactivateUser(ctx, "admin", "operator")
+	if err != nil {
+		t.Fatalf("DeactivateUser() error = %v", err)
+	}
+	if user.IsActive || user.DeactivatedAt == nil {
+		t.Errorf("user = %+v, want deactivated", user)
+	}
+	if len(repo.revoked) != 1 || repo.revoked[0] != "operator" {
+		t.Errorf("revoked refresh tokens of %v, want [operator]", repo.revoked)
+	}
+
+	if _, err := s.DeactivateUser(ctx, "admin", "operator"); err != nil {
+		t.Fatalf("repeated DeactivateUser() error = %v", err)
+	}
+	if len(repo.revoked) != 1 {
+		t.Error("repeated deactivation must not change anything")
+	}
+
+	user, err = s.ReactivateUser(ctx, "admin", "operator")
+	if err != nil {
+		t.Fatalf("ReactivateUser() error = %v", err)
+	}
+	if !user.IsActive || user.DeactivatedAt != nil {
+		t.Errorf("user = %+v, want active", user)
+	}
+
+	repo.users["second-admin"].IsActive = false
+	if _, err := s.DeactivateUser(ctx, "api-key-owner", "admin"); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("deactivating the last admin: error = %v, want ErrLastAdmin", err)
+	}
+}
+
+func TestService_DeactivatedUserIsLockedOut(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newUsersRepo()
+	repo.users["operator"].PasswordHash = string(hash)
+	repo.users["operator"].IsActive = false
+	s := NewService(repo, &fakeAuthenticator{userID: "operator", role: domain.RoleOperator})
+	ctx := context.Background()
+
+	if _, err := s.Login(ctx, LoginInput{Email: "ops@example.com", Password: "password123"}); !errors.Is(err, ErrUserDeactivated) {
+		t.Errorf("Login() error = %v, want ErrUserDeactivated", err)
+	}
+	if _, err := s.Login(ctx, LoginInput{Email: "ops@example.com", Password: "wrong"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login() with a wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := s.ValidateToken(ctx, "token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken() error = %v, want ErrInvalidToken", err)
+	}
+
+	repo.users["operator"].IsActive = true
+	repo.users["operator"].Role = domain.RoleUser
+	_, role, err := s.ValidateToken(ctx, "token")
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if role != domain.RoleUser {
+		t.Errorf("ValidateToken() role = %q, want the current role user", role)
+	}
+}
+
+func TestService_RequirePasswordReset(t *testing.T) {
+	repo := newUsersRepo()
+	repo.users["member"] = &domain.User{ID: "member", Email: "member@example.com", Role: domain.RoleUser, IsActive: true}
+	s := NewService(repo, &fakeAuthenticator{userID: "member", role: domain.RoleUser})
+	ctx := context.Background()
+
+	user, err := s.RequirePasswordReset(ctx, "member")
+	if err != nil {
+		t.Fatalf("RequirePasswordReset() error = %v", err)
+	}
+	if !user.PasswordResetRequired {
+		t.Error("password reset must be required")
+	}
+	if len(repo.revoked) != 1 || repo.revoked[0] != "member" {
+		t.Errorf("revoked refresh tokens of %v, want [member]", repo.revoked)
+	}
+
+	if _, _, err := s.ValidateToken(ctx, "token"); !errors.Is(err, ErrPasswordChangeRequired) {
+		t.Errorf("ValidateToken() error = %v, want ErrPasswordChangeRequired", err)
+	}
+	userID, role, err := s.PasswordChangeValidator().ValidateToken(ctx, "token")
+	if err != nil {
+		t.Fatalf("PasswordChangeValidator().ValidateToken() error = %v", err)
+	}
+	if userID != "member" || role != domain.RoleUser {
+		t.Errorf("PasswordChangeValidator().ValidateToken() = %q, %q, want member, user", userID, role)
+	}
+
+	repo.users["member"].PasswordResetRequired = false
+	if _, _, err := s.ValidateToken(ctx, "token"); err != nil {
+		t.Errorf("ValidateToken() after the change error = %v", err)
+	}
+
+	if _, err := s.RequirePasswordReset(ctx, domain.SystemUserID); !errors.Is(err, ErrSystemUser) {
+		t.Errorf("system user: error = %v, want ErrSystemUser", err)
+	}
+
+	repo.users["second-admin"].PasswordHash = ssoPasswordHash
+	if _, err := s.RequirePasswordReset(ctx, "second-admin"); !errors.Is(err, ErrNoPassword) {
+		t.Errorf("single sign-on user: error = %v, want ErrNoPassword", err)
+	}
+}
+
+func TestService_DeleteUser(t *testing.T) {
+	repo := newUsersRepo()
+	var audited []domain.AuditChange
+	s := NewService(repo, nil)
+	s.SetAuditLog(func(_ context.Context, change domain.AuditChange) {
+		audited = append(audited, change)
+	})
+	ctx := context.Background()
+
+	if err := s.DeleteUser(ctx, "admin", "admin"); !errors.Is(err, ErrOwnAccount) {
+		t.Errorf("own account: error = %v, want ErrOwnAccount", err)
+	}
+
+	if err := s.DeleteUser(ctx, "admin", "second-admin"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if len(repo.deleted) != 1 || repo.deleted[0] != "second-admin" {
+		t.Errorf("deleted = %v, want [second-admin]", repo.deleted)
+	}
+	if len(audited) != 1 || audited[0].Action != domain.AuditActionDelete || audited[0].Before == nil {
+		t.Errorf("audited = %+v, want one delete with the user before", audited)
+	}
+
+	if err := s.DeleteUser(ctx, "api-key-owner", "admin"); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("last admin: error = %v, want ErrLastAdmin", err)
+	}
+}
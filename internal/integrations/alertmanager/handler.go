@@ -0,0 +1,128 @@
+package alertmanager
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// Handler handles Alertmanager webhook requests.
+type Handler struct {
+	service   *Service
+	validator *validator.Validate
+	token     string
+}
+
+// NewHandler creates a new webhook handler authenticated by a bearer token.
+func NewHandler(service *Service, token string) *Handler {
+	return &Handler{
+		service:   service,
+		validator: validator.New(),
+		token:     token,
+	}
+}
+
+// RegisterRoutes registers the webhook route (public, authenticated by bearer token).
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Post("/integrations/alertmanager", h.HandleWebhook)
+}
+
+// WebhookRequest is the part of the Alertmanager webhook payload (version 4) used here.
+type WebhookRequest struct {
+	Alerts []AlertRequest `json:"alerts" validate:"dive"`
+}
+
+// AlertRequest is a single alert of the webhook payload.
+type AlertRequest struct {
+	Status      string            `json:"status" validate:"required,oneof=firing resolved"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Fingerprint string            `json:"fingerprint" validate:"required,max=64"`
+}
+
+// HandleWebhook handles POST /integrations/alertmanager.
+func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		h.respondError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	alerts := make([]Alert, 0, len(req.Alerts))
+	for _, a := range req.Alerts {
+		alerts = append(alerts, Alert{
+			Status:      a.Status,
+			Labels:      a.Labels,
+			Annotations: a.Annotations,
+			StartsAt:    a.StartsAt,
+			Fingerprint: a.Fingerprint,
+		})
+	}
+
+	result, err := h.service.HandleAlerts(r.Context(), alerts)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": data}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
+
+func (h *Handler) respondValidationError(w http.ResponseWriter, err error) {
+	var details []map[string]string
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		for _, e := range validationErrors {
+			details = append(details, map[string]string{
+				"field":   e.Field(),
+				"message": e.Tag(),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": "validation error",
+			"details": details,
+		},
+	}); err != nil {
+		slog.Error("failed to encode validation error response", "error", err)
+	}
+}
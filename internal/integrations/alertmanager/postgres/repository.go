@@ -0,0 +1,166 @@
+// Package postgres provides PostgreSQL implementation of the Alertmanager integration repository.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/integrations/alertmanager"
+	pgutil "github.com/bissquit/incident-garden/internal/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// alertLockClass is the high half of the per-fingerprint advisory lock keys;
+// the low half is the fingerprint hash.
+const alertLockClass int64 = 0x616c7274 // "alrt"
+
+// lockRetryInterval is how often a fingerprint locked by another replica is retried.
+const lockRetryInterval = 50 * time.Millisecond
+
+// Repository implements alertmanager.Repository using PostgreSQL.
+type Repository struct {
+	db *pgxpool.Pool
+
+	mu    sync.Mutex
+	locks map[string]*alertLock
+	// conns limits the connections held by advisory locks, so that alerts
+	// being processed leave the rest of the pool to the work they wait for.
+	conns chan struct{}
+}
+
+// alertLock serializes the alerts of one fingerprint within the process.
+type alertLock struct {
+	held chan struct{}
+	refs int
+}
+
+// NewRepository creates a new PostgreSQL repository.
+// Advisory locks hold at most a quarter of the pool connections.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		db:    db,
+		locks: make(map[string]*alertLock),
+		conns: make(chan struct{}, max(1, int(db.Config().MaxConns)/4)),
+	}
+}
+
+// LockAlert runs fn while no other caller, in this process or on another
+// replica, processes the same fingerprint. Callers in this process wait
+// without holding a connection; other replicas are kept out by a session-level
+// advisory lock that is retried while it is taken.
+func (r *Repository) LockAlert(ctx context.Context, fingerprint string, fn func(ctx context.Context) error) error {
+	release, err := r.lockLocal(ctx, fingerprint)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	select {
+	case r.conns <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-r.conns }()
+
+	key := alertLockKey(fingerprint)
+	for {
+		locked, err := pgutil.WithTryAdvisoryLock(ctx, r.db, key, fn)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return nil
+		}
+
+		select {
+		case <-time.After(lockRetryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// lockLocal waits until no other goroutine of this process holds the fingerprint.
+func (r *Repository) lockLocal(ctx context.Context, fingerprint string) (func(), error) {
+	r.mu.Lock()
+	lock, ok := r.locks[fingerprint]
+	if !ok {
+		lock = &alertLock{held: make(chan struct{}, 1)}
+		r.locks[fingerprint] = lock
+	}
+	lock.refs++
+	r.mu.Unlock()
+
+	unref := func() {
+		r.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(r.locks, fingerprint)
+		}
+		r.mu.Unlock()
+	}
+
+	select {
+	case lock.held <- struct{}{}:
+	case <-ctx.Done():
+		unref()
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-lock.held
+		unref()
+	}, nil
+}
+
+// alertLockKey returns the advisory lock key of a fingerprint.
+func alertLockKey(fingerprint string) int64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fingerprint))
+	return alertLockClass<<32 | int64(h.Sum32())
+}
+
+// GetAlertEventID returns the incident linked to the alert fingerprint.
+func (r *Repository) GetAlertEventID(ctx context.Context, fingerprint string) (string, error) {
+	query := `SELECT event_id FROM alertmanager_alerts WHERE fingerprint = $1`
+
+	var eventID string
+	if err := r.db.QueryRow(ctx, query, fingerprint).Scan(&eventID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", alertmanager.ErrAlertNotFound
+		}
+		return "", fmt.Errorf("get alert: %w", err)
+	}
+	return eventID, nil
+}
+
+// SaveAlert links the alert fingerprint to an incident. An existing link is kept.
+func (r *Repository) SaveAlert(ctx context.Context, fingerprint, eventID string) error {
+	query := `
+		INSERT INTO alertmanager_alerts (fingerprint, event_id)
+		VALUES ($1, $2)
+		ON CONFLICT (fingerprint) DO NOTHING
+	`
+	tag, err := r.db.Exec(ctx, query, fingerprint, eventID)
+	if err != nil {
+		return fmt.Errorf("save alert: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return alertmanager.ErrAlertExists
+	}
+	return nil
+}
+
+// DeleteAlert removes the alert link.
+func (r *Repository) DeleteAlert(ctx context.Context, fingerprint string) error {
+	query := `DELETE FROM alertmanager_alerts WHERE fingerprint = $1`
+	if _, err := r.db.Exec(ctx, query, fingerprint); err != nil {
+		return fmt.Errorf("delete alert: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package alertmanager
+
+import (
+	"context"
+)
+
+// Repository stores which incident was opened for an alert.
+type Repository interface {
+	// LockAlert runs fn while holding a lock on the alert fingerprint, so that
+	// webhooks delivered concurrently, possibly to different replicas, process
+	// the same alert one at a time.
+	LockAlert(ctx context.Context, fingerprint string, fn func(ctx context.Context) error) error
+	// GetAlertEventID returns the incident opened for the alert fingerprint.
+	GetAlertEventID(ctx context.Context, fingerprint string) (string, error)
+	// SaveAlert links the alert fingerprint to an incident. It returns
+	// ErrAlertExists if the fingerprint is already linked.
+	SaveAlert(ctx context.Context, fingerprint, eventID string) error
+	DeleteAlert(ctx context.Context, fingerprint string) error
+}
@@ -0,0 +1,296 @@
+// Package alertmanager opens and resolves incidents from Prometheus Alertmanager webhooks.
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+)
+
+// Service errors.
+var (
+	ErrAlertNotFound = errors.New("alert not found")
+	ErrAlertExists   = errors.New("alert already linked to an incident")
+)
+
+// Alert statuses sent by Alertmanager.
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+// Alert is a single alert from an Alertmanager webhook payload.
+type Alert struct {
+	Status      string
+	Labels      map[string]string
+	Annotations map[string]string
+	StartsAt    time.Time
+	Fingerprint string
+}
+
+// Result counts what was done with the alerts of one webhook.
+type Result struct {
+	Created  int `json:"created"`
+	Updated  int `json:"updated"`
+	Resolved int `json:"resolved"`
+	Skipped  int `json:"skipped"`
+}
+
+// Config holds alert mapping settings.
+type Config struct {
+	ServiceLabel      string
+	SeverityLabel     string
+	SeverityMap       map[string]domain.Severity
+	DefaultSeverity   domain.Severity
+	NotifySubscribers bool
+}
+
+// EventService is the part of events.Service used to manage incidents.
+type EventService interface {
+	CreateEvent(ctx context.Context, input events.CreateEventInput, createdBy string) (*domain.Event, error)
+	GetEvent(ctx context.Context, id string) (*domain.Event, error)
+	AddUpdate(ctx context.Context, input events.CreateEventUpdateInput, createdBy string) (*domain.EventUpdate, error)
+}
+
+// ServiceCatalog is the part of catalog.Service used to find affected services.
+type ServiceCatalog interface {
+	GetServiceBySlug(ctx context.Context, slug string) (*domain.Service, error)
+	ListServices(ctx context.Context, filter catalog.ServiceFilter) ([]domain.Service, error)
+}
+
+// Service turns alerts into incidents.
+type Service struct {
+	repo    Repository
+	events  EventService
+	catalog ServiceCatalog
+	config  Config
+}
+
+// NewService creates a new Alertmanager integration service.
+func NewService(repo Repository, eventService EventService, serviceCatalog ServiceCatalog, config Config) *Service {
+	return &Service{
+		repo:    repo,
+		events:  eventService,
+		catalog: serviceCatalog,
+		config:  config,
+	}
+}
+
+// HandleAlerts processes alerts of one webhook. A firing alert opens an incident
+// on its first delivery and adds an update on repeated deliveries; a resolved
+// alert resolves its incident. Alerts with the same fingerprint are processed
+// one at a time across webhooks. Every alert is processed even if some fail;
+// the first error is returned so Alertmanager retries the webhook.
+func (s *Service) HandleAlerts(ctx context.Context, alerts []Alert) (*Result, error) {
+	result := &Result{}
+	var firstErr error
+
+	for _, alert := range alerts {
+		var err error
+		switch alert.Status {
+		case AlertStatusFiring:
+			err = s.repo.LockAlert(ctx, alert.Fingerprint, func(ctx context.Context) error {
+				return s.handleFiring(ctx, alert, result)
+			})
+		case AlertStatusResolved:
+			err = s.repo.LockAlert(ctx, alert.Fingerprint, func(ctx context.Context) error {
+				return s.handleResolved(ctx, alert, result)
+			})
+		default:
+			result.Skipped++
+		}
+
+		if err != nil {
+			slog.Error("failed to process alert", "fingerprint", alert.Fingerprint, "status", alert.Status, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return result, firstErr
+}
+
+func (s *Service) handleFiring(ctx context.Context, alert Alert, result *Result) error {
+	event, err := s.openEvent(ctx, alert.Fingerprint)
+	if err != nil {
+		return err
+	}
+
+	if event != nil {
+		_, err := s.events.AddUpdate(ctx, events.CreateEventUpdateInput{
+			EventID: event.ID,
+			Status:  event.Status,
+			Message: "Alert is still firing.",
+		}, domain.SystemUserID)
+		if err != nil {
+			return fmt.Errorf("add update: %w", err)
+		}
+		result.Updated++
+		return nil
+	}
+
+	serviceIDs, err := s.resolveServices(ctx, alert.Labels[s.config.ServiceLabel])
+	if err != nil {
+		return err
+	}
+	if len(serviceIDs) == 0 {
+		slog.Warn("alert does not match any service", "fingerprint", alert.Fingerprint, "label", s.config.ServiceLabel)
+		result.Skipped++
+		return nil
+	}
+
+	severity := s.severity(alert.Labels[s.config.SeverityLabel])
+	input := events.CreateEventInput{
+		Title:             alertTitle(alert),
+		Type:              domain.EventTypeIncident,
+		Status:            domain.EventStatusInvestigating,
+		Severity:          &severity,
+		Description:       alert.Annotations["description"],
+		NotifySubscribers: s.config.NotifySubscribers,
+		ServiceIDs:        serviceIDs,
+	}
+	if !alert.StartsAt.IsZero() {
+		startedAt := alert.StartsAt
+		input.StartedAt = &startedAt
+	}
+
+	// Drop the link to an incident that is already resolved or deleted;
+	// SaveAlert never replaces an existing link.
+	if err := s.repo.DeleteAlert(ctx, alert.Fingerprint); err != nil {
+		return err
+	}
+
+	created, err := s.events.CreateEvent(ctx, input, domain.SystemUserID)
+	if err != nil {
+		return fmt.Errorf("create event: %w", err)
+	}
+
+	if err := s.repo.SaveAlert(ctx, alert.Fingerprint, created.ID); err != nil {
+		return err
+	}
+
+	result.Created++
+	return nil
+}
+
+func (s *Service) handleResolved(ctx context.Context, alert Alert, result *Result) error {
+	event, err := s.openEvent(ctx, alert.Fingerprint)
+	if err != nil {
+		return err
+	}
+
+	if event != nil {
+		_, err := s.events.AddUpdate(ctx, events.CreateEventUpdateInput{
+			EventID:           event.ID,
+			Status:            domain.EventStatusResolved,
+			Message:           "Alert has been resolved.",
+			NotifySubscribers: s.config.NotifySubscribers,
+		}, domain.SystemUserID)
+		if err != nil {
+			return fmt.Errorf("add update: %w", err)
+		}
+		result.Resolved++
+	} else {
+		result.Skipped++
+	}
+
+	return s.repo.DeleteAlert(ctx, alert.Fingerprint)
+}
+
+// openEvent returns the unresolved incident opened for the alert, or nil.
+// Incidents resolved by an operator are not reopened.
+func (s *Service) openEvent(ctx context.Context, fingerprint string) (*domain.Event, error) {
+	eventID, err := s.repo.GetAlertEventID(ctx, fingerprint)
+	if err != nil {
+		if errors.Is(err, ErrAlertNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	event, err := s.events.GetEvent(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, events.ErrEventNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get event: %w", err)
+	}
+
+	if event.Status.IsResolved() {
+		return nil, nil
+	}
+	return event, nil
+}
+
+// resolveServices finds services by slug and by a tag keyed by the service label.
+// The label may list several values separated by commas.
+func (s *Service) resolveServices(ctx context.Context, value string) ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(service *domain.Service) {
+		if !service.IsArchived() && !seen[service.ID] {
+			seen[service.ID] = true
+			ids = append(ids, service.ID)
+		}
+	}
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		service, err := s.catalog.GetServiceBySlug(ctx, v)
+		switch {
+		case err == nil:
+			add(service)
+		case !errors.Is(err, catalog.ErrServiceNotFound):
+			return nil, fmt.Errorf("get service %s: %w", v, err)
+		}
+
+		tagged, err := s.catalog.ListServices(ctx, catalog.ServiceFilter{
+			Tag: &domain.ServiceTag{Key: s.config.ServiceLabel, Value: v},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list services by tag: %w", err)
+		}
+		for i := range tagged {
+			add(&tagged[i])
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *Service) severity(label string) domain.Severity {
+	if severity, ok := s.config.SeverityMap[label]; ok {
+		return severity
+	}
+	return s.config.DefaultSeverity
+}
+
+// maxTitleLength matches the events.title column size.
+const maxTitleLength = 500
+
+func alertTitle(alert Alert) string {
+	title := alert.Annotations["summary"]
+	if title == "" {
+		title = alert.Labels["alertname"]
+	}
+	if title == "" {
+		title = "Alert " + alert.Fingerprint
+	}
+
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = string(runes[:maxTitleLength])
+	}
+	return title
+}
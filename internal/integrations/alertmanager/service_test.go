@@ -0,0 +1,245 @@
+package alertmanager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+)
+
+type fakeRepo struct {
+	mu     sync.Mutex
+	alerts map[string]string
+}
+
+func (f *fakeRepo) LockAlert(ctx context.Context, _ string, fn func(ctx context.Context) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fn(ctx)
+}
+
+func (f *fakeRepo) GetAlertEventID(_ context.Context, fingerprint string) (string, error) {
+	id, ok := f.alerts[fingerprint]
+	if !ok {
+		return "", ErrAlertNotFound
+	}
+	return id, nil
+}
+
+func (f *fakeRepo) SaveAlert(_ context.Context, fingerprint, eventID string) error {
+	if _, ok := f.alerts[fingerprint]; ok {
+		return ErrAlertExists
+	}
+	f.alerts[fingerprint] = eventID
+	return nil
+}
+
+func (f *fakeRepo) DeleteAlert(_ context.Context, fingerprint string) error {
+	delete(f.alerts, fingerprint)
+	return nil
+}
+
+type fakeEvents struct {
+	events  map[string]*domain.Event
+	updates []events.CreateEventUpdateInput
+}
+
+func (f *fakeEvents) CreateEvent(_ context.Context, input events.CreateEventInput, _ string) (*domain.Event, error) {
+	event := &domain.Event{
+		ID:         fmt.Sprintf("e%d", len(f.events)+1),
+		Title:      input.Title,
+		Type:       input.Type,
+		Status:     input.Status,
+		Severity:   input.Severity,
+		ServiceIDs: input.ServiceIDs,
+	}
+	f.events[event.ID] = event
+	return event, nil
+}
+
+func (f *fakeEvents) GetEvent(_ context.Context, id string) (*domain.Event, error) {
+	event, ok := f.events[id]
+	if !ok {
+		return nil, events.ErrEventNotFound
+	}
+	return event, nil
+}
+
+func (f *fakeEvents) AddUpdate(_ context.Context, input events.CreateEventUpdateInput, _ string) (*domain.EventUpdate, error) {
+	f.updates = append(f.updates, input)
+	f.events[input.EventID].Status = input.Status
+	return &domain.EventUpdate{EventID: input.EventID, Status: input.Status}, nil
+}
+
+type fakeCatalog struct {
+	services []domain.Service
+	tags     map[string]domain.ServiceTag
+}
+
+func (f *fakeCatalog) GetServiceBySlug(_ context.Context, slug string) (*domain.Service, error) {
+	for i := range f.services {
+		if f.services[i].Slug == slug {
+			return &f.services[i], nil
+		}
+	}
+	return nil, catalog.ErrServiceNotFound
+}
+
+func (f *fakeCatalog) ListServices(_ context.Context, filter catalog.ServiceFilter) ([]domain.Service, error) {
+	var result []domain.Service
+	for _, service := range f.services {
+		if tag, ok := f.tags[service.ID]; ok && tag.Key == filter.Tag.Key && tag.Value == filter.Tag.Value {
+			result = append(result, service)
+		}
+	}
+	return result, nil
+}
+
+func newTestService() (*Service, *fakeRepo, *fakeEvents) {
+	repo := &fakeRepo{alerts: map[string]string{}}
+	ev := &fakeEvents{events: map[string]*domain.Event{}}
+	cat := &fakeCatalog{
+		services: []domain.Service{
+			{ID: "s1", Slug: "api"},
+			{ID: "s2", Slug: "web"},
+		},
+		tags: map[string]domain.ServiceTag{
+			"s2": {Key: "statuspage_service", Value: "frontend"},
+		},
+	}
+	s := NewService(repo, ev, cat, Config{
+		ServiceLabel:    "statuspage_service",
+		SeverityLabel:   "severity",
+		SeverityMap:     map[string]domain.Severity{"critical": domain.SeverityCritical},
+		DefaultSeverity: domain.SeverityMinor,
+	})
+	return s, repo, ev
+}
+
+func alert(status, service, severity string) Alert {
+	return Alert{
+		Status:      status,
+		Fingerprint: "fp1",
+		Labels: map[string]string{
+			"alertname":          "HighErrorRate",
+			"statuspage_service": service,
+			"severity":           severity,
+		},
+	}
+}
+
+func TestService_HandleAlertsLifecycle(t *testing.T) {
+	s, repo, ev := newTestService()
+	ctx := context.Background()
+
+	result, err := s.HandleAlerts(ctx, []Alert{alert(AlertStatusFiring, "api, frontend", "critical")})
+	if err != nil {
+		t.Fatalf("HandleAlerts(firing) error = %v", err)
+	}
+	if result.Created != 1 {
+		t.Fatalf("created = %d, want 1", result.Created)
+	}
+
+	event := ev.events[repo.alerts["fp1"]]
+	if event == nil {
+		t.Fatal("alert is not linked to the incident")
+	}
+	if event.Title != "HighErrorRate" {
+		t.Errorf("title = %q, want alertname", event.Title)
+	}
+	if *event.Severity != domain.SeverityCritical {
+		t.Errorf("severity = %q, want critical", *event.Severity)
+	}
+	if len(event.ServiceIDs) != 2 {
+		t.Errorf("services = %v, want slug and tag matches", event.ServiceIDs)
+	}
+
+	result, err = s.HandleAlerts(ctx, []Alert{alert(AlertStatusFiring, "api", "critical")})
+	if err != nil {
+		t.Fatalf("HandleAlerts(repeat) error = %v", err)
+	}
+	if result.Updated != 1 || len(ev.events) != 1 {
+		t.Errorf("repeated firing: result = %+v, events = %d; want one update and no new incident", result, len(ev.events))
+	}
+
+	result, err = s.HandleAlerts(ctx, []Alert{alert(AlertStatusResolved, "api", "critical")})
+	if err != nil {
+		t.Fatalf("HandleAlerts(resolved) error = %v", err)
+	}
+	if result.Resolved != 1 || event.Status != domain.EventStatusResolved {
+		t.Errorf("resolved: result = %+v, status = %q", result, event.Status)
+	}
+	if _, ok := repo.alerts["fp1"]; ok {
+		t.Error("resolved alert must be unlinked")
+	}
+}
+
+func TestService_HandleAlertsReopensAfterManualResolve(t *testing.T) {
+	s, repo, ev := newTestService()
+	ctx := context.Background()
+
+	if _, err := s.HandleAlerts(ctx, []Alert{alert(AlertStatusFiring, "api", "warning")}); err != nil {
+		t.Fatalf("HandleAlerts() error = %v", err)
+	}
+	first := ev.events[repo.alerts["fp1"]]
+	if *first.Severity != domain.SeverityMinor {
+		t.Errorf("unmapped severity = %q, want default minor", *first.Severity)
+	}
+	first.Status = domain.EventStatusResolved
+
+	result, err := s.HandleAlerts(ctx, []Alert{alert(AlertStatusFiring, "api", "warning")})
+	if err != nil {
+		t.Fatalf("HandleAlerts() error = %v", err)
+	}
+	if result.Created != 1 || repo.alerts["fp1"] == first.ID {
+		t.Errorf("firing after manual resolve must open a new incident, result = %+v", result)
+	}
+}
+
+func TestService_HandleAlertsConcurrentFiring(t *testing.T) {
+	s, repo, ev := newTestService()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.HandleAlerts(context.Background(), []Alert{alert(AlertStatusFiring, "api", "critical")})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("HandleAlerts() error = %v", err)
+		}
+	}
+	if len(ev.events) != 1 {
+		t.Errorf("events = %d, want a single incident for concurrent deliveries", len(ev.events))
+	}
+	if _, ok := ev.events[repo.alerts["fp1"]]; !ok {
+		t.Error("alert is not linked to the incident")
+	}
+}
+
+func TestService_HandleAlertsSkipsUnknown(t *testing.T) {
+	s, repo, ev := newTestService()
+
+	result, err := s.HandleAlerts(context.Background(), []Alert{
+		alert(AlertStatusFiring, "unknown", "critical"),
+		{Status: AlertStatusResolved, Fingerprint: "fp2"},
+	})
+	if err != nil {
+		t.Fatalf("HandleAlerts() error = %v", err)
+	}
+	if result.Skipped != 2 || len(ev.events) != 0 || len(repo.alerts) != 0 {
+		t.Errorf("result = %+v, events = %d; want both alerts skipped", result, len(ev.events))
+	}
+}
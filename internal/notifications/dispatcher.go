@@ -2,20 +2,64 @@ package notifications
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 )
 
-// Dispatcher sends notifications to subscribers.
+// DispatcherConfig holds outbox worker settings.
+type DispatcherConfig struct {
+	// Workers is the number of concurrent outbox workers.
+	Workers int
+	// BatchSize is how many deliveries a worker claims at once.
+	BatchSize int
+	// PollInterval is how long an idle worker waits before polling again.
+	PollInterval time.Duration
+	// MaxAttempts is the number of send attempts before a delivery is dead-lettered.
+	MaxAttempts int
+	// RetryBaseDelay is the delay after the first failed attempt; it doubles on every retry.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the retry delay.
+	RetryMaxDelay time.Duration
+	// Lease is how long a claimed delivery stays locked before another worker may reclaim it.
+	Lease time.Duration
+}
+
+// Dispatcher queues notifications in the outbox and delivers them in the background.
 type Dispatcher struct {
 	repo    Repository
 	senders map[domain.ChannelType]Sender
+	config  DispatcherConfig
 }
 
 // NewDispatcher creates a new notification dispatcher.
-func NewDispatcher(repo Repository, senders ...Sender) *Dispatcher {
+func NewDispatcher(repo Repository, config DispatcherConfig, senders ...Sender) *Dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 10
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.RetryBaseDelay <= 0 {
+		config.RetryBaseDelay = 30 * time.Second
+	}
+	if config.RetryMaxDelay <= 0 {
+		config.RetryMaxDelay = time.Hour
+	}
+	if config.Lease <= 0 {
+		config.Lease = 10 * time.Minute
+	}
+
 	senderMap := make(map[domain.ChannelType]Sender)
 	for _, s := range senders {
 		senderMap[s.Type()] = s
@@ -23,6 +67,7 @@ func NewDispatcher(repo Repository, senders ...Sender) *Dispatcher {
 	return &Dispatcher{
 		repo:    repo,
 		senders: senderMap,
+		config:  config,
 	}
 }
 
@@ -31,47 +76,234 @@ type DispatchInput struct {
 	ServiceIDs []string
 	Subject    string
 	Body       string
+	// Level defaults to info.
+	Level domain.NotificationLevel
+	Link  string
 }
 
-// Dispatch sends notifications to all subscribers of the given services.
+// Dispatch enqueues notifications for all subscribers of the given services.
 func (d *Dispatcher) Dispatch(ctx context.Context, input DispatchInput) error {
 	subscribers, err := d.repo.GetSubscribersForServices(ctx, input.ServiceIDs)
 	if err != nil {
 		return fmt.Errorf("get subscribers: %w", err)
 	}
 
-	slog.Info("dispatching notifications",
-		"service_ids", input.ServiceIDs,
-		"subscriber_count", len(subscribers),
-	)
+	level := input.Level
+	if level == "" {
+		level = domain.NotificationLevelInfo
+	}
 
+	deliveries := make([]*domain.NotificationDelivery, 0)
 	for _, sub := range subscribers {
 		for _, channel := range sub.Channels {
 			if !channel.IsEnabled || !channel.IsVerified {
 				continue
 			}
 
-			sender, ok := d.senders[channel.Type]
-			if !ok {
+			if _, ok := d.senders[channel.Type]; !ok {
 				slog.Warn("no sender for channel type", "type", channel.Type)
 				continue
 			}
 
-			notification := Notification{
-				To:      channel.Target,
-				Subject: input.Subject,
-				Body:    input.Body,
-			}
+			deliveries = append(deliveries, &domain.NotificationDelivery{
+				ChannelID:   channel.ID,
+				ChannelType: channel.Type,
+				Target:      channel.Target,
+				Subject:     input.Subject,
+				Body:        input.Body,
+				Level:       level,
+				Link:        input.Link,
+			})
+		}
+	}
+
+	if err := d.repo.EnqueueDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("enqueue deliveries: %w", err)
+	}
+
+	slog.Info("notifications enqueued",
+		"service_ids", input.ServiceIDs,
+		"subscriber_count", len(subscribers),
+		"delivery_count", len(deliveries),
+	)
+
+	return nil
+}
+
+// Enqueue queues a single notification for a specific channel regardless of its verification state.
+func (d *Dispatcher) Enqueue(ctx context.Context, channel domain.NotificationChannel, subject, body string) error {
+	if _, ok := d.senders[channel.Type]; !ok {
+		return fmt.Errorf("no sender for channel type %s", channel.Type)
+	}
+
+	delivery := &domain.NotificationDelivery{
+		ChannelID:   channel.ID,
+		ChannelType: channel.Type,
+		Target:      channel.Target,
+		Subject:     subject,
+		Body:        body,
+		Level:       domain.NotificationLevelInfo,
+	}
+	if err := d.repo.EnqueueDeliveries(ctx, []*domain.NotificationDelivery{delivery}); err != nil {
+		return fmt.Errorf("enqueue delivery: %w", err)
+	}
+	return nil
+}
+
+// Run starts outbox workers and blocks until ctx is cancelled and all workers have stopped.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for {
+		processed, err := d.ProcessBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("failed to process notification outbox", "error", err)
+		}
 
-			if err := sender.Send(ctx, notification); err != nil {
-				slog.Error("failed to send notification",
-					"channel_type", channel.Type,
-					"target", channel.Target,
-					"error", err,
-				)
+		// Keep draining while there is work, otherwise wait for the next poll.
+		if processed > 0 && err == nil {
+			if ctx.Err() != nil {
+				return
 			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.config.PollInterval):
 		}
 	}
+}
 
-	return nil
+// ProcessBatch claims one batch of due deliveries and attempts to send them.
+// It returns the number of claimed deliveries.
+func (d *Dispatcher) ProcessBatch(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.ClaimDeliveries(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		d.deliver(ctx, delivery)
+	}
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *domain.NotificationDelivery) {
+	sender, ok := d.senders[delivery.ChannelType]
+	if !ok {
+		d.markFailed(ctx, delivery, fmt.Sprintf("no sender for channel type %s", delivery.ChannelType))
+		return
+	}
+
+	err := sender.Send(ctx, Notification{
+		To:      delivery.Target,
+		Subject: delivery.Subject,
+		Body:    delivery.Body,
+		Level:   delivery.Level,
+		Link:    delivery.Link,
+	})
+
+	// Record the outcome even if the worker is being stopped.
+	ctx = context.WithoutCancel(ctx)
+
+	if err == nil {
+		if err := d.repo.MarkDeliverySent(ctx, delivery); err != nil {
+			logOutcomeError("failed to mark delivery sent", delivery.ID, err)
+		}
+		return
+	}
+
+	slog.Warn("failed to send notification",
+		"delivery_id", delivery.ID,
+		"channel_type", delivery.ChannelType,
+		"attempt", delivery.Attempts,
+		"error", err,
+	)
+
+	switch {
+	case errors.Is(err, ErrRecipientUnreachable):
+		// Retrying will not help: disable the channel and dead-letter the delivery.
+		d.disableChannel(ctx, delivery.ChannelID)
+		d.markFailed(ctx, delivery, err.Error())
+	case delivery.Attempts >= d.config.MaxAttempts:
+		d.markFailed(ctx, delivery, err.Error())
+	default:
+		delay := retryDelay(delivery.Attempts, d.config.RetryBaseDelay, d.config.RetryMaxDelay)
+		if err := d.repo.MarkDeliveryRetry(ctx, delivery, delay, err.Error()); err != nil {
+			logOutcomeError("failed to schedule delivery retry", delivery.ID, err)
+		}
+	}
+}
+
+func (d *Dispatcher) markFailed(ctx context.Context, delivery *domain.NotificationDelivery, reason string) {
+	if err := d.repo.MarkDeliveryFailed(ctx, delivery, reason); err != nil {
+		logOutcomeError("failed to mark delivery failed", delivery.ID, err)
+		return
+	}
+
+	slog.Error("notification delivery dead-lettered",
+		"delivery_id", delivery.ID,
+		"channel_id", delivery.ChannelID,
+		"attempts", delivery.Attempts,
+		"reason", reason,
+	)
+}
+
+// logOutcomeError logs a failure to record the outcome of a delivery. A lost
+// lease is expected when sending outlasted it: the worker that reclaimed the
+// delivery records its own outcome.
+func logOutcomeError(msg, deliveryID string, err error) {
+	if errors.Is(err, ErrDeliveryLeaseLost) {
+		slog.Warn(msg, "delivery_id", deliveryID, "error", err)
+		return
+	}
+	slog.Error(msg, "delivery_id", deliveryID, "error", err)
+}
+
+// disableChannel turns off a channel whose recipient can no longer be reached.
+func (d *Dispatcher) disableChannel(ctx context.Context, channelID string) {
+	channel, err := d.repo.GetChannelByID(ctx, channelID)
+	if err != nil {
+		slog.Error("failed to load unreachable channel", "channel_id", channelID, "error", err)
+		return
+	}
+
+	channel.IsEnabled = false
+	if err := d.repo.UpdateChannel(ctx, channel); err != nil {
+		slog.Error("failed to disable unreachable channel",
+			"channel_id", channel.ID,
+			"error", err,
+		)
+		return
+	}
+
+	slog.Warn("notification channel disabled: recipient unreachable",
+		"channel_id", channel.ID,
+		"channel_type", channel.Type,
+	)
+}
+
+// retryDelay returns the exponential backoff delay after the given attempt number.
+func retryDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return min(delay, maxDelay)
 }
@@ -0,0 +1,307 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// fakeOutboxRepo keeps the outbox in memory. Methods not used by the
+// dispatcher panic through the embedded nil Repository.
+type fakeOutboxRepo struct {
+	Repository
+
+	subscribers []SubscriberInfo
+	channels    map[string]*domain.NotificationChannel
+	deliveries  []*domain.NotificationDelivery
+	retryDelays map[string]time.Duration
+}
+
+func newFakeOutboxRepo() *fakeOutboxRepo {
+	return &fakeOutboxRepo{
+		channels:    map[string]*domain.NotificationChannel{},
+		retryDelays: map[string]time.Duration{},
+	}
+}
+
+func (f *fakeOutboxRepo) GetSubscribersForServices(_ context.Context, _ []string) ([]SubscriberInfo, error) {
+	return f.subscribers, nil
+}
+
+func (f *fakeOutboxRepo) EnqueueDeliveries(_ context.Context, deliveries []*domain.NotificationDelivery) error {
+	for _, d := range deliveries {
+		d.ID = fmt.Sprintf("d-%d", len(f.deliveries)+1)
+		d.Status = domain.DeliveryStatusPending
+		f.deliveries = append(f.deliveries, d)
+	}
+	return nil
+}
+
+func (f *fakeOutboxRepo) ClaimDeliveries(_ context.Context, limit int, lease time.Duration) ([]*domain.NotificationDelivery, error) {
+	claimed := make([]*domain.NotificationDelivery, 0)
+	for _, d := range f.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == domain.DeliveryStatusPending {
+			lockedUntil := time.Now().Add(lease)
+			d.Status = domain.DeliveryStatusProcessing
+			d.Attempts++
+			d.LockedUntil = &lockedUntil
+			c := *d
+			claimed = append(claimed, &c)
+		}
+	}
+	return claimed, nil
+}
+
+func (f *fakeOutboxRepo) find(id string) *domain.NotificationDelivery {
+	for _, d := range f.deliveries {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// leased returns the stored delivery if the claim of delivery still holds it.
+func (f *fakeOutboxRepo) leased(delivery *domain.NotificationDelivery) (*domain.NotificationDelivery, error) {
+	d := f.find(delivery.ID)
+	if d == nil || d.Status != domain.DeliveryStatusProcessing || d.LockedUntil == nil ||
+		delivery.LockedUntil == nil || !d.LockedUntil.Equal(*delivery.LockedUntil) {
+		return nil, ErrDeliveryLeaseLost
+	}
+	return d, nil
+}
+
+func (f *fakeOutboxRepo) MarkDeliverySent(_ context.Context, delivery *domain.NotificationDelivery) error {
+	d, err := f.leased(delivery)
+	if err != nil {
+		return err
+	}
+	d.Status = domain.DeliveryStatusSent
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkDeliveryRetry(_ context.Context, delivery *domain.NotificationDelivery, delay time.Duration, lastError string) error {
+	d, err := f.leased(delivery)
+	if err != nil {
+		return err
+	}
+	d.Status = domain.DeliveryStatusPending
+	d.LastError = &lastError
+	f.retryDelays[d.ID] = delay
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkDeliveryFailed(_ context.Context, delivery *domain.NotificationDelivery, lastError string) error {
+	d, err := f.leased(delivery)
+	if err != nil {
+		return err
+	}
+	d.Status = domain.DeliveryStatusFailed
+	d.LastError = &lastError
+	return nil
+}
+
+func (f *fakeOutboxRepo) GetChannelByID(_ context.Context, id string) (*domain.NotificationChannel, error) {
+	ch, ok := f.channels[id]
+	if !ok {
+		return nil, ErrChannelNotFound
+	}
+	c := *ch
+	return &c, nil
+}
+
+func (f *fakeOutboxRepo) UpdateChannel(_ context.Context, channel *domain.NotificationChannel) error {
+	c := *channel
+	f.channels[channel.ID] = &c
+	return nil
+}
+
+type fakeSender struct {
+	channelType domain.ChannelType
+	err         error
+	sent        []Notification
+	// onSend runs before the result is returned, e.g. to let the lease expire.
+	onSend func()
+}
+
+func (s *fakeSender) Send(_ context.Context, n Notification) error {
+	if s.onSend != nil {
+		s.onSend()
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func (s *fakeSender) Type() domain.ChannelType {
+	return s.channelType
+}
+
+func TestDispatcher_DispatchEnqueuesPerChannel(t *testing.T) {
+	repo := newFakeOutboxRepo()
+	repo.subscribers = []SubscriberInfo{
+		{UserID: "u1", Channels: []domain.NotificationChannel{
+			{ID: "c1", Type: domain.ChannelTypeEmail, Target: "a@example.com", IsEnabled: true, IsVerified: true},
+			{ID: "c2", Type: domain.ChannelTypeTelegram, Target: "42", IsEnabled: true, IsVerified: true},
+		}},
+		{UserID: "u2", Channels: []domain.NotificationChannel{
+			{ID: "c3", Type: domain.ChannelTypeEmail, Target: "b@example.com", IsEnabled: false, IsVerified: true},
+			{ID: "c4", Type: domain.ChannelTypeEmail, Target: "c@example.com", IsEnabled: true, IsVerified: true},
+		}},
+	}
+	email := &fakeSender{channelType: domain.ChannelTypeEmail}
+	d := NewDispatcher(repo, DispatcherConfig{}, email)
+
+	err := d.Dispatch(context.Background(), DispatchInput{ServiceIDs: []string{"s1"}, Subject: "subj", Body: "body"})
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+
+	if len(repo.deliveries) != 2 {
+		t.Fatalf("deliveries = %d, want 2 (enabled email channels only)", len(repo.deliveries))
+	}
+	for _, delivery := range repo.deliveries {
+		if delivery.ChannelType != domain.ChannelTypeEmail || delivery.Subject != "subj" || delivery.Body != "body" ||
+			delivery.Level != domain.NotificationLevelInfo {
+			t.Errorf("unexpected delivery %+v", delivery)
+		}
+	}
+	if len(email.sent) != 0 {
+		t.Error("Dispatch must not send synchronously")
+	}
+}
+
+func TestDispatcher_ProcessBatch(t *testing.T) {
+	transient := errors.New("connection refused")
+
+	tests := []struct {
+		name           string
+		sendErr        error
+		attempts       int
+		wantStatus     domain.DeliveryStatus
+		wantRetryDelay time.Duration
+		wantChannelOff bool
+	}{
+		{
+			name:       "sent",
+			wantStatus: domain.DeliveryStatusSent,
+		},
+		{
+			name:           "transient error is retried with backoff",
+			sendErr:        transient,
+			attempts:       2,
+			wantStatus:     domain.DeliveryStatusPending,
+			wantRetryDelay: 4 * time.Second,
+		},
+		{
+			name:       "dead-lettered after max attempts",
+			sendErr:    transient,
+			attempts:   4,
+			wantStatus: domain.DeliveryStatusFailed,
+		},
+		{
+			name:           "unreachable recipient disables channel",
+			sendErr:        fmt.Errorf("%w: blocked", ErrRecipientUnreachable),
+			wantStatus:     domain.DeliveryStatusFailed,
+			wantChannelOff: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeOutboxRepo()
+			repo.channels["c1"] = &domain.NotificationChannel{ID: "c1", Type: domain.ChannelTypeEmail, IsEnabled: true, IsVerified: true}
+			repo.deliveries = []*domain.NotificationDelivery{{
+				ID:          "d-1",
+				ChannelID:   "c1",
+				ChannelType: domain.ChannelTypeEmail,
+				Target:      "a@example.com",
+				Status:      domain.DeliveryStatusPending,
+				Attempts:    tt.attempts,
+			}}
+
+			sender := &fakeSender{channelType: domain.ChannelTypeEmail, err: tt.sendErr}
+			d := NewDispatcher(repo, DispatcherConfig{
+				MaxAttempts:    5,
+				RetryBaseDelay: time.Second,
+				RetryMaxDelay:  time.Minute,
+			}, sender)
+
+			n, err := d.ProcessBatch(context.Background())
+			if err != nil {
+				t.Fatalf("ProcessBatch() error = %v", err)
+			}
+			if n != 1 {
+				t.Fatalf("ProcessBatch() = %d, want 1", n)
+			}
+
+			delivery := repo.deliveries[0]
+			if delivery.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", delivery.Status, tt.wantStatus)
+			}
+			if got := repo.retryDelays["d-1"]; got != tt.wantRetryDelay {
+				t.Errorf("retry delay = %v, want %v", got, tt.wantRetryDelay)
+			}
+			if off := !repo.channels["c1"].IsEnabled; off != tt.wantChannelOff {
+				t.Errorf("channel disabled = %v, want %v", off, tt.wantChannelOff)
+			}
+		})
+	}
+}
+
+func TestDispatcher_ProcessBatchLeaseLost(t *testing.T) {
+	repo := newFakeOutboxRepo()
+	repo.deliveries = []*domain.NotificationDelivery{{
+		ID:          "d-1",
+		ChannelID:   "c1",
+		ChannelType: domain.ChannelTypeEmail,
+		Target:      "a@example.com",
+		Status:      domain.DeliveryStatusPending,
+	}}
+
+	// Another worker reclaims the delivery while this one is still sending.
+	reclaimed := time.Now().Add(time.Hour)
+	sender := &fakeSender{channelType: domain.ChannelTypeEmail, onSend: func() {
+		repo.deliveries[0].LockedUntil = &reclaimed
+	}}
+	d := NewDispatcher(repo, DispatcherConfig{MaxAttempts: 5}, sender)
+
+	if _, err := d.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch() error = %v", err)
+	}
+	if got := repo.deliveries[0].Status; got != domain.DeliveryStatusProcessing {
+		t.Errorf("status = %q, want %q: the new claim owns the outcome", got, domain.DeliveryStatusProcessing)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 30 * time.Second
+	maxDelay := 10 * time.Minute
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt, base, maxDelay); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
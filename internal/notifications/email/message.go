@@ -0,0 +1,115 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+// buildMessage renders an RFC 5322 message with text and HTML alternatives.
+func buildMessage(cfg Config, n notifications.Notification, now time.Time) ([]byte, error) {
+	from := mail.Address{Name: cfg.FromName, Address: cfg.FromAddress}
+	to := mail.Address{Address: n.To}
+
+	messageID, err := newMessageID(cfg.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	if err := writePart(mw, "text/plain; charset=utf-8", n.Body); err != nil {
+		return nil, fmt.Errorf("write text part: %w", err)
+	}
+	if err := writePart(mw, "text/html; charset=utf-8", renderHTML(n.Subject, n.Body)); err != nil {
+		return nil, fmt.Errorf("write html part: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("close multipart: %w", err)
+	}
+
+	var msg bytes.Buffer
+	writeHeader(&msg, "From", from.String())
+	writeHeader(&msg, "To", to.String())
+	writeHeader(&msg, "Subject", mime.QEncoding.Encode("utf-8", n.Subject))
+	writeHeader(&msg, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&msg, "Message-ID", messageID)
+	if cfg.UnsubscribeURL != "" {
+		writeHeader(&msg, "List-Unsubscribe", "<"+cfg.UnsubscribeURL+">")
+	}
+	writeHeader(&msg, "MIME-Version", "1.0")
+	writeHeader(&msg, "Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()))
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+func writePart(mw *multipart.Writer, contentType, content string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	pw, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(pw)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// renderHTML wraps a plain text body into a minimal HTML document.
+func renderHTML(subject, body string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>")
+	b.WriteString(html.EscapeString(subject))
+	b.WriteString("</title></head>\n<body>\n")
+	for _, paragraph := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.Join(lines, "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func newMessageID(fromAddress string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate message id: %w", err)
+	}
+
+	domainPart := "localhost"
+	if at := strings.LastIndex(fromAddress, "@"); at >= 0 && at < len(fromAddress)-1 {
+		domainPart = fromAddress[at+1:]
+	}
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domainPart), nil
+}
@@ -3,12 +3,38 @@ package email
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/notifications"
 )
 
+// TLS modes.
+const (
+	TLSModeNone     = "none"
+	TLSModeStartTLS = "starttls"
+	TLSModeImplicit = "tls"
+)
+
+// Auth mechanisms.
+const (
+	AuthPlain = "plain"
+	AuthLogin = "login"
+)
+
+// Sender errors.
+var (
+	ErrNotConfigured       = errors.New("smtp host is not configured")
+	ErrStartTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+)
+
 // Config holds email sender configuration.
 type Config struct {
 	SMTPHost     string
@@ -16,6 +42,17 @@ type Config struct {
 	SMTPUser     string
 	SMTPPassword string
 	FromAddress  string
+	FromName     string
+
+	// TLSMode is one of "none", "starttls" or "tls" (implicit TLS).
+	TLSMode string
+	// AuthMechanism is one of "plain" or "login".
+	AuthMechanism      string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+
+	// UnsubscribeURL is advertised in the List-Unsubscribe header when set.
+	UnsubscribeURL string
 }
 
 // Sender implements email notification sender.
@@ -25,6 +62,18 @@ type Sender struct {
 
 // NewSender creates a new email sender.
 func NewSender(config Config) *Sender {
+	if config.SMTPPort == 0 {
+		config.SMTPPort = 587
+	}
+	if config.TLSMode == "" {
+		config.TLSMode = TLSModeStartTLS
+	}
+	if config.AuthMechanism == "" {
+		config.AuthMechanism = AuthPlain
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
 	return &Sender{config: config}
 }
 
@@ -34,11 +83,152 @@ func (s *Sender) Type() domain.ChannelType {
 }
 
 // Send sends an email notification.
-func (s *Sender) Send(_ context.Context, notification notifications.Notification) error {
-	slog.Info("sending email notification",
+func (s *Sender) Send(ctx context.Context, notification notifications.Notification) error {
+	if s.config.SMTPHost == "" {
+		return ErrNotConfigured
+	}
+
+	msg, err := buildMessage(s.config, notification, time.Now())
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
+
+	client, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if err := client.Mail(s.config.FromAddress); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := client.Rcpt(notification.To); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data close: %w", err)
+	}
+
+	if err := client.Quit(); err != nil {
+		slog.Warn("smtp quit failed", "error", err)
+	}
+
+	slog.Info("email notification sent",
 		"to", notification.To,
 		"subject", notification.Subject,
 	)
 
 	return nil
 }
+
+// connect dials the SMTP server, negotiates TLS and authenticates.
+func (s *Sender) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.config.SMTPHost, strconv.Itoa(s.config.SMTPPort))
+	tlsConfig := &tls.Config{
+		ServerName:         s.config.SMTPHost,
+		InsecureSkipVerify: s.config.InsecureSkipVerify,
+	}
+
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var conn net.Conn
+	var err error
+	if s.config.TLSMode == TLSModeImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dial smtp %s: %w", addr, err)
+	}
+
+	deadline := time.Now().Add(s.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("set deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.SMTPHost)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("smtp handshake: %w", err)
+	}
+
+	if s.config.TLSMode == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			_ = client.Close()
+			return nil, ErrStartTLSUnsupported
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	if s.config.SMTPUser != "" {
+		if err := client.Auth(s.auth()); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (s *Sender) auth() smtp.Auth {
+	if s.config.AuthMechanism == AuthLogin {
+		return &loginAuth{
+			username: s.config.SMTPUser,
+			password: s.config.SMTPPassword,
+			host:     s.config.SMTPHost,
+		}
+	}
+	return smtp.PlainAuth("", s.config.SMTPUser, s.config.SMTPPassword, s.config.SMTPHost)
+}
+
+// loginAuth implements the LOGIN authentication mechanism which net/smtp lacks.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch string(fromServer) {
+	case "Username:", "User Name\x00":
+		return []byte(a.username), nil
+	case "Password:", "Password\x00":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge: %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
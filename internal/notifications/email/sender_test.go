@@ -0,0 +1,427 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+// fakeSMTPServer is a minimal SMTP server that accepts a single message per connection.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	implicit  bool
+
+	mu       sync.Mutex
+	username string
+	password string
+	mech     string
+	usedTLS  bool
+	from     string
+	rcpt     string
+	data     string
+}
+
+func newFakeSMTPServer(t *testing.T, tlsConfig *tls.Config, implicit bool) *fakeSMTPServer {
+	t.Helper()
+
+	var ln net.Listener
+	var err error
+	if implicit {
+		ln, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	s := &fakeSMTPServer{listener: ln, tlsConfig: tlsConfig, implicit: implicit}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	isTLS := s.implicit
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake.smtp ESMTP ready")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			lines := []string{"250-fake.smtp", "250-AUTH PLAIN LOGIN"}
+			if s.tlsConfig != nil && !isTLS {
+				lines = append(lines, "250-STARTTLS")
+			}
+			lines = append(lines, "250 8BITMIME")
+			for _, l := range lines {
+				_ = tp.PrintfLine("%s", l)
+			}
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			isTLS = true
+		case "AUTH":
+			s.handleAuth(tp, line, isTLS)
+		case "MAIL":
+			s.mu.Lock()
+			s.from = extractAddress(line)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpt = extractAddress(line)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) handleAuth(tp *textproto.Conn, line string, isTLS bool) {
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		_ = tp.PrintfLine("501 syntax error")
+		return
+	}
+
+	s.mu.Lock()
+	s.usedTLS = isTLS
+	s.mech = strings.ToUpper(parts[1])
+	s.mu.Unlock()
+
+	switch strings.ToUpper(parts[1]) {
+	case "PLAIN":
+		var payload string
+		if len(parts) == 3 {
+			payload = parts[2]
+		} else {
+			_ = tp.PrintfLine("334 ")
+			payload, _ = tp.ReadLine()
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(payload)
+		fields := strings.Split(string(decoded), "\x00")
+		if len(fields) == 3 {
+			s.mu.Lock()
+			s.username, s.password = fields[1], fields[2]
+			s.mu.Unlock()
+		}
+	case "LOGIN":
+		_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+		user, _ := tp.ReadLine()
+		_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+		pass, _ := tp.ReadLine()
+		u, _ := base64.StdEncoding.DecodeString(user)
+		p, _ := base64.StdEncoding.DecodeString(pass)
+		s.mu.Lock()
+		s.username, s.password = string(u), string(p)
+		s.mu.Unlock()
+	default:
+		_ = tp.PrintfLine("504 unrecognized mechanism")
+		return
+	}
+	_ = tp.PrintfLine("235 authenticated")
+}
+
+func extractAddress(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	tlsConfig := selfSignedTLSConfig(t)
+
+	tests := []struct {
+		name     string
+		server   func(t *testing.T) *fakeSMTPServer
+		tlsMode  string
+		authMech string
+		wantMech string
+		wantTLS  bool
+	}{
+		{
+			name:     "plain auth without tls",
+			server:   func(t *testing.T) *fakeSMTPServer { return newFakeSMTPServer(t, nil, false) },
+			tlsMode:  TLSModeNone,
+			authMech: AuthPlain,
+			wantMech: "PLAIN",
+		},
+		{
+			name:     "login auth without tls",
+			server:   func(t *testing.T) *fakeSMTPServer { return newFakeSMTPServer(t, nil, false) },
+			tlsMode:  TLSModeNone,
+			authMech: AuthLogin,
+			wantMech: "LOGIN",
+		},
+		{
+			name:     "starttls with plain auth",
+			server:   func(t *testing.T) *fakeSMTPServer { return newFakeSMTPServer(t, tlsConfig, false) },
+			tlsMode:  TLSModeStartTLS,
+			authMech: AuthPlain,
+			wantMech: "PLAIN",
+			wantTLS:  true,
+		},
+		{
+			name:     "implicit tls with login auth",
+			server:   func(t *testing.T) *fakeSMTPServer { return newFakeSMTPServer(t, tlsConfig, true) },
+			tlsMode:  TLSModeImplicit,
+			authMech: AuthLogin,
+			wantMech: "LOGIN",
+			wantTLS:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.server(t)
+
+			sender := NewSender(Config{
+				SMTPHost:           "127.0.0.1",
+				SMTPPort:           server.port(),
+				SMTPUser:           "mailer",
+				SMTPPassword:       "secret",
+				FromAddress:        "status@example.com",
+				FromName:           "Status Page",
+				TLSMode:            tt.tlsMode,
+				AuthMechanism:      tt.authMech,
+				InsecureSkipVerify: true,
+				Timeout:            5 * time.Second,
+			})
+
+			err := sender.Send(context.Background(), notifications.Notification{
+				To:      "user@example.com",
+				Subject: "Incident: API down",
+				Body:    "We are investigating.",
+			})
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+
+			if server.mech != tt.wantMech {
+				t.Errorf("auth mechanism = %q, want %q", server.mech, tt.wantMech)
+			}
+			if server.usedTLS != tt.wantTLS {
+				t.Errorf("auth over TLS = %v, want %v", server.usedTLS, tt.wantTLS)
+			}
+			if server.username != "mailer" || server.password != "secret" {
+				t.Errorf("credentials = %q/%q, want mailer/secret", server.username, server.password)
+			}
+			if server.from != "status@example.com" {
+				t.Errorf("MAIL FROM = %q, want status@example.com", server.from)
+			}
+			if server.rcpt != "user@example.com" {
+				t.Errorf("RCPT TO = %q, want user@example.com", server.rcpt)
+			}
+			if server.data == "" {
+				t.Error("no message data received")
+			}
+		})
+	}
+}
+
+func TestSender_Send_StartTLSUnsupported(t *testing.T) {
+	server := newFakeSMTPServer(t, nil, false)
+
+	sender := NewSender(Config{
+		SMTPHost:    "127.0.0.1",
+		SMTPPort:    server.port(),
+		FromAddress: "status@example.com",
+		TLSMode:     TLSModeStartTLS,
+		Timeout:     5 * time.Second,
+	})
+
+	err := sender.Send(context.Background(), notifications.Notification{To: "user@example.com"})
+	if err != ErrStartTLSUnsupported {
+		t.Errorf("Send() error = %v, want %v", err, ErrStartTLSUnsupported)
+	}
+}
+
+func TestSender_Send_NotConfigured(t *testing.T) {
+	sender := NewSender(Config{})
+
+	err := sender.Send(context.Background(), notifications.Notification{To: "user@example.com"})
+	if err != ErrNotConfigured {
+		t.Errorf("Send() error = %v, want %v", err, ErrNotConfigured)
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	cfg := Config{
+		FromAddress:    "status@example.com",
+		FromName:       "Status Page",
+		UnsubscribeURL: "https://status.example.com/unsubscribe",
+	}
+
+	raw, err := buildMessage(cfg, notifications.Notification{
+		To:      "user@example.com",
+		Subject: "Инцидент <API>",
+		Body:    "First line\nsecond & line\n\nNext paragraph",
+	}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	if subject != "Инцидент <API>" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if got := msg.Header.Get("Message-ID"); !strings.HasPrefix(got, "<") || !strings.HasSuffix(got, "@example.com>") {
+		t.Errorf("Message-ID = %q", got)
+	}
+	if got := msg.Header.Get("List-Unsubscribe"); got != "<https://status.example.com/unsubscribe>" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if got := msg.Header.Get("List-Unsubscribe-Post"); got != "" {
+		t.Errorf("List-Unsubscribe-Post = %q, want none without a one-click endpoint", got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parse content type: %v", err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", mediaType)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	parts := map[string]string{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		ct, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[ct] = string(content)
+	}
+
+	if !strings.Contains(parts["text/plain"], "second & line") {
+		t.Errorf("text part = %q", parts["text/plain"])
+	}
+	html := strings.ReplaceAll(parts["text/html"], "\r\n", "\n")
+	if !strings.Contains(html, "First line<br>\nsecond &amp; line") {
+		t.Errorf("html part = %q", parts["text/html"])
+	}
+	if strings.Count(parts["text/html"], "<p>") != 2 {
+		t.Errorf("html part should contain two paragraphs: %q", parts["text/html"])
+	}
+}
+
+func TestLoginAuth_RejectsUnencryptedRemote(t *testing.T) {
+	auth := &loginAuth{username: "u", password: "p", host: "smtp.example.com"}
+	if _, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.example.com"}); err == nil {
+		t.Error("expected error for unencrypted non-local connection")
+	}
+	if mech, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.example.com", TLS: true}); err != nil || mech != "LOGIN" {
+		t.Errorf("Start() = %q, %v", mech, err)
+	}
+}
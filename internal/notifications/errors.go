@@ -6,4 +6,14 @@ import "errors"
 var (
 	ErrChannelNotFound      = errors.New("notification channel not found")
 	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrVerificationNotFound = errors.New("verification code not found")
+	ErrDeliveryNotFound     = errors.New("notification delivery not found")
+	// ErrDeliveryLeaseLost means the lease on a delivery expired and another
+	// worker may have claimed it, so the outcome was not recorded.
+	ErrDeliveryLeaseLost = errors.New("notification delivery lease lost")
 )
+
+// ErrRecipientUnreachable is returned by senders when the target can no longer
+// receive messages (e.g. the user blocked the bot). The dispatcher disables
+// channels that fail with this error.
+var ErrRecipientUnreachable = errors.New("notification recipient is unreachable")
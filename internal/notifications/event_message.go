@@ -0,0 +1,121 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// EventMessage describes an event change to announce to subscribers.
+type EventMessage struct {
+	Event *domain.Event
+	// Update is the status update being announced, nil for a new event.
+	Update *domain.EventUpdate
+	// ServicesAdded marks an announcement about services added to the event.
+	ServicesAdded bool
+	// StartingSoon marks a reminder about an upcoming maintenance.
+	StartingSoon bool
+}
+
+// RenderEventMessage renders subject and plain text body for an event change.
+// Link is appended to the body when not empty.
+func RenderEventMessage(msg EventMessage, link string) (string, string) {
+	event := msg.Event
+
+	status := event.Status
+	text := event.Description
+	if msg.Update != nil {
+		status = msg.Update.Status
+		text = msg.Update.Message
+	}
+
+	var subject string
+	switch {
+	case msg.ServicesAdded:
+		subject = fmt.Sprintf("[%s] %s: more services affected", typeLabel(event.Type), event.Title)
+	case msg.StartingSoon:
+		subject = fmt.Sprintf("[%s] Starting soon: %s", typeLabel(event.Type), event.Title)
+	case msg.Update != nil:
+		subject = fmt.Sprintf("[%s] %s: %s", typeLabel(event.Type), event.Title, statusLabel(status))
+	case event.Type == domain.EventTypeMaintenance:
+		subject = fmt.Sprintf("[%s] Scheduled: %s", typeLabel(event.Type), event.Title)
+	default:
+		subject = fmt.Sprintf("[%s] New: %s", typeLabel(event.Type), event.Title)
+	}
+
+	var b strings.Builder
+	b.WriteString(event.Title)
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Status: %s\n", statusLabel(status))
+	if event.Severity != nil {
+		fmt.Fprintf(&b, "Severity: %s\n", capitalize(string(*event.Severity)))
+	}
+	if event.ScheduledStartAt != nil && event.ScheduledEndAt != nil {
+		fmt.Fprintf(&b, "Window: %s - %s\n",
+			event.ScheduledStartAt.UTC().Format(time.RFC1123),
+			event.ScheduledEndAt.UTC().Format(time.RFC1123),
+		)
+	}
+
+	if text = strings.TrimSpace(text); text != "" {
+		b.WriteString("\n")
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+
+	if link != "" {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "Details: %s\n", link)
+	}
+
+	return subject, b.String()
+}
+
+// EventLevel classifies an event change: finished events are resolved, maintenance is
+// maintenance, and incidents take their severity.
+func EventLevel(msg EventMessage) domain.NotificationLevel {
+	event := msg.Event
+
+	status := event.Status
+	if msg.Update != nil {
+		status = msg.Update.Status
+	}
+
+	switch {
+	case status.IsResolved():
+		return domain.NotificationLevelResolved
+	case event.Type == domain.EventTypeMaintenance:
+		return domain.NotificationLevelMaintenance
+	case event.Severity == nil:
+		return domain.NotificationLevelInfo
+	}
+
+	switch *event.Severity {
+	case domain.SeverityCritical:
+		return domain.NotificationLevelCritical
+	case domain.SeverityMajor:
+		return domain.NotificationLevelMajor
+	default:
+		return domain.NotificationLevelMinor
+	}
+}
+
+func typeLabel(t domain.EventType) string {
+	if t == domain.EventTypeMaintenance {
+		return "Maintenance"
+	}
+	return "Incident"
+}
+
+func statusLabel(s domain.EventStatus) string {
+	return capitalize(strings.ReplaceAll(string(s), "_", " "))
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,151 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+func TestRenderEventMessage(t *testing.T) {
+	major := domain.SeverityMajor
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	incident := &domain.Event{
+		ID:          "ev-1",
+		Title:       "API Gateway Downtime",
+		Type:        domain.EventTypeIncident,
+		Status:      domain.EventStatusInvestigating,
+		Severity:    &major,
+		Description: "We are investigating.",
+	}
+	maintenance := &domain.Event{
+		ID:               "ev-2",
+		Title:            "Database upgrade",
+		Type:             domain.EventTypeMaintenance,
+		Status:           domain.EventStatusScheduled,
+		ScheduledStartAt: &start,
+		ScheduledEndAt:   &end,
+	}
+
+	tests := []struct {
+		name          string
+		msg           EventMessage
+		link          string
+		wantSubject   string
+		wantInBody    []string
+		wantNotInBody []string
+	}{
+		{
+			name:        "new incident",
+			msg:         EventMessage{Event: incident},
+			link:        "https://status.example.com/events/ev-1",
+			wantSubject: "[Incident] New: API Gateway Downtime",
+			wantInBody: []string{
+				"Status: Investigating",
+				"Severity: Major",
+				"We are investigating.",
+				"Details: https://status.example.com/events/ev-1",
+			},
+		},
+		{
+			name: "incident update",
+			msg: EventMessage{
+				Event:  incident,
+				Update: &domain.EventUpdate{Status: domain.EventStatusResolved, Message: "Fixed."},
+			},
+			wantSubject:   "[Incident] API Gateway Downtime: Resolved",
+			wantInBody:    []string{"Status: Resolved", "Fixed."},
+			wantNotInBody: []string{"We are investigating.", "Details:"},
+		},
+		{
+			name:        "services added",
+			msg:         EventMessage{Event: incident, ServicesAdded: true},
+			wantSubject: "[Incident] API Gateway Downtime: more services affected",
+		},
+		{
+			name:        "scheduled maintenance",
+			msg:         EventMessage{Event: maintenance},
+			wantSubject: "[Maintenance] Scheduled: Database upgrade",
+			wantInBody:  []string{"Window: Sun, 01 Mar 2026 10:00:00 UTC - Sun, 01 Mar 2026 12:00:00 UTC"},
+		},
+		{
+			name:        "maintenance starting soon",
+			msg:         EventMessage{Event: maintenance, StartingSoon: true},
+			wantSubject: "[Maintenance] Starting soon: Database upgrade",
+			wantInBody:  []string{"Status: Scheduled", "Window: Sun, 01 Mar 2026 10:00:00 UTC"},
+		},
+		{
+			name: "maintenance in progress",
+			msg: EventMessage{
+				Event:  maintenance,
+				Update: &domain.EventUpdate{Status: domain.EventStatusInProgress},
+			},
+			wantSubject: "[Maintenance] Database upgrade: In progress",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, body := RenderEventMessage(tt.msg, tt.link)
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantInBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body does not contain %q:\n%s", want, body)
+				}
+			}
+			for _, unwanted := range tt.wantNotInBody {
+				if strings.Contains(body, unwanted) {
+					t.Errorf("body should not contain %q:\n%s", unwanted, body)
+				}
+			}
+		})
+	}
+}
+
+func TestEventLevel(t *testing.T) {
+	critical := domain.SeverityCritical
+	minor := domain.SeverityMinor
+
+	tests := []struct {
+		name string
+		msg  EventMessage
+		want domain.NotificationLevel
+	}{
+		{
+			name: "incident takes its severity",
+			msg:  EventMessage{Event: &domain.Event{Type: domain.EventTypeIncident, Status: domain.EventStatusInvestigating, Severity: &critical}},
+			want: domain.NotificationLevelCritical,
+		},
+		{
+			name: "incident without severity",
+			msg:  EventMessage{Event: &domain.Event{Type: domain.EventTypeIncident, Status: domain.EventStatusInvestigating}},
+			want: domain.NotificationLevelInfo,
+		},
+		{
+			name: "resolving update",
+			msg: EventMessage{
+				Event:  &domain.Event{Type: domain.EventTypeIncident, Status: domain.EventStatusMonitoring, Severity: &minor},
+				Update: &domain.EventUpdate{Status: domain.EventStatusResolved},
+			},
+			want: domain.NotificationLevelResolved,
+		},
+		{
+			name: "maintenance",
+			msg:  EventMessage{Event: &domain.Event{Type: domain.EventTypeMaintenance, Status: domain.EventStatusScheduled}},
+			want: domain.NotificationLevelMaintenance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EventLevel(tt.msg); got != tt.want {
+				t.Errorf("EventLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
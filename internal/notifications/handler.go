@@ -5,6 +5,8 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/pkg/httputil"
@@ -34,6 +36,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Patch("/{id}", h.UpdateChannel)
 		r.Delete("/{id}", h.DeleteChannel)
 		r.Post("/{id}/verify", h.VerifyChannel)
+		r.Post("/{id}/verify/resend", h.ResendVerification)
 	})
 
 	r.Route("/me/subscriptions", func(r chi.Router) {
@@ -43,15 +46,30 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 	})
 }
 
+// RegisterAdminRoutes registers outbox management routes (require admin).
+func (h *Handler) RegisterAdminRoutes(r chi.Router) {
+	r.Route("/notifications/deliveries", func(r chi.Router) {
+		r.Get("/", h.ListDeliveries)
+		r.Delete("/", h.PurgeDeliveries)
+		r.Post("/{id}/retry", h.RetryDelivery)
+	})
+}
+
 // CreateChannelRequest represents request body for creating a channel.
 type CreateChannelRequest struct {
-	Type   string `json:"type" validate:"required,oneof=email telegram"`
+	Type   string `json:"type" validate:"required,oneof=email telegram slack msteams"`
 	Target string `json:"target" validate:"required"`
 }
 
 // UpdateChannelRequest represents request body for updating a channel.
 type UpdateChannelRequest struct {
-	IsEnabled bool `json:"is_enabled"`
+	IsEnabled *bool   `json:"is_enabled"`
+	Target    *string `json:"target" validate:"omitempty,min=1"`
+}
+
+// VerifyChannelRequest represents request body for verifying a channel.
+type VerifyChannelRequest struct {
+	Code string `json:"code" validate:"required"`
 }
 
 // UpdateSubscriptionRequest represents request body for updating subscription.
@@ -107,7 +125,15 @@ func (h *Handler) UpdateChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	channel, err := h.service.UpdateChannel(r.Context(), userID, channelID, req.IsEnabled)
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	channel, err := h.service.UpdateChannel(r.Context(), userID, channelID, UpdateChannelInput{
+		IsEnabled: req.IsEnabled,
+		Target:    req.Target,
+	})
 	if err != nil {
 		h.handleServiceError(w, err)
 		return
@@ -134,7 +160,32 @@ func (h *Handler) VerifyChannel(w http.ResponseWriter, r *http.Request) {
 	userID := httputil.GetUserID(r.Context())
 	channelID := chi.URLParam(r, "id")
 
-	channel, err := h.service.VerifyChannel(r.Context(), userID, channelID)
+	var req VerifyChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	channel, err := h.service.VerifyChannel(r.Context(), userID, channelID, req.Code)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, channel)
+}
+
+// ResendVerification handles POST /me/channels/{id}/verify/resend.
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := httputil.GetUserID(r.Context())
+	channelID := chi.URLParam(r, "id")
+
+	channel, err := h.service.ResendVerification(r.Context(), userID, channelID)
 	if err != nil {
 		h.handleServiceError(w, err)
 		return
@@ -187,6 +238,89 @@ func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// ListDeliveries handles GET /notifications/deliveries.
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	filter := DeliveryFilter{Limit: 50}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		s := domain.DeliveryStatus(status)
+		if !s.IsValid() {
+			h.respondError(w, http.StatusBadRequest, "invalid status")
+			return
+		}
+		filter.Status = &s
+	}
+
+	if channelID := r.URL.Query().Get("channel_id"); channelID != "" {
+		filter.ChannelID = &channelID
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 500 {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		filter.Limit = n
+	}
+
+	if offset := r.URL.Query().Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			h.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		filter.Offset = n
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, deliveries)
+}
+
+// RetryDelivery handles POST /notifications/deliveries/{id}/retry.
+func (h *Handler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	delivery, err := h.service.RetryDelivery(r.Context(), id)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, delivery)
+}
+
+// PurgeDeliveries handles DELETE /notifications/deliveries.
+func (h *Handler) PurgeDeliveries(w http.ResponseWriter, r *http.Request) {
+	status := domain.DeliveryStatusFailed
+	if s := r.URL.Query().Get("status"); s != "" {
+		status = domain.DeliveryStatus(s)
+	}
+
+	var before *time.Time
+	if b := r.URL.Query().Get("before"); b != "" {
+		t, err := time.Parse(time.RFC3339, b)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "before must be an RFC 3339 timestamp")
+			return
+		}
+		before = &t
+	}
+
+	deleted, err := h.service.PurgeDeliveries(r.Context(), status, before)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -226,6 +360,32 @@ func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
 		h.respondError(w, http.StatusNotFound, "subscription not found")
 	case errors.Is(err, ErrChannelNotOwned):
 		h.respondError(w, http.StatusForbidden, "channel does not belong to user")
+	case errors.Is(err, ErrDeliveryNotFound):
+		h.respondError(w, http.StatusNotFound, "notification delivery not found")
+	case errors.Is(err, ErrDeliveryNotFailed):
+		h.respondError(w, http.StatusConflict, "only failed deliveries can be retried")
+	case errors.Is(err, ErrInvalidPurgeStatus):
+		h.respondError(w, http.StatusBadRequest, "only sent or failed deliveries can be purged")
+	case errors.Is(err, ErrTelegramNotConfigured):
+		h.respondError(w, http.StatusBadRequest, "telegram notifications are not configured")
+	case errors.Is(err, ErrInvalidTarget):
+		h.respondError(w, http.StatusBadRequest, "invalid channel target")
+	case errors.Is(err, ErrInvalidChannelType):
+		h.respondError(w, http.StatusBadRequest, "telegram channels are verified through the bot link")
+	case errors.Is(err, ErrChannelAlreadyVerified):
+		h.respondError(w, http.StatusConflict, "channel is already verified")
+	case errors.Is(err, ErrVerificationCodeInvalid):
+		h.respondError(w, http.StatusBadRequest, "invalid or expired verification code")
+	case errors.Is(err, ErrVerificationAttemptsExceeded):
+		h.respondError(w, http.StatusTooManyRequests, "too many verification attempts, request a new code")
+	case errors.Is(err, ErrVerificationLocked):
+		h.respondError(w, http.StatusTooManyRequests, "too many verification attempts, delete the channel and add it again")
+	case errors.Is(err, ErrVerificationResendTooSoon):
+		h.respondError(w, http.StatusTooManyRequests, "verification code was sent recently, try again later")
+	case errors.Is(err, ErrVerificationSendLimited):
+		h.respondError(w, http.StatusTooManyRequests, "too many verification codes sent, try again later")
+	case errors.Is(err, ErrTooManyUnverifiedChannels):
+		h.respondError(w, http.StatusConflict, "too many unverified channels, verify or delete one first")
 	default:
 		slog.Error("service error", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "internal server error")
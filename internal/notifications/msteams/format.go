@@ -0,0 +1,112 @@
+package msteams
+
+import (
+	"strings"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+// maxBodyRunes keeps cards well below the 28 KB message size limit.
+const maxBodyRunes = 5000
+
+// levelStyles are the Adaptive Card container styles for notification levels.
+var levelStyles = map[domain.NotificationLevel]string{
+	domain.NotificationLevelCritical:    "attention",
+	domain.NotificationLevelMajor:       "attention",
+	domain.NotificationLevelMinor:       "warning",
+	domain.NotificationLevelMaintenance: "accent",
+	domain.NotificationLevelResolved:    "good",
+	domain.NotificationLevelInfo:        "emphasis",
+}
+
+type message struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	ContentType string `json:"contentType"`
+	Content     card   `json:"content"`
+}
+
+type card struct {
+	Schema  string            `json:"$schema"`
+	Type    string            `json:"type"`
+	Version string            `json:"version"`
+	Body    []element         `json:"body"`
+	Actions []action          `json:"actions,omitempty"`
+	MSTeams map[string]string `json:"msteams"`
+}
+
+// element is either a Container or a TextBlock.
+type element struct {
+	Type   string    `json:"type"`
+	Style  string    `json:"style,omitempty"`
+	Bleed  bool      `json:"bleed,omitempty"`
+	Items  []element `json:"items,omitempty"`
+	Text   string    `json:"text,omitempty"`
+	Size   string    `json:"size,omitempty"`
+	Weight string    `json:"weight,omitempty"`
+	Wrap   bool      `json:"wrap,omitempty"`
+}
+
+type action struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// formatMessage renders an Adaptive Card with the subject in a container
+// styled by the level, the body below it and the link as a button.
+func formatMessage(n notifications.Notification) message {
+	style, ok := levelStyles[n.Level]
+	if !ok {
+		style = levelStyles[domain.NotificationLevelInfo]
+	}
+
+	body := []element{{
+		Type:  "Container",
+		Style: style,
+		Bleed: true,
+		Items: []element{{
+			Type:   "TextBlock",
+			Text:   n.Subject,
+			Size:   "Medium",
+			Weight: "Bolder",
+			Wrap:   true,
+		}},
+	}}
+	if text := strings.TrimSpace(n.Body); text != "" {
+		// TextBlock markdown needs a blank line to keep line breaks.
+		text = strings.ReplaceAll(truncate(text, maxBodyRunes), "\n", "\n\n")
+		body = append(body, element{Type: "TextBlock", Text: text, Wrap: true})
+	}
+
+	c := card{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    body,
+		MSTeams: map[string]string{"width": "Full"},
+	}
+	if n.Link != "" {
+		c.Actions = []action{{Type: "Action.OpenUrl", Title: "View details", URL: n.Link}}
+	}
+
+	return message{
+		Type: "message",
+		Attachments: []attachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     c,
+		}},
+	}
+}
+
+func truncate(s string, maxRunes int) string {
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
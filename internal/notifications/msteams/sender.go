@@ -0,0 +1,103 @@
+// Package msteams provides notification sending through Microsoft Teams incoming webhooks.
+package msteams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+// maxErrorBody is how much of an error response is kept in the error.
+const maxErrorBody = 512
+
+// Config holds Microsoft Teams sender configuration.
+type Config struct {
+	Timeout time.Duration
+}
+
+// APIError represents an unsuccessful incoming webhook response.
+type APIError struct {
+	Code        int
+	Description string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("teams webhook error %d: %s", e.Code, e.Description)
+}
+
+// Sender implements Microsoft Teams notification sender.
+// Both Workflows webhooks and legacy Office 365 connectors accept Adaptive Card messages.
+type Sender struct {
+	config Config
+	client *http.Client
+}
+
+// NewSender creates a new Microsoft Teams sender.
+func NewSender(config Config) *Sender {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &Sender{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Type returns the channel type.
+func (s *Sender) Type() domain.ChannelType {
+	return domain.ChannelTypeMSTeams
+}
+
+// Send posts a notification to the incoming webhook URL in notification.To.
+func (s *Sender) Send(ctx context.Context, notification notifications.Notification) error {
+	body, err := json.Marshal(formatMessage(notification))
+	if err != nil {
+		return fmt.Errorf("marshal teams message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.To, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("create teams request: invalid webhook url")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// The webhook URL is a credential, so do not leak it through *url.Error.
+		return fmt.Errorf("teams webhook request failed: %w", errors.Unwrap(err))
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		slog.Info("teams notification sent", "subject", notification.Subject)
+		return nil
+	}
+
+	description, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	apiErr := &APIError{Code: resp.StatusCode, Description: strings.TrimSpace(string(description))}
+	if isUnreachable(apiErr) {
+		return fmt.Errorf("%w: %w", notifications.ErrRecipientUnreachable, apiErr)
+	}
+	return apiErr
+}
+
+// isUnreachable reports whether the webhook was deleted or its signature is no longer valid.
+func isUnreachable(err *APIError) bool {
+	switch err.Code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
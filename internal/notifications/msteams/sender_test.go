@@ -0,0 +1,86 @@
+package msteams
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+func TestSender_Send(t *testing.T) {
+	var got message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	err := NewSender(Config{}).Send(context.Background(), notifications.Notification{
+		To:      srv.URL + "/workflows/abc/triggers/manual/paths/invoke",
+		Subject: "[Maintenance] Scheduled: Database upgrade",
+		Body:    "Database upgrade\nStatus: Scheduled",
+		Level:   domain.NotificationLevelMaintenance,
+		Link:    "https://status.example.com/events/2",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got.Type != "message" || len(got.Attachments) != 1 {
+		t.Fatalf("unexpected message %+v", got)
+	}
+	att := got.Attachments[0]
+	if att.ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("contentType = %q", att.ContentType)
+	}
+	card := att.Content
+	if card.Type != "AdaptiveCard" || len(card.Body) != 2 {
+		t.Fatalf("unexpected card %+v", card)
+	}
+	header := card.Body[0]
+	if header.Style != "accent" || header.Items[0].Text != "[Maintenance] Scheduled: Database upgrade" {
+		t.Errorf("header = %+v, want accent container with the subject", header)
+	}
+	if card.Body[1].Text != "Database upgrade\n\nStatus: Scheduled" {
+		t.Errorf("body text = %q", card.Body[1].Text)
+	}
+	if len(card.Actions) != 1 || card.Actions[0].URL != "https://status.example.com/events/2" {
+		t.Errorf("actions = %+v", card.Actions)
+	}
+}
+
+func TestSender_SendErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		wantUnreachable bool
+	}{
+		{name: "deleted workflow", status: http.StatusNotFound, wantUnreachable: true},
+		{name: "invalid signature", status: http.StatusUnauthorized, wantUnreachable: true},
+		{name: "throttled", status: http.StatusTooManyRequests},
+		{name: "bad gateway", status: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := NewSender(Config{}).Send(context.Background(), notifications.Notification{To: srv.URL, Subject: "s"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.status {
+				t.Fatalf("Send() error = %v, want APIError %d", err, tt.status)
+			}
+			if got := errors.Is(err, notifications.ErrRecipientUnreachable); got != tt.wantUnreachable {
+				t.Errorf("unreachable = %v, want %v", got, tt.wantUnreachable)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/notifications"
@@ -105,12 +107,13 @@ func (r *Repository) ListUserChannels(ctx context.Context, userID string) ([]dom
 func (r *Repository) UpdateChannel(ctx context.Context, channel *domain.NotificationChannel) error {
 	query := `
 		UPDATE notification_channels
-		SET is_enabled = $2, is_verified = $3, updated_at = NOW()
+		SET target = $2, is_enabled = $3, is_verified = $4, updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at
 	`
 	err := r.db.QueryRow(ctx, query,
 		channel.ID,
+		channel.Target,
 		channel.IsEnabled,
 		channel.IsVerified,
 	).Scan(&channel.UpdatedAt)
@@ -138,6 +141,129 @@ func (r *Repository) DeleteChannel(ctx context.Context, id string) error {
 	return nil
 }
 
+// SaveVerificationCode stores a verification code hash for a channel, replacing any previous one.
+// The total attempt count of the channel is kept.
+func (r *Repository) SaveVerificationCode(ctx context.Context, channelID, codeHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO channel_verification_codes (channel_id, code_hash, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (channel_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, expires_at = EXCLUDED.expires_at, attempts = 0, created_at = NOW()
+	`
+	if _, err := r.db.Exec(ctx, query, channelID, codeHash, expiresAt); err != nil {
+		return fmt.Errorf("save verification code: %w", err)
+	}
+	return nil
+}
+
+// GetVerificationCode retrieves the pending verification code of a channel.
+func (r *Repository) GetVerificationCode(ctx context.Context, channelID string) (*notifications.VerificationCode, error) {
+	query := `
+		SELECT channel_id, code_hash, attempts, total_attempts, expires_at, created_at
+		FROM channel_verification_codes
+		WHERE channel_id = $1
+	`
+	var code notifications.VerificationCode
+	err := r.db.QueryRow(ctx, query, channelID).Scan(
+		&code.ChannelID,
+		&code.CodeHash,
+		&code.Attempts,
+		&code.TotalAttempts,
+		&code.ExpiresAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, notifications.ErrVerificationNotFound
+		}
+		return nil, fmt.Errorf("get verification code: %w", err)
+	}
+	return &code, nil
+}
+
+// UseVerificationAttempt counts an attempt at the verification code of a
+// channel and returns the code hash to check. The attempt is counted before the
+// code is checked, so concurrent guesses cannot exceed the limits.
+func (r *Repository) UseVerificationAttempt(ctx context.Context, channelID string, maxAttempts, maxTotalAttempts int) (string, error) {
+	query := `
+		UPDATE channel_verification_codes
+		SET attempts = attempts + 1, total_attempts = total_attempts + 1
+		WHERE channel_id = $1 AND attempts < $2 AND total_attempts < $3 AND expires_at > NOW()
+		RETURNING code_hash
+	`
+	var codeHash string
+	if err := r.db.QueryRow(ctx, query, channelID, maxAttempts, maxTotalAttempts).Scan(&codeHash); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", notifications.ErrVerificationNotFound
+		}
+		return "", fmt.Errorf("use verification attempt: %w", err)
+	}
+	return codeHash, nil
+}
+
+// GetTelegramChannelByCode retrieves the telegram channel owning a non-expired verification code.
+func (r *Repository) GetTelegramChannelByCode(ctx context.Context, codeHash string) (*domain.NotificationChannel, error) {
+	query := `
+		SELECT c.id, c.user_id, c.type, c.target, c.is_enabled, c.is_verified, c.created_at, c.updated_at
+		FROM channel_verification_codes v
+		JOIN notification_channels c ON c.id = v.channel_id
+		WHERE v.code_hash = $1 AND v.expires_at > NOW() AND c.type = 'telegram'
+	`
+	var channel domain.NotificationChannel
+	err := r.db.QueryRow(ctx, query, codeHash).Scan(
+		&channel.ID,
+		&channel.UserID,
+		&channel.Type,
+		&channel.Target,
+		&channel.IsEnabled,
+		&channel.IsVerified,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, notifications.ErrVerificationNotFound
+		}
+		return nil, fmt.Errorf("get channel by verification code: %w", err)
+	}
+	return &channel, nil
+}
+
+// DeleteVerificationCode removes the verification code of a channel.
+func (r *Repository) DeleteVerificationCode(ctx context.Context, channelID string) error {
+	query := `DELETE FROM channel_verification_codes WHERE channel_id = $1`
+	if _, err := r.db.Exec(ctx, query, channelID); err != nil {
+		return fmt.Errorf("delete verification code: %w", err)
+	}
+	return nil
+}
+
+// RecordVerificationSend records a verification code sent to target unless a limit is reached.
+// Targets are compared case-insensitively. Records older than window are removed.
+func (r *Repository) RecordVerificationSend(ctx context.Context, userID, target string, window time.Duration, maxPerUser, maxPerTarget int) (bool, error) {
+	cleanup := `DELETE FROM channel_verification_sends WHERE created_at < NOW() - $1 * INTERVAL '1 second'`
+	if _, err := r.db.Exec(ctx, cleanup, window.Seconds()); err != nil {
+		return false, fmt.Errorf("delete old verification sends: %w", err)
+	}
+
+	query := `
+		INSERT INTO channel_verification_sends (user_id, target)
+		SELECT $1::uuid, LOWER($2)
+		WHERE (
+			SELECT COUNT(*) FROM channel_verification_sends
+			WHERE user_id = $1 AND created_at >= NOW() - $3 * INTERVAL '1 second'
+		) < $4 AND (
+			SELECT COUNT(*) FROM channel_verification_sends
+			WHERE target = LOWER($2) AND created_at >= NOW() - $3 * INTERVAL '1 second'
+		) < $5
+	`
+	result, err := r.db.Exec(ctx, query, userID, target, window.Seconds(), maxPerUser, maxPerTarget)
+	if err != nil {
+		return false, fmt.Errorf("record verification send: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
 // CreateSubscription creates a new subscription.
 func (r *Repository) CreateSubscription(ctx context.Context, subscription *domain.Subscription) error {
 	query := `
@@ -329,3 +455,262 @@ func (r *Repository) getEnabledVerifiedChannels(ctx context.Context, userID stri
 
 	return channels, nil
 }
+
+const deliveryColumns = `
+	id, channel_id, channel_type, target, subject, body, level, link, status, attempts,
+	last_error, next_attempt_at, sent_at, created_at, updated_at, locked_until
+`
+
+func scanDelivery(row pgx.Row) (*domain.NotificationDelivery, error) {
+	var d domain.NotificationDelivery
+	err := row.Scan(
+		&d.ID,
+		&d.ChannelID,
+		&d.ChannelType,
+		&d.Target,
+		&d.Subject,
+		&d.Body,
+		&d.Level,
+		&d.Link,
+		&d.Status,
+		&d.Attempts,
+		&d.LastError,
+		&d.NextAttemptAt,
+		&d.SentAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+		&d.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// EnqueueDeliveries inserts pending deliveries into the outbox in a single transaction.
+func (r *Repository) EnqueueDeliveries(ctx context.Context, deliveries []*domain.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query := `
+		INSERT INTO notification_outbox (channel_id, channel_type, target, subject, body, level, link)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + deliveryColumns
+
+	for _, d := range deliveries {
+		inserted, err := scanDelivery(tx.QueryRow(ctx, query,
+			d.ChannelID, d.ChannelType, d.Target, d.Subject, d.Body, d.Level, d.Link,
+		))
+		if err != nil {
+			return fmt.Errorf("enqueue delivery for channel %s: %w", d.ChannelID, err)
+		}
+		*d = *inserted
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDeliveries locks up to limit due deliveries for processing.
+// Rows stuck in processing after their lease expired are reclaimed.
+func (r *Repository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.NotificationDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM notification_outbox
+			WHERE (status = 'pending' AND next_attempt_at <= NOW())
+			   OR (status = 'processing' AND locked_until < NOW())
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notification_outbox o
+		SET status = 'processing',
+			attempts = o.attempts + 1,
+			locked_until = NOW() + $2 * INTERVAL '1 second',
+			updated_at = NOW()
+		FROM due
+		WHERE o.id = due.id
+		RETURNING ` + prefixColumns("o", deliveryColumns)
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*domain.NotificationDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkDeliverySent marks a delivery as successfully sent.
+func (r *Repository) MarkDeliverySent(ctx context.Context, delivery *domain.NotificationDelivery) error {
+	query := `
+		UPDATE notification_outbox
+		SET status = 'sent', sent_at = NOW(), locked_until = NULL, last_error = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_until = $2
+	`
+	return r.execLeasedUpdate(ctx, "mark delivery sent", query, delivery.ID, delivery.LockedUntil)
+}
+
+// MarkDeliveryRetry returns a delivery to the queue to be retried after delay.
+func (r *Repository) MarkDeliveryRetry(ctx context.Context, delivery *domain.NotificationDelivery, delay time.Duration, lastError string) error {
+	query := `
+		UPDATE notification_outbox
+		SET status = 'pending',
+			next_attempt_at = NOW() + $3 * INTERVAL '1 second',
+			last_error = $4,
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_until = $2
+	`
+	return r.execLeasedUpdate(ctx, "mark delivery retry", query, delivery.ID, delivery.LockedUntil, delay.Seconds(), lastError)
+}
+
+// MarkDeliveryFailed moves a delivery to the dead-letter state.
+func (r *Repository) MarkDeliveryFailed(ctx context.Context, delivery *domain.NotificationDelivery, lastError string) error {
+	query := `
+		UPDATE notification_outbox
+		SET status = 'failed', last_error = $3, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_until = $2
+	`
+	return r.execLeasedUpdate(ctx, "mark delivery failed", query, delivery.ID, delivery.LockedUntil, lastError)
+}
+
+// ResetDelivery requeues a delivery for immediate sending with a fresh attempt counter.
+func (r *Repository) ResetDelivery(ctx context.Context, id string) error {
+	query := `
+		UPDATE notification_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	return r.execDeliveryUpdate(ctx, "reset delivery", query, id)
+}
+
+func (r *Repository) execDeliveryUpdate(ctx context.Context, op, query string, args ...interface{}) error {
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return notifications.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// execLeasedUpdate runs an update guarded by the claim of a delivery. No
+// updated row means the lease expired and the delivery was reclaimed or reset.
+func (r *Repository) execLeasedUpdate(ctx context.Context, op, query string, args ...interface{}) error {
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return notifications.ErrDeliveryLeaseLost
+	}
+	return nil
+}
+
+// GetDelivery retrieves an outbox delivery by ID.
+func (r *Repository) GetDelivery(ctx context.Context, id string) (*domain.NotificationDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM notification_outbox WHERE id = $1`
+	d, err := scanDelivery(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, notifications.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("get delivery: %w", err)
+	}
+	return d, nil
+}
+
+// ListDeliveries retrieves outbox deliveries with optional filters, newest first.
+func (r *Repository) ListDeliveries(ctx context.Context, filter notifications.DeliveryFilter) ([]*domain.NotificationDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM notification_outbox WHERE 1=1`
+	args := []interface{}{}
+	argNum := 1
+
+	if filter.Status != nil {
+		query += fmt.Sprintf(" AND status = $%d", argNum)
+		args = append(args, *filter.Status)
+		argNum++
+	}
+
+	if filter.ChannelID != nil {
+		query += fmt.Sprintf(" AND channel_id = $%d", argNum)
+		args = append(args, *filter.ChannelID)
+		argNum++
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*domain.NotificationDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// PurgeDeliveries deletes deliveries in the given status, optionally only those created before a time.
+func (r *Repository) PurgeDeliveries(ctx context.Context, status domain.DeliveryStatus, before *time.Time) (int64, error) {
+	query := `DELETE FROM notification_outbox WHERE status = $1 AND ($2::timestamp IS NULL OR created_at < $2)`
+	result, err := r.db.Exec(ctx, query, status, before)
+	if err != nil {
+		return 0, fmt.Errorf("purge deliveries: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// prefixColumns qualifies a comma separated column list with a table alias.
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, p := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(p)
+	}
+	return strings.Join(parts, ", ")
+}
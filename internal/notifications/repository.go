@@ -3,6 +3,7 @@ package notifications
 
 import (
 	"context"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 )
@@ -15,6 +16,16 @@ type Repository interface {
 	UpdateChannel(ctx context.Context, channel *domain.NotificationChannel) error
 	DeleteChannel(ctx context.Context, id string) error
 
+	SaveVerificationCode(ctx context.Context, channelID, codeHash string, expiresAt time.Time) error
+	GetVerificationCode(ctx context.Context, channelID string) (*VerificationCode, error)
+	UseVerificationAttempt(ctx context.Context, channelID string, maxAttempts, maxTotalAttempts int) (string, error)
+	GetTelegramChannelByCode(ctx context.Context, codeHash string) (*domain.NotificationChannel, error)
+	DeleteVerificationCode(ctx context.Context, channelID string) error
+	// RecordVerificationSend records a code sent by user to target unless the
+	// user or the target already got the maximum number of codes within window.
+	// It reports whether the send was recorded.
+	RecordVerificationSend(ctx context.Context, userID, target string, window time.Duration, maxPerUser, maxPerTarget int) (bool, error)
+
 	CreateSubscription(ctx context.Context, subscription *domain.Subscription) error
 	GetSubscriptionByID(ctx context.Context, id string) (*domain.Subscription, error)
 	GetUserSubscription(ctx context.Context, userID string) (*domain.Subscription, error)
@@ -22,6 +33,38 @@ type Repository interface {
 	DeleteSubscription(ctx context.Context, id string) error
 
 	GetSubscribersForServices(ctx context.Context, serviceIDs []string) ([]SubscriberInfo, error)
+
+	EnqueueDeliveries(ctx context.Context, deliveries []*domain.NotificationDelivery) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.NotificationDelivery, error)
+	// MarkDeliverySent, MarkDeliveryRetry and MarkDeliveryFailed record the
+	// outcome of a claimed delivery. They fail with ErrDeliveryLeaseLost when
+	// the claim is no longer held.
+	MarkDeliverySent(ctx context.Context, delivery *domain.NotificationDelivery) error
+	MarkDeliveryRetry(ctx context.Context, delivery *domain.NotificationDelivery, delay time.Duration, lastError string) error
+	MarkDeliveryFailed(ctx context.Context, delivery *domain.NotificationDelivery, lastError string) error
+	GetDelivery(ctx context.Context, id string) (*domain.NotificationDelivery, error)
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*domain.NotificationDelivery, error)
+	ResetDelivery(ctx context.Context, id string) error
+	PurgeDeliveries(ctx context.Context, status domain.DeliveryStatus, before *time.Time) (int64, error)
+}
+
+// VerificationCode is a pending channel verification code.
+type VerificationCode struct {
+	ChannelID string
+	CodeHash  string
+	Attempts  int
+	// TotalAttempts counts attempts at every code issued for the channel.
+	TotalAttempts int
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// DeliveryFilter holds filter options for listing outbox deliveries.
+type DeliveryFilter struct {
+	Status    *domain.DeliveryStatus
+	ChannelID *string
+	Limit     int
+	Offset    int
 }
 
 // SubscriberInfo contains user notification info for dispatcher.
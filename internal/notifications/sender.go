@@ -11,6 +11,9 @@ type Notification struct {
 	To      string
 	Subject string
 	Body    string
+	// Level and Link are used by chat senders for the message colour and a details button.
+	Level domain.NotificationLevel
+	Link  string
 }
 
 // Sender interface for different notification channels.
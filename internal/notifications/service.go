@@ -3,31 +3,71 @@ package notifications
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 )
 
 // Service errors.
 var (
-	ErrChannelNotOwned = errors.New("channel does not belong to user")
+	ErrChannelNotOwned              = errors.New("channel does not belong to user")
+	ErrInvalidChannelType           = errors.New("invalid channel type for this operation")
+	ErrTelegramNotConfigured        = errors.New("telegram bot is not configured")
+	ErrInvalidTarget                = errors.New("invalid channel target")
+	ErrChannelAlreadyVerified       = errors.New("channel is already verified")
+	ErrVerificationCodeInvalid      = errors.New("invalid or expired verification code")
+	ErrVerificationAttemptsExceeded = errors.New("too many verification attempts")
+	ErrVerificationLocked           = errors.New("channel verification is locked")
+	ErrVerificationResendTooSoon    = errors.New("verification code was sent recently")
+	ErrVerificationSendLimited      = errors.New("too many verification codes sent")
+	ErrTooManyUnverifiedChannels    = errors.New("too many unverified channels")
+	ErrDeliveryNotFailed            = errors.New("only failed deliveries can be retried")
+	ErrInvalidPurgeStatus           = errors.New("only sent or failed deliveries can be purged")
 )
 
+// Config holds notifications service configuration.
+type Config struct {
+	// TelegramBotUsername is used to build t.me deep links for channel verification.
+	TelegramBotUsername string
+	// PublicURL is the status page base URL used for links in notifications.
+	PublicURL string
+}
+
 // Service provides notifications business logic.
 type Service struct {
 	repo       Repository
 	dispatcher *Dispatcher
+	config     Config
+	audit      domain.AuditFunc
 }
 
 // NewService creates a new notifications service.
-func NewService(repo Repository, dispatcher *Dispatcher) *Service {
+func NewService(repo Repository, dispatcher *Dispatcher, config Config) *Service {
 	return &Service{
 		repo:       repo,
 		dispatcher: dispatcher,
+		config:     config,
 	}
 }
 
-// CreateChannel creates a new notification channel for user.
+// SetAuditLog sets where changes to channels, subscriptions and deliveries are recorded.
+func (s *Service) SetAuditLog(audit domain.AuditFunc) {
+	s.audit = audit
+}
+
+// CreateChannel creates a new notification channel for user and starts its verification.
 func (s *Service) CreateChannel(ctx context.Context, userID string, channelType domain.ChannelType, target string) (*domain.NotificationChannel, error) {
+	if err := s.validateTarget(channelType, target); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnverifiedChannels(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.reserveVerificationSend(ctx, userID, channelType, target); err != nil {
+		return nil, err
+	}
+
 	channel := &domain.NotificationChannel{
 		UserID:     userID,
 		Type:       channelType,
@@ -40,6 +80,17 @@ func (s *Service) CreateChannel(ctx context.Context, userID string, channelType
 		return nil, err
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityChannel,
+		EntityID:   channel.ID,
+		After:      channel,
+	})
+
+	if err := s.issueVerification(ctx, channel); err != nil {
+		return nil, err
+	}
+
 	return channel, nil
 }
 
@@ -48,8 +99,14 @@ func (s *Service) ListUserChannels(ctx context.Context, userID string) ([]domain
 	return s.repo.ListUserChannels(ctx, userID)
 }
 
-// UpdateChannel updates a channel (enable/disable).
-func (s *Service) UpdateChannel(ctx context.Context, userID, channelID string, isEnabled bool) (*domain.NotificationChannel, error) {
+// UpdateChannelInput holds optional channel changes.
+type UpdateChannelInput struct {
+	IsEnabled *bool
+	Target    *string
+}
+
+// UpdateChannel updates a channel. Changing the target requires the channel to be verified again.
+func (s *Service) UpdateChannel(ctx context.Context, userID, channelID string, input UpdateChannelInput) (*domain.NotificationChannel, error) {
 	channel, err := s.repo.GetChannelByID(ctx, channelID)
 	if err != nil {
 		return nil, err
@@ -59,12 +116,41 @@ func (s *Service) UpdateChannel(ctx context.Context, userID, channelID string, i
 		return nil, ErrChannelNotOwned
 	}
 
-	channel.IsEnabled = isEnabled
+	before := *channel
+	if input.IsEnabled != nil {
+		channel.IsEnabled = *input.IsEnabled
+	}
+
+	targetChanged := input.Target != nil && *input.Target != channel.Target
+	if targetChanged {
+		if err := s.validateTarget(channel.Type, *input.Target); err != nil {
+			return nil, err
+		}
+		if err := s.reserveVerificationSend(ctx, userID, channel.Type, *input.Target); err != nil {
+			return nil, err
+		}
+		channel.Target = *input.Target
+		channel.IsVerified = false
+	}
 
 	if err := s.repo.UpdateChannel(ctx, channel); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityChannel,
+		EntityID:   channel.ID,
+		Before:     &before,
+		After:      channel,
+	})
+
+	if targetChanged {
+		if err := s.issueVerification(ctx, channel); err != nil {
+			return nil, err
+		}
+	}
+
 	return channel, nil
 }
 
@@ -79,27 +165,17 @@ func (s *Service) DeleteChannel(ctx context.Context, userID, channelID string) e
 		return ErrChannelNotOwned
 	}
 
-	return s.repo.DeleteChannel(ctx, channelID)
-}
-
-// VerifyChannel marks a channel as verified.
-func (s *Service) VerifyChannel(ctx context.Context, userID, channelID string) (*domain.NotificationChannel, error) {
-	channel, err := s.repo.GetChannelByID(ctx, channelID)
-	if err != nil {
-		return nil, err
-	}
-
-	if channel.UserID != userID {
-		return nil, ErrChannelNotOwned
-	}
-
-	channel.IsVerified = true
-
-	if err := s.repo.UpdateChannel(ctx, channel); err != nil {
-		return nil, err
+	if err := s.repo.DeleteChannel(ctx, channelID); err != nil {
+		return err
 	}
 
-	return channel, nil
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityChannel,
+		EntityID:   channelID,
+		Before:     channel,
+	})
+	return nil
 }
 
 // GetOrCreateSubscription gets or creates a subscription for user.
@@ -136,7 +212,15 @@ func (s *Service) UpdateSubscriptionServices(ctx context.Context, userID string,
 		return nil, err
 	}
 
+	before := *sub
 	sub.ServiceIDs = serviceIDs
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntitySubscription,
+		EntityID:   sub.ID,
+		Before:     &before,
+		After:      sub,
+	})
 	return sub, nil
 }
 
@@ -147,7 +231,34 @@ func (s *Service) DeleteSubscription(ctx context.Context, userID string) error {
 		return err
 	}
 
-	return s.repo.DeleteSubscription(ctx, sub.ID)
+	if err := s.repo.DeleteSubscription(ctx, sub.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntitySubscription,
+		EntityID:   sub.ID,
+		Before:     sub,
+	})
+	return nil
+}
+
+// NotifyEvent renders an event change and sends it to subscribers of the given services.
+func (s *Service) NotifyEvent(ctx context.Context, serviceIDs []string, msg EventMessage) error {
+	var link string
+	if s.config.PublicURL != "" {
+		link = strings.TrimRight(s.config.PublicURL, "/") + "/events/" + msg.Event.ID
+	}
+
+	subject, body := RenderEventMessage(msg, link)
+	return s.dispatcher.Dispatch(ctx, DispatchInput{
+		ServiceIDs: serviceIDs,
+		Subject:    subject,
+		Body:       body,
+		Level:      EventLevel(msg),
+		Link:       link,
+	})
 }
 
 // NotifySubscribers sends notifications about an event.
@@ -158,3 +269,55 @@ func (s *Service) NotifySubscribers(ctx context.Context, serviceIDs []string, su
 		Body:       body,
 	})
 }
+
+// ListDeliveries returns outbox deliveries matching the filter.
+func (s *Service) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*domain.NotificationDelivery, error) {
+	return s.repo.ListDeliveries(ctx, filter)
+}
+
+// RetryDelivery requeues a dead-lettered delivery.
+func (s *Service) RetryDelivery(ctx context.Context, id string) (*domain.NotificationDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.Status != domain.DeliveryStatusFailed {
+		return nil, ErrDeliveryNotFailed
+	}
+
+	if err := s.repo.ResetDelivery(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionRetry,
+		EntityType: domain.AuditEntityNotificationDelivery,
+		EntityID:   id,
+	})
+
+	return s.repo.GetDelivery(ctx, id)
+}
+
+// PurgeDeliveries deletes sent or failed deliveries, optionally only those created before a time.
+func (s *Service) PurgeDeliveries(ctx context.Context, status domain.DeliveryStatus, before *time.Time) (int64, error) {
+	if status != domain.DeliveryStatusSent && status != domain.DeliveryStatusFailed {
+		return 0, ErrInvalidPurgeStatus
+	}
+
+	deleted, err := s.repo.PurgeDeliveries(ctx, status, before)
+	if err != nil {
+		return 0, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionPurge,
+		EntityType: domain.AuditEntityNotificationDelivery,
+		After: map[string]interface{}{
+			"status":  status,
+			"before":  before,
+			"deleted": deleted,
+		},
+	})
+	return deleted, nil
+}
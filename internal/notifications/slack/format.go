@@ -0,0 +1,103 @@
+package slack
+
+import (
+	"strings"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+const (
+	// maxHeaderRunes is the Block Kit limit for header text.
+	maxHeaderRunes = 150
+	// maxBodyRunes keeps the section below its 3000 character limit after escaping.
+	maxBodyRunes = 2800
+)
+
+// levelColors are the attachment bar colours for notification levels.
+var levelColors = map[domain.NotificationLevel]string{
+	domain.NotificationLevelCritical:    "#E01E5A",
+	domain.NotificationLevelMajor:       "#F2711C",
+	domain.NotificationLevelMinor:       "#ECB22E",
+	domain.NotificationLevelMaintenance: "#1D9BD1",
+	domain.NotificationLevelResolved:    "#2EB67D",
+	domain.NotificationLevelInfo:        "#868686",
+}
+
+type message struct {
+	// Text is the fallback shown in notifications and clients without Block Kit.
+	Text        string       `json:"text"`
+	Attachments []attachment `json:"attachments"`
+}
+
+// attachment wraps the blocks because only attachments carry a colour bar.
+type attachment struct {
+	Color  string  `json:"color"`
+	Blocks []block `json:"blocks"`
+}
+
+type block struct {
+	Type     string    `json:"type"`
+	Text     *text     `json:"text,omitempty"`
+	Elements []element `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type element struct {
+	Type string `json:"type"`
+	Text *text  `json:"text"`
+	URL  string `json:"url"`
+}
+
+var mrkdwnReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escapeMrkdwn escapes the characters Slack treats as control sequences.
+func escapeMrkdwn(s string) string {
+	return mrkdwnReplacer.Replace(s)
+}
+
+// formatMessage renders the subject as a header, the body as a section and
+// the link as a button, inside an attachment coloured by the level.
+func formatMessage(n notifications.Notification) message {
+	color, ok := levelColors[n.Level]
+	if !ok {
+		color = levelColors[domain.NotificationLevelInfo]
+	}
+
+	blocks := []block{
+		{Type: "header", Text: &text{Type: "plain_text", Text: truncate(n.Subject, maxHeaderRunes)}},
+	}
+	if body := strings.TrimSpace(n.Body); body != "" {
+		blocks = append(blocks, block{
+			Type: "section",
+			Text: &text{Type: "mrkdwn", Text: escapeMrkdwn(truncate(body, maxBodyRunes))},
+		})
+	}
+	if n.Link != "" {
+		blocks = append(blocks, block{
+			Type: "actions",
+			Elements: []element{{
+				Type: "button",
+				Text: &text{Type: "plain_text", Text: "View details"},
+				URL:  n.Link,
+			}},
+		})
+	}
+
+	return message{
+		Text:        escapeMrkdwn(n.Subject),
+		Attachments: []attachment{{Color: color, Blocks: blocks}},
+	}
+}
+
+func truncate(s string, maxRunes int) string {
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+func TestSender_Send(t *testing.T) {
+	var got message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	err := NewSender(Config{}).Send(context.Background(), notifications.Notification{
+		To:      srv.URL + "/services/T000/B000/XXXX",
+		Subject: "[Incident] New: API down",
+		Body:    "Errors > 5% <investigating>",
+		Level:   domain.NotificationLevelCritical,
+		Link:    "https://status.example.com/events/1",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got.Text != "[Incident] New: API down" {
+		t.Errorf("fallback text = %q", got.Text)
+	}
+	if len(got.Attachments) != 1 {
+		t.Fatalf("attachments = %d, want 1", len(got.Attachments))
+	}
+	att := got.Attachments[0]
+	if att.Color != levelColors[domain.NotificationLevelCritical] {
+		t.Errorf("color = %q, want critical colour", att.Color)
+	}
+	if len(att.Blocks) != 3 {
+		t.Fatalf("blocks = %d, want header, section and actions", len(att.Blocks))
+	}
+	if section := att.Blocks[1].Text.Text; section != "Errors &gt; 5% &lt;investigating&gt;" {
+		t.Errorf("section = %q, want escaped body", section)
+	}
+	if button := att.Blocks[2].Elements[0]; button.URL != "https://status.example.com/events/1" {
+		t.Errorf("button url = %q", button.URL)
+	}
+}
+
+func TestSender_SendErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantUnreachable bool
+	}{
+		{name: "revoked webhook", status: http.StatusForbidden, body: "invalid_token", wantUnreachable: true},
+		{name: "archived channel", status: http.StatusGone, body: "channel_is_archived", wantUnreachable: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: "rate_limited"},
+		{name: "server error", status: http.StatusInternalServerError, body: "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			err := NewSender(Config{}).Send(context.Background(), notifications.Notification{To: srv.URL, Subject: "s"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.status || apiErr.Description != tt.body {
+				t.Fatalf("Send() error = %v, want APIError %d %s", err, tt.status, tt.body)
+			}
+			if got := errors.Is(err, notifications.ErrRecipientUnreachable); got != tt.wantUnreachable {
+				t.Errorf("unreachable = %v, want %v", got, tt.wantUnreachable)
+			}
+		})
+	}
+}
+
+func TestSender_SendDoesNotLeakURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	target := srv.URL + "/services/T000/B000/SECRET"
+	srv.Close()
+
+	err := NewSender(Config{}).Send(context.Background(), notifications.Notification{To: target, Subject: "s"})
+	if err == nil {
+		t.Fatal("Send() to a closed server must fail")
+	}
+	if strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("error leaks the webhook url: %v", err)
+	}
+}
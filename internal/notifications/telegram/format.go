@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"html"
+	"strings"
+)
+
+// maxBodyRunes keeps messages below the 4096 character sendMessage limit after escaping.
+const maxBodyRunes = 3500
+
+// markdownV2Replacer escapes every character reserved by MarkdownV2.
+var markdownV2Replacer = strings.NewReplacer(
+	`\`, `\\`,
+	"_", `\_`,
+	"*", `\*`,
+	"[", `\[`,
+	"]", `\]`,
+	"(", `\(`,
+	")", `\)`,
+	"~", `\~`,
+	"`", "\\`",
+	">", `\>`,
+	"#", `\#`,
+	"+", `\+`,
+	"-", `\-`,
+	"=", `\=`,
+	"|", `\|`,
+	"{", `\{`,
+	"}", `\}`,
+	".", `\.`,
+	"!", `\!`,
+)
+
+// EscapeMarkdownV2 escapes text for use with parse_mode=MarkdownV2.
+func EscapeMarkdownV2(text string) string {
+	return markdownV2Replacer.Replace(text)
+}
+
+// EscapeHTML escapes text for use with parse_mode=HTML.
+func EscapeHTML(text string) string {
+	return html.EscapeString(text)
+}
+
+// formatMessage renders the subject in bold followed by the body.
+func formatMessage(parseMode, subject, body string) string {
+	body = truncate(body, maxBodyRunes)
+
+	switch parseMode {
+	case ParseModeHTML:
+		return "<b>" + EscapeHTML(subject) + "</b>\n\n" + EscapeHTML(body)
+	case ParseModeMarkdownV2:
+		return "*" + EscapeMarkdownV2(subject) + "*\n\n" + EscapeMarkdownV2(body)
+	default:
+		return subject + "\n\n" + body
+	}
+}
+
+func truncate(s string, maxRunes int) string {
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
@@ -2,26 +2,85 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/notifications"
 )
 
+// DefaultAPIURL is the public Telegram Bot API endpoint.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// Parse modes supported by sendMessage.
+const (
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeHTML       = "HTML"
+)
+
+// ErrNotConfigured is returned when the bot token is missing.
+var ErrNotConfigured = errors.New("telegram bot token is not configured")
+
 // Config holds telegram sender configuration.
 type Config struct {
 	BotToken string
+	// APIURL is the Bot API base URL, overridable for tests or a local Bot API server.
+	APIURL string
+	// ParseMode is either "MarkdownV2" or "HTML".
+	ParseMode string
+	Timeout   time.Duration
+	// MaxRetries limits how many times a rate-limited (429) request is retried.
+	MaxRetries int
+	// MaxRetryAfter caps the wait requested by retry_after.
+	MaxRetryAfter time.Duration
+}
+
+// APIError represents an unsuccessful Bot API response.
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram api error %d: %s", e.Code, e.Description)
 }
 
 // Sender implements telegram notification sender.
 type Sender struct {
 	config Config
+	client *http.Client
 }
 
 // NewSender creates a new telegram sender.
 func NewSender(config Config) *Sender {
-	return &Sender{config: config}
+	if config.APIURL == "" {
+		config.APIURL = DefaultAPIURL
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	if config.ParseMode == "" {
+		config.ParseMode = ParseModeMarkdownV2
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.MaxRetryAfter == 0 {
+		config.MaxRetryAfter = time.Minute
+	}
+	return &Sender{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
 }
 
 // Type returns the channel type.
@@ -30,11 +89,127 @@ func (s *Sender) Type() domain.ChannelType {
 }
 
 // Send sends a telegram notification.
-func (s *Sender) Send(_ context.Context, notification notifications.Notification) error {
-	slog.Info("sending telegram notification",
+func (s *Sender) Send(ctx context.Context, notification notifications.Notification) error {
+	text := formatMessage(s.config.ParseMode, notification.Subject, notification.Body)
+	if err := s.sendMessage(ctx, notification.To, text, s.config.ParseMode); err != nil {
+		return err
+	}
+
+	slog.Info("telegram notification sent",
 		"to", notification.To,
 		"subject", notification.Subject,
 	)
 
 	return nil
 }
+
+type sendMessageRequest struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
+}
+
+type apiResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// sendMessage calls sendMessage, waiting out 429 responses up to MaxRetries times.
+func (s *Sender) sendMessage(ctx context.Context, chatID, text, parseMode string) error {
+	if s.config.BotToken == "" {
+		return ErrNotConfigured
+	}
+
+	req := sendMessageRequest{
+		ChatID:                chatID,
+		Text:                  text,
+		ParseMode:             parseMode,
+		DisableWebPagePreview: true,
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := s.call(ctx, "sendMessage", req)
+		if err == nil {
+			return nil
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			return err
+		}
+
+		switch {
+		case apiErr.Code == http.StatusTooManyRequests && attempt < s.config.MaxRetries:
+			wait := min(apiErr.RetryAfter, s.config.MaxRetryAfter)
+			slog.Warn("telegram rate limit hit, retrying",
+				"chat_id", chatID,
+				"retry_after", wait,
+			)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		case isUnreachable(apiErr):
+			return fmt.Errorf("%w: %w", notifications.ErrRecipientUnreachable, apiErr)
+		default:
+			return err
+		}
+	}
+}
+
+// call performs a Bot API method call and decodes its envelope.
+func (s *Sender) call(ctx context.Context, method string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s request: %w", method, err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/%s", s.config.APIURL, s.config.BotToken, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// The URL contains the bot token, so do not leak it through *url.Error.
+		return fmt.Errorf("telegram %s request failed: %w", method, errors.Unwrap(err))
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode %s response (status %d): %w", method, resp.StatusCode, err)
+	}
+
+	if result.OK {
+		return nil
+	}
+
+	apiErr := &APIError{Code: result.ErrorCode, Description: result.Description}
+	if apiErr.Code == 0 {
+		apiErr.Code = resp.StatusCode
+	}
+	if result.Parameters != nil {
+		apiErr.RetryAfter = time.Duration(result.Parameters.RetryAfter) * time.Second
+	}
+	return apiErr
+}
+
+// isUnreachable reports whether the chat can no longer receive messages from the bot.
+func isUnreachable(err *APIError) bool {
+	if err.Code == http.StatusForbidden {
+		// bot was blocked by the user, user is deactivated, bot was kicked, ...
+		return true
+	}
+	return err.Code == http.StatusBadRequest && strings.Contains(strings.ToLower(err.Description), "chat not found")
+}
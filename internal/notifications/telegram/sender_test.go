@@ -0,0 +1,221 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+// fakeBotAPI is a local stand-in for the Bot API that replays canned responses.
+type fakeBotAPI struct {
+	mu        sync.Mutex
+	responses []fakeResponse
+	requests  []sendMessageRequest
+	paths     []string
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+func newFakeBotAPI(t *testing.T, responses ...fakeResponse) (*fakeBotAPI, *httptest.Server) {
+	t.Helper()
+	api := &fakeBotAPI{responses: responses}
+	srv := httptest.NewServer(http.HandlerFunc(api.handle))
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+func (f *fakeBotAPI) handle(w http.ResponseWriter, r *http.Request) {
+	var req sendMessageRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.paths = append(f.paths, r.URL.Path)
+	resp := fakeResponse{status: http.StatusOK, body: `{"ok":true,"result":{}}`}
+	if len(f.responses) > 0 {
+		resp = f.responses[0]
+		f.responses = f.responses[1:]
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.status)
+	_, _ = w.Write([]byte(resp.body))
+}
+
+func (f *fakeBotAPI) recorded() ([]sendMessageRequest, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sendMessageRequest(nil), f.requests...), append([]string(nil), f.paths...)
+}
+
+func TestSender_Send(t *testing.T) {
+	api, srv := newFakeBotAPI(t)
+	sender := NewSender(Config{BotToken: "123:abc", APIURL: srv.URL})
+
+	err := sender.Send(context.Background(), notifications.Notification{
+		To:      "42",
+		Subject: "API down",
+		Body:    "Errors at 5.2% (investigating)",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	requests, paths := api.recorded()
+	if len(requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(requests))
+	}
+	if paths[0] != "/bot123:abc/sendMessage" {
+		t.Errorf("path = %q", paths[0])
+	}
+	got := requests[0]
+	if got.ChatID != "42" {
+		t.Errorf("chat_id = %q, want 42", got.ChatID)
+	}
+	if got.ParseMode != ParseModeMarkdownV2 {
+		t.Errorf("parse_mode = %q, want %q", got.ParseMode, ParseModeMarkdownV2)
+	}
+	want := "*API down*\n\nErrors at 5\\.2% \\(investigating\\)"
+	if got.Text != want {
+		t.Errorf("text = %q, want %q", got.Text, want)
+	}
+}
+
+func TestSender_Send_RetriesAfterRateLimit(t *testing.T) {
+	api, srv := newFakeBotAPI(t,
+		fakeResponse{
+			status: http.StatusTooManyRequests,
+			body:   `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`,
+		},
+	)
+	sender := NewSender(Config{BotToken: "t", APIURL: srv.URL, MaxRetryAfter: 10 * time.Millisecond})
+
+	if err := sender.Send(context.Background(), notifications.Notification{To: "42", Subject: "s", Body: "b"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if requests, _ := api.recorded(); len(requests) != 2 {
+		t.Errorf("requests = %d, want 2", len(requests))
+	}
+}
+
+func TestSender_Send_RateLimitExhausted(t *testing.T) {
+	limited := fakeResponse{
+		status: http.StatusTooManyRequests,
+		body:   `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":1}}`,
+	}
+	api, srv := newFakeBotAPI(t, limited, limited, limited)
+	sender := NewSender(Config{BotToken: "t", APIURL: srv.URL, MaxRetries: 2, MaxRetryAfter: time.Millisecond})
+
+	err := sender.Send(context.Background(), notifications.Notification{To: "42", Subject: "s", Body: "b"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Send() error = %v, want 429 APIError", err)
+	}
+	if requests, _ := api.recorded(); len(requests) != 3 {
+		t.Errorf("requests = %d, want 3", len(requests))
+	}
+}
+
+func TestSender_Send_Unreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		resp fakeResponse
+	}{
+		{
+			name: "bot blocked",
+			resp: fakeResponse{http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`},
+		},
+		{
+			name: "chat not found",
+			resp: fakeResponse{http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, srv := newFakeBotAPI(t, tt.resp)
+			sender := NewSender(Config{BotToken: "t", APIURL: srv.URL})
+
+			err := sender.Send(context.Background(), notifications.Notification{To: "42", Subject: "s", Body: "b"})
+			if !errors.Is(err, notifications.ErrRecipientUnreachable) {
+				t.Errorf("Send() error = %v, want ErrRecipientUnreachable", err)
+			}
+		})
+	}
+}
+
+func TestSender_Send_OtherAPIError(t *testing.T) {
+	_, srv := newFakeBotAPI(t, fakeResponse{http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`})
+	sender := NewSender(Config{BotToken: "t", APIURL: srv.URL})
+
+	err := sender.Send(context.Background(), notifications.Notification{To: "42", Subject: "s", Body: "b"})
+	if err == nil || errors.Is(err, notifications.ErrRecipientUnreachable) {
+		t.Errorf("Send() error = %v, want plain API error", err)
+	}
+}
+
+func TestSender_Send_NotConfigured(t *testing.T) {
+	sender := NewSender(Config{})
+	err := sender.Send(context.Background(), notifications.Notification{To: "42"})
+	if !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Send() error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		parseMode string
+		subject   string
+		body      string
+		want      string
+	}{
+		{
+			name:      "markdown v2",
+			parseMode: ParseModeMarkdownV2,
+			subject:   "[Major] api_gateway",
+			body:      "see https://status.example.com/events/1!",
+			want:      "*\\[Major\\] api\\_gateway*\n\nsee https://status\\.example\\.com/events/1\\!",
+		},
+		{
+			name:      "html",
+			parseMode: ParseModeHTML,
+			subject:   "A & B",
+			body:      "<script>",
+			want:      "<b>A &amp; B</b>\n\n&lt;script&gt;",
+		},
+		{
+			name:      "plain",
+			parseMode: "",
+			subject:   "*s*",
+			body:      "b",
+			want:      "*s*\n\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMessage(tt.parseMode, tt.subject, tt.body); got != tt.want {
+				t.Errorf("formatMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeMarkdownV2_Backslash(t *testing.T) {
+	if got := EscapeMarkdownV2(`a\b`); got != `a\\b` {
+		t.Errorf("EscapeMarkdownV2() = %q", got)
+	}
+}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/notifications"
+	"github.com/go-chi/chi/v5"
+)
+
+// secretTokenHeader carries the secret_token passed to setWebhook.
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Replies sent to the chat after a /start command.
+const (
+	replyVerified    = "Notifications are now enabled for this chat."
+	replyInvalidCode = "This link is invalid or has expired. Create a new Telegram channel in your profile to get a fresh link."
+	replyNoCode      = "To receive notifications, open the link shown when adding a Telegram channel in your profile."
+)
+
+// ChannelVerifier completes channel verification for a Telegram chat.
+type ChannelVerifier interface {
+	VerifyTelegramChat(ctx context.Context, code, chatID string) (*domain.NotificationChannel, error)
+}
+
+// WebhookHandler handles Bot API webhook updates.
+type WebhookHandler struct {
+	sender   *Sender
+	verifier ChannelVerifier
+	secret   string
+}
+
+// NewWebhookHandler creates a new webhook handler.
+// Requests are accepted only with the secret in the secret token header.
+func NewWebhookHandler(sender *Sender, verifier ChannelVerifier, secret string) *WebhookHandler {
+	return &WebhookHandler{
+		sender:   sender,
+		verifier: verifier,
+		secret:   secret,
+	}
+}
+
+// RegisterRoutes registers the webhook route (public, authenticated by secret token).
+func (h *WebhookHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/telegram/webhook", h.HandleUpdate)
+}
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message"`
+}
+
+type message struct {
+	Text string `json:"text"`
+	Chat struct {
+		ID   int64  `json:"id"`
+		Type string `json:"type"`
+	} `json:"chat"`
+}
+
+// HandleUpdate handles POST /telegram/webhook.
+func (h *WebhookHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	got := r.Header.Get(secretTokenHeader)
+	if h.secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(h.secret)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var upd update
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Telegram redelivers updates on non-2xx responses, so failures below are
+	// reported to the chat and logged, but always acknowledged.
+	if upd.Message != nil {
+		if code, ok := parseStartCommand(upd.Message.Text); ok {
+			h.handleStart(r.Context(), strconv.FormatInt(upd.Message.Chat.ID, 10), code)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *WebhookHandler) handleStart(ctx context.Context, chatID, code string) {
+	if code == "" {
+		h.reply(ctx, chatID, replyNoCode)
+		return
+	}
+
+	channel, err := h.verifier.VerifyTelegramChat(ctx, code, chatID)
+	if err != nil {
+		if errors.Is(err, notifications.ErrVerificationNotFound) || errors.Is(err, notifications.ErrInvalidChannelType) {
+			h.reply(ctx, chatID, replyInvalidCode)
+			return
+		}
+		slog.Error("telegram channel verification failed", "chat_id", chatID, "error", err)
+		return
+	}
+
+	slog.Info("telegram channel verified", "channel_id", channel.ID, "chat_id", chatID)
+	h.reply(ctx, chatID, replyVerified)
+}
+
+func (h *WebhookHandler) reply(ctx context.Context, chatID, text string) {
+	if err := h.sender.sendMessage(ctx, chatID, text, ""); err != nil {
+		slog.Warn("failed to reply to telegram chat", "chat_id", chatID, "error", err)
+	}
+}
+
+// parseStartCommand extracts the deep link payload from "/start <code>".
+// The command may be addressed to the bot explicitly ("/start@my_bot <code>").
+func parseStartCommand(text string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	command, _, _ := strings.Cut(fields[0], "@")
+	if command != "/start" {
+		return "", false
+	}
+
+	if len(fields) < 2 {
+		return "", true
+	}
+	return fields[1], true
+}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/notifications"
+)
+
+type fakeVerifier struct {
+	code   string
+	chatID string
+	err    error
+}
+
+func (f *fakeVerifier) VerifyTelegramChat(_ context.Context, code, chatID string) (*domain.NotificationChannel, error) {
+	f.code = code
+	f.chatID = chatID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &domain.NotificationChannel{ID: "ch-1", Type: domain.ChannelTypeTelegram, Target: chatID}, nil
+}
+
+func postUpdate(h *WebhookHandler, secret, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(body))
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+	rec := httptest.NewRecorder()
+	h.HandleUpdate(rec, req)
+	return rec
+}
+
+func TestWebhookHandler_VerifiesChannel(t *testing.T) {
+	api, srv := newFakeBotAPI(t)
+	verifier := &fakeVerifier{}
+	h := NewWebhookHandler(NewSender(Config{BotToken: "t", APIURL: srv.URL}), verifier, "s3cret")
+
+	rec := postUpdate(h, "s3cret", `{"update_id":1,"message":{"text":"/start abc123","chat":{"id":987654321,"type":"private"}}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if verifier.code != "abc123" || verifier.chatID != "987654321" {
+		t.Errorf("verifier got code=%q chat=%q", verifier.code, verifier.chatID)
+	}
+
+	requests, _ := api.recorded()
+	if len(requests) != 1 || requests[0].Text != replyVerified || requests[0].ChatID != "987654321" {
+		t.Errorf("reply = %+v", requests)
+	}
+}
+
+func TestWebhookHandler_InvalidCode(t *testing.T) {
+	api, srv := newFakeBotAPI(t)
+	verifier := &fakeVerifier{err: notifications.ErrVerificationNotFound}
+	h := NewWebhookHandler(NewSender(Config{BotToken: "t", APIURL: srv.URL}), verifier, "s3cret")
+
+	rec := postUpdate(h, "s3cret", `{"update_id":1,"message":{"text":"/start nope","chat":{"id":1}}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	requests, _ := api.recorded()
+	if len(requests) != 1 || requests[0].Text != replyInvalidCode {
+		t.Errorf("reply = %+v", requests)
+	}
+}
+
+func TestWebhookHandler_RejectsWrongSecret(t *testing.T) {
+	verifier := &fakeVerifier{}
+	h := NewWebhookHandler(NewSender(Config{BotToken: "t"}), verifier, "s3cret")
+
+	rec := postUpdate(h, "wrong", `{"update_id":1,"message":{"text":"/start abc","chat":{"id":1}}}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+	if verifier.code != "" {
+		t.Error("verifier must not be called")
+	}
+}
+
+func TestWebhookHandler_RejectsWithoutSecret(t *testing.T) {
+	verifier := &fakeVerifier{}
+	h := NewWebhookHandler(NewSender(Config{BotToken: "t"}), verifier, "")
+
+	rec := postUpdate(h, "", `{"update_id":1,"message":{"text":"/start abc","chat":{"id":1}}}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+	if verifier.code != "" {
+		t.Error("verifier must not be called")
+	}
+}
+
+func TestWebhookHandler_IgnoresOtherMessages(t *testing.T) {
+	api, srv := newFakeBotAPI(t)
+	verifier := &fakeVerifier{}
+	h := NewWebhookHandler(NewSender(Config{BotToken: "t", APIURL: srv.URL}), verifier, "s3cret")
+
+	rec := postUpdate(h, "s3cret", `{"update_id":1,"message":{"text":"hello","chat":{"id":1}}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if verifier.code != "" {
+		t.Error("verifier must not be called")
+	}
+	if requests, _ := api.recorded(); len(requests) != 0 {
+		t.Errorf("unexpected replies: %+v", requests)
+	}
+}
+
+func TestParseStartCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantCode string
+		wantOK   bool
+	}{
+		{"/start abc", "abc", true},
+		{"/start@status_bot abc", "abc", true},
+		{"/start", "", true},
+		{"/help", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		code, ok := parseStartCommand(tt.text)
+		if code != tt.wantCode || ok != tt.wantOK {
+			t.Errorf("parseStartCommand(%q) = (%q, %v), want (%q, %v)", tt.text, code, ok, tt.wantCode, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package notifications
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+const (
+	// telegramVerificationTTL is how long a Telegram deep link stays valid.
+	telegramVerificationTTL = 24 * time.Hour
+	// codeVerificationTTL is how long a code sent through the channel stays valid.
+	codeVerificationTTL = 30 * time.Minute
+	// maxVerificationAttempts is the number of codes tried, right or wrong, before the code is burned.
+	maxVerificationAttempts = 5
+	// maxVerificationTotalAttempts is the number of attempts across all codes of
+	// a channel, so that resending does not give unlimited guesses.
+	maxVerificationTotalAttempts = 20
+	// verificationResendCooldown is the minimum interval between two issued codes.
+	verificationResendCooldown = time.Minute
+	// Codes sent within verificationSendWindow are limited per user and per
+	// target, so that channels cannot be created in a loop to flood an address.
+	verificationSendWindow        = time.Hour
+	maxVerificationSendsPerUser   = 10
+	maxVerificationSendsPerTarget = 5
+	// maxUnverifiedChannels is the number of unverified channels a user may have.
+	maxUnverifiedChannels = 5
+)
+
+// VerifyChannel verifies a channel with the code delivered to its target.
+func (s *Service) VerifyChannel(ctx context.Context, userID, channelID, code string) (*domain.NotificationChannel, error) {
+	channel, err := s.repo.GetChannelByID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if channel.UserID != userID {
+		return nil, ErrChannelNotOwned
+	}
+
+	if channel.IsVerified {
+		return channel, nil
+	}
+
+	// Telegram channels are verified by the bot, which learns the chat ID.
+	if channel.Type == domain.ChannelTypeTelegram {
+		return nil, ErrInvalidChannelType
+	}
+
+	codeHash, err := s.repo.UseVerificationAttempt(ctx, channel.ID, maxVerificationAttempts, maxVerificationTotalAttempts)
+	if err != nil {
+		if errors.Is(err, ErrVerificationNotFound) {
+			return nil, s.unusableVerificationError(ctx, channel.ID)
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashVerificationCode(code)), []byte(codeHash)) != 1 {
+		return nil, ErrVerificationCodeInvalid
+	}
+
+	before := *channel
+	channel.IsVerified = true
+	if err := s.repo.UpdateChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteVerificationCode(ctx, channel.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionVerify,
+		EntityType: domain.AuditEntityChannel,
+		EntityID:   channel.ID,
+		Before:     &before,
+		After:      channel,
+	})
+
+	return channel, nil
+}
+
+// unusableVerificationError explains why no attempt could be made at the code
+// of a channel: the attempts are used up, or the code is missing or expired.
+func (s *Service) unusableVerificationError(ctx context.Context, channelID string) error {
+	pending, err := s.repo.GetVerificationCode(ctx, channelID)
+	if err != nil {
+		if errors.Is(err, ErrVerificationNotFound) {
+			return ErrVerificationCodeInvalid
+		}
+		return err
+	}
+	if pending.TotalAttempts >= maxVerificationTotalAttempts {
+		return ErrVerificationLocked
+	}
+	if pending.Attempts >= maxVerificationAttempts && time.Now().Before(pending.ExpiresAt) {
+		return ErrVerificationAttemptsExceeded
+	}
+	return ErrVerificationCodeInvalid
+}
+
+// ResendVerification issues a new verification code (or Telegram link) for an unverified channel.
+func (s *Service) ResendVerification(ctx context.Context, userID, channelID string) (*domain.NotificationChannel, error) {
+	channel, err := s.repo.GetChannelByID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if channel.UserID != userID {
+		return nil, ErrChannelNotOwned
+	}
+
+	if channel.IsVerified {
+		return nil, ErrChannelAlreadyVerified
+	}
+
+	pending, err := s.repo.GetVerificationCode(ctx, channel.ID)
+	if err != nil && !errors.Is(err, ErrVerificationNotFound) {
+		return nil, err
+	}
+	if pending != nil && pending.TotalAttempts >= maxVerificationTotalAttempts {
+		return nil, ErrVerificationLocked
+	}
+	if pending != nil && time.Since(pending.CreatedAt) < verificationResendCooldown {
+		return nil, ErrVerificationResendTooSoon
+	}
+	if err := s.reserveVerificationSend(ctx, userID, channel.Type, channel.Target); err != nil {
+		return nil, err
+	}
+
+	if err := s.issueVerification(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// VerifyTelegramChat completes verification of a Telegram channel from a bot /start payload.
+// The channel target is replaced with the chat ID the bot can send messages to.
+func (s *Service) VerifyTelegramChat(ctx context.Context, code, chatID string) (*domain.NotificationChannel, error) {
+	channel, err := s.repo.GetTelegramChannelByCode(ctx, hashVerificationCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if channel.Type != domain.ChannelTypeTelegram {
+		return nil, ErrInvalidChannelType
+	}
+
+	before := *channel
+	channel.Target = chatID
+	channel.IsVerified = true
+	channel.IsEnabled = true
+
+	if err := s.repo.UpdateChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteVerificationCode(ctx, channel.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionVerify,
+		EntityType: domain.AuditEntityChannel,
+		EntityID:   channel.ID,
+		Before:     &before,
+		After:      channel,
+	})
+
+	return channel, nil
+}
+
+// issueVerification starts verification of a channel: Telegram channels get a
+// bot deep link, other channels receive a one-time code through their sender.
+func (s *Service) issueVerification(ctx context.Context, channel *domain.NotificationChannel) error {
+	if channel.Type == domain.ChannelTypeTelegram {
+		link, err := s.issueTelegramLink(ctx, channel.ID)
+		if err != nil {
+			return err
+		}
+		channel.VerificationLink = link
+		return nil
+	}
+
+	code, err := generateNumericCode()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(codeVerificationTTL)
+	if err := s.repo.SaveVerificationCode(ctx, channel.ID, hashVerificationCode(code), expiresAt); err != nil {
+		return err
+	}
+
+	subject := "Confirm your notification channel"
+	body := fmt.Sprintf(
+		"Your verification code is %s\n\nIt expires in %d minutes. If you did not add this notification channel, ignore this message.",
+		code, int(codeVerificationTTL.Minutes()),
+	)
+	return s.dispatcher.Enqueue(ctx, *channel, subject, body)
+}
+
+// reserveVerificationSend counts a code about to be sent to target against the
+// per-user and per-target limits. Telegram links are returned to the user and
+// send nothing, so they are not counted.
+func (s *Service) reserveVerificationSend(ctx context.Context, userID string, channelType domain.ChannelType, target string) error {
+	if channelType == domain.ChannelTypeTelegram {
+		return nil
+	}
+
+	recorded, err := s.repo.RecordVerificationSend(ctx, userID, target, verificationSendWindow,
+		maxVerificationSendsPerUser, maxVerificationSendsPerTarget)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return ErrVerificationSendLimited
+	}
+	return nil
+}
+
+// checkUnverifiedChannels refuses a new channel when the user already has too many unverified ones.
+func (s *Service) checkUnverifiedChannels(ctx context.Context, userID string) error {
+	channels, err := s.repo.ListUserChannels(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	unverified := 0
+	for _, channel := range channels {
+		if !channel.IsVerified {
+			unverified++
+		}
+	}
+	if unverified >= maxUnverifiedChannels {
+		return ErrTooManyUnverifiedChannels
+	}
+	return nil
+}
+
+// issueTelegramLink stores a fresh verification code and returns the bot deep link for it.
+func (s *Service) issueTelegramLink(ctx context.Context, channelID string) (string, error) {
+	if s.config.TelegramBotUsername == "" {
+		return "", ErrTelegramNotConfigured
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(telegramVerificationTTL)
+	if err := s.repo.SaveVerificationCode(ctx, channelID, hashVerificationCode(code), expiresAt); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("https://t.me/%s?start=%s", s.config.TelegramBotUsername, code), nil
+}
+
+// validateTarget checks the target format for channel types with a well-known format.
+func (s *Service) validateTarget(channelType domain.ChannelType, target string) error {
+	switch channelType {
+	case domain.ChannelTypeEmail:
+		addr, err := mail.ParseAddress(target)
+		if err != nil || addr.Address != target {
+			return ErrInvalidTarget
+		}
+	case domain.ChannelTypeTelegram:
+		if s.config.TelegramBotUsername == "" {
+			return ErrTelegramNotConfigured
+		}
+	case domain.ChannelTypeSlack:
+		return validateWebhookTarget(target, slackWebhookHosts)
+	case domain.ChannelTypeMSTeams:
+		return validateWebhookTarget(target, teamsWebhookHosts)
+	}
+	return nil
+}
+
+// Hosts that serve incoming webhooks. A leading dot matches any subdomain.
+// Webhook targets are limited to them so that the server cannot be pointed
+// at arbitrary URLs.
+var (
+	slackWebhookHosts = []string{"hooks.slack.com", "hooks.slack-gov.com"}
+	teamsWebhookHosts = []string{".webhook.office.com", ".logic.azure.com", ".api.powerplatform.com"}
+)
+
+// validateWebhookTarget checks that target is an https URL on one of hosts.
+func validateWebhookTarget(target string, hosts []string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return ErrInvalidTarget
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		if host == h || (strings.HasPrefix(h, ".") && strings.HasSuffix(host, h)) {
+			return nil
+		}
+	}
+	return ErrInvalidTarget
+}
+
+// generateVerificationCode returns a random code usable as a Telegram deep link payload.
+func generateVerificationCode() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate verification code: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// generateNumericCode returns a random 6-digit code that is easy to type.
+func generateNumericCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("generate verification code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashVerificationCode returns the hex SHA-256 of a code; only hashes are stored.
+func hashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
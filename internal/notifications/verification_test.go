@@ -0,0 +1,435 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// fakeVerificationRepo adds channel and verification code storage to fakeOutboxRepo.
+type fakeVerificationRepo struct {
+	*fakeOutboxRepo
+
+	codes map[string]*VerificationCode
+	sends []verificationSend
+	seq   int
+}
+
+type verificationSend struct {
+	userID string
+	target string
+}
+
+func newFakeVerificationRepo() *fakeVerificationRepo {
+	return &fakeVerificationRepo{
+		fakeOutboxRepo: newFakeOutboxRepo(),
+		codes:          map[string]*VerificationCode{},
+	}
+}
+
+func (f *fakeVerificationRepo) CreateChannel(_ context.Context, channel *domain.NotificationChannel) error {
+	f.seq++
+	channel.ID = fmt.Sprintf("c%d", f.seq)
+	c := *channel
+	f.channels[channel.ID] = &c
+	return nil
+}
+
+func (f *fakeVerificationRepo) ListUserChannels(_ context.Context, userID string) ([]domain.NotificationChannel, error) {
+	var channels []domain.NotificationChannel
+	for _, channel := range f.channels {
+		if channel.UserID == userID {
+			channels = append(channels, *channel)
+		}
+	}
+	return channels, nil
+}
+
+func (f *fakeVerificationRepo) DeleteChannel(_ context.Context, id string) error {
+	delete(f.channels, id)
+	delete(f.codes, id)
+	return nil
+}
+
+func (f *fakeVerificationRepo) RecordVerificationSend(_ context.Context, userID, target string, _ time.Duration, maxPerUser, maxPerTarget int) (bool, error) {
+	byUser, byTarget := 0, 0
+	for _, send := range f.sends {
+		if send.userID == userID {
+			byUser++
+		}
+		if send.target == strings.ToLower(target) {
+			byTarget++
+		}
+	}
+	if byUser >= maxPerUser || byTarget >= maxPerTarget {
+		return false, nil
+	}
+	f.sends = append(f.sends, verificationSend{userID: userID, target: strings.ToLower(target)})
+	return true, nil
+}
+
+func (f *fakeVerificationRepo) SaveVerificationCode(_ context.Context, channelID, codeHash string, expiresAt time.Time) error {
+	code := &VerificationCode{
+		ChannelID: channelID,
+		CodeHash:  codeHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if previous, ok := f.codes[channelID]; ok {
+		code.TotalAttempts = previous.TotalAttempts
+	}
+	f.codes[channelID] = code
+	return nil
+}
+
+func (f *fakeVerificationRepo) GetVerificationCode(_ context.Context, channelID string) (*VerificationCode, error) {
+	code, ok := f.codes[channelID]
+	if !ok {
+		return nil, ErrVerificationNotFound
+	}
+	c := *code
+	return &c, nil
+}
+
+func (f *fakeVerificationRepo) UseVerificationAttempt(_ context.Context, channelID string, maxAttempts, maxTotalAttempts int) (string, error) {
+	code, ok := f.codes[channelID]
+	if !ok || code.Attempts >= maxAttempts || code.TotalAttempts >= maxTotalAttempts || !time.Now().Before(code.ExpiresAt) {
+		return "", ErrVerificationNotFound
+	}
+	code.Attempts++
+	code.TotalAttempts++
+	return code.CodeHash, nil
+}
+
+func (f *fakeVerificationRepo) DeleteVerificationCode(_ context.Context, channelID string) error {
+	delete(f.codes, channelID)
+	return nil
+}
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func newVerificationService(repo *fakeVerificationRepo) *Service {
+	dispatcher := NewDispatcher(repo, DispatcherConfig{}, &fakeSender{channelType: domain.ChannelTypeEmail})
+	return NewService(repo, dispatcher, Config{})
+}
+
+func sentCode(t *testing.T, repo *fakeVerificationRepo) string {
+	t.Helper()
+	if len(repo.deliveries) == 0 {
+		t.Fatal("no verification message enqueued")
+	}
+	code := codePattern.FindString(repo.deliveries[len(repo.deliveries)-1].Body)
+	if code == "" {
+		t.Fatal("verification message has no code")
+	}
+	return code
+}
+
+func TestService_CreateChannelSendsCode(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+
+	if _, err := s.CreateChannel(context.Background(), "u1", domain.ChannelTypeEmail, "not-an-email"); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("CreateChannel(invalid) error = %v, want ErrInvalidTarget", err)
+	}
+
+	channel, err := s.CreateChannel(context.Background(), "u1", domain.ChannelTypeEmail, "a@example.com")
+	if err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+	if channel.IsVerified {
+		t.Error("new channel must not be verified")
+	}
+
+	code := sentCode(t, repo)
+	if repo.deliveries[0].Target != "a@example.com" {
+		t.Errorf("code sent to %q, want a@example.com", repo.deliveries[0].Target)
+	}
+	if repo.codes["c1"].CodeHash == code {
+		t.Error("code must be stored hashed")
+	}
+}
+
+func TestService_VerifyChannel(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+	ctx := context.Background()
+
+	if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "a@example.com"); err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+	code := sentCode(t, repo)
+
+	if _, err := s.VerifyChannel(ctx, "u2", "c1", code); !errors.Is(err, ErrChannelNotOwned) {
+		t.Errorf("VerifyChannel(other user) error = %v, want ErrChannelNotOwned", err)
+	}
+	if _, err := s.VerifyChannel(ctx, "u1", "c1", "000000x"); !errors.Is(err, ErrVerificationCodeInvalid) {
+		t.Errorf("VerifyChannel(wrong code) error = %v, want ErrVerificationCodeInvalid", err)
+	}
+	if repo.codes["c1"].Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", repo.codes["c1"].Attempts)
+	}
+
+	channel, err := s.VerifyChannel(ctx, "u1", "c1", code)
+	if err != nil {
+		t.Fatalf("VerifyChannel() error = %v", err)
+	}
+	if !channel.IsVerified || !repo.channels["c1"].IsVerified {
+		t.Error("channel must be verified")
+	}
+	if _, ok := repo.codes["c1"]; ok {
+		t.Error("code must be deleted after verification")
+	}
+}
+
+func TestService_VerifyChannelRejectsExpiredAndExhaustedCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*VerificationCode)
+		wantErr error
+	}{
+		{
+			name:    "expired",
+			mutate:  func(c *VerificationCode) { c.ExpiresAt = time.Now().Add(-time.Second) },
+			wantErr: ErrVerificationCodeInvalid,
+		},
+		{
+			name:    "too many attempts",
+			mutate:  func(c *VerificationCode) { c.Attempts = maxVerificationAttempts },
+			wantErr: ErrVerificationAttemptsExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeVerificationRepo()
+			s := newVerificationService(repo)
+			ctx := context.Background()
+
+			if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "a@example.com"); err != nil {
+				t.Fatalf("CreateChannel() error = %v", err)
+			}
+			code := sentCode(t, repo)
+			tt.mutate(repo.codes["c1"])
+
+			if _, err := s.VerifyChannel(ctx, "u1", "c1", code); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyChannel() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.channels["c1"].IsVerified {
+				t.Error("channel must stay unverified")
+			}
+		})
+	}
+}
+
+func TestService_VerifyChannelCountsEveryAttempt(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+	ctx := context.Background()
+
+	if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "a@example.com"); err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+	code := sentCode(t, repo)
+	repo.codes["c1"].Attempts = maxVerificationAttempts - 1
+
+	if _, err := s.VerifyChannel(ctx, "u1", "c1", "000000x"); !errors.Is(err, ErrVerificationCodeInvalid) {
+		t.Fatalf("VerifyChannel(wrong code) error = %v, want ErrVerificationCodeInvalid", err)
+	}
+	if _, err := s.VerifyChannel(ctx, "u1", "c1", code); !errors.Is(err, ErrVerificationAttemptsExceeded) {
+		t.Errorf("VerifyChannel(after last attempt) error = %v, want ErrVerificationAttemptsExceeded", err)
+	}
+	if repo.channels["c1"].IsVerified {
+		t.Error("channel must stay unverified")
+	}
+}
+
+func TestService_VerifyChannelLocksAfterTotalAttempts(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+	ctx := context.Background()
+
+	if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "a@example.com"); err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+
+	for i := 0; i < maxVerificationTotalAttempts; i++ {
+		if i > 0 && i%maxVerificationAttempts == 0 {
+			repo.codes["c1"].CreatedAt = time.Now().Add(-verificationResendCooldown)
+			if _, err := s.ResendVerification(ctx, "u1", "c1"); err != nil {
+				t.Fatalf("ResendVerification() error = %v", err)
+			}
+		}
+		if _, err := s.VerifyChannel(ctx, "u1", "c1", "000000x"); !errors.Is(err, ErrVerificationCodeInvalid) {
+			t.Fatalf("VerifyChannel(attempt %d) error = %v, want ErrVerificationCodeInvalid", i+1, err)
+		}
+	}
+
+	code := sentCode(t, repo)
+	if _, err := s.VerifyChannel(ctx, "u1", "c1", code); !errors.Is(err, ErrVerificationLocked) {
+		t.Errorf("VerifyChannel(after total limit) error = %v, want ErrVerificationLocked", err)
+	}
+	repo.codes["c1"].CreatedAt = time.Now().Add(-verificationResendCooldown)
+	if _, err := s.ResendVerification(ctx, "u1", "c1"); !errors.Is(err, ErrVerificationLocked) {
+		t.Errorf("ResendVerification(after total limit) error = %v, want ErrVerificationLocked", err)
+	}
+}
+
+func TestService_ResendVerification(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+	ctx := context.Background()
+
+	if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "a@example.com"); err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+	first := sentCode(t, repo)
+
+	if _, err := s.ResendVerification(ctx, "u1", "c1"); !errors.Is(err, ErrVerificationResendTooSoon) {
+		t.Fatalf("ResendVerification() error = %v, want ErrVerificationResendTooSoon", err)
+	}
+
+	repo.codes["c1"].CreatedAt = time.Now().Add(-verificationResendCooldown)
+	if _, err := s.ResendVerification(ctx, "u1", "c1"); err != nil {
+		t.Fatalf("ResendVerification() error = %v", err)
+	}
+	if len(repo.deliveries) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(repo.deliveries))
+	}
+
+	second := sentCode(t, repo)
+	if first != second {
+		if _, err := s.VerifyChannel(ctx, "u1", "c1", first); !errors.Is(err, ErrVerificationCodeInvalid) {
+			t.Errorf("VerifyChannel(old code) error = %v, want ErrVerificationCodeInvalid", err)
+		}
+	}
+	if _, err := s.VerifyChannel(ctx, "u1", "c1", second); err != nil {
+		t.Fatalf("VerifyChannel(new code) error = %v", err)
+	}
+
+	if _, err := s.ResendVerification(ctx, "u1", "c1"); !errors.Is(err, ErrChannelAlreadyVerified) {
+		t.Errorf("ResendVerification(verified) error = %v, want ErrChannelAlreadyVerified", err)
+	}
+}
+
+func TestService_CreateChannelLimitsVerificationSends(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+	ctx := context.Background()
+
+	for i := 0; i < maxVerificationSendsPerTarget; i++ {
+		channel, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "victim@example.com")
+		if err != nil {
+			t.Fatalf("CreateChannel(%d) error = %v", i+1, err)
+		}
+		if err := s.DeleteChannel(ctx, "u1", channel.ID); err != nil {
+			t.Fatalf("DeleteChannel() error = %v", err)
+		}
+	}
+
+	if _, err := s.CreateChannel(ctx, "u2", domain.ChannelTypeEmail, "Victim@example.com"); !errors.Is(err, ErrVerificationSendLimited) {
+		t.Errorf("CreateChannel(same target) error = %v, want ErrVerificationSendLimited", err)
+	}
+	if len(repo.deliveries) != maxVerificationSendsPerTarget {
+		t.Errorf("deliveries = %d, want %d", len(repo.deliveries), maxVerificationSendsPerTarget)
+	}
+
+	for i := 0; i < maxVerificationSendsPerUser-maxVerificationSendsPerTarget; i++ {
+		channel, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, fmt.Sprintf("a%d@example.com", i))
+		if err != nil {
+			t.Fatalf("CreateChannel(%d) error = %v", i+1, err)
+		}
+		if err := s.DeleteChannel(ctx, "u1", channel.ID); err != nil {
+			t.Fatalf("DeleteChannel() error = %v", err)
+		}
+	}
+	if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "other@example.com"); !errors.Is(err, ErrVerificationSendLimited) {
+		t.Errorf("CreateChannel(over user limit) error = %v, want ErrVerificationSendLimited", err)
+	}
+	if len(repo.channels) != 0 {
+		t.Errorf("channels = %d, want none created over the limit", len(repo.channels))
+	}
+}
+
+func TestService_CreateChannelLimitsUnverifiedChannels(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+	ctx := context.Background()
+
+	for i := 0; i < maxUnverifiedChannels; i++ {
+		if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, fmt.Sprintf("a%d@example.com", i)); err != nil {
+			t.Fatalf("CreateChannel(%d) error = %v", i+1, err)
+		}
+	}
+
+	if _, err := s.CreateChannel(ctx, "u1", domain.ChannelTypeEmail, "more@example.com"); !errors.Is(err, ErrTooManyUnverifiedChannels) {
+		t.Errorf("CreateChannel() error = %v, want ErrTooManyUnverifiedChannels", err)
+	}
+	if _, err := s.CreateChannel(ctx, "u2", domain.ChannelTypeEmail, "more@example.com"); err != nil {
+		t.Errorf("CreateChannel(other user) error = %v", err)
+	}
+}
+
+func TestService_UpdateChannelTargetRequiresVerification(t *testing.T) {
+	repo := newFakeVerificationRepo()
+	s := newVerificationService(repo)
+	ctx := context.Background()
+
+	repo.channels["c1"] = &domain.NotificationChannel{
+		ID: "c1", UserID: "u1", Type: domain.ChannelTypeEmail, Target: "a@example.com", IsEnabled: true, IsVerified: true,
+	}
+
+	target := "b@example.com"
+	channel, err := s.UpdateChannel(ctx, "u1", "c1", UpdateChannelInput{Target: &target})
+	if err != nil {
+		t.Fatalf("UpdateChannel() error = %v", err)
+	}
+	if channel.IsVerified || repo.channels["c1"].IsVerified {
+		t.Error("changing the target must reset verification")
+	}
+	sentCode(t, repo)
+	if repo.deliveries[0].Target != target {
+		t.Errorf("code sent to %q, want %q", repo.deliveries[0].Target, target)
+	}
+
+	enabled := false
+	if _, err := s.UpdateChannel(ctx, "u1", "c1", UpdateChannelInput{IsEnabled: &enabled}); err != nil {
+		t.Fatalf("UpdateChannel() error = %v", err)
+	}
+	if len(repo.deliveries) != 1 {
+		t.Error("toggling the channel must not send a new code")
+	}
+}
+
+func TestValidateWebhookTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		hosts   []string
+		wantErr bool
+	}{
+		{name: "slack", target: "https://hooks.slack.com/services/T000/B000/XXXX", hosts: slackWebhookHosts},
+		{name: "teams connector", target: "https://contoso.webhook.office.com/webhookb2/abc/IncomingWebhook/def", hosts: teamsWebhookHosts},
+		{name: "teams workflow", target: "https://prod-01.westus.logic.azure.com:443/workflows/abc/triggers/manual/paths/invoke", hosts: teamsWebhookHosts},
+		{name: "plain http", target: "http://hooks.slack.com/services/T000/B000/XXXX", hosts: slackWebhookHosts, wantErr: true},
+		{name: "other host", target: "https://hooks.slack.com.evil.example/services", hosts: slackWebhookHosts, wantErr: true},
+		{name: "suffix without dot", target: "https://evilwebhook.office.com/webhookb2", hosts: teamsWebhookHosts, wantErr: true},
+		{name: "internal address", target: "https://169.254.169.254/latest", hosts: teamsWebhookHosts, wantErr: true},
+		{name: "not a url", target: "hooks.slack.com", hosts: slackWebhookHosts, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookTarget(tt.target, tt.hosts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhookTarget(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+			}
+		})
+	}
+}
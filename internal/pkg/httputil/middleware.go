@@ -3,8 +3,11 @@ package httputil
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 
 	"github.com/bissquit/incident-garden/internal/domain"
@@ -31,7 +34,7 @@ func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 			// Handle preflight OPTIONS request
 			if r.Method == http.MethodOptions {
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 				w.Header().Set("Access-Control-Max-Age", "86400")
 				w.WriteHeader(http.StatusNoContent)
 				return
@@ -42,45 +45,152 @@ func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 	}
 }
 
+// ClientIPMiddleware stores the client address and user agent in the request
+// context, and the address in RemoteAddr for request logs. X-Forwarded-For and
+// X-Real-IP are only honored for requests from trustedProxies; otherwise the
+// socket address is used, so clients cannot forge their address.
+func ClientIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r, trustedProxies)
+			r.RemoteAddr = ip
+
+			ctx := context.WithValue(r.Context(), ClientIPKey, ip)
+			ctx = context.WithValue(ctx, UserAgentKey, r.UserAgent())
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// clientIP returns the socket address of r or, when it is a trusted proxy, the
+// nearest address in X-Forwarded-For that is not a trusted proxy.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return ip
+			}
+			ip = addr.Unmap().String()
+			if !isTrustedProxy(ip, trustedProxies) {
+				return ip
+			}
+		}
+		return ip
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String()
+	}
+	return ip
+}
+
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 type contextKey string
 
 // Context keys for storing user information.
 const (
 	UserIDKey contextKey = "user_id"
 	RoleKey   contextKey = "role"
+	// ScopesKey is set only for API keys narrowed to a scope list.
+	ScopesKey contextKey = "scopes"
+	// APIKeyIDKey is set when the request is authenticated with an API key.
+	APIKeyIDKey contextKey = "api_key_id"
+	// ClientIPKey holds the client address, see ClientIPMiddleware.
+	ClientIPKey contextKey = "client_ip"
+	// UserAgentKey holds the client User-Agent header, see ClientIPMiddleware.
+	UserAgentKey contextKey = "user_agent"
 )
 
+// APIKeyHeader carries an API key as an alternative to the Authorization header.
+const APIKeyHeader = "X-API-Key"
+
+// ErrPasswordChangeRequired is returned by TokenValidator for the tokens and
+// API keys of a user who has to change the password before using the API.
+var ErrPasswordChangeRequired = errors.New("password change required")
+
 // TokenValidator interface for validating tokens.
 type TokenValidator interface {
 	ValidateToken(ctx context.Context, token string) (userID string, role domain.Role, err error)
+	ValidateAPIKey(ctx context.Context, key string) (*domain.APIKey, error)
 }
 
 // AuthMiddleware creates authentication middleware.
+// It accepts a JWT or an API key as a bearer token, or an API key in the X-API-Key header.
 func AuthMiddleware(validator TokenValidator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				respondError(w, http.StatusUnauthorized, "missing authorization header")
-				return
-			}
+			token := r.Header.Get(APIKeyHeader)
+			if token == "" {
+				authHeader := r.Header.Get("Authorization")
+				if authHeader == "" {
+					respondError(w, http.StatusUnauthorized, "missing authorization header")
+					return
+				}
 
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				respondError(w, http.StatusUnauthorized, "invalid authorization header format")
-				return
+				parts := strings.SplitN(authHeader, " ", 2)
+				if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+					respondError(w, http.StatusUnauthorized, "invalid authorization header format")
+					return
+				}
+
+				token = parts[1]
 			}
 
-			token := parts[1]
+			ctx := r.Context()
+			if domain.IsAPIKey(token) {
+				key, err := validator.ValidateAPIKey(ctx, token)
+				if errors.Is(err, ErrPasswordChangeRequired) {
+					respondError(w, http.StatusForbidden, err.Error())
+					return
+				}
+				if err != nil {
+					respondError(w, http.StatusUnauthorized, "invalid or expired token")
+					return
+				}
 
-			userID, role, err := validator.ValidateToken(r.Context(), token)
-			if err != nil {
-				respondError(w, http.StatusUnauthorized, "invalid or expired token")
-				return
-			}
+				ctx = context.WithValue(ctx, UserIDKey, key.CreatedBy)
+				ctx = context.WithValue(ctx, RoleKey, key.Role)
+				ctx = context.WithValue(ctx, APIKeyIDKey, key.ID)
+				if len(key.Scopes) > 0 {
+					ctx = context.WithValue(ctx, ScopesKey, key.Scopes)
+				}
+			} else {
+				userID, role, err := validator.ValidateToken(ctx, token)
+				if errors.Is(err, ErrPasswordChangeRequired) {
+					respondError(w, http.StatusForbidden, err.Error())
+					return
+				}
+				if err != nil {
+					respondError(w, http.StatusUnauthorized, "invalid or expired token")
+					return
+				}
 
-			ctx := context.WithValue(r.Context(), UserIDKey, userID)
-			ctx = context.WithValue(ctx, RoleKey, role)
+				ctx = context.WithValue(ctx, UserIDKey, userID)
+				ctx = context.WithValue(ctx, RoleKey, role)
+			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -107,6 +217,38 @@ func RequireRole(minRole domain.Role) func(http.Handler) http.Handler {
 	}
 }
 
+// RequireScope restricts scoped API keys to the given resource: safe methods
+// need "<resource>:read", others "<resource>:write". Callers without a scope
+// list (users and role-bound API keys) are only limited by their role.
+func RequireScope(resource string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scopes, ok := r.Context().Value(ScopesKey).([]string); ok {
+				write := r.Method != http.MethodGet && r.Method != http.MethodHead
+				if !domain.ScopesAllow(scopes, resource, write) {
+					respondError(w, http.StatusForbidden, "insufficient scope")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RejectAPIKeys restricts routes to users logged in themselves. Account routes
+// act on the key's creator, so an API key must not reach them whatever its scopes.
+func RejectAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetAPIKeyID(r.Context()) != "" {
+			respondError(w, http.StatusForbidden, "api keys cannot access account routes")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // GetUserID extracts user ID from context.
 func GetUserID(ctx context.Context) string {
 	if id, ok := ctx.Value(UserIDKey).(string); ok {
@@ -115,6 +257,30 @@ func GetUserID(ctx context.Context) string {
 	return ""
 }
 
+// GetAPIKeyID extracts the API key ID from context.
+func GetAPIKeyID(ctx context.Context) string {
+	if id, ok := ctx.Value(APIKeyIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// GetClientIP extracts the client address from context.
+func GetClientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(ClientIPKey).(string); ok {
+		return ip
+	}
+	return ""
+}
+
+// GetUserAgent extracts the client User-Agent header from context.
+func GetUserAgent(ctx context.Context) string {
+	if ua, ok := ctx.Value(UserAgentKey).(string); ok {
+		return ua
+	}
+	return ""
+}
+
 // GetRole extracts role from context.
 func GetRole(ctx context.Context) domain.Role {
 	if role, ok := ctx.Value(RoleKey).(domain.Role); ok {
@@ -40,3 +40,31 @@ func Connect(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// WithTryAdvisoryLock runs fn while holding a session-level advisory lock on key.
+// If another session holds the lock, fn is not called and false is returned.
+// It lets background jobs run on a single replica at a time.
+func WithTryAdvisoryLock(ctx context.Context, pool *pgxpool.Pool, key int64, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		return false, fmt.Errorf("try advisory lock: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	defer func() {
+		// Unlock even if ctx is cancelled, otherwise the lock lives as long as the pooled connection.
+		if _, err := conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			_ = conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+	}()
+
+	return true, fn(ctx)
+}
@@ -0,0 +1,178 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// ContentType is the content type of the stream.
+const ContentType = "text/event-stream"
+
+// retryDelay is how long clients wait before reconnecting, in milliseconds.
+const retryDelay = 5000
+
+// ServiceCatalog is the part of catalog.Service used to resolve service filters.
+type ServiceCatalog interface {
+	GetServiceBySlug(ctx context.Context, slug string) (*domain.Service, error)
+}
+
+// Handler handles stream requests.
+type Handler struct {
+	service *Service
+	catalog ServiceCatalog
+}
+
+// NewHandler creates a new stream handler.
+func NewHandler(service *Service, serviceCatalog ServiceCatalog) *Handler {
+	return &Handler{
+		service: service,
+		catalog: serviceCatalog,
+	}
+}
+
+// RegisterRoutes registers the public stream route.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/status/stream", h.Stream)
+}
+
+// Stream handles GET /status/stream.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	lastID, resume, err := lastEventID(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var serviceIDs []string
+	for _, slug := range r.URL.Query()["service"] {
+		service, err := h.catalog.GetServiceBySlug(r.Context(), slug)
+		if err != nil {
+			if errors.Is(err, catalog.ErrServiceNotFound) {
+				h.respondError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			slog.Error("internal error", "error", err)
+			h.respondError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		serviceIDs = append(serviceIDs, service.ID)
+	}
+
+	// Subscribe before reading the backlog so that nothing is missed in between.
+	sub := h.service.Subscribe(serviceIDs)
+	defer h.service.Unsubscribe(sub)
+
+	var backlog []*Message
+	if resume {
+		backlog, err = h.service.Replay(r.Context(), lastID, sub)
+		if err != nil {
+			slog.Error("internal error", "error", err)
+			h.respondError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	// The stream outlives the server read and write timeouts.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("failed to clear read deadline", "error", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("failed to clear write deadline", "error", err)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	// Disable response buffering in nginx.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", retryDelay); err != nil {
+		return
+	}
+	for _, msg := range backlog {
+		if err := writeMessage(w, msg); err != nil {
+			return
+		}
+		lastID = msg.ID
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.service.config.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return
+			}
+			// Already sent from the backlog.
+			if msg.ID <= lastID {
+				continue
+			}
+			if err := writeMessage(w, msg); err != nil {
+				return
+			}
+			lastID = msg.ID
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// lastEventID returns the ID to resume after, from the Last-Event-ID header
+// sent by reconnecting clients or the last_event_id query parameter.
+func lastEventID(r *http.Request) (int64, bool, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, errors.New("last event id must be a non-negative integer")
+	}
+	return id, true, nil
+}
+
+// writeMessage writes a message as a server-sent event.
+// JSON data has no raw newlines, so it fits in a single data line.
+func writeMessage(w io.Writer, msg *Message) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Data)
+	return err
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package postgres provides PostgreSQL implementation of the stream repository.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/stream"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// channel is the NOTIFY channel used by the status_stream_messages insert trigger.
+const channel = "status_stream"
+
+// insertLockKey serializes message inserts so that IDs become visible in order
+// and a reader never skips a message committed after a higher ID.
+const insertLockKey int64 = 0x7374726561 // "strea"
+
+// Repository implements stream.Repository using PostgreSQL.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a new PostgreSQL repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateMessage stores a message and notifies listeners on commit.
+func (r *Repository) CreateMessage(ctx context.Context, msg *stream.Message) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, insertLockKey); err != nil {
+		return fmt.Errorf("lock stream messages: %w", err)
+	}
+
+	query := `
+		INSERT INTO status_stream_messages (type, service_ids, data)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	serviceIDs := msg.ServiceIDs
+	if serviceIDs == nil {
+		serviceIDs = []string{}
+	}
+	if err := tx.QueryRow(ctx, query, msg.Type, serviceIDs, msg.Data).Scan(&msg.ID, &msg.CreatedAt); err != nil {
+		return fmt.Errorf("create stream message: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListMessagesAfter returns up to limit messages with an ID greater than afterID, oldest first.
+func (r *Repository) ListMessagesAfter(ctx context.Context, afterID int64, limit int) ([]*stream.Message, error) {
+	query := `
+		SELECT id, type, service_ids::text[], data, created_at
+		FROM status_stream_messages
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list stream messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*stream.Message
+	for rows.Next() {
+		var msg stream.Message
+		if err := rows.Scan(&msg.ID, &msg.Type, &msg.ServiceIDs, &msg.Data, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan stream message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate stream messages: %w", err)
+	}
+	return messages, nil
+}
+
+// LatestMessageID returns the ID of the newest message, or zero if there are none.
+func (r *Repository) LatestMessageID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.db.QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM status_stream_messages`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("get latest stream message: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteMessagesBefore removes messages created before the given time.
+func (r *Repository) DeleteMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM status_stream_messages WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete stream messages: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Listen holds a dedicated connection subscribed to the stream channel.
+func (r *Repository) Listen(ctx context.Context, notify func()) error {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	// The connection is in LISTEN state, so it is closed rather than returned to the pool.
+	defer func() {
+		_ = conn.Conn().Close(context.WithoutCancel(ctx))
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	// Catch up on messages created while not listening.
+	notify()
+
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		notify()
+	}
+}
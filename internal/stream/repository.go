@@ -0,0 +1,30 @@
+// Package stream delivers live status updates to clients as server-sent events.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Message is a status update stored for delivery to stream clients.
+// IDs grow in commit order, so a client can resume after the last ID it received.
+type Message struct {
+	ID         int64
+	Type       string
+	ServiceIDs []string
+	Data       json.RawMessage
+	CreatedAt  time.Time
+}
+
+// Repository defines the interface for stream message storage.
+type Repository interface {
+	CreateMessage(ctx context.Context, msg *Message) error
+	// ListMessagesAfter returns up to limit messages with an ID greater than afterID, oldest first.
+	ListMessagesAfter(ctx context.Context, afterID int64, limit int) ([]*Message, error)
+	LatestMessageID(ctx context.Context) (int64, error)
+	DeleteMessagesBefore(ctx context.Context, before time.Time) (int64, error)
+	// Listen calls notify once connected and then whenever a message is created,
+	// on this or any other replica. It blocks until ctx is cancelled or the connection fails.
+	Listen(ctx context.Context, notify func()) error
+}
@@ -0,0 +1,321 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// Message types.
+const (
+	TypeEventCreated         = "event.created"
+	TypeEventUpdated         = "event.updated"
+	TypeEventServicesAdded   = "event.services_added"
+	TypeEventServicesRemoved = "event.services_removed"
+	TypeEventDeleted         = "event.deleted"
+	TypeServiceStatusChanged = "service.status_changed"
+)
+
+const (
+	// pageSize is how many messages are read from the database at once.
+	pageSize = 500
+	// subscriberBuffer is how many messages a slow client may lag behind before it is disconnected.
+	subscriberBuffer = 64
+	// reconnectDelay is the pause before listening again after the connection failed.
+	reconnectDelay = 5 * time.Second
+	// pruneInterval is how often expired messages are deleted.
+	pruneInterval = time.Hour
+	// gapTimeout is how long a skipped message ID is waited for. An ID that
+	// does not show up by then belongs to an insert that was rolled back.
+	gapTimeout = time.Minute
+	// maxGap is the largest jump in IDs whose missing messages are waited for,
+	// so that a sequence reset does not fill memory.
+	maxGap = 1000
+)
+
+// EventPayload is the data of event.* messages.
+type EventPayload struct {
+	Event  *domain.Event       `json:"event"`
+	Update *domain.EventUpdate `json:"update,omitempty"`
+	// ServiceIDs are the affected services: all event services,
+	// or only the added/removed ones for services_added/services_removed.
+	ServiceIDs []string `json:"service_ids"`
+}
+
+// StatusPayload is the data of service.status_changed messages.
+type StatusPayload struct {
+	ServiceID string                    `json:"service_id"`
+	OldStatus *domain.ServiceStatus     `json:"old_status"`
+	NewStatus domain.ServiceStatus      `json:"new_status"`
+	Reason    domain.StatusChangeReason `json:"reason"`
+	EventID   *string                   `json:"event_id"`
+	ChangedAt time.Time                 `json:"changed_at"`
+}
+
+// Config holds stream settings.
+type Config struct {
+	// HeartbeatInterval is how often an idle stream sends a comment to keep the connection open.
+	HeartbeatInterval time.Duration
+	// Retention is how long messages are kept for clients resuming with Last-Event-ID.
+	Retention time.Duration
+}
+
+// Subscription receives live messages for a stream client.
+type Subscription struct {
+	ch         chan *Message
+	serviceIDs map[string]bool
+}
+
+// Messages returns the channel of live messages. It is closed when the client
+// falls too far behind or the server shuts down.
+func (sub *Subscription) Messages() <-chan *Message {
+	return sub.ch
+}
+
+// Matches reports whether the message concerns the subscribed services.
+// A subscription without services matches every message.
+func (sub *Subscription) Matches(msg *Message) bool {
+	if len(sub.serviceIDs) == 0 {
+		return true
+	}
+	for _, id := range msg.ServiceIDs {
+		if sub.serviceIDs[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// Service stores status updates and fans them out to stream clients.
+// Messages reach every replica through the database, so clients may connect to any of them.
+type Service struct {
+	repo   Repository
+	config Config
+
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+
+	// lastID is the highest message delivered to subscriptions and gaps are
+	// the lower IDs not seen yet, with the time they were skipped. Both are
+	// only used by Run.
+	lastID int64
+	gaps   map[int64]time.Time
+}
+
+// NewService creates a new stream service.
+func NewService(repo Repository, config Config) *Service {
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 15 * time.Second
+	}
+	if config.Retention <= 0 {
+		config.Retention = 24 * time.Hour
+	}
+	return &Service{
+		repo:          repo,
+		config:        config,
+		subscriptions: make(map[*Subscription]struct{}),
+		gaps:          make(map[int64]time.Time),
+	}
+}
+
+// Publish stores a message for all stream clients interested in the given services.
+func (s *Service) Publish(ctx context.Context, msgType string, serviceIDs []string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal stream message: %w", err)
+	}
+
+	msg := &Message{
+		Type:       msgType,
+		ServiceIDs: serviceIDs,
+		Data:       payload,
+	}
+	return s.repo.CreateMessage(ctx, msg)
+}
+
+// Subscribe starts receiving live messages about the given services, or all messages if none are given.
+func (s *Service) Subscribe(serviceIDs []string) *Subscription {
+	sub := &Subscription{
+		ch:         make(chan *Message, subscriberBuffer),
+		serviceIDs: make(map[string]bool, len(serviceIDs)),
+	}
+	for _, id := range serviceIDs {
+		sub.serviceIDs[id] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(sub.ch)
+		return sub
+	}
+	s.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe stops delivering messages to the subscription.
+func (s *Service) Unsubscribe(sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscriptions[sub]; ok {
+		delete(s.subscriptions, sub)
+		close(sub.ch)
+	}
+}
+
+// Close ends all subscriptions so that open streams finish, e.g. on shutdown.
+// Later subscriptions end immediately.
+func (s *Service) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for sub := range s.subscriptions {
+		delete(s.subscriptions, sub)
+		close(sub.ch)
+	}
+}
+
+// Replay returns the retained messages after afterID that match the subscription, oldest first.
+func (s *Service) Replay(ctx context.Context, afterID int64, sub *Subscription) ([]*Message, error) {
+	var result []*Message
+	for {
+		messages, err := s.repo.ListMessagesAfter(ctx, afterID, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			if sub.Matches(msg) {
+				result = append(result, msg)
+			}
+		}
+		if len(messages) < pageSize {
+			return result, nil
+		}
+		afterID = messages[len(messages)-1].ID
+	}
+}
+
+// Run delivers new messages to subscriptions and deletes expired ones.
+// It blocks until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.prune(ctx)
+	}()
+	defer wg.Wait()
+
+	started := false
+	for {
+		if !started {
+			// Start from the newest message: older ones are only replayed on request.
+			id, err := s.repo.LatestMessageID(ctx)
+			if err == nil {
+				s.lastID, started = id, true
+			} else if ctx.Err() == nil {
+				slog.Error("failed to get latest stream message", "error", err)
+			}
+		}
+
+		if started {
+			err := s.repo.Listen(ctx, func() { s.deliver(ctx) })
+			if ctx.Err() == nil {
+				slog.Error("status stream listener failed", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// deliver sends the messages created since the last delivery to matching subscriptions.
+// IDs are taken before the insert commits, so a message may become visible after
+// one with a higher ID. Skipped IDs are read again until they show up or time out.
+func (s *Service) deliver(ctx context.Context) {
+	now := time.Now()
+	after := s.lastID
+	for id, skipped := range s.gaps {
+		if now.Sub(skipped) > gapTimeout {
+			delete(s.gaps, id)
+		} else if id <= after {
+			after = id - 1
+		}
+	}
+
+	for {
+		messages, err := s.repo.ListMessagesAfter(ctx, after, pageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("failed to list stream messages", "error", err)
+			}
+			return
+		}
+
+		for _, msg := range messages {
+			after = msg.ID
+			if msg.ID <= s.lastID {
+				if _, missing := s.gaps[msg.ID]; !missing {
+					continue
+				}
+				delete(s.gaps, msg.ID)
+			} else {
+				if msg.ID-s.lastID <= maxGap {
+					for id := s.lastID + 1; id < msg.ID; id++ {
+						s.gaps[id] = now
+					}
+				}
+				s.lastID = msg.ID
+			}
+			s.broadcast(msg)
+		}
+		if len(messages) < pageSize {
+			return
+		}
+	}
+}
+
+func (s *Service) broadcast(msg *Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscriptions {
+		if !sub.Matches(msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			// The client is too slow: disconnect it, it resumes with Last-Event-ID.
+			delete(s.subscriptions, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (s *Service) prune(ctx context.Context) {
+	for {
+		deleted, err := s.repo.DeleteMessagesBefore(ctx, time.Now().Add(-s.config.Retention))
+		if err != nil && ctx.Err() == nil {
+			slog.Error("failed to delete expired stream messages", "error", err)
+		}
+		if deleted > 0 {
+			slog.Debug("expired stream messages deleted", "count", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pruneInterval):
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeRepo struct {
+	messages []*Message
+}
+
+func (f *fakeRepo) CreateMessage(_ context.Context, msg *Message) error {
+	msg.ID = int64(len(f.messages) + 1)
+	msg.CreatedAt = time.Now()
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+func (f *fakeRepo) ListMessagesAfter(_ context.Context, afterID int64, limit int) ([]*Message, error) {
+	var result []*Message
+	for _, msg := range f.messages {
+		if msg.ID > afterID && len(result) < limit {
+			result = append(result, msg)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeRepo) LatestMessageID(_ context.Context) (int64, error) {
+	return int64(len(f.messages)), nil
+}
+
+func (f *fakeRepo) DeleteMessagesBefore(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeRepo) Listen(ctx context.Context, notify func()) error {
+	notify()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func publish(t *testing.T, svc *Service, msgType string, serviceIDs ...string) {
+	t.Helper()
+	if err := svc.Publish(context.Background(), msgType, serviceIDs, map[string]string{"type": msgType}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func TestSubscription_Matches(t *testing.T) {
+	svc := NewService(&fakeRepo{}, Config{})
+	all := svc.Subscribe(nil)
+	api := svc.Subscribe([]string{"svc-api"})
+
+	msg := &Message{ServiceIDs: []string{"svc-db", "svc-api"}}
+	if !all.Matches(msg) || !api.Matches(msg) {
+		t.Error("expected both subscriptions to match")
+	}
+
+	msg = &Message{}
+	if !all.Matches(msg) || api.Matches(msg) {
+		t.Error("a message without services matches only unfiltered subscriptions")
+	}
+}
+
+func TestService_Deliver(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := NewService(repo, Config{})
+	publish(t, svc, TypeEventCreated, "svc-old")
+
+	all := svc.Subscribe(nil)
+	db := svc.Subscribe([]string{"svc-db"})
+
+	svc.lastID = 1
+	publish(t, svc, TypeEventCreated, "svc-api")
+	publish(t, svc, TypeServiceStatusChanged, "svc-db")
+	svc.deliver(context.Background())
+
+	if got := len(all.Messages()); got != 2 {
+		t.Errorf("all received %d messages, want 2", got)
+	}
+	if got := len(db.Messages()); got != 1 {
+		t.Fatalf("db received %d messages, want 1", got)
+	}
+	if msg := <-db.Messages(); msg.ID != 3 || msg.Type != TypeServiceStatusChanged {
+		t.Errorf("db message = %+v", msg)
+	}
+	if svc.lastID != 3 {
+		t.Errorf("lastID = %d, want 3", svc.lastID)
+	}
+}
+
+func TestService_DeliverLateMessage(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := NewService(repo, Config{})
+	sub := svc.Subscribe(nil)
+
+	// Message 2 commits after message 3.
+	repo.messages = []*Message{{ID: 1}, {ID: 3}}
+	svc.deliver(context.Background())
+	repo.messages = []*Message{{ID: 1}, {ID: 2}, {ID: 3}}
+	svc.deliver(context.Background())
+	svc.deliver(context.Background())
+
+	var ids []int64
+	for len(sub.Messages()) > 0 {
+		ids = append(ids, (<-sub.Messages()).ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 3 || ids[2] != 2 {
+		t.Errorf("delivered %v, want [1 3 2]", ids)
+	}
+	if len(svc.gaps) != 0 {
+		t.Errorf("gaps = %v, want none", svc.gaps)
+	}
+}
+
+func TestService_DeliverForgetsRolledBackIDs(t *testing.T) {
+	repo := &fakeRepo{messages: []*Message{{ID: 1}, {ID: 3}}}
+	svc := NewService(repo, Config{})
+	svc.deliver(context.Background())
+	if _, ok := svc.gaps[2]; !ok {
+		t.Fatalf("gaps = %v, want 2", svc.gaps)
+	}
+
+	svc.gaps[2] = time.Now().Add(-gapTimeout - time.Second)
+	svc.deliver(context.Background())
+	if len(svc.gaps) != 0 {
+		t.Errorf("gaps = %v, want none after the timeout", svc.gaps)
+	}
+}
+
+func TestService_DisconnectsSlowSubscriber(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := NewService(repo, Config{})
+	sub := svc.Subscribe(nil)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		publish(t, svc, TypeEventUpdated)
+	}
+	svc.deliver(context.Background())
+
+	received := 0
+	for range sub.Messages() {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("received %d messages before disconnect, want %d", received, subscriberBuffer)
+	}
+
+	// Unsubscribing a disconnected subscriber must not close its channel twice.
+	svc.Unsubscribe(sub)
+}
+
+func TestService_Run(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := NewService(repo, Config{})
+	publish(t, svc, TypeEventCreated)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	<-done
+
+	// Messages created before Run are not delivered live.
+	if svc.lastID != 1 {
+		t.Errorf("lastID = %d, want 1", svc.lastID)
+	}
+}
+
+type fakeCatalog struct{}
+
+func (fakeCatalog) GetServiceBySlug(_ context.Context, slug string) (*domain.Service, error) {
+	if slug != "api" {
+		return nil, catalog.ErrServiceNotFound
+	}
+	return &domain.Service{ID: "svc-api", Slug: "api"}, nil
+}
+
+func TestHandler_Stream(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := NewService(repo, Config{})
+	publish(t, svc, TypeEventCreated, "svc-api")
+	publish(t, svc, TypeEventUpdated, "svc-db")
+	publish(t, svc, TypeServiceStatusChanged, "svc-api")
+	// Closed service ends the stream right after the backlog.
+	svc.Close()
+
+	r := chi.NewRouter()
+	NewHandler(svc, fakeCatalog{}).RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/status/stream?service=api", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	want := "retry: 5000\n\n" +
+		"id: 3\nevent: service.status_changed\ndata: {\"type\":\"service.status_changed\"}\n\n"
+	if body := rec.Body.String(); body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestHandler_StreamErrors(t *testing.T) {
+	r := chi.NewRouter()
+	NewHandler(NewService(&fakeRepo{}, Config{}), fakeCatalog{}).RegisterRoutes(r)
+
+	tests := []struct {
+		path   string
+		header string
+		want   int
+	}{
+		{path: "/status/stream?service=missing", want: http.StatusNotFound},
+		{path: "/status/stream?last_event_id=abc", want: http.StatusBadRequest},
+		{path: "/status/stream", header: "-1", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("Last-Event-ID", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s %q: status = %d, want %d", tt.path, tt.header, rec.Code, tt.want)
+		}
+		var resp map[string]map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp["error"]["message"] == "" {
+			t.Errorf("%s: error body = %q", tt.path, rec.Body.String())
+		}
+	}
+}
+
+func TestHandler_StreamLive(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := NewService(repo, Config{HeartbeatInterval: time.Hour})
+
+	r := chi.NewRouter()
+	NewHandler(svc, fakeCatalog{}).RegisterRoutes(r)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/status/stream")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	publish(t, svc, TypeEventCreated, "svc-api")
+	svc.deliver(context.Background())
+
+	buf := make([]byte, 256)
+	var body string
+	for !strings.Contains(body, "id: 1\n") {
+		n, err := resp.Body.Read(buf)
+		if err != nil {
+			t.Fatalf("read: %v (body %q)", err, body)
+		}
+		body += string(buf[:n])
+	}
+	if !strings.Contains(body, "event: event.created\n") {
+		t.Errorf("body = %q", body)
+	}
+
+	svc.Close()
+}
@@ -0,0 +1,72 @@
+package summary
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/pkg/httputil"
+	"github.com/go-chi/chi/v5"
+)
+
+// CacheMaxAge is how long clients and proxies may cache the summary.
+const CacheMaxAge = 30 * time.Second
+
+// Handler handles summary requests.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new summary handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the public summary route.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/status/summary", h.GetSummary)
+}
+
+// GetSummary handles GET /status/summary.
+func (h *Handler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.GetSummary(r.Context())
+	if err != nil {
+		slog.Error("failed to build status summary", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"data": summary})
+	if err != nil {
+		slog.Error("failed to encode response", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	etag := httputil.ETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(CacheMaxAge.Seconds())))
+
+	if httputil.NotModified(r, etag, time.Time{}) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		slog.Error("failed to write response", "error", err)
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
@@ -0,0 +1,290 @@
+// Package summary builds the aggregated public status of the whole status page.
+package summary
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+	"github.com/bissquit/incident-garden/internal/uptime"
+)
+
+// Indicator is the overall health of the status page.
+type Indicator string
+
+// Indicators, from best to worst.
+const (
+	IndicatorNone        Indicator = "none"
+	IndicatorMaintenance Indicator = "maintenance"
+	IndicatorMinor       Indicator = "minor"
+	IndicatorMajor       Indicator = "major"
+	IndicatorCritical    Indicator = "critical"
+)
+
+var indicatorRank = map[Indicator]int{
+	IndicatorNone:        0,
+	IndicatorMaintenance: 1,
+	IndicatorMinor:       2,
+	IndicatorMajor:       3,
+	IndicatorCritical:    4,
+}
+
+var indicatorDescriptions = map[Indicator]string{
+	IndicatorNone:        "All Systems Operational",
+	IndicatorMaintenance: "Service Under Maintenance",
+	IndicatorMinor:       "Minor Service Outage",
+	IndicatorMajor:       "Partial System Outage",
+	IndicatorCritical:    "Major System Outage",
+}
+
+// MaxEvents limits the incidents and the maintenance windows in a summary.
+const MaxEvents = 50
+
+// Summary is the current public status.
+type Summary struct {
+	Status            Status           `json:"status"`
+	Groups            []GroupSummary   `json:"groups"`
+	UngroupedServices []ServiceSummary `json:"ungrouped_services"`
+	Incidents         []EventSummary   `json:"incidents"`
+	Maintenance       []EventSummary   `json:"maintenance"`
+}
+
+// Status is the overall indicator with a human-readable description.
+type Status struct {
+	Indicator   Indicator `json:"indicator"`
+	Description string    `json:"description"`
+}
+
+// GroupSummary is a group with its services; its status is the worst status of the services.
+type GroupSummary struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Slug        string               `json:"slug"`
+	Description string               `json:"description"`
+	Status      domain.ServiceStatus `json:"status"`
+	Services    []ServiceSummary     `json:"services"`
+}
+
+// ServiceSummary is a service with its current status and uptime history.
+type ServiceSummary struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Slug        string               `json:"slug"`
+	Description string               `json:"description"`
+	Status      domain.ServiceStatus `json:"status"`
+	Uptime      *uptime.Uptime       `json:"uptime"`
+}
+
+// EventSummary is an unresolved event with its latest update.
+type EventSummary struct {
+	*domain.Event
+	LatestUpdate *domain.EventUpdate `json:"latest_update"`
+}
+
+// EventService is the part of events.Service used to read events.
+type EventService interface {
+	ListEvents(ctx context.Context, filters events.EventFilters) ([]*domain.Event, error)
+	GetEventUpdates(ctx context.Context, eventID string) ([]*domain.EventUpdate, error)
+}
+
+// ServiceCatalog is the part of catalog.Service used to read groups and services.
+type ServiceCatalog interface {
+	ListGroups(ctx context.Context, filter catalog.GroupFilter) ([]domain.ServiceGroup, error)
+	ListServices(ctx context.Context, filter catalog.ServiceFilter) ([]domain.Service, error)
+}
+
+// UptimeService is the part of uptime.Service used to read availability history.
+type UptimeService interface {
+	ServicesUptime(ctx context.Context, services []domain.Service, days int) (map[string]*uptime.Uptime, error)
+}
+
+// Service builds status summaries.
+type Service struct {
+	events  EventService
+	catalog ServiceCatalog
+	uptime  UptimeService
+}
+
+// NewService creates a new summary service.
+func NewService(eventService EventService, serviceCatalog ServiceCatalog, uptimeService UptimeService) *Service {
+	return &Service{
+		events:  eventService,
+		catalog: serviceCatalog,
+		uptime:  uptimeService,
+	}
+}
+
+// GetSummary returns the status and uptime of all non-archived groups and services,
+// unresolved incidents and scheduled or in-progress maintenance.
+func (s *Service) GetSummary(ctx context.Context) (*Summary, error) {
+	groups, err := s.catalog.ListGroups(ctx, catalog.GroupFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+
+	services, err := s.catalog.ListServices(ctx, catalog.ServiceFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list services: %w", err)
+	}
+
+	history, err := s.uptime.ServicesUptime(ctx, services, uptime.DefaultDays)
+	if err != nil {
+		return nil, fmt.Errorf("get uptime: %w", err)
+	}
+
+	incidents, err := s.listUnresolved(ctx, domain.EventTypeIncident)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenance, err := s.listUnresolved(ctx, domain.EventTypeMaintenance)
+	if err != nil {
+		return nil, err
+	}
+	// Upcoming windows first: in progress, then by scheduled start.
+	sort.SliceStable(maintenance, func(i, j int) bool {
+		return scheduledStart(maintenance[i].Event).Before(scheduledStart(maintenance[j].Event))
+	})
+
+	groupList, ungrouped := groupSummaries(groups, services, history)
+	summary := &Summary{
+		Groups:            groupList,
+		UngroupedServices: ungrouped,
+		Incidents:         incidents,
+		Maintenance:       maintenance,
+	}
+
+	indicator := IndicatorNone
+	for _, service := range services {
+		indicator = worse(indicator, serviceIndicator(service.Status))
+	}
+	for _, incident := range incidents {
+		indicator = worse(indicator, incidentIndicator(incident.Severity))
+	}
+	summary.Status = Status{Indicator: indicator, Description: indicatorDescriptions[indicator]}
+
+	return summary, nil
+}
+
+func (s *Service) listUnresolved(ctx context.Context, eventType domain.EventType) ([]EventSummary, error) {
+	list, err := s.events.ListEvents(ctx, events.EventFilters{
+		Type:       &eventType,
+		Unresolved: true,
+		Limit:      MaxEvents,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	result := make([]EventSummary, 0, len(list))
+	for _, event := range list {
+		updates, err := s.events.GetEventUpdates(ctx, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get event updates: %w", err)
+		}
+
+		summary := EventSummary{Event: event}
+		// Updates come newest first.
+		if len(updates) > 0 {
+			summary.LatestUpdate = updates[0]
+		}
+		result = append(result, summary)
+	}
+	return result, nil
+}
+
+// groupSummaries places services into their groups. Services outside any
+// listed group, including those only in archived groups, are returned as ungrouped.
+func groupSummaries(groups []domain.ServiceGroup, services []domain.Service, history map[string]*uptime.Uptime) ([]GroupSummary, []ServiceSummary) {
+	index := make(map[string]int, len(groups))
+	result := make([]GroupSummary, 0, len(groups))
+	for _, group := range groups {
+		index[group.ID] = len(result)
+		result = append(result, GroupSummary{
+			ID:          group.ID,
+			Name:        group.Name,
+			Slug:        group.Slug,
+			Description: group.Description,
+			Status:      domain.ServiceStatusOperational,
+			Services:    []ServiceSummary{},
+		})
+	}
+
+	// Services are ordered, so each group keeps the service order.
+	ungrouped := []ServiceSummary{}
+	for _, service := range services {
+		placed := false
+		for _, groupID := range service.GroupIDs {
+			i, ok := index[groupID]
+			if !ok {
+				continue
+			}
+			result[i].Services = append(result[i].Services, serviceSummary(service, history[service.ID]))
+			result[i].Status = catalog.WorstStatus(result[i].Status, service.Status)
+			placed = true
+		}
+		if !placed {
+			ungrouped = append(ungrouped, serviceSummary(service, history[service.ID]))
+		}
+	}
+	return result, ungrouped
+}
+
+func serviceSummary(service domain.Service, history *uptime.Uptime) ServiceSummary {
+	return ServiceSummary{
+		ID:          service.ID,
+		Name:        service.Name,
+		Slug:        service.Slug,
+		Description: service.Description,
+		Status:      service.Status,
+		Uptime:      history,
+	}
+}
+
+func serviceIndicator(status domain.ServiceStatus) Indicator {
+	switch status {
+	case domain.ServiceStatusMajorOutage:
+		return IndicatorCritical
+	case domain.ServiceStatusPartialOutage:
+		return IndicatorMajor
+	case domain.ServiceStatusDegraded:
+		return IndicatorMinor
+	case domain.ServiceStatusMaintenance:
+		return IndicatorMaintenance
+	default:
+		return IndicatorNone
+	}
+}
+
+func incidentIndicator(severity *domain.Severity) Indicator {
+	if severity == nil {
+		return IndicatorMinor
+	}
+	switch *severity {
+	case domain.SeverityCritical:
+		return IndicatorCritical
+	case domain.SeverityMajor:
+		return IndicatorMajor
+	default:
+		return IndicatorMinor
+	}
+}
+
+func worse(a, b Indicator) Indicator {
+	if indicatorRank[b] > indicatorRank[a] {
+		return b
+	}
+	return a
+}
+
+// scheduledStart orders maintenance windows; windows in progress or without a start come first.
+func scheduledStart(event *domain.Event) time.Time {
+	if event.Status == domain.EventStatusInProgress || event.ScheduledStartAt == nil {
+		return time.Time{}
+	}
+	return *event.ScheduledStartAt
+}
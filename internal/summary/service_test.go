@@ -0,0 +1,245 @@
+package summary
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+	"github.com/bissquit/incident-garden/internal/uptime"
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeEvents struct {
+	events  []*domain.Event
+	updates map[string][]*domain.EventUpdate
+	filters []events.EventFilters
+}
+
+func (f *fakeEvents) ListEvents(_ context.Context, filters events.EventFilters) ([]*domain.Event, error) {
+	f.filters = append(f.filters, filters)
+	var result []*domain.Event
+	for _, event := range f.events {
+		if filters.Type == nil || event.Type == *filters.Type {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeEvents) GetEventUpdates(_ context.Context, eventID string) ([]*domain.EventUpdate, error) {
+	return f.updates[eventID], nil
+}
+
+type fakeCatalog struct {
+	groups   []domain.ServiceGroup
+	services []domain.Service
+}
+
+func (f *fakeCatalog) ListGroups(_ context.Context, _ catalog.GroupFilter) ([]domain.ServiceGroup, error) {
+	return f.groups, nil
+}
+
+func (f *fakeCatalog) ListServices(_ context.Context, _ catalog.ServiceFilter) ([]domain.Service, error) {
+	return f.services, nil
+}
+
+type fakeUptime struct{}
+
+func (fakeUptime) ServicesUptime(_ context.Context, services []domain.Service, days int) (map[string]*uptime.Uptime, error) {
+	result := make(map[string]*uptime.Uptime, len(services))
+	for _, service := range services {
+		result[service.ID] = &uptime.Uptime{ServiceID: service.ID, Availability: 99.5, Days: make([]uptime.Day, days)}
+	}
+	return result, nil
+}
+
+func newTestCatalog() *fakeCatalog {
+	return &fakeCatalog{
+		groups: []domain.ServiceGroup{
+			{ID: "grp-core", Name: "Core", Slug: "core"},
+			{ID: "grp-edge", Name: "Edge", Slug: "edge"},
+		},
+		services: []domain.Service{
+			{ID: "svc-api", Name: "API", Slug: "api", Status: domain.ServiceStatusOperational, GroupIDs: []string{"grp-core"}},
+			{ID: "svc-db", Name: "Database", Slug: "db", Status: domain.ServiceStatusDegraded, GroupIDs: []string{"grp-core"}},
+			{ID: "svc-cdn", Name: "CDN", Slug: "cdn", Status: domain.ServiceStatusOperational, GroupIDs: []string{"grp-archived"}},
+			{ID: "svc-dns", Name: "DNS", Slug: "dns", Status: domain.ServiceStatusOperational},
+		},
+	}
+}
+
+func TestGetSummary_Groups(t *testing.T) {
+	svc := NewService(&fakeEvents{}, newTestCatalog(), fakeUptime{})
+
+	summary, err := svc.GetSummary(context.Background())
+	if err != nil {
+		t.Fatalf("GetSummary: %v", err)
+	}
+
+	if len(summary.Groups) != 2 {
+		t.Fatalf("groups = %d, want 2", len(summary.Groups))
+	}
+	core, edge := summary.Groups[0], summary.Groups[1]
+	if core.Status != domain.ServiceStatusDegraded || len(core.Services) != 2 || core.Services[0].Slug != "api" {
+		t.Errorf("core = %+v", core)
+	}
+	if edge.Status != domain.ServiceStatusOperational || len(edge.Services) != 0 {
+		t.Errorf("edge = %+v", edge)
+	}
+
+	if u := core.Services[1].Uptime; u == nil || u.ServiceID != "svc-db" || len(u.Days) != uptime.DefaultDays {
+		t.Errorf("db uptime = %+v", u)
+	}
+
+	// A service only in an archived group is shown as ungrouped.
+	if len(summary.UngroupedServices) != 2 || summary.UngroupedServices[0].Slug != "cdn" || summary.UngroupedServices[1].Slug != "dns" {
+		t.Errorf("ungrouped = %+v", summary.UngroupedServices)
+	}
+
+	if summary.Status.Indicator != IndicatorMinor || summary.Status.Description != "Minor Service Outage" {
+		t.Errorf("status = %+v", summary.Status)
+	}
+}
+
+func TestGetSummary_Indicator(t *testing.T) {
+	critical := domain.SeverityCritical
+	minor := domain.SeverityMinor
+
+	tests := []struct {
+		name     string
+		statuses []domain.ServiceStatus
+		events   []*domain.Event
+		want     Indicator
+	}{
+		{
+			name: "all operational",
+			want: IndicatorNone,
+		},
+		{
+			name:     "maintenance",
+			statuses: []domain.ServiceStatus{domain.ServiceStatusMaintenance},
+			want:     IndicatorMaintenance,
+		},
+		{
+			name:     "partial outage",
+			statuses: []domain.ServiceStatus{domain.ServiceStatusMaintenance, domain.ServiceStatusPartialOutage},
+			want:     IndicatorMajor,
+		},
+		{
+			name:     "major outage",
+			statuses: []domain.ServiceStatus{domain.ServiceStatusDegraded, domain.ServiceStatusMajorOutage},
+			want:     IndicatorCritical,
+		},
+		{
+			name:   "critical incident with operational services",
+			events: []*domain.Event{{ID: "e1", Type: domain.EventTypeIncident, Severity: &critical}},
+			want:   IndicatorCritical,
+		},
+		{
+			name:   "minor incident",
+			events: []*domain.Event{{ID: "e1", Type: domain.EventTypeIncident, Severity: &minor}},
+			want:   IndicatorMinor,
+		},
+		{
+			name:   "scheduled maintenance only",
+			events: []*domain.Event{{ID: "e1", Type: domain.EventTypeMaintenance, Status: domain.EventStatusScheduled}},
+			want:   IndicatorNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cat := &fakeCatalog{}
+			for i, status := range tt.statuses {
+				cat.services = append(cat.services, domain.Service{ID: string(rune('a' + i)), Status: status})
+			}
+
+			summary, err := NewService(&fakeEvents{events: tt.events}, cat, fakeUptime{}).GetSummary(context.Background())
+			if err != nil {
+				t.Fatalf("GetSummary: %v", err)
+			}
+			if summary.Status.Indicator != tt.want {
+				t.Errorf("indicator = %q, want %q", summary.Status.Indicator, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSummary_Events(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	later, soon := now.Add(48*time.Hour), now.Add(2*time.Hour)
+	started := now.Add(-time.Hour)
+
+	ev := &fakeEvents{
+		events: []*domain.Event{
+			{ID: "inc", Type: domain.EventTypeIncident, Status: domain.EventStatusIdentified},
+			{ID: "later", Type: domain.EventTypeMaintenance, Status: domain.EventStatusScheduled, ScheduledStartAt: &later},
+			{ID: "soon", Type: domain.EventTypeMaintenance, Status: domain.EventStatusScheduled, ScheduledStartAt: &soon},
+			{ID: "running", Type: domain.EventTypeMaintenance, Status: domain.EventStatusInProgress, ScheduledStartAt: &started},
+		},
+		updates: map[string][]*domain.EventUpdate{
+			"inc": {
+				{ID: "u2", Message: "Fix in progress"},
+				{ID: "u1", Message: "Investigating"},
+			},
+		},
+	}
+
+	summary, err := NewService(ev, &fakeCatalog{}, fakeUptime{}).GetSummary(context.Background())
+	if err != nil {
+		t.Fatalf("GetSummary: %v", err)
+	}
+
+	for _, filters := range ev.filters {
+		if !filters.Unresolved || filters.Limit != MaxEvents {
+			t.Errorf("filters = %+v, want unresolved with limit %d", filters, MaxEvents)
+		}
+	}
+
+	if len(summary.Incidents) != 1 || summary.Incidents[0].LatestUpdate == nil || summary.Incidents[0].LatestUpdate.ID != "u2" {
+		t.Fatalf("incidents = %+v", summary.Incidents)
+	}
+
+	var order []string
+	for _, m := range summary.Maintenance {
+		order = append(order, m.ID)
+		if m.LatestUpdate != nil {
+			t.Errorf("maintenance %s latest update = %+v, want nil", m.ID, m.LatestUpdate)
+		}
+	}
+	if len(order) != 3 || order[0] != "running" || order[1] != "soon" || order[2] != "later" {
+		t.Errorf("maintenance order = %v", order)
+	}
+}
+
+func TestHandler_GetSummary(t *testing.T) {
+	r := chi.NewRouter()
+	NewHandler(NewService(&fakeEvents{}, newTestCatalog(), fakeUptime{})).RegisterRoutes(r)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/summary", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=30" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag is empty")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/status/summary", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("conditional status = %d, want 304", rec.Code)
+	}
+}
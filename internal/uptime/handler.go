@@ -0,0 +1,71 @@
+package uptime
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler handles uptime requests.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new uptime handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the public uptime route.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/services/{slug}/uptime", h.GetServiceUptime)
+}
+
+// GetServiceUptime handles GET /services/{slug}/uptime.
+func (h *Handler) GetServiceUptime(w http.ResponseWriter, r *http.Request) {
+	days := DefaultDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxDays {
+			h.respondError(w, http.StatusBadRequest, "days must be between 1 and "+strconv.Itoa(MaxDays))
+			return
+		}
+		days = n
+	}
+
+	uptime, err := h.service.ServiceUptime(r.Context(), chi.URLParam(r, "slug"), days)
+	if err != nil {
+		if errors.Is(err, catalog.ErrServiceNotFound) {
+			h.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		slog.Error("internal error", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, uptime)
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": data}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
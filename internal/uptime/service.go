@@ -0,0 +1,245 @@
+// Package uptime computes service availability from past incidents.
+package uptime
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+)
+
+// Limits of the history length, in days.
+const (
+	DefaultDays = 90
+	MaxDays     = 90
+)
+
+// Weights is the share of time in a status counted as downtime.
+// Maintenance is planned and is not counted.
+var Weights = map[domain.ServiceStatus]float64{
+	domain.ServiceStatusMajorOutage:   1,
+	domain.ServiceStatusPartialOutage: 0.3,
+	domain.ServiceStatusDegraded:      0.1,
+}
+
+// Day is the availability of a service over one UTC day.
+type Day struct {
+	Date          string  `json:"date"`
+	Availability  float64 `json:"availability"`
+	MajorOutage   int64   `json:"major_outage_seconds"`
+	PartialOutage int64   `json:"partial_outage_seconds"`
+	Degraded      int64   `json:"degraded_seconds"`
+}
+
+// Uptime is the availability history of a service, oldest day first.
+// Days before the service was created are omitted, and today is counted up to now.
+type Uptime struct {
+	ServiceID    string  `json:"service_id"`
+	Availability float64 `json:"availability"`
+	Days         []Day   `json:"days"`
+}
+
+// EventService is the part of events.Service used to read incidents.
+type EventService interface {
+	ListEvents(ctx context.Context, filters events.EventFilters) ([]*domain.Event, error)
+}
+
+// ServiceCatalog is the part of catalog.Service used to look up services.
+type ServiceCatalog interface {
+	GetServiceBySlug(ctx context.Context, slug string) (*domain.Service, error)
+}
+
+// Service computes uptime history.
+type Service struct {
+	events  EventService
+	catalog ServiceCatalog
+}
+
+// NewService creates a new uptime service.
+func NewService(eventService EventService, serviceCatalog ServiceCatalog) *Service {
+	return &Service{
+		events:  eventService,
+		catalog: serviceCatalog,
+	}
+}
+
+// ServiceUptime returns the history of the service over the last days.
+func (s *Service) ServiceUptime(ctx context.Context, slug string, days int) (*Uptime, error) {
+	service, err := s.catalog.GetServiceBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.ServicesUptime(ctx, []domain.Service{*service}, days)
+	if err != nil {
+		return nil, err
+	}
+	return result[service.ID], nil
+}
+
+// ServicesUptime returns the history of each service over the last days, keyed by service ID.
+func (s *Service) ServicesUptime(ctx context.Context, services []domain.Service, days int) (map[string]*Uptime, error) {
+	result := make(map[string]*Uptime, len(services))
+	if len(services) == 0 {
+		return result, nil
+	}
+
+	now := time.Now().UTC()
+	from := startOfDay(now).AddDate(0, 0, -(days - 1))
+
+	ids := make([]string, 0, len(services))
+	for _, service := range services {
+		ids = append(ids, service.ID)
+	}
+
+	incidentType := domain.EventTypeIncident
+	list, err := s.events.ListEvents(ctx, events.EventFilters{
+		Type:          &incidentType,
+		ServiceIDs:    ids,
+		ResolvedSince: &from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list incidents: %w", err)
+	}
+
+	outages := make(map[string][]outage, len(services))
+	for _, event := range list {
+		o, ok := eventOutage(event, now)
+		if !ok {
+			continue
+		}
+		for _, id := range event.ServiceIDs {
+			outages[id] = append(outages[id], o)
+		}
+	}
+
+	for _, service := range services {
+		result[service.ID] = history(service.ID, service.CreatedAt, outages[service.ID], from, now, days)
+	}
+	return result, nil
+}
+
+// outage is the time a service spent in a status because of an incident.
+type outage struct {
+	start  time.Time
+	end    time.Time
+	status domain.ServiceStatus
+}
+
+// eventOutage uses the current severity of the incident for its whole duration.
+func eventOutage(event *domain.Event, now time.Time) (outage, bool) {
+	start := event.CreatedAt
+	if event.StartedAt != nil {
+		start = *event.StartedAt
+	}
+
+	end := now
+	switch {
+	case event.ResolvedAt != nil:
+		end = *event.ResolvedAt
+	case event.Status.IsResolved():
+		end = event.UpdatedAt
+	}
+
+	if !end.After(start) {
+		return outage{}, false
+	}
+	return outage{start: start, end: end, status: catalog.IncidentStatus(event.Severity)}, true
+}
+
+func history(serviceID string, created time.Time, outages []outage, from, now time.Time, days int) *Uptime {
+	result := &Uptime{ServiceID: serviceID, Days: []Day{}}
+
+	var observed, downtime float64
+	for i := 0; i < days; i++ {
+		dayStart := from.AddDate(0, 0, i)
+		start, end := latest(dayStart, created), earliest(dayStart.AddDate(0, 0, 1), now)
+		if !end.After(start) {
+			continue
+		}
+
+		spent := statusDurations(outages, start, end)
+		var dayDowntime float64
+		for status, d := range spent {
+			dayDowntime += d.Seconds() * Weights[status]
+		}
+		length := end.Sub(start).Seconds()
+
+		result.Days = append(result.Days, Day{
+			Date:          dayStart.Format(time.DateOnly),
+			Availability:  availability(dayDowntime, length),
+			MajorOutage:   int64(spent[domain.ServiceStatusMajorOutage].Seconds()),
+			PartialOutage: int64(spent[domain.ServiceStatusPartialOutage].Seconds()),
+			Degraded:      int64(spent[domain.ServiceStatusDegraded].Seconds()),
+		})
+		observed += length
+		downtime += dayDowntime
+	}
+
+	result.Availability = availability(downtime, observed)
+	return result
+}
+
+// statusDurations returns the time spent in each status between start and end.
+// Where incidents overlap the worst status counts.
+func statusDurations(outages []outage, start, end time.Time) map[domain.ServiceStatus]time.Duration {
+	points := []time.Time{start, end}
+	for _, o := range outages {
+		if o.end.After(start) && o.start.Before(end) {
+			points = append(points, latest(o.start, start), earliest(o.end, end))
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Before(points[j]) })
+
+	spent := make(map[domain.ServiceStatus]time.Duration)
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if !b.After(a) {
+			continue
+		}
+
+		var statuses []domain.ServiceStatus
+		for _, o := range outages {
+			if !o.start.After(a) && !o.end.Before(b) {
+				statuses = append(statuses, o.status)
+			}
+		}
+		if status := catalog.WorstStatus(statuses...); status != domain.ServiceStatusOperational {
+			spent[status] += b.Sub(a)
+		}
+	}
+	return spent
+}
+
+// availability returns the percentage of time up, rounded down to three decimals
+// so that noticeable downtime keeps it below 100.
+func availability(downtime, observed float64) float64 {
+	if observed <= 0 {
+		return 100
+	}
+	// The epsilon absorbs floating point error, e.g. 29999.999999 for 30%.
+	return math.Floor(100000-downtime/observed*100000+1e-6) / 1000
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,209 @@
+package uptime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/catalog"
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/events"
+	"github.com/go-chi/chi/v5"
+)
+
+var day = time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func TestHistory(t *testing.T) {
+	from := day.AddDate(0, 0, -2)
+	now := day.Add(12 * time.Hour)
+	outages := []outage{
+		// 6h major outage on the first day.
+		{start: from.Add(6 * time.Hour), end: from.Add(12 * time.Hour), status: domain.ServiceStatusMajorOutage},
+		// Degraded across midnight, partially hidden by a partial outage on the second day.
+		{start: from.Add(22 * time.Hour), end: from.Add(26 * time.Hour), status: domain.ServiceStatusDegraded},
+		{start: from.Add(24 * time.Hour), end: from.Add(25 * time.Hour), status: domain.ServiceStatusPartialOutage},
+	}
+
+	u := history("svc-1", from.AddDate(0, -1, 0), outages, from, now, 3)
+	if len(u.Days) != 3 {
+		t.Fatalf("days = %d, want 3", len(u.Days))
+	}
+
+	first := u.Days[0]
+	if first.Date != "2026-03-08" || first.MajorOutage != 6*3600 || first.Degraded != 2*3600 {
+		t.Errorf("first day = %+v", first)
+	}
+	// 6h * 1 + 2h * 0.1 of 24h.
+	if first.Availability != 74.166 {
+		t.Errorf("first day availability = %v, want 74.166", first.Availability)
+	}
+
+	second := u.Days[1]
+	if second.PartialOutage != 3600 || second.Degraded != 3600 || second.MajorOutage != 0 {
+		t.Errorf("second day = %+v", second)
+	}
+
+	// Today is counted up to now.
+	if today := u.Days[2]; today.Date != "2026-03-10" || today.Availability != 100 {
+		t.Errorf("today = %+v", today)
+	}
+
+	// 6h + 2h*0.1 + 1h*0.3 + 1h*0.1 = 6.6h of 60h.
+	if u.Availability != 89 {
+		t.Errorf("availability = %v, want 89", u.Availability)
+	}
+}
+
+func TestHistory_SkipsDaysBeforeCreation(t *testing.T) {
+	from := day.AddDate(0, 0, -4)
+	u := history("svc-1", day.Add(-12*time.Hour), nil, from, day.Add(12*time.Hour), 5)
+
+	if len(u.Days) != 2 || u.Days[0].Date != "2026-03-09" || u.Availability != 100 {
+		t.Errorf("uptime = %+v", u)
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	tests := []struct {
+		downtime, observed float64
+		want               float64
+	}{
+		{0, 86400, 100},
+		{0, 0, 100},
+		{1, 86400, 99.998},
+		{0.7 * 86400, 86400, 30},
+		{86400, 86400, 0},
+	}
+	for _, tt := range tests {
+		if got := availability(tt.downtime, tt.observed); got != tt.want {
+			t.Errorf("availability(%v, %v) = %v, want %v", tt.downtime, tt.observed, got, tt.want)
+		}
+	}
+}
+
+func TestEventOutage(t *testing.T) {
+	now := day.Add(time.Hour)
+	major := domain.SeverityMajor
+	resolvedAt := day.Add(-time.Hour)
+
+	tests := []struct {
+		name  string
+		event domain.Event
+		want  outage
+		ok    bool
+	}{
+		{
+			name:  "ongoing without severity",
+			event: domain.Event{Status: domain.EventStatusInvestigating, CreatedAt: day},
+			want:  outage{start: day, end: now, status: domain.ServiceStatusDegraded},
+			ok:    true,
+		},
+		{
+			name:  "resolved",
+			event: domain.Event{Status: domain.EventStatusResolved, Severity: &major, CreatedAt: day.Add(-2 * time.Hour), ResolvedAt: &resolvedAt},
+			want:  outage{start: day.Add(-2 * time.Hour), end: resolvedAt, status: domain.ServiceStatusPartialOutage},
+			ok:    true,
+		},
+		{
+			name:  "created resolved",
+			event: domain.Event{Status: domain.EventStatusResolved, CreatedAt: day, UpdatedAt: day},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := eventOutage(&tt.event, now)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("eventOutage = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+type fakeEvents struct {
+	events  []*domain.Event
+	filters events.EventFilters
+}
+
+func (f *fakeEvents) ListEvents(_ context.Context, filters events.EventFilters) ([]*domain.Event, error) {
+	f.filters = filters
+	return f.events, nil
+}
+
+type fakeCatalog struct{}
+
+func (fakeCatalog) GetServiceBySlug(_ context.Context, slug string) (*domain.Service, error) {
+	if slug != "api" {
+		return nil, catalog.ErrServiceNotFound
+	}
+	return &domain.Service{ID: "svc-1", Slug: "api", CreatedAt: time.Now().AddDate(-1, 0, 0)}, nil
+}
+
+func TestServicesUptime(t *testing.T) {
+	critical := domain.SeverityCritical
+	started := time.Now().UTC().Add(-30 * time.Minute)
+	ev := &fakeEvents{events: []*domain.Event{{
+		ID:         "e1",
+		Status:     domain.EventStatusInvestigating,
+		Severity:   &critical,
+		StartedAt:  &started,
+		ServiceIDs: []string{"svc-1", "svc-2"},
+	}}}
+	created := time.Now().AddDate(-1, 0, 0)
+
+	result, err := NewService(ev, fakeCatalog{}).ServicesUptime(context.Background(), []domain.Service{
+		{ID: "svc-1", CreatedAt: created},
+		{ID: "svc-3", CreatedAt: created},
+	}, 7)
+	if err != nil {
+		t.Fatalf("ServicesUptime: %v", err)
+	}
+
+	if ev.filters.Type == nil || *ev.filters.Type != domain.EventTypeIncident || ev.filters.ResolvedSince == nil || len(ev.filters.ServiceIDs) != 2 {
+		t.Errorf("filters = %+v", ev.filters)
+	}
+	if len(result) != 2 || len(result["svc-1"].Days) != 7 {
+		t.Fatalf("result = %+v", result)
+	}
+	if result["svc-1"].Availability >= 100 || result["svc-3"].Availability != 100 {
+		t.Errorf("availability svc-1 = %v, svc-3 = %v", result["svc-1"].Availability, result["svc-3"].Availability)
+	}
+}
+
+func TestHandler_GetServiceUptime(t *testing.T) {
+	r := chi.NewRouter()
+	NewHandler(NewService(&fakeEvents{}, fakeCatalog{})).RegisterRoutes(r)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/services/api/uptime?days=30")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp struct {
+		Data Uptime `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Data.Days) != 30 || resp.Data.Availability != 100 {
+		t.Errorf("uptime = %+v", resp.Data)
+	}
+
+	for _, days := range []string{"0", "91", "abc"} {
+		if rec := get("/services/api/uptime?days=" + days); rec.Code != http.StatusBadRequest {
+			t.Errorf("days=%s: status = %d, want 400", days, rec.Code)
+		}
+	}
+
+	if rec := get("/services/missing/uptime"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing service: status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,366 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderDeliveryID = "X-Webhook-Id"
+	HeaderEvent      = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// userAgent identifies webhook requests.
+const userAgent = "IncidentGarden-Webhooks/1.0"
+
+// maxResponseBody is how much of a response body is kept with an attempt.
+const maxResponseBody = 1024
+
+// DispatcherConfig holds webhook delivery worker settings.
+type DispatcherConfig struct {
+	// Workers is the number of concurrent delivery workers.
+	Workers int
+	// BatchSize is how many deliveries a worker claims at once.
+	BatchSize int
+	// PollInterval is how long an idle worker waits before polling again.
+	PollInterval time.Duration
+	// MaxAttempts is the number of attempts before a delivery is marked failed.
+	MaxAttempts int
+	// RetryBaseDelay is the delay after the first failed attempt; it doubles on every retry.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the retry delay.
+	RetryMaxDelay time.Duration
+	// Lease is how long a claimed delivery stays locked before another worker may reclaim it.
+	Lease time.Duration
+	// Timeout limits a single HTTP request to a webhook.
+	Timeout time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private and link-local
+	// addresses. Without it such connections are refused when dialing, so that
+	// webhooks, including redirects and re-resolved host names, cannot be used
+	// to read internal endpoints.
+	AllowPrivateNetworks bool
+}
+
+// Dispatcher sends queued webhook deliveries in the background.
+type Dispatcher struct {
+	repo   Repository
+	config DispatcherConfig
+	client *http.Client
+}
+
+// NewDispatcher creates a new webhook dispatcher.
+func NewDispatcher(repo Repository, config DispatcherConfig) *Dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 10
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.RetryBaseDelay <= 0 {
+		config.RetryBaseDelay = 30 * time.Second
+	}
+	if config.RetryMaxDelay <= 0 {
+		config.RetryMaxDelay = time.Hour
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &Dispatcher{
+		repo:   repo,
+		config: config,
+		client: newClient(config),
+	}
+}
+
+// ErrDestinationNotAllowed is returned when a webhook resolves to an address it may not reach.
+var ErrDestinationNotAllowed = errors.New("webhook destination is not allowed")
+
+// newClient returns the HTTP client for deliveries. Connections go directly to
+// the webhook host, without environment proxies, so every dialed address is checked.
+func newClient(config DispatcherConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = checkDestination
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: config.Timeout, Transport: transport}
+}
+
+// blockedPrefixes are special-purpose ranges not covered by the netip predicates.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// checkDestination refuses connections to loopback, private, link-local and
+// other non-public addresses. It runs after DNS resolution, for every address dialed.
+func checkDestination(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, address)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, address)
+	}
+
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, addr)
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, addr)
+		}
+	}
+	return nil
+}
+
+// Envelope is the JSON body of a delivery.
+type Envelope struct {
+	// ID is the delivery ID; it stays the same across retries and redeliveries.
+	ID        string                  `json:"id"`
+	Kind      domain.WebhookEventKind `json:"kind"`
+	CreatedAt time.Time               `json:"created_at"`
+	Data      json.RawMessage         `json:"data"`
+}
+
+// Sign returns the signature header value for a delivery body sent at timestamp:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run starts delivery workers and blocks until ctx is cancelled and all workers have stopped.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for {
+		processed, err := d.ProcessBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("failed to process webhook deliveries", "error", err)
+		}
+
+		// Keep draining while there is work, otherwise wait for the next poll.
+		if processed > 0 && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.config.PollInterval):
+		}
+	}
+}
+
+// ProcessBatch claims one batch of due deliveries and attempts to send them.
+// It returns the number of claimed deliveries.
+func (d *Dispatcher) ProcessBatch(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.ClaimDeliveries(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		d.deliver(ctx, delivery)
+	}
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *domain.WebhookDelivery) {
+	webhook, err := d.repo.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		if !errors.Is(err, ErrWebhookNotFound) {
+			slog.Error("failed to load webhook", "webhook_id", delivery.WebhookID, "error", err)
+		}
+		// A deleted webhook takes its deliveries with it; otherwise the lease expires and it is retried.
+		return
+	}
+
+	if !webhook.IsEnabled {
+		d.markFailed(context.WithoutCancel(ctx), delivery, nil, "webhook is disabled")
+		return
+	}
+
+	started := time.Now()
+	code, body, err := d.send(ctx, webhook, delivery)
+
+	// Record the outcome even if the worker is being stopped.
+	ctx = context.WithoutCancel(ctx)
+
+	attempt := &domain.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	var responseCode *int
+	if code != 0 {
+		responseCode = &code
+		attempt.ResponseCode = responseCode
+		attempt.ResponseBody = &body
+	}
+	if err != nil {
+		message := err.Error()
+		attempt.Error = &message
+	}
+	if err := d.repo.CreateAttempt(ctx, attempt); err != nil {
+		slog.Error("failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+
+	if err == nil {
+		if err := d.repo.MarkDeliverySent(ctx, delivery, code); err != nil {
+			logOutcomeError("failed to mark webhook delivery sent", delivery.ID, err)
+		}
+		return
+	}
+
+	slog.Warn("failed to deliver webhook",
+		"delivery_id", delivery.ID,
+		"webhook_id", webhook.ID,
+		"attempt", delivery.Attempts,
+		"error", err,
+	)
+
+	if delivery.Attempts >= d.config.MaxAttempts {
+		d.markFailed(ctx, delivery, responseCode, err.Error())
+		return
+	}
+
+	delay := retryDelay(delivery.Attempts, d.config.RetryBaseDelay, d.config.RetryMaxDelay)
+	if err := d.repo.MarkDeliveryRetry(ctx, delivery, delay, responseCode, err.Error()); err != nil {
+		logOutcomeError("failed to schedule webhook retry", delivery.ID, err)
+	}
+}
+
+// send posts the delivery and returns the response status and the start of the response body.
+// A non-2xx response is an error.
+func (d *Dispatcher) send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery) (int, string, error) {
+	body, err := json.Marshal(Envelope{
+		ID:        delivery.ID,
+		Kind:      delivery.EventKind,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("marshal envelope: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderDeliveryID, delivery.ID)
+	req.Header.Set(HeaderEvent, string(delivery.EventKind))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+func (d *Dispatcher) markFailed(ctx context.Context, delivery *domain.WebhookDelivery, responseCode *int, reason string) {
+	if err := d.repo.MarkDeliveryFailed(ctx, delivery, responseCode, reason); err != nil {
+		logOutcomeError("failed to mark webhook delivery failed", delivery.ID, err)
+		return
+	}
+
+	slog.Error("webhook delivery failed",
+		"delivery_id", delivery.ID,
+		"webhook_id", delivery.WebhookID,
+		"attempts", delivery.Attempts,
+		"reason", reason,
+	)
+}
+
+// logOutcomeError logs a failure to record the outcome of a delivery. A lost
+// lease is expected when sending outlasted it: the worker that reclaimed the
+// delivery records its own outcome.
+func logOutcomeError(msg, deliveryID string, err error) {
+	if errors.Is(err, ErrDeliveryLeaseLost) {
+		slog.Warn(msg, "delivery_id", deliveryID, "error", err)
+		return
+	}
+	slog.Error(msg, "delivery_id", deliveryID, "error", err)
+}
+
+// retryDelay returns base * 2^(attempt-1), capped at maxDelay.
+func retryDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return min(delay, maxDelay)
+}
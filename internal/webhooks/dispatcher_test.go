@@ -0,0 +1,466 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// fakeRepo keeps webhooks and deliveries in memory.
+type fakeRepo struct {
+	webhooks    map[string]*domain.Webhook
+	deliveries  []*domain.WebhookDelivery
+	attempts    []domain.WebhookDeliveryAttempt
+	retryDelays map[string]time.Duration
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		webhooks:    map[string]*domain.Webhook{},
+		retryDelays: map[string]time.Duration{},
+	}
+}
+
+func (f *fakeRepo) CreateWebhook(_ context.Context, webhook *domain.Webhook) error {
+	webhook.ID = fmt.Sprintf("w-%d", len(f.webhooks)+1)
+	w := *webhook
+	f.webhooks[webhook.ID] = &w
+	return nil
+}
+
+func (f *fakeRepo) GetWebhook(_ context.Context, id string) (*domain.Webhook, error) {
+	webhook, ok := f.webhooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	w := *webhook
+	return &w, nil
+}
+
+func (f *fakeRepo) ListWebhooks(_ context.Context) ([]*domain.Webhook, error) {
+	result := make([]*domain.Webhook, 0, len(f.webhooks))
+	for _, webhook := range f.webhooks {
+		w := *webhook
+		result = append(result, &w)
+	}
+	return result, nil
+}
+
+func (f *fakeRepo) UpdateWebhook(_ context.Context, webhook *domain.Webhook) error {
+	if _, ok := f.webhooks[webhook.ID]; !ok {
+		return ErrWebhookNotFound
+	}
+	w := *webhook
+	f.webhooks[webhook.ID] = &w
+	return nil
+}
+
+func (f *fakeRepo) DeleteWebhook(_ context.Context, id string) error {
+	if _, ok := f.webhooks[id]; !ok {
+		return ErrWebhookNotFound
+	}
+	delete(f.webhooks, id)
+	return nil
+}
+
+func (f *fakeRepo) EnqueueDeliveries(_ context.Context, deliveries []*domain.WebhookDelivery) error {
+	for _, d := range deliveries {
+		d.ID = fmt.Sprintf("d-%d", len(f.deliveries)+1)
+		d.Status = domain.DeliveryStatusPending
+		f.deliveries = append(f.deliveries, d)
+	}
+	return nil
+}
+
+func (f *fakeRepo) ClaimDeliveries(_ context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	claimed := make([]*domain.WebhookDelivery, 0)
+	for _, d := range f.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == domain.DeliveryStatusPending {
+			lockedUntil := time.Now().Add(lease)
+			d.Status = domain.DeliveryStatusProcessing
+			d.Attempts++
+			d.LockedUntil = &lockedUntil
+			c := *d
+			claimed = append(claimed, &c)
+		}
+	}
+	return claimed, nil
+}
+
+func (f *fakeRepo) find(id string) *domain.WebhookDelivery {
+	for _, d := range f.deliveries {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// leased returns the stored delivery if the claim of delivery still holds it.
+func (f *fakeRepo) leased(delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	d := f.find(delivery.ID)
+	if d == nil || d.Status != domain.DeliveryStatusProcessing || d.LockedUntil == nil ||
+		delivery.LockedUntil == nil || !d.LockedUntil.Equal(*delivery.LockedUntil) {
+		return nil, ErrDeliveryLeaseLost
+	}
+	return d, nil
+}
+
+func (f *fakeRepo) MarkDeliverySent(_ context.Context, delivery *domain.WebhookDelivery, responseCode int) error {
+	d, err := f.leased(delivery)
+	if err != nil {
+		return err
+	}
+	d.Status = domain.DeliveryStatusSent
+	d.ResponseCode = &responseCode
+	return nil
+}
+
+func (f *fakeRepo) MarkDeliveryRetry(_ context.Context, delivery *domain.WebhookDelivery, delay time.Duration, responseCode *int, lastError string) error {
+	d, err := f.leased(delivery)
+	if err != nil {
+		return err
+	}
+	d.Status = domain.DeliveryStatusPending
+	d.ResponseCode = responseCode
+	d.LastError = &lastError
+	f.retryDelays[d.ID] = delay
+	return nil
+}
+
+func (f *fakeRepo) MarkDeliveryFailed(_ context.Context, delivery *domain.WebhookDelivery, responseCode *int, lastError string) error {
+	d, err := f.leased(delivery)
+	if err != nil {
+		return err
+	}
+	d.Status = domain.DeliveryStatusFailed
+	d.ResponseCode = responseCode
+	d.LastError = &lastError
+	return nil
+}
+
+func (f *fakeRepo) GetDelivery(_ context.Context, id string) (*domain.WebhookDelivery, error) {
+	d := f.find(id)
+	if d == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	c := *d
+	return &c, nil
+}
+
+func (f *fakeRepo) ListDeliveries(_ context.Context, filter DeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	result := make([]*domain.WebhookDelivery, 0)
+	for _, d := range f.deliveries {
+		if d.WebhookID == filter.WebhookID {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeRepo) ResetDelivery(_ context.Context, id string) error {
+	d := f.find(id)
+	if d == nil {
+		return ErrDeliveryNotFound
+	}
+	d.Status = domain.DeliveryStatusPending
+	d.Attempts = 0
+	return nil
+}
+
+func (f *fakeRepo) CreateAttempt(_ context.Context, attempt *domain.WebhookDeliveryAttempt) error {
+	f.attempts = append(f.attempts, *attempt)
+	return nil
+}
+
+func (f *fakeRepo) ListAttempts(_ context.Context, deliveryID string) ([]domain.WebhookDeliveryAttempt, error) {
+	result := make([]domain.WebhookDeliveryAttempt, 0)
+	for _, a := range f.attempts {
+		if a.DeliveryID == deliveryID {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got := Sign("secret", 1700000000, []byte(`{"a":1}`)); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestDispatcher_SendsSignedRequest(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	repo := newFakeRepo()
+	repo.webhooks["w-1"] = &domain.Webhook{ID: "w-1", URL: server.URL, Secret: "topsecret", IsEnabled: true}
+	repo.deliveries = []*domain.WebhookDelivery{{
+		ID:        "d-1",
+		WebhookID: "w-1",
+		EventKind: domain.WebhookEventCreated,
+		Payload:   json.RawMessage(`{"event":{"id":"e-1"}}`),
+		Status:    domain.DeliveryStatusPending,
+	}}
+
+	d := NewDispatcher(repo, DispatcherConfig{AllowPrivateNetworks: true})
+	if _, err := d.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch() error = %v", err)
+	}
+
+	req := <-requests
+	if got := req.header.Get(HeaderEvent); got != string(domain.WebhookEventCreated) {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, domain.WebhookEventCreated)
+	}
+	if got := req.header.Get(HeaderDeliveryID); got != "d-1" {
+		t.Errorf("%s = %q, want d-1", HeaderDeliveryID, got)
+	}
+	timestamp, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", HeaderTimestamp, err)
+	}
+	if got, want := req.header.Get(HeaderSignature), Sign("topsecret", timestamp, req.body); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(req.body, &envelope); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+	if envelope.ID != "d-1" || envelope.Kind != domain.WebhookEventCreated || string(envelope.Data) != `{"event":{"id":"e-1"}}` {
+		t.Errorf("unexpected envelope %+v", envelope)
+	}
+
+	delivery := repo.deliveries[0]
+	if delivery.Status != domain.DeliveryStatusSent {
+		t.Errorf("status = %q, want sent", delivery.Status)
+	}
+	if len(repo.attempts) != 1 || repo.attempts[0].ResponseCode == nil || *repo.attempts[0].ResponseCode != http.StatusAccepted {
+		t.Errorf("attempts = %+v, want one attempt with response code 202", repo.attempts)
+	}
+}
+
+func TestDispatcher_ProcessBatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		attempts       int
+		disabled       bool
+		wantStatus     domain.DeliveryStatus
+		wantRetryDelay time.Duration
+		wantAttempts   int
+	}{
+		{
+			name:         "2xx is sent",
+			status:       http.StatusOK,
+			wantStatus:   domain.DeliveryStatusSent,
+			wantAttempts: 1,
+		},
+		{
+			name:           "error response is retried with backoff",
+			status:         http.StatusInternalServerError,
+			attempts:       2,
+			wantStatus:     domain.DeliveryStatusPending,
+			wantRetryDelay: 4 * time.Second,
+			wantAttempts:   1,
+		},
+		{
+			name:         "failed after max attempts",
+			status:       http.StatusBadGateway,
+			attempts:     4,
+			wantStatus:   domain.DeliveryStatusFailed,
+			wantAttempts: 1,
+		},
+		{
+			name:       "disabled webhook is not called",
+			status:     http.StatusOK,
+			disabled:   true,
+			wantStatus: domain.DeliveryStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			repo := newFakeRepo()
+			repo.webhooks["w-1"] = &domain.Webhook{ID: "w-1", URL: server.URL, Secret: "s", IsEnabled: !tt.disabled}
+			repo.deliveries = []*domain.WebhookDelivery{{
+				ID:        "d-1",
+				WebhookID: "w-1",
+				EventKind: domain.WebhookEventUpdated,
+				Payload:   json.RawMessage(`{}`),
+				Status:    domain.DeliveryStatusPending,
+				Attempts:  tt.attempts,
+			}}
+
+			d := NewDispatcher(repo, DispatcherConfig{
+				MaxAttempts:          5,
+				RetryBaseDelay:       time.Second,
+				RetryMaxDelay:        time.Minute,
+				AllowPrivateNetworks: true,
+			})
+
+			n, err := d.ProcessBatch(context.Background())
+			if err != nil {
+				t.Fatalf("ProcessBatch() error = %v", err)
+			}
+			if n != 1 {
+				t.Fatalf("ProcessBatch() = %d, want 1", n)
+			}
+
+			delivery := repo.deliveries[0]
+			if delivery.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", delivery.Status, tt.wantStatus)
+			}
+			if got := repo.retryDelays["d-1"]; got != tt.wantRetryDelay {
+				t.Errorf("retry delay = %v, want %v", got, tt.wantRetryDelay)
+			}
+			if len(repo.attempts) != tt.wantAttempts {
+				t.Errorf("attempts recorded = %d, want %d", len(repo.attempts), tt.wantAttempts)
+			}
+			if tt.wantAttempts > 0 && (delivery.ResponseCode == nil || *delivery.ResponseCode != tt.status) {
+				t.Errorf("response code = %v, want %d", delivery.ResponseCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestDispatcher_ProcessBatchLeaseLost(t *testing.T) {
+	repo := newFakeRepo()
+
+	// Another worker reclaims the delivery while this one waits for the response.
+	reclaimed := time.Now().Add(time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		repo.deliveries[0].LockedUntil = &reclaimed
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo.webhooks["w-1"] = &domain.Webhook{ID: "w-1", URL: server.URL, Secret: "s", IsEnabled: true}
+	repo.deliveries = []*domain.WebhookDelivery{{
+		ID:        "d-1",
+		WebhookID: "w-1",
+		EventKind: domain.WebhookEventUpdated,
+		Payload:   json.RawMessage(`{}`),
+		Status:    domain.DeliveryStatusPending,
+	}}
+
+	d := NewDispatcher(repo, DispatcherConfig{MaxAttempts: 5, AllowPrivateNetworks: true})
+	if _, err := d.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch() error = %v", err)
+	}
+	if got := repo.deliveries[0].Status; got != domain.DeliveryStatusProcessing {
+		t.Errorf("status = %q, want %q: the new claim owns the outcome", got, domain.DeliveryStatusProcessing)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 30 * time.Second
+	maxDelay := 10 * time.Minute
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt, base, maxDelay); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestDispatcher_RefusesPrivateDestinations(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := newFakeRepo()
+	repo.webhooks["w-1"] = &domain.Webhook{ID: "w-1", URL: server.URL, Secret: "s", IsEnabled: true}
+	repo.deliveries = []*domain.WebhookDelivery{{
+		ID:        "d-1",
+		WebhookID: "w-1",
+		EventKind: domain.WebhookEventUpdated,
+		Payload:   json.RawMessage(`{}`),
+		Status:    domain.DeliveryStatusPending,
+	}}
+
+	d := NewDispatcher(repo, DispatcherConfig{MaxAttempts: 5})
+	if _, err := d.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch() error = %v", err)
+	}
+	if called {
+		t.Error("loopback webhook must not be called")
+	}
+	delivery := repo.deliveries[0]
+	if delivery.Status != domain.DeliveryStatusPending || delivery.LastError == nil ||
+		!strings.Contains(*delivery.LastError, ErrDestinationNotAllowed.Error()) {
+		t.Errorf("delivery = %q, last error %v; want a retry with the refused destination", delivery.Status, delivery.LastError)
+	}
+}
+
+func TestCheckDestination(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"100.64.0.1:80", false},
+		{"0.0.0.0:80", false},
+		{"[fd00:ec2::254]:80", false},
+		{"[fe80::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkDestination("tcp", tt.address, nil)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkDestination(%q) error = %v, want allowed %v", tt.address, err, tt.allowed)
+			}
+		})
+	}
+}
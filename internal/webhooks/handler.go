@@ -0,0 +1,279 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// Handler handles HTTP requests for the webhooks module.
+type Handler struct {
+	service   *Service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new webhooks handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// RegisterRoutes registers webhook management routes (require admin).
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", h.ListWebhooks)
+		r.Post("/", h.CreateWebhook)
+		r.Get("/{id}", h.GetWebhook)
+		r.Patch("/{id}", h.UpdateWebhook)
+		r.Delete("/{id}", h.DeleteWebhook)
+		r.Get("/{id}/deliveries", h.ListDeliveries)
+		r.Get("/{id}/deliveries/{deliveryID}", h.GetDelivery)
+		r.Post("/{id}/deliveries/{deliveryID}/redeliver", h.Redeliver)
+	})
+}
+
+// CreateWebhookRequest represents request body for creating a webhook.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,max=2048"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	EventKinds []string `json:"event_kinds" validate:"required,min=1"`
+	ServiceIDs []string `json:"service_ids" validate:"omitempty,dive,uuid"`
+	IsEnabled  *bool    `json:"is_enabled"`
+}
+
+// UpdateWebhookRequest represents request body for updating a webhook.
+type UpdateWebhookRequest struct {
+	URL        *string   `json:"url" validate:"omitempty,min=1,max=2048"`
+	Secret     *string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	EventKinds *[]string `json:"event_kinds" validate:"omitempty,min=1"`
+	ServiceIDs *[]string `json:"service_ids" validate:"omitempty,dive,uuid"`
+	IsEnabled  *bool     `json:"is_enabled"`
+}
+
+// CreateWebhookResponse is a created webhook together with its signing secret.
+type CreateWebhookResponse struct {
+	*domain.Webhook
+	Secret string `json:"secret"`
+}
+
+// ListWebhooks handles GET /webhooks.
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, webhooks)
+}
+
+// CreateWebhook handles POST /webhooks.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(r.Context(), CreateWebhookInput{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventKinds: toEventKinds(req.EventKinds),
+		ServiceIDs: req.ServiceIDs,
+		IsEnabled:  req.IsEnabled,
+	})
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, CreateWebhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// GetWebhook handles GET /webhooks/{id}.
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := h.service.GetWebhook(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, webhook)
+}
+
+// UpdateWebhook handles PATCH /webhooks/{id}.
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.respondValidationError(w, err)
+		return
+	}
+
+	input := UpdateWebhookInput{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		ServiceIDs: req.ServiceIDs,
+		IsEnabled:  req.IsEnabled,
+	}
+	if req.EventKinds != nil {
+		kinds := toEventKinds(*req.EventKinds)
+		input.EventKinds = &kinds
+	}
+
+	webhook, err := h.service.UpdateWebhook(r.Context(), chi.URLParam(r, "id"), input)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /webhooks/{id}.
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteWebhook(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/{id}/deliveries.
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	filter := DeliveryFilter{WebhookID: chi.URLParam(r, "id"), Limit: 50}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		s := domain.DeliveryStatus(status)
+		if !s.IsValid() {
+			h.respondError(w, http.StatusBadRequest, "invalid status")
+			return
+		}
+		filter.Status = &s
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 500 {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		filter.Limit = n
+	}
+
+	if offset := r.URL.Query().Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			h.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		filter.Offset = n
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, deliveries)
+}
+
+// GetDelivery handles GET /webhooks/{id}/deliveries/{deliveryID}.
+func (h *Handler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.service.GetDelivery(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, delivery)
+}
+
+// Redeliver handles POST /webhooks/{id}/deliveries/{deliveryID}/redeliver.
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.service.Redeliver(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, delivery)
+}
+
+func toEventKinds(kinds []string) []domain.WebhookEventKind {
+	result := make([]domain.WebhookEventKind, 0, len(kinds))
+	for _, kind := range kinds {
+		result = append(result, domain.WebhookEventKind(kind))
+	}
+	return result
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": data}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message},
+	}); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
+
+func (h *Handler) respondValidationError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": "validation error",
+			"details": err.Error(),
+		},
+	}); err != nil {
+		slog.Error("failed to encode validation error response", "error", err)
+	}
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrWebhookNotFound):
+		h.respondError(w, http.StatusNotFound, "webhook not found")
+	case errors.Is(err, ErrDeliveryNotFound):
+		h.respondError(w, http.StatusNotFound, "webhook delivery not found")
+	case errors.Is(err, ErrInvalidURL):
+		h.respondError(w, http.StatusBadRequest, ErrInvalidURL.Error())
+	case errors.Is(err, ErrInvalidEventKind):
+		h.respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNoEventKinds):
+		h.respondError(w, http.StatusBadRequest, ErrNoEventKinds.Error())
+	case errors.Is(err, ErrDeliveryInProgress):
+		h.respondError(w, http.StatusConflict, "only sent or failed deliveries can be redelivered")
+	default:
+		slog.Error("service error", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
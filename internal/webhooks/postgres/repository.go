@@ -0,0 +1,434 @@
+// Package postgres provides PostgreSQL implementation of the webhooks repository.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+	"github.com/bissquit/incident-garden/internal/webhooks"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository implements webhooks.Repository using PostgreSQL.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a new PostgreSQL repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+const webhookColumns = `
+	id, url, secret, event_kinds::text[], service_ids::text[], is_enabled, created_at, updated_at
+`
+
+func scanWebhook(row pgx.Row) (*domain.Webhook, error) {
+	var w domain.Webhook
+	var kinds []string
+	err := row.Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		&kinds,
+		&w.ServiceIDs,
+		&w.IsEnabled,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	w.EventKinds = make([]domain.WebhookEventKind, 0, len(kinds))
+	for _, kind := range kinds {
+		w.EventKinds = append(w.EventKinds, domain.WebhookEventKind(kind))
+	}
+	return &w, nil
+}
+
+func eventKinds(kinds []domain.WebhookEventKind) []string {
+	result := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		result = append(result, string(kind))
+	}
+	return result
+}
+
+// CreateWebhook creates a new webhook.
+func (r *Repository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	query := `
+		INSERT INTO webhooks (url, secret, event_kinds, service_ids, is_enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db.QueryRow(ctx, query,
+		webhook.URL, webhook.Secret, eventKinds(webhook.EventKinds), webhook.ServiceIDs, webhook.IsEnabled,
+	).Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create webhook: %w", err)
+	}
+	return nil
+}
+
+// GetWebhook retrieves a webhook by ID.
+func (r *Repository) GetWebhook(ctx context.Context, id string) (*domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	webhook, err := scanWebhook(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, webhooks.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks, oldest first.
+func (r *Repository) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*domain.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook: %w", err)
+		}
+		result = append(result, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhooks: %w", err)
+	}
+	return result, nil
+}
+
+// UpdateWebhook updates a webhook.
+func (r *Repository) UpdateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $2, secret = $3, event_kinds = $4, service_ids = $5, is_enabled = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err := r.db.QueryRow(ctx, query,
+		webhook.ID, webhook.URL, webhook.Secret, eventKinds(webhook.EventKinds), webhook.ServiceIDs, webhook.IsEnabled,
+	).Scan(&webhook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return webhooks.ErrWebhookNotFound
+		}
+		return fmt.Errorf("update webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook deletes a webhook with its deliveries.
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return webhooks.ErrWebhookNotFound
+	}
+	return nil
+}
+
+const deliveryColumns = `
+	id, webhook_id, event_kind, payload, status, attempts, response_code,
+	last_error, next_attempt_at, delivered_at, created_at, updated_at, locked_until
+`
+
+func scanDelivery(row pgx.Row) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventKind,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseCode,
+		&d.LastError,
+		&d.NextAttemptAt,
+		&d.DeliveredAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+		&d.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// EnqueueDeliveries inserts pending deliveries in a single transaction.
+func (r *Repository) EnqueueDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback transaction", "error", err)
+		}
+	}()
+
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_kind, payload)
+		VALUES ($1, $2, $3)
+		RETURNING ` + deliveryColumns
+
+	for _, d := range deliveries {
+		inserted, err := scanDelivery(tx.QueryRow(ctx, query, d.WebhookID, d.EventKind, d.Payload))
+		if err != nil {
+			return fmt.Errorf("enqueue delivery for webhook %s: %w", d.WebhookID, err)
+		}
+		*d = *inserted
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries locks up to limit due deliveries for processing.
+// Rows stuck in processing after their lease expired are reclaimed.
+func (r *Repository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE (status = 'pending' AND next_attempt_at <= NOW())
+			   OR (status = 'processing' AND locked_until < NOW())
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET status = 'processing',
+			attempts = d.attempts + 1,
+			locked_until = NOW() + $2 * INTERVAL '1 second',
+			updated_at = NOW()
+		FROM due
+		WHERE d.id = due.id
+		RETURNING ` + prefixColumns("d", deliveryColumns)
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*domain.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkDeliverySent marks a delivery as delivered.
+func (r *Repository) MarkDeliverySent(ctx context.Context, delivery *domain.WebhookDelivery, responseCode int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'sent', response_code = $3, delivered_at = NOW(), locked_until = NULL,
+			last_error = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_until = $2
+	`
+	return r.execLeasedUpdate(ctx, "mark delivery sent", query, delivery.ID, delivery.LockedUntil, responseCode)
+}
+
+// MarkDeliveryRetry returns a delivery to the queue to be retried after delay.
+func (r *Repository) MarkDeliveryRetry(ctx context.Context, delivery *domain.WebhookDelivery, delay time.Duration, responseCode *int, lastError string) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending',
+			next_attempt_at = NOW() + $3 * INTERVAL '1 second',
+			response_code = $4,
+			last_error = $5,
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_until = $2
+	`
+	return r.execLeasedUpdate(ctx, "mark delivery retry", query, delivery.ID, delivery.LockedUntil, delay.Seconds(), responseCode, lastError)
+}
+
+// MarkDeliveryFailed stops retrying a delivery.
+func (r *Repository) MarkDeliveryFailed(ctx context.Context, delivery *domain.WebhookDelivery, responseCode *int, lastError string) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'failed', response_code = $3, last_error = $4, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_until = $2
+	`
+	return r.execLeasedUpdate(ctx, "mark delivery failed", query, delivery.ID, delivery.LockedUntil, responseCode, lastError)
+}
+
+// ResetDelivery requeues a delivery for immediate sending with a fresh attempt counter.
+// Earlier attempts are kept.
+func (r *Repository) ResetDelivery(ctx context.Context, id string) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	return r.execDeliveryUpdate(ctx, "reset delivery", query, id)
+}
+
+func (r *Repository) execDeliveryUpdate(ctx context.Context, op, query string, args ...interface{}) error {
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return webhooks.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// execLeasedUpdate runs an update guarded by the claim of a delivery. No
+// updated row means the lease expired and the delivery was reclaimed or reset.
+func (r *Repository) execLeasedUpdate(ctx context.Context, op, query string, args ...interface{}) error {
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return webhooks.ErrDeliveryLeaseLost
+	}
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID.
+func (r *Repository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	d, err := scanDelivery(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, webhooks.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("get delivery: %w", err)
+	}
+	return d, nil
+}
+
+// ListDeliveries retrieves the deliveries of a webhook, newest first.
+func (r *Repository) ListDeliveries(ctx context.Context, filter webhooks.DeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = $1`
+	args := []interface{}{filter.WebhookID}
+	argNum := 2
+
+	if filter.Status != nil {
+		query += fmt.Sprintf(" AND status = $%d", argNum)
+		args = append(args, *filter.Status)
+		argNum++
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*domain.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// CreateAttempt records a delivery attempt.
+func (r *Repository) CreateAttempt(ctx context.Context, attempt *domain.WebhookDeliveryAttempt) error {
+	query := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, response_code, response_body, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query,
+		attempt.DeliveryID, attempt.Attempt, attempt.ResponseCode, attempt.ResponseBody, attempt.Error, attempt.DurationMS,
+	).Scan(&attempt.ID, &attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create attempt: %w", err)
+	}
+	return nil
+}
+
+// ListAttempts retrieves the attempts of a delivery, oldest first.
+func (r *Repository) ListAttempts(ctx context.Context, deliveryID string) ([]domain.WebhookDeliveryAttempt, error) {
+	query := `
+		SELECT id, delivery_id, attempt, response_code, response_body, error, duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.db.Query(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := make([]domain.WebhookDeliveryAttempt, 0)
+	for rows.Next() {
+		var a domain.WebhookDeliveryAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.ResponseCode, &a.ResponseBody, &a.Error, &a.DurationMS, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// prefixColumns qualifies a comma separated column list with a table alias.
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, p := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(p)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,41 @@
+// Package webhooks delivers signed change notifications to external endpoints.
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// Repository defines the interface for webhooks data access.
+type Repository interface {
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*domain.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *domain.Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+
+	EnqueueDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error)
+	// MarkDeliverySent, MarkDeliveryRetry and MarkDeliveryFailed record the
+	// outcome of a claimed delivery. They fail with ErrDeliveryLeaseLost when
+	// the claim is no longer held.
+	MarkDeliverySent(ctx context.Context, delivery *domain.WebhookDelivery, responseCode int) error
+	MarkDeliveryRetry(ctx context.Context, delivery *domain.WebhookDelivery, delay time.Duration, responseCode *int, lastError string) error
+	MarkDeliveryFailed(ctx context.Context, delivery *domain.WebhookDelivery, responseCode *int, lastError string) error
+	GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*domain.WebhookDelivery, error)
+	ResetDelivery(ctx context.Context, id string) error
+
+	CreateAttempt(ctx context.Context, attempt *domain.WebhookDeliveryAttempt) error
+	ListAttempts(ctx context.Context, deliveryID string) ([]domain.WebhookDeliveryAttempt, error)
+}
+
+// DeliveryFilter holds filter options for listing webhook deliveries.
+type DeliveryFilter struct {
+	WebhookID string
+	Status    *domain.DeliveryStatus
+	Limit     int
+	Offset    int
+}
@@ -0,0 +1,305 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+// Service errors.
+var (
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrInvalidURL         = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidEventKind   = errors.New("invalid webhook event kind")
+	ErrNoEventKinds       = errors.New("webhook must subscribe to at least one event kind")
+	ErrDeliveryInProgress = errors.New("webhook delivery is still queued")
+	// ErrDeliveryLeaseLost means the lease on a delivery expired and another
+	// worker may have claimed it, so the outcome was not recorded.
+	ErrDeliveryLeaseLost = errors.New("webhook delivery lease lost")
+)
+
+// secretBytes is the length of generated signing secrets.
+const secretBytes = 32
+
+// Service manages webhooks and queues their deliveries.
+type Service struct {
+	repo  Repository
+	audit domain.AuditFunc
+}
+
+// NewService creates a new webhooks service.
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// SetAuditLog sets where changes to webhooks and redeliveries are recorded.
+func (s *Service) SetAuditLog(audit domain.AuditFunc) {
+	s.audit = audit
+}
+
+// CreateWebhookInput holds data for creating a webhook.
+type CreateWebhookInput struct {
+	URL string
+	// Secret signs deliveries; a random one is generated when empty.
+	Secret     string
+	EventKinds []domain.WebhookEventKind
+	ServiceIDs []string
+	IsEnabled  *bool
+}
+
+// CreateWebhook creates a webhook. The returned webhook carries its secret.
+func (s *Service) CreateWebhook(ctx context.Context, input CreateWebhookInput) (*domain.Webhook, error) {
+	if err := validateURL(input.URL); err != nil {
+		return nil, err
+	}
+	if err := validateEventKinds(input.EventKinds); err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	webhook := &domain.Webhook{
+		URL:        input.URL,
+		Secret:     secret,
+		EventKinds: input.EventKinds,
+		ServiceIDs: input.ServiceIDs,
+		IsEnabled:  true,
+	}
+	if webhook.ServiceIDs == nil {
+		webhook.ServiceIDs = []string{}
+	}
+	if input.IsEnabled != nil {
+		webhook.IsEnabled = *input.IsEnabled
+	}
+
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityWebhook,
+		EntityID:   webhook.ID,
+		After:      webhook,
+	})
+	return webhook, nil
+}
+
+// GetWebhook returns a webhook by ID.
+func (s *Service) GetWebhook(ctx context.Context, id string) (*domain.Webhook, error) {
+	return s.repo.GetWebhook(ctx, id)
+}
+
+// ListWebhooks returns all webhooks.
+func (s *Service) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	return s.repo.ListWebhooks(ctx)
+}
+
+// UpdateWebhookInput holds data for updating a webhook; nil fields are left unchanged.
+type UpdateWebhookInput struct {
+	URL        *string
+	Secret     *string
+	EventKinds *[]domain.WebhookEventKind
+	ServiceIDs *[]string
+	IsEnabled  *bool
+}
+
+// UpdateWebhook updates a webhook.
+func (s *Service) UpdateWebhook(ctx context.Context, id string, input UpdateWebhookInput) (*domain.Webhook, error) {
+	webhook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *webhook
+
+	if input.URL != nil {
+		if err := validateURL(*input.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *input.URL
+	}
+	if input.Secret != nil {
+		webhook.Secret = *input.Secret
+	}
+	if input.EventKinds != nil {
+		if err := validateEventKinds(*input.EventKinds); err != nil {
+			return nil, err
+		}
+		webhook.EventKinds = *input.EventKinds
+	}
+	if input.ServiceIDs != nil {
+		webhook.ServiceIDs = *input.ServiceIDs
+		if webhook.ServiceIDs == nil {
+			webhook.ServiceIDs = []string{}
+		}
+	}
+	if input.IsEnabled != nil {
+		webhook.IsEnabled = *input.IsEnabled
+	}
+
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityWebhook,
+		EntityID:   id,
+		Before:     &before,
+		After:      webhook,
+	})
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook with its deliveries.
+func (s *Service) DeleteWebhook(ctx context.Context, id string) error {
+	webhook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityWebhook,
+		EntityID:   id,
+		Before:     webhook,
+	})
+	return nil
+}
+
+// Enqueue queues a delivery of data to every enabled webhook subscribed to kind
+// and to at least one of the affected services.
+func (s *Service) Enqueue(ctx context.Context, kind domain.WebhookEventKind, serviceIDs []string, data interface{}) error {
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+
+	var deliveries []*domain.WebhookDelivery
+	var payload json.RawMessage
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(kind, serviceIDs) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(data); err != nil {
+				return fmt.Errorf("marshal webhook payload: %w", err)
+			}
+		}
+		deliveries = append(deliveries, &domain.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventKind: kind,
+			Payload:   payload,
+		})
+	}
+
+	if err := s.repo.EnqueueDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the deliveries of a webhook, newest first.
+func (s *Service) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.repo.GetWebhook(ctx, filter.WebhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, filter)
+}
+
+// GetDelivery returns a delivery of a webhook with all its attempts.
+func (s *Service) GetDelivery(ctx context.Context, webhookID, id string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.webhookDelivery(ctx, webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.repo.ListAttempts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery.AttemptHistory = attempts
+	return delivery, nil
+}
+
+// Redeliver queues a sent or failed delivery to be sent again with the same payload.
+func (s *Service) Redeliver(ctx context.Context, webhookID, id string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.webhookDelivery(ctx, webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.Status != domain.DeliveryStatusSent && delivery.Status != domain.DeliveryStatusFailed {
+		return nil, ErrDeliveryInProgress
+	}
+
+	if err := s.repo.ResetDelivery(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditChange{
+		Action:     domain.AuditActionRetry,
+		EntityType: domain.AuditEntityWebhookDelivery,
+		EntityID:   id,
+	})
+
+	return s.repo.GetDelivery(ctx, id)
+}
+
+// webhookDelivery returns a delivery only if it belongs to the webhook.
+func (s *Service) webhookDelivery(ctx context.Context, webhookID, id string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+func validateEventKinds(kinds []domain.WebhookEventKind) error {
+	if len(kinds) == 0 {
+		return ErrNoEventKinds
+	}
+	for _, kind := range kinds {
+		if !kind.IsValid() {
+			return fmt.Errorf("%w: %s", ErrInvalidEventKind, kind)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
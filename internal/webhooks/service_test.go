@@ -0,0 +1,148 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/domain"
+)
+
+func TestService_CreateWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   CreateWebhookInput
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			input: CreateWebhookInput{URL: "https://example.com/hook", EventKinds: []domain.WebhookEventKind{domain.WebhookEventCreated}},
+		},
+		{
+			name:    "relative url",
+			input:   CreateWebhookInput{URL: "/hook", EventKinds: []domain.WebhookEventKind{domain.WebhookEventCreated}},
+			wantErr: ErrInvalidURL,
+		},
+		{
+			name:    "unsupported scheme",
+			input:   CreateWebhookInput{URL: "ftp://example.com", EventKinds: []domain.WebhookEventKind{domain.WebhookEventCreated}},
+			wantErr: ErrInvalidURL,
+		},
+		{
+			name:    "no event kinds",
+			input:   CreateWebhookInput{URL: "https://example.com/hook"},
+			wantErr: ErrNoEventKinds,
+		},
+		{
+			name:    "unknown event kind",
+			input:   CreateWebhookInput{URL: "https://example.com/hook", EventKinds: []domain.WebhookEventKind{"event.exploded"}},
+			wantErr: ErrInvalidEventKind,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(newFakeRepo())
+
+			webhook, err := s.CreateWebhook(context.Background(), tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(webhook.Secret) != 2*secretBytes {
+				t.Errorf("generated secret length = %d, want %d", len(webhook.Secret), 2*secretBytes)
+			}
+			if !webhook.IsEnabled {
+				t.Error("webhook must be enabled by default")
+			}
+		})
+	}
+}
+
+func TestService_Enqueue(t *testing.T) {
+	repo := newFakeRepo()
+	repo.webhooks["all"] = &domain.Webhook{
+		ID: "all", EventKinds: []domain.WebhookEventKind{domain.WebhookEventCreated}, IsEnabled: true,
+	}
+	repo.webhooks["api"] = &domain.Webhook{
+		ID: "api", EventKinds: []domain.WebhookEventKind{domain.WebhookEventCreated}, ServiceIDs: []string{"s-api"}, IsEnabled: true,
+	}
+	repo.webhooks["other-kind"] = &domain.Webhook{
+		ID: "other-kind", EventKinds: []domain.WebhookEventKind{domain.WebhookEventResolved}, IsEnabled: true,
+	}
+	repo.webhooks["disabled"] = &domain.Webhook{
+		ID: "disabled", EventKinds: []domain.WebhookEventKind{domain.WebhookEventCreated}, IsEnabled: false,
+	}
+	s := NewService(repo)
+
+	err := s.Enqueue(context.Background(), domain.WebhookEventCreated, []string{"s-db"}, map[string]string{"id": "e-1"})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(repo.deliveries))
+	}
+	delivery := repo.deliveries[0]
+	if delivery.WebhookID != "all" || delivery.EventKind != domain.WebhookEventCreated {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+	if !json.Valid(delivery.Payload) || string(delivery.Payload) != `{"id":"e-1"}` {
+		t.Errorf("payload = %s", delivery.Payload)
+	}
+
+	if err := s.Enqueue(context.Background(), domain.WebhookEventCreated, []string{"s-api"}, nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if len(repo.deliveries) != 3 {
+		t.Errorf("deliveries = %d, want 3 after a change to the filtered service", len(repo.deliveries))
+	}
+}
+
+func TestService_Redeliver(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    domain.DeliveryStatus
+		webhookID string
+		wantErr   error
+	}{
+		{name: "failed", status: domain.DeliveryStatusFailed, webhookID: "w-1"},
+		{name: "sent", status: domain.DeliveryStatusSent, webhookID: "w-1"},
+		{name: "pending", status: domain.DeliveryStatusPending, webhookID: "w-1", wantErr: ErrDeliveryInProgress},
+		{name: "processing", status: domain.DeliveryStatusProcessing, webhookID: "w-1", wantErr: ErrDeliveryInProgress},
+		{name: "other webhook", status: domain.DeliveryStatusFailed, webhookID: "w-2", wantErr: ErrDeliveryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.deliveries = []*domain.WebhookDelivery{{ID: "d-1", WebhookID: "w-1", Status: tt.status, Attempts: 8}}
+
+			var audited []domain.AuditChange
+			s := NewService(repo)
+			s.SetAuditLog(func(_ context.Context, change domain.AuditChange) {
+				audited = append(audited, change)
+			})
+
+			delivery, err := s.Redeliver(context.Background(), tt.webhookID, "d-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Redeliver() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(audited) != 0 {
+					t.Error("rejected redelivery must not be audited")
+				}
+				return
+			}
+			if delivery.Status != domain.DeliveryStatusPending || delivery.Attempts != 0 {
+				t.Errorf("delivery = %+v, want pending with no attempts", delivery)
+			}
+			if len(audited) != 1 || audited[0].Action != domain.AuditActionRetry {
+				t.Errorf("audited = %+v, want one retry", audited)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+-- Одноразовые коды подтверждения каналов уведомлений (хранится только хэш)
+CREATE TABLE channel_verification_codes (
+    channel_id UUID PRIMARY KEY REFERENCES notification_channels(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_channel_verification_codes_expires_at ON channel_verification_codes(expires_at);
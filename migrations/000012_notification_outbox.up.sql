@@ -0,0 +1,25 @@
+-- Outbox уведомлений: одна строка на пару подписчик × канал
+CREATE TABLE notification_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    channel_id UUID NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    channel_type VARCHAR(50) NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_outbox_status CHECK (status IN ('pending', 'processing', 'sent', 'failed'))
+);
+
+-- Выборка готовых к отправке записей воркерами
+CREATE INDEX idx_notification_outbox_ready ON notification_outbox(next_attempt_at) WHERE status = 'pending';
+-- Подбор записей, зависших в processing после падения воркера
+CREATE INDEX idx_notification_outbox_locked ON notification_outbox(locked_until) WHERE status = 'processing';
+CREATE INDEX idx_notification_outbox_status_created_at ON notification_outbox(status, created_at);
+CREATE INDEX idx_notification_outbox_channel_id ON notification_outbox(channel_id);
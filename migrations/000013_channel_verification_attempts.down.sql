@@ -0,0 +1,5 @@
+DROP INDEX idx_channel_verification_codes_code_hash;
+DELETE FROM channel_verification_codes;
+ALTER TABLE channel_verification_codes ADD CONSTRAINT channel_verification_codes_code_hash_key UNIQUE (code_hash);
+
+ALTER TABLE channel_verification_codes DROP COLUMN attempts;
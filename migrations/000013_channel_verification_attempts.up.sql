@@ -0,0 +1,6 @@
+-- Счётчик неверных попыток ввода кода (защита от перебора)
+ALTER TABLE channel_verification_codes ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+
+-- Короткие email-коды могут совпадать у разных каналов
+ALTER TABLE channel_verification_codes DROP CONSTRAINT channel_verification_codes_code_hash_key;
+CREATE INDEX idx_channel_verification_codes_code_hash ON channel_verification_codes(code_hash);
@@ -0,0 +1,5 @@
+-- Ручная фиксация статуса сервиса: пока флаг установлен, статус не пересчитывается по событиям
+ALTER TABLE services ADD COLUMN status_override BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Статусы, выставленные вручную до появления автоматического расчёта, сохраняем как ручные
+UPDATE services SET status_override = TRUE WHERE status <> 'operational';
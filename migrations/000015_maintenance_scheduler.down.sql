@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_events_scheduled_maintenance;
+ALTER TABLE events DROP COLUMN reminder_sent_at;
+
+-- Системный пользователь остаётся: на него ссылаются события и обновления,
+-- созданные автоматически; повторный up пропустит вставку (ON CONFLICT DO NOTHING)
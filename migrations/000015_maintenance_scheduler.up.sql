@@ -0,0 +1,18 @@
+-- Системный пользователь: автор автоматических обновлений (например, старт/завершение обслуживания)
+-- Вход под ним невозможен: password_hash не является bcrypt-хэшем
+INSERT INTO users (id, email, password_hash, first_name, last_name, role)
+VALUES (
+    '00000000-0000-0000-0000-000000000001',
+    'system@incident-garden.local',
+    '!',
+    'System',
+    '',
+    'operator'
+) ON CONFLICT (id) DO NOTHING;
+
+-- Отметка об отправленном напоминании о скором начале обслуживания
+ALTER TABLE events ADD COLUMN reminder_sent_at TIMESTAMP;
+
+-- Выборка обслуживаний, которым пора сменить статус
+CREATE INDEX idx_events_scheduled_maintenance ON events(scheduled_start_at, scheduled_end_at)
+    WHERE type = 'maintenance' AND status IN ('scheduled', 'in_progress');
@@ -0,0 +1,9 @@
+-- Инциденты, открытые алертами Alertmanager; ключ — fingerprint алерта
+CREATE TABLE alertmanager_alerts (
+    fingerprint VARCHAR(64) PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_alertmanager_alerts_event_id ON alertmanager_alerts(event_id);
@@ -0,0 +1,18 @@
+-- API-ключи для машинных интеграций; хранится только SHA-256 ключа
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    -- Пустой список — ключ ограничен только ролью
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_api_key_role CHECK (role IN ('user', 'operator', 'admin'))
+);
+
+CREATE INDEX idx_api_keys_created_by ON api_keys(created_by);
@@ -0,0 +1,2 @@
+-- Счётчик изменений события: SEQUENCE в iCalendar
+ALTER TABLE events ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;
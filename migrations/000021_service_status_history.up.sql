@@ -0,0 +1,16 @@
+-- История изменений статусов сервисов
+CREATE TABLE service_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    -- NULL для начального статуса нового сервиса
+    old_status VARCHAR(50),
+    new_status VARCHAR(50) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    -- Без внешних ключей: история переживает удаление пользователей и событий
+    actor_id UUID,
+    event_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_status_change_reason CHECK (reason IN ('created', 'manual', 'override_cleared', 'event'))
+);
+
+CREATE INDEX idx_service_status_history_service_id ON service_status_history(service_id, created_at DESC);
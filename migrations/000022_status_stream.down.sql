@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS status_stream_messages_notify ON status_stream_messages;
+DROP FUNCTION IF EXISTS notify_status_stream();
+DROP TABLE IF EXISTS status_stream_messages;
@@ -0,0 +1,24 @@
+-- Сообщения live-потока статуса (SSE).
+-- Хранятся ограниченное время, чтобы клиенты могли продолжить поток по Last-Event-ID.
+CREATE TABLE status_stream_messages (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    -- Сервисы, которых касается сообщение; по ним фильтруется поток
+    service_ids UUID[] NOT NULL DEFAULT '{}',
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_status_stream_messages_created_at ON status_stream_messages(created_at);
+
+-- Уведомляет все реплики о новом сообщении через LISTEN/NOTIFY
+CREATE FUNCTION notify_status_stream() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('status_stream', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER status_stream_messages_notify
+    AFTER INSERT ON status_stream_messages
+    FOR EACH ROW EXECUTE FUNCTION notify_status_stream();
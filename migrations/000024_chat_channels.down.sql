@@ -0,0 +1,11 @@
+ALTER TABLE notification_outbox DROP COLUMN link;
+ALTER TABLE notification_outbox DROP COLUMN level;
+
+-- Доставки удаляются каскадно вместе с каналами
+DELETE FROM notification_channels WHERE type IN ('slack', 'msteams');
+
+ALTER TABLE notification_outbox ALTER COLUMN target TYPE VARCHAR(255);
+ALTER TABLE notification_channels ALTER COLUMN target TYPE VARCHAR(255);
+
+ALTER TABLE notification_channels DROP CONSTRAINT check_channel_type;
+ALTER TABLE notification_channels ADD CONSTRAINT check_channel_type CHECK (type IN ('email', 'telegram'));
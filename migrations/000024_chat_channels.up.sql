@@ -0,0 +1,11 @@
+-- Каналы Slack и Microsoft Teams: target — URL входящего webhook
+ALTER TABLE notification_channels DROP CONSTRAINT check_channel_type;
+ALTER TABLE notification_channels ADD CONSTRAINT check_channel_type CHECK (type IN ('email', 'telegram', 'slack', 'msteams'));
+
+-- URL webhook Teams длиннее 255 символов
+ALTER TABLE notification_channels ALTER COLUMN target TYPE TEXT;
+ALTER TABLE notification_outbox ALTER COLUMN target TYPE TEXT;
+
+-- Уровень (цвет сообщения в чатах) и ссылка на событие
+ALTER TABLE notification_outbox ADD COLUMN level VARCHAR(20) NOT NULL DEFAULT 'info';
+ALTER TABLE notification_outbox ADD COLUMN link TEXT NOT NULL DEFAULT '';
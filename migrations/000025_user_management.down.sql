@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_is_active;
+
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_required;
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_active;
@@ -0,0 +1,8 @@
+-- Блокировка пользователей: неактивный пользователь не может войти
+ALTER TABLE users ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP;
+
+-- Администратор может потребовать сменить пароль при следующем входе
+ALTER TABLE users ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_users_is_active ON users(is_active);
@@ -0,0 +1,8 @@
+-- Незавершённые сбросы пароля: не больше одного на пользователя.
+-- Хранится только SHA-256 хэш токена из письма
+CREATE TABLE password_resets (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- Хэши нельзя превратить обратно в токены, поэтому все сессии завершаются
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_session_id;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS used_at;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS token_hash;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS session_id;
+ALTER TABLE refresh_tokens ADD COLUMN token VARCHAR(255) NOT NULL UNIQUE;
+
+CREATE INDEX idx_refresh_tokens_token ON refresh_tokens(token);
+
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,35 @@
+-- Сессии: вход с одного устройства. Refresh токены сессии образуют семейство,
+-- каждый refresh заменяет токен новым
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+
+-- Каждый существующий токен становится отдельной сессией
+INSERT INTO sessions (id, user_id, expires_at, last_used_at, created_at)
+SELECT id, user_id, expires_at, created_at, created_at FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens ADD COLUMN session_id UUID REFERENCES sessions(id) ON DELETE CASCADE;
+UPDATE refresh_tokens SET session_id = id;
+ALTER TABLE refresh_tokens ALTER COLUMN session_id SET NOT NULL;
+
+-- Вместо токена хранится его SHA-256 хэш
+ALTER TABLE refresh_tokens ADD COLUMN token_hash VARCHAR(64);
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash SET NOT NULL;
+ALTER TABLE refresh_tokens ADD CONSTRAINT refresh_tokens_token_hash_key UNIQUE (token_hash);
+
+DROP INDEX IF EXISTS idx_refresh_tokens_token;
+ALTER TABLE refresh_tokens DROP COLUMN token;
+
+-- Использованный токен хранится, чтобы распознать повторное предъявление
+ALTER TABLE refresh_tokens ADD COLUMN used_at TIMESTAMP;
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
@@ -0,0 +1,40 @@
+-- Двухфакторная аутентификация (TOTP). Секрет сохраняется при подключении,
+-- totp_enabled_at заполняется после подтверждения первым кодом
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP;
+-- Последний принятый временной шаг: один и тот же код нельзя использовать дважды
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT;
+
+-- Коды восстановления на случай потери устройства. Хранятся SHA-256 хэши,
+-- использованный код удаляется
+CREATE TABLE recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_recovery_codes UNIQUE (user_id, code_hash)
+);
+
+-- Входы, ожидающие второго фактора. Хранится SHA-256 хэш токена,
+-- attempts ограничивает перебор кодов
+CREATE TABLE mfa_challenges (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mfa_challenges_expires_at ON mfa_challenges(expires_at);
+
+-- Политика двухфакторной аутентификации: единственная строка.
+-- required_role — роль, начиная с которой 2FA обязательна; NULL — не обязательна
+CREATE TABLE mfa_policy (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE,
+    required_role VARCHAR(50),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_mfa_policy_single_row CHECK (id),
+    CONSTRAINT check_mfa_policy_role CHECK (required_role IN ('operator', 'admin'))
+);
+
+INSERT INTO mfa_policy (id) VALUES (TRUE);
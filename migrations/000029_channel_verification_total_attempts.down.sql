@@ -0,0 +1 @@
+ALTER TABLE channel_verification_codes DROP COLUMN total_attempts;
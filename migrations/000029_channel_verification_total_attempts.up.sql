@@ -0,0 +1,2 @@
+-- Попытки по всем кодам канала: не сбрасываются при повторной отправке кода
+ALTER TABLE channel_verification_codes ADD COLUMN total_attempts INT NOT NULL DEFAULT 0;
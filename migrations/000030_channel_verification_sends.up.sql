@@ -0,0 +1,11 @@
+-- Отправленные коды подтверждения: ограничивают частоту отправки пользователем
+-- и на один адрес, в том числе после удаления и повторного создания канала
+CREATE TABLE channel_verification_sends (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_channel_verification_sends_user_id ON channel_verification_sends(user_id, created_at);
+CREATE INDEX idx_channel_verification_sends_target ON channel_verification_sends(target, created_at);
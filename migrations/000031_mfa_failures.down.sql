@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_failed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_failed_attempts;
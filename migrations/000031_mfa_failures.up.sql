@@ -0,0 +1,4 @@
+-- Неудачные попытки второго фактора по всем токенам проверки пользователя:
+-- после серии неудач в окне новые попытки и вход отклоняются
+ALTER TABLE users ADD COLUMN mfa_failed_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN mfa_failed_at TIMESTAMP;
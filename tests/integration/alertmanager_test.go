@@ -0,0 +1,127 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertmanager_AlertLifecycle(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("alerted-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Alerted Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	alertmanager := newTestClient(t)
+	alertmanager.Token = alertmanagerToken
+
+	fingerprint := testutil.RandomSlug("fp")
+	summary := "High error rate on " + slug
+	send := func(status string) map[string]int {
+		resp, err := alertmanager.POST("/api/v1/integrations/alertmanager", map[string]interface{}{
+			"version": "4",
+			"status":  status,
+			"alerts": []map[string]interface{}{{
+				"status":      status,
+				"fingerprint": fingerprint,
+				"labels": map[string]string{
+					"alertname":          "HighErrorRate",
+					"severity":           "critical",
+					"statuspage_service": slug,
+				},
+				"annotations": map[string]string{"summary": summary},
+				"startsAt":    "2026-01-01T00:00:00Z",
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Data map[string]int `json:"data"`
+		}
+		testutil.DecodeJSON(t, resp, &result)
+		return result.Data
+	}
+
+	findEvent := func() (id, status string) {
+		resp, err := admin.GET("/api/v1/events?type=incident")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Data []struct {
+				ID       string `json:"id"`
+				Title    string `json:"title"`
+				Status   string `json:"status"`
+				Severity string `json:"severity"`
+			} `json:"data"`
+		}
+		testutil.DecodeJSON(t, resp, &result)
+		for _, e := range result.Data {
+			if e.Title == summary {
+				assert.Equal(t, "critical", e.Severity)
+				return e.ID, e.Status
+			}
+		}
+		t.Fatalf("incident %q not found", summary)
+		return "", ""
+	}
+
+	assert.Equal(t, 1, send("firing")["created"])
+	eventID, status := findEvent()
+	assert.Equal(t, "investigating", status)
+
+	assert.Equal(t, 1, send("firing")["updated"], "repeated firing adds an update")
+	id, _ := findEvent()
+	assert.Equal(t, eventID, id, "repeated firing does not open a new incident")
+
+	assert.Equal(t, 1, send("resolved")["resolved"])
+	_, status = findEvent()
+	assert.Equal(t, "resolved", status)
+
+	resp, err = admin.GET("/api/v1/events/" + eventID + "/updates")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var updates struct {
+		Data []struct {
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &updates)
+	assert.Len(t, updates.Data, 2)
+
+	resp, err = admin.GET("/api/v1/services/" + slug)
+	require.NoError(t, err)
+	var service struct {
+		Data struct {
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+	assert.Equal(t, "operational", service.Data.Status)
+}
+
+func TestAlertmanager_RequiresToken(t *testing.T) {
+	client := newTestClientWithoutValidation()
+	client.Token = "wrong-token"
+
+	resp, err := client.POST("/api/v1/integrations/alertmanager", map[string]interface{}{
+		"alerts": []interface{}{},
+	})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
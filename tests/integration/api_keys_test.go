@@ -0,0 +1,165 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createdAPIKey struct {
+	Data struct {
+		ID     string   `json:"id"`
+		Prefix string   `json:"prefix"`
+		Role   string   `json:"role"`
+		Scopes []string `json:"scopes"`
+		Key    string   `json:"key"`
+	} `json:"data"`
+}
+
+func createAPIKey(t *testing.T, admin *testutil.Client, body map[string]interface{}) createdAPIKey {
+	t.Helper()
+	resp, err := admin.POST("/api/v1/api-keys", body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var key createdAPIKey
+	testutil.DecodeJSON(t, resp, &key)
+	require.NotEmpty(t, key.Data.Key)
+	return key
+}
+
+func TestAPIKeys_RoleBoundKey(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	key := createAPIKey(t, admin, map[string]interface{}{
+		"name": "ci",
+		"role": "operator",
+	})
+	assert.Contains(t, key.Data.Key, "sp_"+key.Data.Prefix+"_")
+	assert.Empty(t, key.Data.Scopes)
+
+	bot := newTestClient(t)
+	bot.Token = key.Data.Key
+
+	resp, err := bot.GET("/api/v1/events")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = bot.POST("/api/v1/services", map[string]string{
+		"name": "Forbidden",
+		"slug": testutil.RandomSlug("forbidden"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "operator key cannot use admin routes")
+	resp.Body.Close()
+
+	// The key is never returned again
+	resp, err = admin.GET("/api/v1/api-keys")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body := testutil.ReadBody(t, resp)
+	assert.Contains(t, body, key.Data.ID)
+	assert.NotContains(t, body, key.Data.Key)
+
+	resp, err = admin.DELETE("/api/v1/api-keys/" + key.Data.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = bot.GET("/api/v1/events")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "revoked key is rejected")
+	resp.Body.Close()
+}
+
+func TestAPIKeys_ScopedKey(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	key := createAPIKey(t, admin, map[string]interface{}{
+		"name":   "monitoring",
+		"role":   "admin",
+		"scopes": []string{"events:read"},
+	})
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/v1/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", key.Data.Key)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "X-API-Key header is accepted")
+	resp.Body.Close()
+
+	bot := newTestClientWithoutValidation()
+	bot.Token = key.Data.Key
+
+	resp, err = bot.POST("/api/v1/events", map[string]interface{}{
+		"title":    "Not allowed",
+		"type":     "incident",
+		"status":   "investigating",
+		"severity": "minor",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "events:read does not allow writes")
+	resp.Body.Close()
+
+	resp, err = bot.GET("/api/v1/api-keys")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "scopes limit an admin key")
+	resp.Body.Close()
+}
+
+func TestAPIKeys_ScopedKeyCannotManageAccount(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	key := createAPIKey(t, admin, map[string]interface{}{
+		"name":   "automation",
+		"role":   "admin",
+		"scopes": []string{"events:write"},
+	})
+
+	bot := newTestClientWithoutValidation()
+	bot.Token = key.Data.Key
+
+	resp, err := bot.POST("/api/v1/me/mfa/totp", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "a key cannot enroll its creator in MFA")
+	resp.Body.Close()
+
+	resp, err = bot.GET("/api/v1/me/sessions")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "a key cannot list its creator's sessions")
+	resp.Body.Close()
+}
+
+func TestAPIKeys_InvalidScope(t *testing.T) {
+	admin := newTestClientWithoutValidation()
+	admin.LoginAsAdmin(t)
+
+	resp, err := admin.POST("/api/v1/api-keys", map[string]interface{}{
+		"name":   "bad",
+		"role":   "operator",
+		"scopes": []string{"everything:write"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestAPIKeys_AdminOnly(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsOperator(t)
+
+	resp, err := client.GET("/api/v1/api-keys")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,164 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditEntries struct {
+	Data []struct {
+		ActorType  string                 `json:"actor_type"`
+		ActorID    string                 `json:"actor_id"`
+		APIKeyID   string                 `json:"api_key_id"`
+		Action     string                 `json:"action"`
+		EntityType string                 `json:"entity_type"`
+		EntityID   string                 `json:"entity_id"`
+		Before     map[string]interface{} `json:"before"`
+		After      map[string]interface{} `json:"after"`
+		RequestID  string                 `json:"request_id"`
+	} `json:"data"`
+}
+
+func TestAudit_RecordsServiceChanges(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	resp, err := admin.GET("/api/v1/me")
+	require.NoError(t, err)
+	var me struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &me)
+
+	slug := testutil.RandomSlug("audited")
+	resp, err = admin.POST("/api/v1/services", map[string]string{
+		"name": "Audited",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &created)
+
+	resp, err = admin.PATCH("/api/v1/services/"+slug, map[string]string{
+		"name": "Audited Renamed",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = admin.GET("/api/v1/audit?entity_type=service&entity_id=" + created.Data.ID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entries auditEntries
+	testutil.DecodeJSON(t, resp, &entries)
+	require.Len(t, entries.Data, 2)
+
+	update, create := entries.Data[0], entries.Data[1]
+	assert.Equal(t, "create", create.Action)
+	assert.Nil(t, create.Before)
+	assert.Equal(t, "Audited", create.After["name"])
+
+	assert.Equal(t, "update", update.Action)
+	assert.Equal(t, "user", update.ActorType)
+	assert.Equal(t, me.Data.ID, update.ActorID)
+	assert.NotEmpty(t, update.RequestID)
+	assert.Equal(t, map[string]interface{}{"name": "Audited"}, update.Before)
+	assert.Equal(t, "Audited Renamed", update.After["name"])
+	assert.NotContains(t, update.After, "slug", "unchanged fields are not recorded")
+}
+
+func TestAudit_RecordsAPIKeyActor(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	key := createAPIKey(t, admin, map[string]interface{}{
+		"name":   "audit-bot",
+		"role":   "admin",
+		"scopes": []string{"catalog:write"},
+	})
+
+	bot := newTestClient(t)
+	bot.Token = key.Data.Key
+	resp, err := bot.POST("/api/v1/groups", map[string]string{
+		"name": "Bot Group",
+		"slug": testutil.RandomSlug("bot-group"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = admin.GET("/api/v1/audit?actor_type=api_key&api_key_id=" + key.Data.ID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entries auditEntries
+	testutil.DecodeJSON(t, resp, &entries)
+	require.Len(t, entries.Data, 1)
+	assert.Equal(t, "service_group", entries.Data[0].EntityType)
+	assert.Equal(t, key.Data.ID, entries.Data[0].APIKeyID)
+
+	// The key has no audit scope.
+	resp, err = bot.GET("/api/v1/audit")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestAudit_CSVExport(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	resp, err := admin.POST("/api/v1/groups", map[string]string{
+		"name": "CSV Group",
+		"slug": testutil.RandomSlug("csv-group"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = admin.GET("/api/v1/audit?format=csv&entity_type=service_group&limit=10")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/csv")
+
+	records, err := csv.NewReader(strings.NewReader(testutil.ReadBody(t, resp))).ReadAll()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(records), 2)
+	assert.Equal(t, "id", records[0][0])
+	assert.Equal(t, "service_group", records[1][6])
+}
+
+func TestAudit_RequiresAdmin(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsOperator(t)
+
+	resp, err := client.GET("/api/v1/audit")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	admin := newTestClientWithoutValidation()
+	admin.LoginAsAdmin(t)
+
+	resp, err = admin.GET("/api/v1/audit?from=yesterday")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,130 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBadges_ServiceStatus(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("badge-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Badge Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	resp, body := getPlain(t, "/api/v1/badges/services/"+slug+".svg", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "image/svg+xml")
+	assert.Equal(t, "public, max-age=60", resp.Header.Get("Cache-Control"))
+	assert.Contains(t, string(body), "<title>Badge Service: operational</title>")
+
+	etag := resp.Header.Get("ETag")
+	resp, _ = getPlain(t, "/api/v1/badges/services/"+slug+".svg", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":       "Badge incident",
+		"type":        "incident",
+		"status":      "investigating",
+		"severity":    "critical",
+		"description": "Down",
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	client := newTestClient(t)
+	resp, err = client.GET("/api/v1/badges/services/" + slug + ".json?label=api")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var endpoint struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}
+	testutil.DecodeJSON(t, resp, &endpoint)
+	assert.Equal(t, 1, endpoint.SchemaVersion)
+	assert.Equal(t, "api", endpoint.Label)
+	assert.Equal(t, "major outage", endpoint.Message)
+	assert.Equal(t, "red", endpoint.Color)
+}
+
+func TestBadges_GroupWorstStatus(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	groupSlug := testutil.RandomSlug("badge-group")
+	resp, err := admin.POST("/api/v1/groups", map[string]string{
+		"name": "Badge Group",
+		"slug": groupSlug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var group struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &group)
+
+	for _, status := range []string{"operational", "degraded"} {
+		resp, err := admin.POST("/api/v1/services", map[string]interface{}{
+			"name":      "Badge member " + status,
+			"slug":      testutil.RandomSlug("badge-member"),
+			"status":    status,
+			"group_ids": []string{group.Data.ID},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	client := newTestClient(t)
+	resp, err = client.GET("/api/v1/badges/groups/" + groupSlug + ".json")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var endpoint struct {
+		Label   string `json:"label"`
+		Message string `json:"message"`
+		Color   string `json:"color"`
+	}
+	testutil.DecodeJSON(t, resp, &endpoint)
+	assert.Equal(t, "Badge Group", endpoint.Label)
+	assert.Equal(t, "degraded", endpoint.Message)
+	assert.Equal(t, "yellow", endpoint.Color)
+}
+
+func TestBadges_NotFoundAndInvalidStyle(t *testing.T) {
+	resp, body := getPlain(t, "/api/v1/badges/services/"+testutil.RandomSlug("missing")+".svg", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, string(body), "not found")
+
+	client := newTestClient(t)
+	resp, err := client.GET("/api/v1/badges/groups/" + testutil.RandomSlug("missing") + ".json?style=3d")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
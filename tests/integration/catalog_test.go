@@ -309,3 +309,102 @@ func TestCatalog_EmptyList_ReturnsEmptyArray(t *testing.T) {
 		assert.NotEqual(t, slug, svc.Slug, "archived service should not appear in default list")
 	}
 }
+
+func TestCatalog_ServiceStatusHistory(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("history-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "History Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":       "History incident",
+		"type":        "incident",
+		"status":      "investigating",
+		"severity":    "major",
+		"description": "Affects history service",
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var event struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &event)
+
+	resp, err = admin.PATCH("/api/v1/services/"+slug, map[string]interface{}{
+		"name":   "History Service",
+		"slug":   slug,
+		"status": "major_outage",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	operator := newTestClient(t)
+	operator.LoginAsOperator(t)
+
+	resp, err = operator.GET("/api/v1/services/" + slug + "/history")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var history struct {
+		Data []struct {
+			OldStatus *string `json:"old_status"`
+			NewStatus string  `json:"new_status"`
+			Reason    string  `json:"reason"`
+			ActorID   *string `json:"actor_id"`
+			EventID   *string `json:"event_id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &history)
+	require.Len(t, history.Data, 3)
+
+	// Newest first
+	manual, fromEvent, created := history.Data[0], history.Data[1], history.Data[2]
+	assert.Equal(t, "manual", manual.Reason)
+	assert.Equal(t, "major_outage", manual.NewStatus)
+	require.NotNil(t, manual.OldStatus)
+	assert.Equal(t, "partial_outage", *manual.OldStatus)
+	assert.NotNil(t, manual.ActorID)
+
+	assert.Equal(t, "event", fromEvent.Reason)
+	assert.Equal(t, "partial_outage", fromEvent.NewStatus)
+	require.NotNil(t, fromEvent.EventID)
+	assert.Equal(t, event.Data.ID, *fromEvent.EventID)
+
+	assert.Equal(t, "created", created.Reason)
+	assert.Nil(t, created.OldStatus)
+	assert.Equal(t, "operational", created.NewStatus)
+
+	resp, err = operator.GET("/api/v1/services/" + slug + "/history?to=2000-01-01T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	testutil.DecodeJSON(t, resp, &history)
+	assert.Empty(t, history.Data)
+
+	resp, err = operator.GET("/api/v1/services/" + slug + "/history?from=yesterday")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = newTestClient(t).GET("/api/v1/services/" + slug + "/history")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+}
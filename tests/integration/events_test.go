@@ -157,3 +157,113 @@ func TestEvents_PublicStatus(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	resp.Body.Close()
 }
+
+func TestEvents_ServiceStatusFollowsEvents(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("status-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Status Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	serviceStatus := func() (string, bool) {
+		resp, err := admin.GET("/api/v1/services/" + slug)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Data struct {
+				Status         string `json:"status"`
+				StatusOverride bool   `json:"status_override"`
+			} `json:"data"`
+		}
+		testutil.DecodeJSON(t, resp, &result)
+		return result.Data.Status, result.Data.StatusOverride
+	}
+
+	createIncident := func(severity string) string {
+		resp, err := admin.POST("/api/v1/events", map[string]interface{}{
+			"title":       "Status incident " + severity,
+			"type":        "incident",
+			"status":      "investigating",
+			"severity":    severity,
+			"description": "Affects status service",
+			"service_ids": []string{service.Data.ID},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var event struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		testutil.DecodeJSON(t, resp, &event)
+		return event.Data.ID
+	}
+
+	resolve := func(eventID string) {
+		resp, err := admin.POST("/api/v1/events/"+eventID+"/updates", map[string]interface{}{
+			"status":  "resolved",
+			"message": "Resolved",
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	minor := createIncident("minor")
+	status, _ := serviceStatus()
+	assert.Equal(t, "degraded", status)
+
+	critical := createIncident("critical")
+	status, _ = serviceStatus()
+	assert.Equal(t, "major_outage", status, "worst of overlapping events wins")
+
+	resolve(critical)
+	status, _ = serviceStatus()
+	assert.Equal(t, "degraded", status)
+
+	// Manual status sticks while the override is set
+	resp, err = admin.PATCH("/api/v1/services/"+slug, map[string]interface{}{
+		"name":   "Status Service",
+		"slug":   slug,
+		"status": "operational",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	status, override := serviceStatus()
+	assert.Equal(t, "operational", status)
+	assert.True(t, override)
+
+	// Clearing the override recalculates the status from active events
+	resp, err = admin.PATCH("/api/v1/services/"+slug, map[string]interface{}{
+		"name":            "Status Service",
+		"slug":            slug,
+		"status_override": false,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	status, override = serviceStatus()
+	assert.Equal(t, "degraded", status)
+	assert.False(t, override)
+
+	resolve(minor)
+	status, _ = serviceStatus()
+	assert.Equal(t, "operational", status)
+}
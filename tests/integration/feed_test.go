@@ -0,0 +1,220 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type atomFeed struct {
+	ID      string `xml:"id"`
+	Updated string `xml:"updated"`
+	Entries []struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+		Content string `xml:"content"`
+	} `xml:"entry"`
+}
+
+// getPlain fetches a non-JSON resource (feeds, calendars, badges), which is not validated against the OpenAPI schema.
+func getPlain(t *testing.T, path string, header map[string]string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, body
+}
+
+func TestFeed_ServiceFeed(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("feed-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Feed Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":       "Feed incident",
+		"type":        "incident",
+		"status":      "investigating",
+		"severity":    "minor",
+		"description": "Slow responses",
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var event struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &event)
+
+	path := "/api/v1/services/" + slug + "/feed.atom"
+	resp, body := getPlain(t, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/atom+xml")
+
+	var feed atomFeed
+	require.NoError(t, xml.Unmarshal(body, &feed))
+	assert.Equal(t, "urn:incident-garden:feed:service:"+service.Data.ID, feed.ID)
+	require.Len(t, feed.Entries, 1)
+	assert.Equal(t, "urn:uuid:"+event.Data.ID, feed.Entries[0].ID)
+	assert.Equal(t, "Feed incident", feed.Entries[0].Title)
+	assert.Equal(t, feed.Updated, feed.Entries[0].Updated)
+
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	resp, body = getPlain(t, path, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Empty(t, body)
+
+	resp, _ = getPlain(t, path, map[string]string{"If-Modified-Since": resp.Header.Get("Last-Modified")})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, err = admin.POST("/api/v1/events/"+event.Data.ID+"/updates", map[string]interface{}{
+		"status":  "identified",
+		"message": "Database failover in progress",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, body = getPlain(t, path, map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+
+	feed = atomFeed{}
+	require.NoError(t, xml.Unmarshal(body, &feed))
+	require.Len(t, feed.Entries, 1)
+	assert.Equal(t, "urn:uuid:"+event.Data.ID, feed.Entries[0].ID, "entry id is stable across updates")
+	assert.Contains(t, feed.Entries[0].Content, "Database failover in progress")
+}
+
+func TestFeed_StatusFeedRSS(t *testing.T) {
+	resp, body := getPlain(t, "/api/v1/status/feed.rss", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/rss+xml")
+	assert.NotEmpty(t, resp.Header.Get("Last-Modified"))
+
+	var rss struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(body, &rss))
+	assert.Equal(t, "2.0", rss.Version)
+	assert.NotEmpty(t, rss.Channel.Title)
+}
+
+func TestFeed_UnknownSlug(t *testing.T) {
+	resp, _ := getPlain(t, "/api/v1/services/"+testutil.RandomSlug("missing")+"/feed.rss", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = getPlain(t, "/api/v1/groups/"+testutil.RandomSlug("missing")+"/feed.atom", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestFeed_MaintenanceCalendar(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("calendar-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Calendar Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":              "Calendar maintenance",
+		"type":               "maintenance",
+		"status":             "scheduled",
+		"description":        "Storage migration",
+		"scheduled_start_at": "2030-02-10T02:00:00Z",
+		"scheduled_end_at":   "2030-02-10T04:00:00Z",
+		"service_ids":        []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var event struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &event)
+
+	path := "/api/v1/services/" + slug + "/maintenance.ics"
+	resp, body := getPlain(t, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/calendar")
+
+	ics := string(body)
+	assert.Contains(t, ics, "UID:"+event.Data.ID+"@incident-garden\r\n")
+	assert.Contains(t, ics, "SEQUENCE:0\r\n")
+	assert.Contains(t, ics, "DTSTART:20300210T020000Z\r\n")
+	assert.Contains(t, ics, "DTEND:20300210T040000Z\r\n")
+	assert.Contains(t, ics, "STATUS:CONFIRMED\r\n")
+
+	resp, err = admin.POST("/api/v1/events/"+event.Data.ID+"/updates", map[string]interface{}{
+		"status":  "cancelled",
+		"message": "Postponed to next quarter",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, body = getPlain(t, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Unfold long lines (RFC 5545, section 3.1) before looking for the description.
+	ics = strings.ReplaceAll(string(body), "\r\n ", "")
+	assert.Contains(t, ics, "UID:"+event.Data.ID+"@incident-garden\r\n")
+	assert.Contains(t, ics, "SEQUENCE:1\r\n")
+	assert.Contains(t, ics, "STATUS:CANCELLED\r\n")
+	assert.Contains(t, ics, "Postponed to next quarter")
+
+	resp, _ = getPlain(t, "/api/v1/maintenance.ics", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,55 @@
+//go:build integration
+
+package integration
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJWKS_VerifiesAccessTokens checks that another service can verify access
+// tokens with nothing but the published key set.
+func TestJWKS_VerifiesAccessTokens(t *testing.T) {
+	resp, err := newTestClient(t).GET("/.well-known/jwks.json")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "max-age")
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+		} `json:"keys"`
+	}
+	testutil.DecodeJSON(t, resp, &set)
+	require.Len(t, set.Keys, 1)
+	key := set.Keys[0]
+	assert.Equal(t, "OKP", key.Kty)
+	assert.Equal(t, "EdDSA", key.Alg)
+	assert.Equal(t, "Ed25519", key.Crv)
+
+	public, err := base64.RawURLEncoding.DecodeString(key.X)
+	require.NoError(t, err)
+
+	_, email := registerUser(t)
+	tokens := login(t, email)
+
+	var claims jwt.MapClaims
+	token, err := jwt.ParseWithClaims(tokens.AccessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, key.Kid, token.Header["kid"])
+		return ed25519.PublicKey(public), nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	require.NoError(t, err)
+	assert.True(t, token.Valid)
+	assert.NotEmpty(t, claims["user_id"])
+}
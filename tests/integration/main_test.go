@@ -4,14 +4,20 @@ package integration
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"log"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/bissquit/incident-garden/internal/app"
 	"github.com/bissquit/incident-garden/internal/config"
+	"github.com/bissquit/incident-garden/internal/domain"
 	"github.com/bissquit/incident-garden/internal/testutil"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -27,6 +33,17 @@ var (
 // OpenAPI spec path relative to the tests/integration directory.
 const openAPISpecPath = "../../api/openapi/openapi.yaml"
 
+// oidcClientID and oidcRedirectURL are registered at the mock identity provider.
+const (
+	oidcClientID    = "statuspage-test"
+	oidcRedirectURL = "http://statuspage.test/api/v1/auth/oidc/callback"
+)
+
+var oidcProvider *testutil.MockOIDCProvider
+
+// alertmanagerToken authenticates Alertmanager webhook requests in tests.
+const alertmanagerToken = "test-alertmanager-token"
+
 // newTestClient creates a new test client with OpenAPI validation enabled.
 // Use this at the beginning of each test that makes API calls.
 func newTestClient(t *testing.T) *testutil.Client {
@@ -66,6 +83,18 @@ func TestMain(m *testing.M) {
 		log.Fatalf("run migrations: %v", err)
 	}
 
+	oidcProvider, err = testutil.NewMockOIDCProvider(oidcClientID)
+	if err != nil {
+		log.Fatalf("start oidc provider: %v", err)
+	}
+	defer oidcProvider.Close()
+
+	signingKeyFile, err := writeSigningKey()
+	if err != nil {
+		log.Fatalf("write jwt signing key: %v", err)
+	}
+	defer os.RemoveAll(filepath.Dir(signingKeyFile))
+
 	cfg := &config.Config{
 		Server: config.ServerConfig{
 			Host:         "127.0.0.1",
@@ -85,9 +114,31 @@ func TestMain(m *testing.M) {
 		},
 		JWT: config.JWTConfig{
 			SecretKey:            "test-secret-key",
+			SigningKeyFile:       signingKeyFile,
 			AccessTokenDuration:  15 * time.Minute,
 			RefreshTokenDuration: 24 * time.Hour,
 		},
+		Alertmanager: config.AlertmanagerConfig{
+			WebhookToken:  alertmanagerToken,
+			ServiceLabel:  "statuspage_service",
+			SeverityLabel: "severity",
+			SeverityMap: map[string]domain.Severity{
+				"critical": domain.SeverityCritical,
+				"warning":  domain.SeverityMajor,
+			},
+			DefaultSeverity: domain.SeverityMinor,
+		},
+		OIDC: config.OIDCConfig{
+			IssuerURL:   oidcProvider.Issuer(),
+			ClientID:    oidcClientID,
+			RedirectURL: oidcRedirectURL,
+			Scopes:      []string{"openid", "email", "profile"},
+			RoleClaim:   "groups",
+			RoleMap: map[string]domain.Role{
+				"statuspage-operators": domain.RoleOperator,
+			},
+			DefaultRole: domain.RoleUser,
+		},
 	}
 
 	application, err := app.New(cfg)
@@ -118,3 +169,22 @@ func TestMain(m *testing.M) {
 
 	os.Exit(code)
 }
+
+// writeSigningKey writes a new Ed25519 access token signing key to a temporary file.
+func writeSigningKey() (string, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "statuspage-jwt")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "signing.pem")
+	return path, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+}
@@ -0,0 +1,227 @@
+//go:build integration
+
+package integration
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// totp returns the code an authenticator app shows for secret, offset by a
+// number of 30 second steps.
+func totp(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	require.NoError(t, err)
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30+offset))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	i := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[i:i+4])&0x7fffffff)%1_000_000)
+}
+
+type loginResult struct {
+	Data struct {
+		Tokens      *tokenPairResponse `json:"tokens"`
+		MFARequired bool               `json:"mfa_required"`
+		MFAToken    string             `json:"mfa_token"`
+	} `json:"data"`
+}
+
+func loginWithMFA(t *testing.T, email string) loginResult {
+	t.Helper()
+
+	resp, err := newTestClient(t).POST("/api/v1/auth/login", map[string]string{
+		"email":    email,
+		"password": "password123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result loginResult
+	testutil.DecodeJSON(t, resp, &result)
+	return result
+}
+
+func TestMFA_TOTPLogin(t *testing.T) {
+	id, email := registerUser(t)
+	client := newTestClient(t)
+	client.Token = login(t, email).AccessToken
+
+	resp, err := client.POST("/api/v1/me/mfa/totp", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+			URI    string `json:"uri"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &enrollment)
+	assert.Contains(t, enrollment.Data.URI, "otpauth://totp/")
+
+	resp, err = client.POST("/api/v1/me/mfa/totp/confirm", map[string]string{"code": totp(t, enrollment.Data.Secret, 0)})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var recovery struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &recovery)
+	require.Len(t, recovery.Data.RecoveryCodes, 10)
+
+	// The password alone no longer gives tokens.
+	result := loginWithMFA(t, email)
+	require.True(t, result.Data.MFARequired)
+	assert.Nil(t, result.Data.Tokens)
+
+	resp, err = newTestClient(t).POST("/api/v1/auth/mfa/verify", map[string]string{
+		"mfa_token": result.Data.MFAToken,
+		"code":      "00000-00000",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = newTestClient(t).POST("/api/v1/auth/mfa/verify", map[string]string{
+		"mfa_token": result.Data.MFAToken,
+		"code":      recovery.Data.RecoveryCodes[0],
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var verified loginResult
+	testutil.DecodeJSON(t, resp, &verified)
+	require.NotNil(t, verified.Data.Tokens)
+	assert.NotEmpty(t, verified.Data.Tokens.AccessToken)
+
+	// The recovery code and the challenge work once.
+	result = loginWithMFA(t, email)
+	resp, err = newTestClient(t).POST("/api/v1/auth/mfa/verify", map[string]string{
+		"mfa_token": result.Data.MFAToken,
+		"code":      recovery.Data.RecoveryCodes[0],
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = newTestClient(t).POST("/api/v1/auth/mfa/verify", map[string]string{
+		"mfa_token": "not-a-token",
+		"code":      recovery.Data.RecoveryCodes[1],
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// An admin resets the second factor of a user who lost it.
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+	resp, err = admin.POST("/api/v1/users/"+id+"/reset-mfa", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var user struct {
+		Data struct {
+			MFAEnabled bool `json:"mfa_enabled"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &user)
+	assert.False(t, user.Data.MFAEnabled)
+
+	result = loginWithMFA(t, email)
+	assert.False(t, result.Data.MFARequired)
+	assert.NotNil(t, result.Data.Tokens)
+}
+
+func TestMFA_LockoutAfterFailedCodes(t *testing.T) {
+	_, email := registerUser(t)
+	client := newTestClient(t)
+	client.Token = login(t, email).AccessToken
+
+	resp, err := client.POST("/api/v1/me/mfa/totp", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &enrollment)
+	resp, err = client.POST("/api/v1/me/mfa/totp/confirm", map[string]string{"code": totp(t, enrollment.Data.Secret, 0)})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	verify := func(mfaToken, code string) int {
+		t.Helper()
+		resp, err := newTestClient(t).POST("/api/v1/auth/mfa/verify", map[string]string{
+			"mfa_token": mfaToken,
+			"code":      code,
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A fresh challenge for every two guesses does not get around the per-user limit.
+	pending := loginWithMFA(t, email).Data.MFAToken
+	for i := 0; i < 5; i++ {
+		mfaToken := loginWithMFA(t, email).Data.MFAToken
+		require.Equal(t, http.StatusBadRequest, verify(mfaToken, "00000-00000"))
+		require.Equal(t, http.StatusBadRequest, verify(mfaToken, "00000-00000"))
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, verify(pending, totp(t, enrollment.Data.Secret, 1)))
+
+	resp, err = newTestClient(t).POST("/api/v1/auth/login", map[string]string{
+		"email":    email,
+		"password": "password123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestMFA_Policy(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	resp, err := admin.GET("/api/v1/mfa-policy")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var policy struct {
+		Data struct {
+			RequiredRole *string `json:"required_role"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &policy)
+	assert.Nil(t, policy.Data.RequiredRole)
+
+	// Changing the policy would lock out operators of other tests, so only
+	// the validation is checked here.
+	resp, err = admin.WithoutValidation().PUT("/api/v1/mfa-policy", map[string]string{"required_role": "user"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	user := newTestClient(t)
+	user.LoginAsUser(t)
+	resp, err = user.GET("/api/v1/mfa-policy")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
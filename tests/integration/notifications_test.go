@@ -0,0 +1,190 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications_Deliveries_AdminOnly(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsOperator(t)
+
+	resp, err := client.GET("/api/v1/notifications/deliveries")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNotifications_Deliveries_ListAndPurge(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsAdmin(t)
+
+	resp, err := client.GET("/api/v1/notifications/deliveries?status=failed&limit=10")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = client.DELETE("/api/v1/notifications/deliveries?status=sent")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNotifications_Deliveries_RetryNotFound(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsAdmin(t)
+
+	resp, err := client.POST("/api/v1/notifications/deliveries/00000000-0000-0000-0000-000000000000/retry", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNotifications_Deliveries_InvalidStatus(t *testing.T) {
+	client := newTestClientWithoutValidation()
+	client.LoginAsAdmin(t)
+
+	resp, err := client.GET("/api/v1/notifications/deliveries?status=bogus")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNotifications_ChannelVerification_RequiresCode(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsUser(t)
+
+	resp, err := client.POST("/api/v1/me/channels", map[string]string{
+		"type":   "email",
+		"target": testutil.RandomEmail(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		Data struct {
+			ID         string `json:"id"`
+			IsVerified bool   `json:"is_verified"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &created)
+	assert.False(t, created.Data.IsVerified)
+
+	t.Cleanup(func() {
+		resp, err := client.DELETE("/api/v1/me/channels/" + created.Data.ID)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+
+	resp, err = client.POST("/api/v1/me/channels/"+created.Data.ID+"/verify", map[string]string{
+		"code": "000000",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = client.POST("/api/v1/me/channels/"+created.Data.ID+"/verify/resend", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNotifications_ChannelVerification_OtherUser(t *testing.T) {
+	owner := newTestClient(t)
+	owner.LoginAsUser(t)
+
+	resp, err := owner.POST("/api/v1/me/channels", map[string]string{
+		"type":   "email",
+		"target": testutil.RandomEmail(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &created)
+
+	t.Cleanup(func() {
+		resp, err := owner.DELETE("/api/v1/me/channels/" + created.Data.ID)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+
+	other := newTestClient(t)
+	other.LoginAsOperator(t)
+
+	resp, err = other.POST("/api/v1/me/channels/"+created.Data.ID+"/verify", map[string]string{
+		"code": "000000",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNotifications_ChatChannels(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsUser(t)
+
+	tests := []struct {
+		channelType string
+		target      string
+	}{
+		{"slack", "https://hooks.slack.com/services/T000/B000/" + testutil.RandomSlug("hook")},
+		{"msteams", "https://prod-01.westus.logic.azure.com:443/workflows/" + testutil.RandomSlug("flow") + "/triggers/manual/paths/invoke?api-version=2016-06-01&sp=%2Ftriggers%2Fmanual%2Frun&sv=1.0&sig=" + testutil.RandomSlug("sig")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channelType, func(t *testing.T) {
+			resp, err := client.POST("/api/v1/me/channels", map[string]string{
+				"type":   tt.channelType,
+				"target": tt.target,
+			})
+			require.NoError(t, err)
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+			var created struct {
+				Data struct {
+					ID         string `json:"id"`
+					Target     string `json:"target"`
+					IsVerified bool   `json:"is_verified"`
+				} `json:"data"`
+			}
+			testutil.DecodeJSON(t, resp, &created)
+			assert.Equal(t, tt.target, created.Data.Target)
+			assert.False(t, created.Data.IsVerified)
+
+			t.Cleanup(func() {
+				resp, err := client.DELETE("/api/v1/me/channels/" + created.Data.ID)
+				if err == nil {
+					resp.Body.Close()
+				}
+			})
+
+			resp, err = client.POST("/api/v1/me/channels/"+created.Data.ID+"/verify", map[string]string{
+				"code": "000000",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			resp.Body.Close()
+		})
+	}
+
+	resp, err := client.POST("/api/v1/me/channels", map[string]string{
+		"type":   "slack",
+		"target": "https://internal.example.com/hook",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,155 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type oidcLoginResponse struct {
+	Data struct {
+		User struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+			Role  string `json:"role"`
+		} `json:"user"`
+		Tokens struct {
+			AccessToken string `json:"access_token"`
+		} `json:"tokens"`
+	} `json:"data"`
+}
+
+// oidcAuthorize starts the SSO login and lets the mock provider consent, returning
+// the callback query the provider redirects the browser to.
+func oidcAuthorize(t *testing.T) url.Values {
+	t.Helper()
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := browser.Get(testServer.URL + "/api/v1/auth/oidc/login")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	resp, err = browser.Get(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, oidcRedirectURL, callback.Scheme+"://"+callback.Host+callback.Path)
+	return callback.Query()
+}
+
+func oidcLogin(t *testing.T, client *testutil.Client, claims map[string]interface{}) oidcLoginResponse {
+	t.Helper()
+	oidcProvider.SetClaims(claims)
+	query := oidcAuthorize(t)
+
+	resp, err := client.GET("/api/v1/auth/oidc/callback?" + query.Encode())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var login oidcLoginResponse
+	testutil.DecodeJSON(t, resp, &login)
+	return login
+}
+
+func TestOIDC_LoginProvisionsUser(t *testing.T) {
+	client := newTestClient(t)
+	email := testutil.RandomEmail()
+	subject := testutil.RandomSlug("sub")
+
+	login := oidcLogin(t, client, map[string]interface{}{
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"groups":         []string{"statuspage-operators"},
+	})
+	assert.Equal(t, email, login.Data.User.Email)
+	assert.Equal(t, "operator", login.Data.User.Role)
+	require.NotEmpty(t, login.Data.Tokens.AccessToken)
+
+	client.Token = login.Data.Tokens.AccessToken
+	resp, err := client.GET("/api/v1/me")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// The same subject signs in as the same user; the role follows the provider.
+	again := oidcLogin(t, newTestClient(t), map[string]interface{}{
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+	})
+	assert.Equal(t, login.Data.User.ID, again.Data.User.ID)
+	assert.Equal(t, "user", again.Data.User.Role)
+
+	// SSO users have no local password.
+	resp, err = newTestClientWithoutValidation().POST("/api/v1/auth/login", map[string]string{
+		"email":    email,
+		"password": "anything-at-all",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestOIDC_UnverifiedEmailOfExistingUser(t *testing.T) {
+	oidcProvider.SetClaims(map[string]interface{}{
+		"sub":   testutil.RandomSlug("sub"),
+		"email": "admin@example.com",
+	})
+	query := oidcAuthorize(t)
+
+	resp, err := newTestClientWithoutValidation().GET("/api/v1/auth/oidc/callback?" + query.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestOIDC_CallbackRejectsReusedState(t *testing.T) {
+	client := newTestClient(t)
+	oidcProvider.SetClaims(map[string]interface{}{
+		"sub":            testutil.RandomSlug("sub"),
+		"email":          testutil.RandomEmail(),
+		"email_verified": true,
+	})
+	query := oidcAuthorize(t)
+
+	resp, err := client.GET("/api/v1/auth/oidc/callback?" + query.Encode())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = client.GET("/api/v1/auth/oidc/callback?" + query.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestOIDC_Methods(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.GET("/api/v1/auth/methods")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var methods struct {
+		Data struct {
+			Password bool `json:"password"`
+			OIDC     bool `json:"oidc"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &methods)
+	assert.True(t, methods.Data.Password)
+	assert.True(t, methods.Data.OIDC)
+}
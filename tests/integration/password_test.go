@@ -0,0 +1,75 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword_Change(t *testing.T) {
+	_, email := registerUser(t)
+
+	client := newTestClient(t)
+	client.LoginAs(t, email, "password123")
+
+	resp, err := client.POST("/api/v1/me/password", map[string]string{
+		"current_password": "wrong-password",
+		"new_password":     "new-password456",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = client.POST("/api/v1/me/password", map[string]string{
+		"current_password": "password123",
+		"new_password":     "new-password456",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var tokens struct {
+		Data struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &tokens)
+	assert.NotEmpty(t, tokens.Data.AccessToken)
+	assert.NotEmpty(t, tokens.Data.RefreshToken)
+
+	resp, err = newTestClient(t).POST("/api/v1/auth/login", map[string]string{
+		"email":    email,
+		"password": "password123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	newTestClient(t).LoginAs(t, email, "new-password456")
+}
+
+func TestPassword_ForgotDoesNotRevealAccounts(t *testing.T) {
+	_, email := registerUser(t)
+	client := newTestClient(t)
+
+	for _, address := range []string{email, testutil.RandomEmail()} {
+		resp, err := client.POST("/api/v1/auth/forgot-password", map[string]string{"email": address})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		resp.Body.Close()
+	}
+}
+
+func TestPassword_ResetWithInvalidToken(t *testing.T) {
+	resp, err := newTestClient(t).POST("/api/v1/auth/reset-password", map[string]string{
+		"token":        "not-a-token",
+		"new_password": "new-password456",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
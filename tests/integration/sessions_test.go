@@ -0,0 +1,173 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tokenPairResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func login(t *testing.T, email string) tokenPairResponse {
+	t.Helper()
+
+	resp, err := newTestClient(t).POST("/api/v1/auth/login", map[string]string{
+		"email":    email,
+		"password": "password123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Data struct {
+			Tokens tokenPairResponse `json:"tokens"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &result)
+	return result.Data.Tokens
+}
+
+func refresh(t *testing.T, refreshToken string) (*http.Response, tokenPairResponse) {
+	t.Helper()
+
+	resp, err := newTestClient(t).POST("/api/v1/auth/refresh", map[string]string{"refresh_token": refreshToken})
+	require.NoError(t, err)
+
+	var result struct {
+		Data tokenPairResponse `json:"data"`
+	}
+	if resp.StatusCode == http.StatusOK {
+		testutil.DecodeJSON(t, resp, &result)
+	} else {
+		resp.Body.Close()
+	}
+	return resp, result.Data
+}
+
+func TestSessions_RefreshRotatesToken(t *testing.T) {
+	_, email := registerUser(t)
+	first := login(t, email)
+
+	resp, second := refresh(t, first.RefreshToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	resp, third := refresh(t, second.RefreshToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Reusing a replaced token ends the session, including the latest token.
+	resp, _ = refresh(t, first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = refresh(t, third.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	_, email := registerUser(t)
+	laptop := login(t, email)
+	phone := login(t, email)
+
+	client := newTestClient(t)
+	client.Token = laptop.AccessToken
+
+	resp, err := client.GET("/api/v1/me/sessions")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Data []struct {
+			ID        string `json:"id"`
+			UserAgent string `json:"user_agent"`
+			IP        string `json:"ip"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &list)
+	require.Len(t, list.Data, 2)
+	assert.NotEmpty(t, list.Data[0].IP)
+
+	// The phone logged in last, so its session is listed first.
+	resp, err = client.DELETE("/api/v1/me/sessions/" + list.Data[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, _ = refresh(t, phone.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = refresh(t, laptop.RefreshToken)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = client.DELETE("/api/v1/me/sessions/" + list.Data[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestSessions_OtherUsersSessionsAreHidden(t *testing.T) {
+	_, email := registerUser(t)
+	tokens := login(t, email)
+
+	owner := newTestClient(t)
+	owner.Token = tokens.AccessToken
+	resp, err := owner.GET("/api/v1/me/sessions")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &list)
+	require.Len(t, list.Data, 1)
+
+	other := newTestClient(t)
+	other.LoginAsUser(t)
+	resp, err = other.DELETE("/api/v1/me/sessions/" + list.Data[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestSessions_IgnoreForwardedForFromUntrustedClients(t *testing.T) {
+	_, email := registerUser(t)
+
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/auth/login",
+		strings.NewReader(`{"email":"`+email+`","password":"password123"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Real-IP", "203.0.113.8")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result struct {
+		Data struct {
+			Tokens tokenPairResponse `json:"tokens"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &result)
+
+	client := newTestClient(t)
+	client.Token = result.Data.Tokens.AccessToken
+
+	resp, err = client.GET("/api/v1/me/sessions")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Data []struct {
+			IP string `json:"ip"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "127.0.0.1", list.Data[0].IP)
+}
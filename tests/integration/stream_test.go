@@ -0,0 +1,84 @@
+//go:build integration
+
+package integration
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusStream_Resume(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("stream-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Stream Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":       "Stream incident",
+		"type":        "incident",
+		"status":      "investigating",
+		"severity":    "major",
+		"description": "Slow responses",
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	// The live fan-out runs in the background; resuming replays the stored messages.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/api/v1/status/stream?service="+slug, nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "0")
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(types) < 2 && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			types = append(types, strings.TrimPrefix(line, "event: "))
+		}
+		if strings.HasPrefix(line, "data: ") && strings.Contains(line, "new_status") {
+			assert.Contains(t, line, `"new_status":"partial_outage"`)
+			assert.NotContains(t, line, "actor_id")
+		}
+	}
+	assert.ElementsMatch(t, []string{"event.created", "service.status_changed"}, types)
+}
+
+func TestStatusStream_Errors(t *testing.T) {
+	resp, _ := getPlain(t, "/api/v1/status/stream?service="+testutil.RandomSlug("missing"), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = getPlain(t, "/api/v1/status/stream", map[string]string{"Last-Event-ID": "abc"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,136 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statusSummary struct {
+	Data struct {
+		Status struct {
+			Indicator   string `json:"indicator"`
+			Description string `json:"description"`
+		} `json:"status"`
+		Groups []struct {
+			ID       string `json:"id"`
+			Slug     string `json:"slug"`
+			Status   string `json:"status"`
+			Services []struct {
+				Slug   string `json:"slug"`
+				Status string `json:"status"`
+			} `json:"services"`
+		} `json:"groups"`
+		Incidents []struct {
+			ID           string `json:"id"`
+			LatestUpdate *struct {
+				Message string `json:"message"`
+			} `json:"latest_update"`
+		} `json:"incidents"`
+	} `json:"data"`
+}
+
+func TestStatusSummary(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	groupSlug := testutil.RandomSlug("summary-group")
+	resp, err := admin.POST("/api/v1/groups", map[string]string{
+		"name": "Summary Group",
+		"slug": groupSlug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var group struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &group)
+
+	serviceSlug := testutil.RandomSlug("summary-service")
+	resp, err = admin.POST("/api/v1/services", map[string]interface{}{
+		"name":      "Summary Service",
+		"slug":      serviceSlug,
+		"group_ids": []string{group.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":       "Summary incident",
+		"type":        "incident",
+		"status":      "investigating",
+		"severity":    "critical",
+		"description": "Down",
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var event struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &event)
+
+	resp, err = admin.POST("/api/v1/events/"+event.Data.ID+"/updates", map[string]interface{}{
+		"status":  "identified",
+		"message": "Root cause identified",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	client := newTestClient(t)
+	resp, err = client.GET("/api/v1/status/summary")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=30", resp.Header.Get("Cache-Control"))
+	etag := resp.Header.Get("ETag")
+
+	var summary statusSummary
+	testutil.DecodeJSON(t, resp, &summary)
+	assert.Equal(t, "critical", summary.Data.Status.Indicator)
+
+	foundGroup := false
+	for _, g := range summary.Data.Groups {
+		if g.Slug != groupSlug {
+			continue
+		}
+		foundGroup = true
+		assert.Equal(t, "major_outage", g.Status)
+		require.Len(t, g.Services, 1)
+		assert.Equal(t, serviceSlug, g.Services[0].Slug)
+		assert.Equal(t, "major_outage", g.Services[0].Status)
+	}
+	assert.True(t, foundGroup, "group not found in summary")
+
+	foundIncident := false
+	for _, incident := range summary.Data.Incidents {
+		if incident.ID != event.Data.ID {
+			continue
+		}
+		foundIncident = true
+		require.NotNil(t, incident.LatestUpdate)
+		assert.Equal(t, "Root cause identified", incident.LatestUpdate.Message)
+	}
+	assert.True(t, foundIncident, "incident not found in summary")
+
+	resp, _ = getPlain(t, "/api/v1/status/summary", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+}
@@ -0,0 +1,95 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type uptimeResponse struct {
+	Data struct {
+		ServiceID    string  `json:"service_id"`
+		Availability float64 `json:"availability"`
+		Days         []struct {
+			Date         string  `json:"date"`
+			Availability float64 `json:"availability"`
+			MajorOutage  int64   `json:"major_outage_seconds"`
+		} `json:"days"`
+	} `json:"data"`
+}
+
+func TestServiceUptime(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	slug := testutil.RandomSlug("uptime-service")
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Uptime Service",
+		"slug": slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	client := newTestClient(t)
+	resp, err = client.GET("/api/v1/services/" + slug + "/uptime")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var uptime uptimeResponse
+	testutil.DecodeJSON(t, resp, &uptime)
+	assert.Equal(t, service.Data.ID, uptime.Data.ServiceID)
+	assert.Equal(t, float64(100), uptime.Data.Availability)
+	// The service was created today, so earlier days are omitted.
+	require.Len(t, uptime.Data.Days, 1)
+	assert.Equal(t, time.Now().UTC().Format(time.DateOnly), uptime.Data.Days[0].Date)
+
+	startedAt := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":       "Uptime incident",
+		"type":        "incident",
+		"status":      "investigating",
+		"severity":    "critical",
+		"description": "Down",
+		"started_at":  startedAt,
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = client.GET("/api/v1/services/" + slug + "/uptime?days=7")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	testutil.DecodeJSON(t, resp, &uptime)
+	require.Len(t, uptime.Data.Days, 1)
+	assert.Less(t, uptime.Data.Availability, float64(100))
+	assert.Positive(t, uptime.Data.Days[0].MajorOutage)
+}
+
+func TestServiceUptime_Errors(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.GET("/api/v1/services/" + testutil.RandomSlug("missing") + "/uptime")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = client.GET("/api/v1/services/any/uptime?days=365")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
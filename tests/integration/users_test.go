@@ -0,0 +1,255 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userResponse struct {
+	Data struct {
+		ID                    string `json:"id"`
+		Email                 string `json:"email"`
+		Role                  string `json:"role"`
+		IsActive              bool   `json:"is_active"`
+		PasswordResetRequired bool   `json:"password_reset_required"`
+	} `json:"data"`
+}
+
+func registerUser(t *testing.T) (string, string) {
+	t.Helper()
+
+	email := testutil.RandomEmail()
+	resp, err := newTestClient(t).POST("/api/v1/auth/register", map[string]string{
+		"email":    email,
+		"password": "password123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var user userResponse
+	testutil.DecodeJSON(t, resp, &user)
+	return user.Data.ID, email
+}
+
+func TestUsers_ListAndSearch(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	id, email := registerUser(t)
+
+	resp, err := admin.GET("/api/v1/users?q=" + url.QueryEscape(email))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, id, list.Data[0].ID)
+
+	resp, err = admin.GET("/api/v1/users?role=admin&is_active=true&limit=500")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	testutil.DecodeJSON(t, resp, &list)
+	assert.NotEmpty(t, list.Data)
+
+	resp, err = admin.GET("/api/v1/users/" + id)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var user userResponse
+	testutil.DecodeJSON(t, resp, &user)
+	assert.Equal(t, email, user.Data.Email)
+	assert.True(t, user.Data.IsActive)
+}
+
+func TestUsers_ChangeRole(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	id, email := registerUser(t)
+
+	resp, err := admin.PATCH("/api/v1/users/"+id+"/role", map[string]string{"role": "operator"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var user userResponse
+	testutil.DecodeJSON(t, resp, &user)
+	assert.Equal(t, "operator", user.Data.Role)
+
+	operator := newTestClient(t)
+	operator.LoginAs(t, email, "password123")
+	resp, err = operator.GET("/api/v1/events")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// The demotion applies to the access token issued before it.
+	resp, err = admin.PATCH("/api/v1/users/"+id+"/role", map[string]string{"role": "user"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = operator.GET("/api/v1/events")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestUsers_Deactivate(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	id, email := registerUser(t)
+
+	member := newTestClient(t)
+	member.LoginAs(t, email, "password123")
+
+	resp, err := admin.POST("/api/v1/users/"+id+"/deactivate", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var user userResponse
+	testutil.DecodeJSON(t, resp, &user)
+	assert.False(t, user.Data.IsActive)
+
+	resp, err = member.GET("/api/v1/me")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = newTestClient(t).POST("/api/v1/auth/login", map[string]string{
+		"email":    email,
+		"password": "password123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = admin.POST("/api/v1/users/"+id+"/reactivate", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	member.LoginAs(t, email, "password123")
+}
+
+func TestUsers_ForcePasswordReset(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	id, email := registerUser(t)
+
+	resp, err := admin.POST("/api/v1/users/"+id+"/force-password-reset", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var user userResponse
+	testutil.DecodeJSON(t, resp, &user)
+	assert.True(t, user.Data.PasswordResetRequired)
+
+	resp, err = newTestClient(t).POST("/api/v1/auth/login", map[string]string{
+		"email":    email,
+		"password": "password123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var login struct {
+		Data struct {
+			User struct {
+				PasswordResetRequired bool `json:"password_reset_required"`
+			} `json:"user"`
+			Tokens tokenPairResponse `json:"tokens"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &login)
+	assert.True(t, login.Data.User.PasswordResetRequired)
+
+	// The token only works for the password change until it is done.
+	member := newTestClientWithoutValidation()
+	member.Token = login.Data.Tokens.AccessToken
+	resp, err = member.GET("/api/v1/me")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = member.POST("/api/v1/me/password", map[string]string{
+		"current_password": "password123",
+		"new_password":     "new-password456",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var tokens struct {
+		Data tokenPairResponse `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &tokens)
+
+	member.Token = tokens.Data.AccessToken
+	resp, err = member.GET("/api/v1/me")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestUsers_Delete(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	id, _ := registerUser(t)
+
+	resp, err := admin.DELETE("/api/v1/users/" + id)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = admin.GET("/api/v1/users/" + id)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestUsers_Safeguards(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	resp, err := admin.GET("/api/v1/me")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var me struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &me)
+
+	for _, path := range []string{
+		"/api/v1/users/" + me.Data.ID + "/deactivate",
+		"/api/v1/users/00000000-0000-0000-0000-000000000001/deactivate",
+	} {
+		resp, err = admin.POST(path, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode, path)
+		resp.Body.Close()
+	}
+
+	resp, err = admin.DELETE("/api/v1/users/" + me.Data.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestUsers_AdminOnly(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsOperator(t)
+
+	resp, err := client.GET("/api/v1/users")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
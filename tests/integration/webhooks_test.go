@@ -0,0 +1,185 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bissquit/incident-garden/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookResponse struct {
+	Data struct {
+		ID         string   `json:"id"`
+		URL        string   `json:"url"`
+		Secret     string   `json:"secret"`
+		EventKinds []string `json:"event_kinds"`
+		ServiceIDs []string `json:"service_ids"`
+		IsEnabled  bool     `json:"is_enabled"`
+	} `json:"data"`
+}
+
+type webhookDeliveries struct {
+	Data []struct {
+		ID        string `json:"id"`
+		EventKind string `json:"event_kind"`
+		Status    string `json:"status"`
+	} `json:"data"`
+}
+
+func TestWebhooks_CRUD(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	resp, err := admin.POST("/api/v1/webhooks", map[string]interface{}{
+		"url":         "https://example.com/hooks/statuspage",
+		"event_kinds": []string{"event.created", "event.resolved"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created webhookResponse
+	testutil.DecodeJSON(t, resp, &created)
+	assert.Len(t, created.Data.Secret, 64, "generated secret is returned once")
+	assert.True(t, created.Data.IsEnabled)
+	assert.Empty(t, created.Data.ServiceIDs)
+
+	resp, err = admin.GET("/api/v1/webhooks/" + created.Data.ID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var fetched webhookResponse
+	testutil.DecodeJSON(t, resp, &fetched)
+	assert.Empty(t, fetched.Data.Secret, "secret must not be shown again")
+	assert.Equal(t, created.Data.EventKinds, fetched.Data.EventKinds)
+
+	resp, err = admin.PATCH("/api/v1/webhooks/"+created.Data.ID, map[string]interface{}{
+		"is_enabled":  false,
+		"event_kinds": []string{"service.status_changed"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated webhookResponse
+	testutil.DecodeJSON(t, resp, &updated)
+	assert.False(t, updated.Data.IsEnabled)
+	assert.Equal(t, []string{"service.status_changed"}, updated.Data.EventKinds)
+
+	resp, err = admin.DELETE("/api/v1/webhooks/" + created.Data.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = admin.GET("/api/v1/webhooks/" + created.Data.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestWebhooks_Validation(t *testing.T) {
+	admin := newTestClientWithoutValidation()
+	admin.LoginAsAdmin(t)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"relative url", map[string]interface{}{"url": "/hook", "event_kinds": []string{"event.created"}}},
+		{"no event kinds", map[string]interface{}{"url": "https://example.com", "event_kinds": []string{}}},
+		{"unknown event kind", map[string]interface{}{"url": "https://example.com", "event_kinds": []string{"event.exploded"}}},
+		{"short secret", map[string]interface{}{"url": "https://example.com", "event_kinds": []string{"event.created"}, "secret": "short"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := admin.POST("/api/v1/webhooks", tt.body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestWebhooks_AdminOnly(t *testing.T) {
+	client := newTestClient(t)
+	client.LoginAsOperator(t)
+
+	resp, err := client.GET("/api/v1/webhooks")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestWebhooks_EventQueuesDelivery(t *testing.T) {
+	admin := newTestClient(t)
+	admin.LoginAsAdmin(t)
+
+	resp, err := admin.POST("/api/v1/services", map[string]string{
+		"name": "Webhook Service",
+		"slug": testutil.RandomSlug("webhook-service"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var service struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	testutil.DecodeJSON(t, resp, &service)
+
+	resp, err = admin.POST("/api/v1/webhooks", map[string]interface{}{
+		"url":         "https://example.com/hooks/statuspage",
+		"event_kinds": []string{"event.created", "service.status_changed"},
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var webhook webhookResponse
+	testutil.DecodeJSON(t, resp, &webhook)
+
+	t.Cleanup(func() {
+		resp, err := admin.DELETE("/api/v1/webhooks/" + webhook.Data.ID)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+
+	resp, err = admin.POST("/api/v1/events", map[string]interface{}{
+		"title":       "Webhook incident",
+		"type":        "incident",
+		"status":      "investigating",
+		"severity":    "major",
+		"description": "Slow responses",
+		"service_ids": []string{service.Data.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	// Delivery workers do not run in tests, so the deliveries stay queued.
+	resp, err = admin.GET("/api/v1/webhooks/" + webhook.Data.ID + "/deliveries")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var deliveries webhookDeliveries
+	testutil.DecodeJSON(t, resp, &deliveries)
+	require.Len(t, deliveries.Data, 2)
+
+	kinds := make([]string, 0, len(deliveries.Data))
+	for _, d := range deliveries.Data {
+		assert.Equal(t, "pending", d.Status)
+		kinds = append(kinds, d.EventKind)
+	}
+	assert.ElementsMatch(t, []string{"event.created", "service.status_changed"}, kinds)
+
+	deliveryPath := "/api/v1/webhooks/" + webhook.Data.ID + "/deliveries/" + deliveries.Data[0].ID
+	resp, err = admin.GET(deliveryPath)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = admin.POST(deliveryPath+"/redeliver", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+}